@@ -33,6 +33,7 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -40,7 +41,9 @@ import (
 
 	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
 	"github.com/openyurtio/openyurt/pkg/apis"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
 	edgexclients "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients/edgex-foundry"
+	mqttclients "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients/mqtt"
 	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers"
 	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
 )
@@ -94,8 +97,16 @@ func Run(opts *options.YurtIoTDockOptions, stopCh <-chan struct{}) {
 		ExtraHandlers: make(map[string]http.Handler, 0),
 	}
 
+	// only the namespaces which the edge resources are synchronized into are watched,
+	// so that yurt-iot-dock can run with the permissions of these namespaces
+	defaultNamespaces := make(map[string]cache.Config)
+	for _, namespace := range opts.DeviceNamespaces() {
+		defaultNamespaces[namespace] = cache.Config{}
+	}
+
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  cache.Options{DefaultNamespaces: defaultNamespaces},
 		Metrics:                metricsServerOpts,
 		HealthProbeBindAddress: opts.ProbeAddr,
 		LeaderElection:         opts.EnableLeaderElection,
@@ -128,17 +139,17 @@ func Run(opts *options.YurtIoTDockOptions, stopCh <-chan struct{}) {
 		}
 	}
 
-	edgexdock := edgexclients.NewEdgexDock(opts.Version, opts.CoreMetadataAddr, opts.CoreCommandAddr)
+	iotdock := newIoTDock(opts)
 
 	// setup the DeviceProfile Reconciler and Syncer
 	if err = (&controllers.DeviceProfileReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr, opts, edgexdock); err != nil {
+	}).SetupWithManager(mgr, opts, iotdock); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DeviceProfile")
 		os.Exit(1)
 	}
-	dfs, err := controllers.NewDeviceProfileSyncer(mgr.GetClient(), opts, edgexdock)
+	dfs, err := controllers.NewDeviceProfileSyncer(mgr.GetClient(), opts, iotdock)
 	if err != nil {
 		setupLog.Error(err, "unable to create syncer", "syncer", "DeviceProfile")
 		os.Exit(1)
@@ -149,19 +160,33 @@ func Run(opts *options.YurtIoTDockOptions, stopCh <-chan struct{}) {
 		os.Exit(1)
 	}
 
+	// setup the Device Journal, which replays the writes of devices failed while the cloud is not reachable
+	journal, err := controllers.NewDeviceJournal(mgr.GetClient(), mgr.GetEventRecorderFor("yurt-iot-dock"), opts)
+	if err != nil {
+		setupLog.Error(err, "unable to create journal", "journal", "Device")
+		os.Exit(1)
+	}
+	err = mgr.Add(journal.NewDeviceJournalRunnable())
+	if err != nil {
+		setupLog.Error(err, "unable to create journal runnable", "journal", "Device")
+		os.Exit(1)
+	}
+
 	// setup the Device Reconciler and Syncer
 	if err = (&controllers.DeviceReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr, opts, edgexdock); err != nil {
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Journal: journal,
+	}).SetupWithManager(mgr, opts, iotdock); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Device")
 		os.Exit(1)
 	}
-	ds, err := controllers.NewDeviceSyncer(mgr.GetClient(), opts, edgexdock)
+	ds, err := controllers.NewDeviceSyncer(mgr.GetClient(), opts, iotdock)
 	if err != nil {
 		setupLog.Error(err, "unable to create syncer", "controller", "Device")
 		os.Exit(1)
 	}
+	ds.Journal = journal
 	err = mgr.Add(ds.NewDeviceSyncerRunnable())
 	if err != nil {
 		setupLog.Error(err, "unable to create syncer runnable", "syncer", "Device")
@@ -172,11 +197,11 @@ func Run(opts *options.YurtIoTDockOptions, stopCh <-chan struct{}) {
 	if err = (&controllers.DeviceServiceReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr, opts, edgexdock); err != nil {
+	}).SetupWithManager(mgr, opts, iotdock); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DeviceService")
 		os.Exit(1)
 	}
-	dss, err := controllers.NewDeviceServiceSyncer(mgr.GetClient(), opts, edgexdock)
+	dss, err := controllers.NewDeviceServiceSyncer(mgr.GetClient(), opts, iotdock)
 	if err != nil {
 		setupLog.Error(err, "unable to create syncer", "syncer", "DeviceService")
 		os.Exit(1)
@@ -186,6 +211,45 @@ func Run(opts *options.YurtIoTDockOptions, stopCh <-chan struct{}) {
 		setupLog.Error(err, "unable to create syncer runnable", "syncer", "DeviceService")
 		os.Exit(1)
 	}
+	// setup the DeviceSet Reconciler
+	if err = (&controllers.DeviceSetReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Journal: journal,
+	}).SetupWithManager(mgr, opts); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DeviceSet")
+		os.Exit(1)
+	}
+	// setup the DeviceOperation Reconciler
+	if err = (&controllers.DeviceOperationReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr, opts, iotdock); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DeviceOperation")
+		os.Exit(1)
+	}
+	// setup the DeviceReading Collector
+	if opts.ReadingCollectPeriod > 0 {
+		rc, err := controllers.NewDeviceReadingCollector(mgr.GetClient(), opts, iotdock)
+		if err != nil {
+			setupLog.Error(err, "unable to create collector", "collector", "DeviceReading")
+			os.Exit(1)
+		}
+		err = mgr.Add(rc.NewDeviceReadingCollectorRunnable())
+		if err != nil {
+			setupLog.Error(err, "unable to create collector runnable", "collector", "DeviceReading")
+			os.Exit(1)
+		}
+	}
+	// setup the DeviceHealth Checker
+	if opts.HealthCheckPeriod > 0 {
+		hc := controllers.NewDeviceHealthChecker(mgr.GetClient(), mgr.GetEventRecorderFor("yurt-iot-dock"), opts)
+		err = mgr.Add(hc.NewDeviceHealthCheckerRunnable())
+		if err != nil {
+			setupLog.Error(err, "unable to create checker runnable", "checker", "DeviceHealth")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
@@ -197,7 +261,7 @@ func Run(opts *options.YurtIoTDockOptions, stopCh <-chan struct{}) {
 		os.Exit(1)
 	}
 
-	setupLog.Info("[run controllers] Starting manager, acting on " + fmt.Sprintf("[NodePool: %s, Namespace: %s]", opts.Nodepool, opts.Namespace))
+	setupLog.Info("[run controllers] Starting manager, acting on " + fmt.Sprintf("[NodePool: %s, Namespace: %s, DeviceNamespaces: %v]", opts.Nodepool, opts.Namespace, opts.DeviceNamespaces()))
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "could not running manager")
 		os.Exit(1)
@@ -248,13 +312,27 @@ func SetupSignalHandler(client client.Client, opts *options.YurtIoTDockOptions)
 	return ctx
 }
 
+// newIoTDock creates the clients factory of the edge platform which yurt-iot-dock connects to
+func newIoTDock(opts *options.YurtIoTDockOptions) clients.IoTDock {
+	switch opts.Platform {
+	case options.PlatformMQTT:
+		// the client id must be unique on the broker, so the pod name is used
+		hostname, _ := os.Hostname()
+		return mqttclients.NewMQTTDock(opts.MQTTBrokerAddr, opts.MQTTTopicPrefix, fmt.Sprintf("yurt-iot-dock-%s", hostname))
+	default:
+		return edgexclients.NewEdgexDock(opts.Version, opts.CoreMetadataAddr, opts.CoreCommandAddr, opts.MessageBusAddr, opts.MessageBusPrefix)
+	}
+}
+
 func preflightCheck(mgr ctrl.Manager, opts *options.YurtIoTDockOptions) error {
 	client, err := kubernetes.NewForConfig(mgr.GetConfig())
 	if err != nil {
 		return err
 	}
-	if _, err := client.CoreV1().Namespaces().Get(context.TODO(), opts.Namespace, metav1.GetOptions{}); err != nil {
-		return err
+	for _, namespace := range opts.DeviceNamespaces() {
+		if _, err := client.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{}); err != nil {
+			return err
+		}
 	}
 	return nil
 }
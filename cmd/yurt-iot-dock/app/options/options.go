@@ -19,8 +19,26 @@ package options
 import (
 	"fmt"
 	"net"
+	"sort"
+	"strings"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// PlatformEdgeX and PlatformMQTT are the edge platforms supported by yurt-iot-dock
+	PlatformEdgeX = "edgex"
+	PlatformMQTT  = "mqtt"
+
+	// NamespaceMappingByLabel, NamespaceMappingByProfile and NamespaceMappingByService are the policies
+	// to map the devices of the edge platform into namespaces, by a label, the profile or the service of device.
+	NamespaceMappingByLabel   = "label"
+	NamespaceMappingByProfile = "profile"
+	NamespaceMappingByService = "service"
+
+	// DefaultJournalPath is the file which the journal of device writes is persisted to by default
+	DefaultJournalPath = "/var/lib/yurt-iot-dock/journal.json"
 )
 
 // YurtIoTDockOptions is the main settings for the yurt-iot-dock
@@ -30,11 +48,30 @@ type YurtIoTDockOptions struct {
 	EnableLeaderElection bool
 	Nodepool             string
 	Namespace            string
+	Platform             string
 	Version              string
 	CoreDataAddr         string
 	CoreMetadataAddr     string
 	CoreCommandAddr      string
 	EdgeSyncPeriod       uint
+	EdgeResyncPeriod     uint
+	MessageBusAddr       string
+	MessageBusPrefix     string
+	MQTTBrokerAddr       string
+	MQTTTopicPrefix      string
+	ReadingCollectPeriod uint
+	ReadingStatusPeriod  uint
+	ReadingStatusQPS     float64
+	OperationTTL         uint
+	JournalPath          string
+	JournalReplayPeriod  uint
+	HealthCheckPeriod    uint
+	UnreachableThreshold uint
+	StaleThreshold       uint
+	// NamespaceMappingPolicy and NamespaceMapping decide the namespaces of the devices,
+	// the devices which are not mapped are synced into Namespace
+	NamespaceMappingPolicy string
+	NamespaceMapping       map[string]string
 }
 
 func NewYurtIoTDockOptions() *YurtIoTDockOptions {
@@ -44,18 +81,46 @@ func NewYurtIoTDockOptions() *YurtIoTDockOptions {
 		EnableLeaderElection: false,
 		Nodepool:             "",
 		Namespace:            "default",
+		Platform:             PlatformEdgeX,
 		Version:              "",
 		CoreDataAddr:         "edgex-core-data:59880",
 		CoreMetadataAddr:     "edgex-core-metadata:59881",
 		CoreCommandAddr:      "edgex-core-command:59882",
 		EdgeSyncPeriod:       5,
+		EdgeResyncPeriod:     300,
+		MessageBusAddr:       "",
+		MessageBusPrefix:     "edgex",
+		MQTTBrokerAddr:       "mqtt-broker:1883",
+		MQTTTopicPrefix:      "openyurt/iot",
+		ReadingCollectPeriod: 10,
+		ReadingStatusPeriod:  60,
+		ReadingStatusQPS:     1,
+		OperationTTL:         7 * 24 * 3600,
+		JournalPath:          DefaultJournalPath,
+		JournalReplayPeriod:  10,
+		HealthCheckPeriod:    30,
+		UnreachableThreshold: 300,
+		StaleThreshold:       600,
+		NamespaceMapping:     map[string]string{},
 	}
 }
 
 func ValidateOptions(options *YurtIoTDockOptions) error {
+	if err := ValidatePlatform(options); err != nil {
+		return err
+	}
+	if err := ValidateReadingOptions(options); err != nil {
+		return err
+	}
 	if err := ValidateEdgePlatformAddress(options); err != nil {
 		return err
 	}
+	if options.JournalReplayPeriod == 0 {
+		return fmt.Errorf("journal-replay-period must be positive")
+	}
+	if err := ValidateNamespaceMapping(options); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -65,15 +130,53 @@ func (o *YurtIoTDockOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&o.EnableLeaderElection, "leader-elect", false, "Enable leader election for controller manager. "+"Enabling this will ensure there is only one active controller manager.")
 	fs.StringVar(&o.Nodepool, "nodepool", "", "The nodePool deviceController is deployed in.(just for debugging)")
 	fs.StringVar(&o.Namespace, "namespace", "default", "The cluster namespace for edge resources synchronization.")
+	fs.StringVar(&o.Platform, "platform", o.Platform, "The edge platform yurt-iot-dock connects to, edgex or mqtt.")
 	fs.StringVar(&o.Version, "version", "", "The version of edge resources deployment.")
 	fs.StringVar(&o.CoreDataAddr, "core-data-address", "edgex-core-data:59880", "The address of edge core-data service.")
 	fs.StringVar(&o.CoreMetadataAddr, "core-metadata-address", "edgex-core-metadata:59881", "The address of edge core-metadata service.")
 	fs.StringVar(&o.CoreCommandAddr, "core-command-address", "edgex-core-command:59882", "The address of edge core-command service.")
 	fs.UintVar(&o.EdgeSyncPeriod, "edge-sync-period", 5, "The period of the device management platform synchronizing the device status to the cloud.(in seconds,not less than 5 seconds)")
+	fs.UintVar(&o.EdgeResyncPeriod, "edge-resync-period", o.EdgeResyncPeriod, "The period of the full synchronization with the device management platform when its system events are watched, the system events are applied as they come.(in seconds)")
+	fs.StringVar(&o.MessageBusAddr, "message-bus-address", o.MessageBusAddr, "The address of the mqtt message bus of edgex to watch the system events, the device management platform is polled every edge-sync-period if it is empty.")
+	fs.StringVar(&o.MessageBusPrefix, "message-bus-topic-prefix", o.MessageBusPrefix, "The base topic of the mqtt message bus of edgex.")
+	fs.UintVar(&o.ReadingCollectPeriod, "reading-collect-period", o.ReadingCollectPeriod, "The period of collecting the readings of the devices annotated with yurt-iot-dock/export-readings, 0 disables the collection.(in seconds)")
+	fs.UintVar(&o.ReadingStatusPeriod, "reading-status-period", o.ReadingStatusPeriod, "The minimum period between two updates of the summarized readings in the device status, 0 disables the updates.(in seconds)")
+	fs.Float64Var(&o.ReadingStatusQPS, "reading-status-qps", o.ReadingStatusQPS, "The maximum number of updates of the summarized readings per second for all devices, to protect the network between edge and cloud.")
+	fs.UintVar(&o.OperationTTL, "operation-ttl", o.OperationTTL, "The time to keep the finished device operations which don't set ttlSecondsAfterFinished, 0 keeps them forever.(in seconds)")
+	fs.StringVar(&o.JournalPath, "journal-path", o.JournalPath, "The file to persist the journal of device writes which failed while the cloud is not reachable, the journal is kept in memory only if it is empty, which loses the journal when yurt-iot-dock restarts.")
+	fs.UintVar(&o.JournalReplayPeriod, "journal-replay-period", o.JournalReplayPeriod, "The period of replaying the journal of device writes to the cloud.(in seconds,not less than 1 second)")
+	fs.UintVar(&o.HealthCheckPeriod, "health-check-period", o.HealthCheckPeriod, "The period of checking the health of devices by their last connected and reported time, 0 disables the check.(in seconds)")
+	fs.UintVar(&o.UnreachableThreshold, "unreachable-threshold", o.UnreachableThreshold, "The time since the device last connected after which it is unreachable, it can be overridden by the yurt-iot-dock/unreachable-threshold annotation of DeviceProfile, 0 disables the Reachable condition.(in seconds)")
+	fs.UintVar(&o.StaleThreshold, "reporting-stale-threshold", o.StaleThreshold, "The time since the device last reported after which its reporting is stale, it can be overridden by the yurt-iot-dock/reporting-stale-threshold annotation of DeviceProfile, 0 disables the ReportingStale condition.(in seconds)")
+	fs.StringVar(&o.NamespaceMappingPolicy, "namespace-mapping-policy", o.NamespaceMappingPolicy, "The policy to map the devices into namespaces, label, profile or service. All devices are synced into the namespace if it is empty.")
+	fs.StringToStringVar(&o.NamespaceMapping, "namespace-mapping", o.NamespaceMapping, "The namespaces of the devices by their label, profile or service according to the namespace-mapping-policy, such as line-a=team-a,line-b=team-b. The devices which are not mapped are synced into the namespace.")
+	fs.StringVar(&o.MQTTBrokerAddr, "mqtt-broker-address", o.MQTTBrokerAddr, "The address of the mqtt broker which hosts the device registry, only used by the mqtt platform.")
+	fs.StringVar(&o.MQTTTopicPrefix, "mqtt-topic-prefix", o.MQTTTopicPrefix, "The root topic of the device registry on the mqtt broker, only used by the mqtt platform.")
+}
+
+func ValidateReadingOptions(options *YurtIoTDockOptions) error {
+	if options.ReadingStatusPeriod > 0 && options.ReadingStatusQPS <= 0 {
+		return fmt.Errorf("reading-status-qps must be positive when reading-status-period is set")
+	}
+	return nil
+}
+
+func ValidatePlatform(options *YurtIoTDockOptions) error {
+	switch options.Platform {
+	case "", PlatformEdgeX:
+		return nil
+	case PlatformMQTT:
+		if _, _, err := net.SplitHostPort(options.MQTTBrokerAddr); err != nil {
+			return fmt.Errorf("invalid mqtt broker address: %s", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported platform: %s", options.Platform)
+	}
 }
 
 func ValidateEdgePlatformAddress(options *YurtIoTDockOptions) error {
-	addrs := []string{options.CoreDataAddr, options.CoreMetadataAddr, options.CoreCommandAddr}
+	addrs := []string{options.CoreDataAddr, options.CoreMetadataAddr, options.CoreCommandAddr, options.MessageBusAddr}
 	for _, addr := range addrs {
 		if addr != "" {
 			if _, _, err := net.SplitHostPort(addr); err != nil {
@@ -83,3 +186,41 @@ func ValidateEdgePlatformAddress(options *YurtIoTDockOptions) error {
 	}
 	return nil
 }
+
+func ValidateNamespaceMapping(options *YurtIoTDockOptions) error {
+	switch options.NamespaceMappingPolicy {
+	case "":
+		if len(options.NamespaceMapping) != 0 {
+			return fmt.Errorf("namespace-mapping-policy is required when namespace-mapping is set")
+		}
+		return nil
+	case NamespaceMappingByLabel, NamespaceMappingByProfile, NamespaceMappingByService:
+	default:
+		return fmt.Errorf("unsupported namespace mapping policy: %s", options.NamespaceMappingPolicy)
+	}
+
+	for key, namespace := range options.NamespaceMapping {
+		if key == "" {
+			return fmt.Errorf("invalid namespace mapping: the %s of device is empty", options.NamespaceMappingPolicy)
+		}
+		if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+			return fmt.Errorf("invalid namespace %q of %s %s: %s", namespace, options.NamespaceMappingPolicy, key, strings.Join(errs, ","))
+		}
+	}
+	return nil
+}
+
+// DeviceNamespaces returns the namespaces which the devices are synced into, yurt-iot-dock only watches them
+// so that it can be granted the permissions of these namespaces only.
+func (o *YurtIoTDockOptions) DeviceNamespaces() []string {
+	namespaces := []string{o.Namespace}
+	seen := map[string]bool{o.Namespace: true}
+	for _, namespace := range o.NamespaceMapping {
+		if !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces[1:])
+	return namespaces
+}
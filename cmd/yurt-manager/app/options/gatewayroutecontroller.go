@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewayroute/config"
+)
+
+type GatewayRouteControllerOptions struct {
+	*config.GatewayRouteControllerConfiguration
+}
+
+func NewGatewayRouteControllerOptions() *GatewayRouteControllerOptions {
+	return &GatewayRouteControllerOptions{
+		&config.GatewayRouteControllerConfiguration{
+			ConcurrentGatewayRouteWorkers: 1,
+		},
+	}
+}
+
+// AddFlags adds flags related to gateway route for yurt-manager to the specified FlagSet.
+func (g *GatewayRouteControllerOptions) AddFlags(fs *pflag.FlagSet) {
+	if g == nil {
+		return
+	}
+
+	fs.Int32Var(&g.ConcurrentGatewayRouteWorkers, "concurrent-gateway-route-workers", g.ConcurrentGatewayRouteWorkers, "The number of gateway route objects that are allowed to reconcile concurrently. Larger number = more responsive gateway routes, but more CPU (and network) load")
+}
+
+// ApplyTo fills up gateway route config with options.
+func (g *GatewayRouteControllerOptions) ApplyTo(cfg *config.GatewayRouteControllerConfiguration) error {
+	if g == nil {
+		return nil
+	}
+
+	cfg.ConcurrentGatewayRouteWorkers = g.ConcurrentGatewayRouteWorkers
+	return nil
+}
+
+// Validate checks validation of GatewayRouteControllerOptions.
+func (g *GatewayRouteControllerOptions) Validate() []error {
+	if g == nil {
+		return nil
+	}
+	var errs []error
+	return errs
+}
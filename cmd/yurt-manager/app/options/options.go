@@ -46,6 +46,7 @@ type YurtManagerOptions struct {
 	GatewayDNSController          *GatewayDNSControllerOptions
 	GatewayInternalSvcController  *GatewayInternalSvcControllerOptions
 	GatewayPublicSvcController    *GatewayPublicSvcControllerOptions
+	GatewayRouteController        *GatewayRouteControllerOptions
 	HubLeaderController           *HubLeaderControllerOptions
 }
 
@@ -74,6 +75,7 @@ func NewYurtManagerOptions() (*YurtManagerOptions, error) {
 		GatewayDNSController:          NewGatewayDNSControllerOptions(),
 		GatewayInternalSvcController:  NewGatewayInternalSvcControllerOptions(),
 		GatewayPublicSvcController:    NewGatewayPublicSvcControllerOptions(),
+		GatewayRouteController:        NewGatewayRouteControllerOptions(),
 		HubLeaderController:           NewHubLeaderControllerOptions(),
 	}
 
@@ -103,6 +105,7 @@ func (y *YurtManagerOptions) Flags(allControllers, disabledByDefaultControllers
 	y.GatewayDNSController.AddFlags(fss.FlagSet("gatewaydns controller"))
 	y.GatewayInternalSvcController.AddFlags(fss.FlagSet("gatewayinternalsvc controller"))
 	y.GatewayPublicSvcController.AddFlags(fss.FlagSet("gatewaypublicsvc controller"))
+	y.GatewayRouteController.AddFlags(fss.FlagSet("gatewayroute controller"))
 	y.HubLeaderController.AddFlags(fss.FlagSet("hubleader controller"))
 	return fss
 }
@@ -131,6 +134,7 @@ func (y *YurtManagerOptions) Validate(allControllers []string, controllerAliases
 	errs = append(errs, y.GatewayDNSController.Validate()...)
 	errs = append(errs, y.GatewayInternalSvcController.Validate()...)
 	errs = append(errs, y.GatewayPublicSvcController.Validate()...)
+	errs = append(errs, y.GatewayRouteController.Validate()...)
 	errs = append(errs, y.HubLeaderController.Validate()...)
 	return utilerrors.NewAggregate(errs)
 }
@@ -200,6 +204,9 @@ func (y *YurtManagerOptions) ApplyTo(c *config.Config, controllerAliases map[str
 	if err := y.GatewayPublicSvcController.ApplyTo(&c.ComponentConfig.GatewayPublicSvcController); err != nil {
 		return err
 	}
+	if err := y.GatewayRouteController.ApplyTo(&c.ComponentConfig.GatewayRouteController); err != nil {
+		return err
+	}
 	if err := y.HubLeaderController.ApplyTo(&c.ComponentConfig.HubLeaderController); err != nil {
 		return err
 	}
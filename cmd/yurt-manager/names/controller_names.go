@@ -34,6 +34,7 @@ const (
 	GatewayInternalServiceController       = "gateway-internal-service-controller"
 	GatewayPublicServiceController         = "gateway-public-service-controller"
 	GatewayDNSController                   = "gateway-dns-controller"
+	GatewayRouteController                 = "gateway-route-controller"
 	NodeLifeCycleController                = "node-life-cycle-controller"
 	NodeBucketController                   = "node-bucket-controller"
 	LoadBalancerSetController              = "load-balancer-set-controller"
@@ -60,6 +61,7 @@ func YurtManagerControllerAliases() map[string]string {
 		"gatewayinternalservice":        GatewayInternalServiceController,
 		"gatewaypublicservice":          GatewayPublicServiceController,
 		"gatewaydns":                    GatewayDNSController,
+		"gatewayroute":                  GatewayRouteController,
 		"nodelifecycle":                 NodeLifeCycleController,
 		"nodebucket":                    NodeBucketController,
 		"loadbalancerset":               LoadBalancerSetController,
@@ -44,7 +44,14 @@ func NewUpgradeCmd() *cobra.Command {
 				klog.Fatalf("could not create static-pod-upgrade controller, %v", err)
 			}
 
-			if err = ctrl.Upgrade(); err != nil {
+			err = ctrl.Upgrade()
+			// Report the upgrade result through the termination message of worker pod
+			if result := ctrl.Result(); result != nil && len(o.ResultPath()) != 0 {
+				if err := result.WriteTerminationMessage(o.ResultPath()); err != nil {
+					klog.Errorf("could not write static pod upgrade result, %v", err)
+				}
+			}
+			if err != nil {
 				klog.Fatalf("could not upgrade static pod, %v", err)
 			}
 
@@ -195,6 +195,7 @@ EOF
    mv ${crd_dir}/apiextensions.k8s.io_v1_customresourcedefinition_yurtappsets.apps.openyurt.io.yaml ${crd_dir}/apps.openyurt.io_yurtappsets.yaml
    mv ${crd_dir}/apiextensions.k8s.io_v1_customresourcedefinition_yurtappoverriders.apps.openyurt.io.yaml ${crd_dir}/apps.openyurt.io_yurtappoverriders.yaml
    mv ${crd_dir}/apiextensions.k8s.io_v1_customresourcedefinition_gateways.raven.openyurt.io.yaml ${crd_dir}/raven.openyurt.io_gateways.yaml
+   mv ${crd_dir}/apiextensions.k8s.io_v1_customresourcedefinition_gatewayroutes.raven.openyurt.io.yaml ${crd_dir}/raven.openyurt.io_gatewayroutes.yaml
    mv ${crd_dir}/apiextensions.k8s.io_v1_customresourcedefinition_platformadmins.iot.openyurt.io.yaml ${crd_dir}/iot.openyurt.io_platformadmins.yaml
    mv ${crd_dir}/apiextensions.k8s.io_v1_customresourcedefinition_nodebuckets.apps.openyurt.io.yaml ${crd_dir}/apps.openyurt.io_nodebuckets.yaml
    mv ${crd_dir}/apiextensions.k8s.io_v1_customresourcedefinition_poolservices.network.openyurt.io.yaml ${crd_dir}/network.openyurt.io_poolservices.yaml
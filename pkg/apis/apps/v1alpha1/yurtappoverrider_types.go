@@ -74,9 +74,11 @@ type Entry struct {
 }
 
 // Describe the object Entries belongs
+// The subject is YurtAppSet, YurtAppDaemon, or a workload of any group/kind with pod template, e.g. StatefulSet.
 type Subject struct {
 	metav1.TypeMeta `json:",inline"`
-	// Name is the name of YurtAppSet or YurtAppDaemon
+	// Name is the name of YurtAppSet, YurtAppDaemon or the workload.
+	// The nodepool of workload is specified by label apps.openyurt.io/pool-name.
 	Name string `json:"name"`
 }
 
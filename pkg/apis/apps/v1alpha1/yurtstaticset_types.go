@@ -30,6 +30,43 @@ type YurtStaticSetUpgradeStrategy struct {
 	// AdvancedRollingUpdate upgrade config params. Present only if type = "AdvancedRollingUpdate".
 	//+optional
 	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// Paused indicates that the upgrade is paused. No new upgrade worker pods are created
+	// and no static pods are marked as upgradable while it is true.
+	//+optional
+	Paused bool `json:"paused,omitempty"`
+
+	// MaintenanceWindows restricts upgrades to the given time windows. A node is upgraded only
+	// when one of the windows that applies to it is open. Nodes which are not covered by any
+	// window are not upgraded while any window is configured.
+	//+optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// ReadyTimeout is how long to wait for the upgraded static pod to be ready on a node.
+	// If the static pod is not ready in time, the old manifest is restored on the node.
+	// Defaults to 2m.
+	//+optional
+	ReadyTimeout *metav1.Duration `json:"readyTimeout,omitempty"`
+}
+
+// MaintenanceWindow defines a recurring period of time in which upgrades are allowed.
+type MaintenanceWindow struct {
+	// Schedule is a cron expression with five fields (minute, hour, day of month, month
+	// and day of week) which specifies when the window opens, e.g. "0 2 * * *".
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open after each scheduled start, e.g. "2h".
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the name of the time zone used to interpret Schedule, e.g. "Asia/Shanghai".
+	// Defaults to UTC.
+	//+optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// NodePools limits the window to nodes which belong to the listed node pools.
+	// An empty list means the window applies to all nodes.
+	//+optional
+	NodePools []string `json:"nodePools,omitempty"`
 }
 
 // YurtStaticSetUpgradeStrategyType is a strategy according to which static pods gets upgraded.
@@ -75,6 +112,53 @@ type YurtStaticSetStatus struct {
 	// The most recent generation observed by the static pod controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration"`
+
+	// The number of nodes on which the latest static pod failed to upgrade.
+	// +optional
+	FailedNumber int32 `json:"failedNumber,omitempty"`
+
+	// The upgrade status of every node that is running the static pod, sorted by node name.
+	// +optional
+	NodeStatuses []NodeUpgradeStatus `json:"nodeStatuses,omitempty"`
+}
+
+// NodeUpgradePhase is the phase of the upgrade on a node
+type NodeUpgradePhase string
+
+const (
+	// NodeUpgradePending means the node is waiting to be upgraded
+	NodeUpgradePending NodeUpgradePhase = "Pending"
+	// NodeUpgradeInProgress means the upgrade is being conducted on the node
+	NodeUpgradeInProgress NodeUpgradePhase = "InProgress"
+	// NodeUpgradeSucceeded means the node is running the latest version
+	NodeUpgradeSucceeded NodeUpgradePhase = "Succeeded"
+	// NodeUpgradeFailed means the upgrade to the latest version failed on the node
+	NodeUpgradeFailed NodeUpgradePhase = "Failed"
+)
+
+// NodeUpgradeStatus records the upgrade status of a workload on a node.
+type NodeUpgradeStatus struct {
+	// NodeName is the name of the node.
+	NodeName string `json:"nodeName"`
+
+	// Phase is the phase of the upgrade on the node.
+	Phase NodeUpgradePhase `json:"phase"`
+
+	// RolledBack indicates whether the old version has been restored on the node after the upgrade failed.
+	// +optional
+	RolledBack bool `json:"rolledBack,omitempty"`
+
+	// Reason is a brief CamelCase string that describes why the upgrade is in this phase.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable description of the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is the last time the phase transitioned.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // +genclient
@@ -86,6 +170,7 @@ type YurtStaticSetStatus struct {
 //+kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.totalNumber",description="The total number of static pods"
 //+kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyNumber",description="The number of ready static pods"
 //+kubebuilder:printcolumn:name="Upgraded",type="integer",JSONPath=".status.upgradedNumber",description="The number of static pods that have been upgraded"
+//+kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedNumber",description="The number of static pods that failed to upgrade"
 
 // YurtStaticSet is the Schema for the yurtstaticsets API
 type YurtStaticSet struct {
@@ -37,6 +37,15 @@ const (
 	AnnotationRefNodePool = "apps.openyurt.io/ref-nodepool"
 )
 
+// YurtAppOverrider related annotations
+const (
+	// AnnotationOverrideBase records the spec of a workload before it is rendered by YurtAppOverrider
+	AnnotationOverrideBase = "apps.openyurt.io/override-base"
+
+	// AnnotationOverrideHash records the hash of the spec of a workload rendered by YurtAppOverrider
+	AnnotationOverrideHash = "apps.openyurt.io/override-hash"
+)
+
 // NodePool related labels and annotations
 const (
 	AnnotationPrevAttrs      = "nodepool.openyurt.io/previous-attributes"
@@ -43,6 +43,8 @@ const (
 
 	DeviceCreateSyncedReason = "Failed to create device on edge platform"
 
+	DeviceNameConflictReason = "DeviceNameConflict"
+
 	// DeviceManagingCondition indicates that the device is being managed by cloud and its properties are being reconciled
 	DeviceManagingCondition DeviceConditionType = "DeviceManaging"
 
@@ -50,6 +52,24 @@ const (
 
 	DeviceUpdateStateReason = "Failed to update AdminState or OperatingState of device on edge platform"
 
+	// DeviceReachableCondition indicates that the device connected with the edge platform within the unreachable threshold
+	DeviceReachableCondition DeviceConditionType = "Reachable"
+
+	DeviceConnectedReason = "DeviceConnected"
+
+	DeviceUnreachableReason = "DeviceUnreachable"
+
+	DeviceNeverConnectedReason = "DeviceNeverConnected"
+
+	// DeviceReportingStaleCondition indicates that the device has not reported data within the reporting stale threshold
+	DeviceReportingStaleCondition DeviceConditionType = "ReportingStale"
+
+	DeviceReportingReason = "DeviceReporting"
+
+	DeviceReportingStaleReason = "DeviceReportingStale"
+
+	DeviceNeverReportedReason = "DeviceNeverReported"
+
 	// DeviceServiceSyncedCondition indicates that the deviceService exists in both OpenYurt and edge platform
 	DeviceServiceSyncedCondition DeviceServiceConditionType = "DeviceServiceSynced"
 
@@ -22,3 +22,11 @@ func SetDefaultsPlatformAdmin(obj *PlatformAdmin) {
 		obj.Annotations = make(map[string]string)
 	}
 }
+
+// SetDefaultsDeviceOperation set default values for DeviceOperation.
+func SetDefaultsDeviceOperation(obj *DeviceOperation) {
+	if obj.Spec.MaxRetries == nil {
+		maxRetries := DefaultDeviceOperationMaxRetries
+		obj.Spec.MaxRetries = &maxRetries
+	}
+}
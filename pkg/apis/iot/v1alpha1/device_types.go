@@ -110,6 +110,31 @@ type DeviceStatus struct {
 	// current device state
 	// +optional
 	Conditions []DeviceCondition `json:"conditions,omitempty"`
+	// the summary of the readings of the exported device properties in the last reading window
+	// +optional
+	Readings []DeviceReading `json:"readings,omitempty"`
+}
+
+// DeviceReading summarizes the readings of a device property collected in a reading window.
+type DeviceReading struct {
+	// Name of the device property
+	Name string `json:"name"`
+	// The last reading of the property
+	LastValue string `json:"lastValue"`
+	// The minimum of the numeric readings in the window
+	// +optional
+	Min string `json:"min,omitempty"`
+	// The maximum of the numeric readings in the window
+	// +optional
+	Max string `json:"max,omitempty"`
+	// The average of the numeric readings in the window
+	// +optional
+	Average string `json:"average,omitempty"`
+	// Number of the readings collected in the window
+	Samples int32 `json:"samples,omitempty"`
+	// Time of the last reading
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 // DeviceCondition describes current state of a Device.
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultDeviceOperationMaxRetries is the number of retries of a DeviceOperation when maxRetries is not set
+	DefaultDeviceOperationMaxRetries int32 = 3
+)
+
+// DeviceOperationPhase is the phase of a DeviceOperation
+type DeviceOperationPhase string
+
+const (
+	// DeviceOperationPending means the operation has not been executed successfully and will be (re)tried
+	DeviceOperationPending DeviceOperationPhase = "Pending"
+	// DeviceOperationInProgress means the command of the operation is being sent to the device,
+	// an operation is never executed again once an attempt is recorded without its result
+	DeviceOperationInProgress DeviceOperationPhase = "InProgress"
+	// DeviceOperationSucceeded means the property of the device has been set
+	DeviceOperationSucceeded DeviceOperationPhase = "Succeeded"
+	// DeviceOperationFailed means the operation failed after all the retries
+	DeviceOperationFailed DeviceOperationPhase = "Failed"
+)
+
+// DeviceOperationSpec defines the command which sets a property of a device.
+// The spec is immutable, each command is a new DeviceOperation.
+type DeviceOperationSpec struct {
+	// Name of the device in the namespace of the DeviceOperation
+	DeviceName string `json:"deviceName"`
+	// Name of the device property to set
+	PropertyName string `json:"propertyName"`
+	// The value to set to the property
+	Value string `json:"value"`
+	// Number of retries before the operation is marked as failed
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+	// The finished operation is deleted after the ttl, the default ttl of yurt-iot-dock is used when it is not set
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// Requester is the user who created the operation, it is set by the admission webhook
+	// +optional
+	Requester string `json:"requester,omitempty"`
+}
+
+// DeviceOperationStatus defines the observed state of DeviceOperation
+type DeviceOperationStatus struct {
+	// Phase of the operation
+	// +optional
+	Phase DeviceOperationPhase `json:"phase,omitempty"`
+	// Number of the attempts to execute the operation
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+	// Number of the failed attempts
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
+	// Time of the first attempt
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Time of the last attempt
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+	// Time when the operation succeeded or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// A human readable message indicating the result of the last attempt
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=devop
+// +kubebuilder:printcolumn:name="DEVICE",type="string",JSONPath=".spec.deviceName",description="The device of operation"
+// +kubebuilder:printcolumn:name="PROPERTY",type="string",JSONPath=".spec.propertyName",description="The property set by operation"
+// +kubebuilder:printcolumn:name="VALUE",type="string",JSONPath=".spec.value",description="The value set by operation"
+// +kubebuilder:printcolumn:name="PHASE",type="string",JSONPath=".status.phase",description="The phase of operation"
+// +kubebuilder:printcolumn:name="REQUESTER",type="string",priority=1,JSONPath=".spec.requester",description="The user who created operation"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DeviceOperation is the Schema for the deviceoperations API
+type DeviceOperation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeviceOperationSpec   `json:"spec,omitempty"`
+	Status DeviceOperationStatus `json:"status,omitempty"`
+}
+
+// IsFinished returns whether the operation succeeded or failed
+func (op *DeviceOperation) IsFinished() bool {
+	return op.Status.Phase == DeviceOperationSucceeded || op.Status.Phase == DeviceOperationFailed
+}
+
+//+kubebuilder:object:root=true
+
+// DeviceOperationList contains a list of DeviceOperation
+type DeviceOperationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeviceOperation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeviceOperation{}, &DeviceOperationList{})
+}
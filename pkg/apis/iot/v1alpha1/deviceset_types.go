@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DeviceSetLabel is the label of the devices created by a DeviceSet, the value is the name of the DeviceSet
+	DeviceSetLabel = "iot.openyurt.io/deviceset"
+
+	// DeviceSetMaxRangeSize is the maximum number of the devices listed by the range of a DeviceSet
+	DeviceSetMaxRangeSize = 10000
+	// DeviceSetMaxIndex is the maximum index of the range of a DeviceSet
+	DeviceSetMaxIndex = 1000000000
+	// DeviceSetMaxStatusErrors is the maximum number of the provisioning errors reported in the DeviceSet status
+	DeviceSetMaxStatusErrors = 100
+)
+
+// DeviceSetSpec defines the desired state of DeviceSet.
+// The devices of the set are listed by the items, the range and the csv, each of them is created from the template.
+type DeviceSetSpec struct {
+	// NodePool indicates which nodePool the devices of the set belong to
+	NodePool string `json:"nodePool"`
+	// Template describes the devices that will be created. The placeholders ${name}, ${index} and
+	// ${<parameter>} in the string fields of the template are replaced by the values of the device.
+	Template DeviceTemplate `json:"template"`
+	// Items lists the devices of the set with their parameters
+	// +optional
+	Items []DeviceSetItem `json:"items,omitempty"`
+	// Range creates the devices named <set name>-<index> for each index in the range
+	// +optional
+	Range *DeviceSetRange `json:"range,omitempty"`
+	// CSV lists the devices of the set in CSV format. The first line is the header which must contain
+	// the name column, the other columns are the parameters of the devices.
+	// +optional
+	CSV string `json:"csv,omitempty"`
+}
+
+// DeviceTemplate describes the devices created by a DeviceSet
+type DeviceTemplate struct {
+	// Labels added to the devices
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations added to the devices
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Spec of the devices, the nodePool is always set to the nodePool of the DeviceSet
+	Spec DeviceSpec `json:"spec"`
+}
+
+// DeviceSetItem is a device of the DeviceSet
+type DeviceSetItem struct {
+	// Name of the device
+	Name string `json:"name"`
+	// Parameters used to expand the template for the device
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// DeviceSetRange is a range of indexes of the devices of the DeviceSet
+// +kubebuilder:validation:XValidation:rule="self.end >= self.start",message="end must be greater than or equal to start"
+// +kubebuilder:validation:XValidation:rule="self.end - self.start < 10000",message="the range must not list more than 10000 devices"
+type DeviceSetRange struct {
+	// The first index of the range
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000000000
+	Start int32 `json:"start"`
+	// The last index of the range, inclusive
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000000000
+	End int32 `json:"end"`
+}
+
+// DeviceSetStatus defines the observed state of DeviceSet
+type DeviceSetStatus struct {
+	// The generation observed by the DeviceSet controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Number of the devices listed by the DeviceSet
+	DesiredDevices int32 `json:"desiredDevices"`
+	// Number of the devices which are created and synced to the edge platform
+	ProvisionedDevices int32 `json:"provisionedDevices"`
+	// The devices which could not be provisioned, at most 100 errors are reported
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Errors []DeviceProvisionError `json:"errors,omitempty"`
+}
+
+// DeviceProvisionError describes why a device of the DeviceSet could not be provisioned
+type DeviceProvisionError struct {
+	// Name of the device
+	Name string `json:"name"`
+	// A human readable message indicating the error
+	Message string `json:"message"`
+}
+
+// +genclient
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=devset
+// +kubebuilder:printcolumn:name="NODEPOOL",type="string",JSONPath=".spec.nodePool",description="The nodepool of devices"
+// +kubebuilder:printcolumn:name="DESIRED",type="integer",JSONPath=".status.desiredDevices",description="The number of desired devices"
+// +kubebuilder:printcolumn:name="PROVISIONED",type="integer",JSONPath=".status.provisionedDevices",description="The number of provisioned devices"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DeviceSet is the Schema for the devicesets API
+type DeviceSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeviceSetSpec   `json:"spec,omitempty"`
+	Status DeviceSetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DeviceSetList contains a list of DeviceSet
+type DeviceSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeviceSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeviceSet{}, &DeviceSetList{})
+}
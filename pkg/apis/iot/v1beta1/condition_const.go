@@ -29,4 +29,16 @@ const (
 	ComponentProvisioningReason = "ComponentProvisioning"
 
 	ComponentProvisioningFailedReason = "ComponentProvisioningFailed"
+	// VersionUpgradedCondition documents the status of upgrading the nodepools to the version of PlatformAdmin.
+	VersionUpgradedCondition PlatformAdminConditionType = "VersionUpgraded"
+
+	VersionUpgradingReason = "VersionUpgrading"
+
+	VersionUpgradePausedReason = "VersionUpgradePaused"
+
+	VersionIncompatibleReason = "VersionIncompatible"
+
+	VersionMigrationFailedReason = "VersionMigrationFailed"
+
+	VersionUpgradeStalledReason = "VersionUpgradeStalled"
 )
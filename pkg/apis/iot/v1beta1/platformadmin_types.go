@@ -31,6 +31,7 @@ const (
 // PlatformAdmin platform supported by openyurt
 const (
 	PlatformAdminPlatformEdgeX = "edgex"
+	PlatformAdminPlatformMQTT  = "mqtt"
 )
 
 // PlatformAdminConditionType indicates valid conditions type of a PlatformAdmin.
@@ -53,11 +54,52 @@ type PlatformAdminSpec struct {
 	// +optional
 	Platform string `json:"platform,omitempty"`
 
+	// Components are the optional components to deploy, which are either the standard components of the version
+	// or the custom components defined in the configmaps of customComponentConfigMaps
 	// +optional
 	Components []Component `json:"components,omitempty"`
 
+	// CustomComponentConfigMaps are the names of the configmaps in the namespace of PlatformAdmin which define
+	// the custom components, the configmaps which are not listed here are never deployed as components
+	// +optional
+	CustomComponentConfigMaps []string `json:"customComponentConfigMaps,omitempty"`
+
 	// +optional
 	Security bool `json:"security,omitempty"`
+
+	// MessageBusAddress is the host:port of the mqtt message bus of EdgeX which yurt-iot-dock watches the
+	// system events on, the edge platform is polled if it is empty
+	// +optional
+	MessageBusAddress string `json:"messageBusAddress,omitempty"`
+
+	// MQTTBrokerAddress is the host:port of the mqtt broker which hosts the device registry of the mqtt platform,
+	// yurt-iot-dock uses its default broker if it is empty
+	// +optional
+	MQTTBrokerAddress string `json:"mqttBrokerAddress,omitempty"`
+
+	// UpgradeStrategy controls how the nodepools are upgraded when the version changes
+	// +optional
+	UpgradeStrategy *PlatformAdminUpgradeStrategy `json:"upgradeStrategy,omitempty"`
+}
+
+// PlatformAdminUpgradeStrategy defines the staged rollout of a new version over the nodepools
+type PlatformAdminUpgradeStrategy struct {
+	// The maximum number of nodepools which are upgraded at the same time, defaults to 1.
+	// The nodepools are upgraded in the order of spec.nodepools. The configmaps are shared by all
+	// the nodepools, so a version whose configuration changes is only upgraded when it covers all
+	// the nodepools.
+	// +optional
+	MaxUnavailable int32 `json:"maxUnavailable,omitempty"`
+
+	// Paused stops upgrading the nodepools which have not started upgrading yet
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// The maximum time in seconds for a nodepool to be upgraded, defaults to 600. The upgrade stops
+	// and the nodepools are reported as stalled once they are not upgraded in time.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ProgressDeadlineSeconds int32 `json:"progressDeadlineSeconds,omitempty"`
 }
 
 // PlatformAdminStatus defines the observed state of PlatformAdmin
@@ -74,11 +116,63 @@ type PlatformAdminStatus struct {
 	// +optional
 	UnreadyComponentNum int32 `json:"unreadyComponentNum,omitempty"`
 
+	// Version is the version which all the nodepools run,
+	// it differs from spec.version while the nodepools are upgraded
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// PoolVersions is the version of the components in each nodepool
+	// +optional
+	PoolVersions []PlatformAdminPoolVersion `json:"poolVersions,omitempty"`
+
+	// DeviceHealth is the number of unhealthy devices in each nodepool, reported by yurt-iot-dock
+	// +optional
+	DeviceHealth []PlatformAdminDeviceHealth `json:"deviceHealth,omitempty"`
+
 	// Current PlatformAdmin state
 	// +optional
 	Conditions []PlatformAdminCondition `json:"conditions,omitempty"`
 }
 
+// PlatformAdminPoolVersion describes the version of the components in a nodepool
+type PlatformAdminPoolVersion struct {
+	// NodePool is the name of the nodepool
+	NodePool string `json:"nodePool"`
+
+	// CurrentVersion is the version which the components of the nodepool are running
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// TargetVersion is the version which the components of the nodepool are rolled to
+	// +optional
+	TargetVersion string `json:"targetVersion,omitempty"`
+
+	// UpgradeStartTime is the time the nodepool started to be rolled to the target version
+	// +optional
+	UpgradeStartTime *metav1.Time `json:"upgradeStartTime,omitempty"`
+}
+
+// PlatformAdminDeviceHealth describes the health of the devices in a nodepool
+type PlatformAdminDeviceHealth struct {
+	// NodePool is the name of the nodepool
+	NodePool string `json:"nodePool"`
+
+	// Devices is the number of devices in the nodepool
+	Devices int32 `json:"devices"`
+
+	// UnreachableDevices is the number of devices whose Reachable condition is false
+	// +optional
+	UnreachableDevices int32 `json:"unreachableDevices,omitempty"`
+
+	// ReportingStaleDevices is the number of devices whose ReportingStale condition is true
+	// +optional
+	ReportingStaleDevices int32 `json:"reportingStaleDevices,omitempty"`
+
+	// Last time the health of the devices was reported
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
 // PlatformAdminCondition describes current state of a PlatformAdmin.
 type PlatformAdminCondition struct {
 	// Type of in place set condition.
@@ -105,6 +199,7 @@ type PlatformAdminCondition struct {
 // +kubebuilder:printcolumn:name="READY",type="boolean",JSONPath=".status.ready",description="The platformadmin ready status"
 // +kubebuilder:printcolumn:name="ReadyComponentNum",type="integer",JSONPath=".status.readyComponentNum",description="The Ready Component."
 // +kubebuilder:printcolumn:name="UnreadyComponentNum",type="integer",JSONPath=".status.unreadyComponentNum",description="The Unready Component."
+// +kubebuilder:printcolumn:name="VERSION",type="string",JSONPath=".status.version",description="The version of all the nodepools."
 // +kubebuilder:storageversion
 
 // PlatformAdmin is the Schema for the samples API
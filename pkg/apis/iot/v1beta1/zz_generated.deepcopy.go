@@ -82,6 +82,22 @@ func (in *PlatformAdminCondition) DeepCopy() *PlatformAdminCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformAdminDeviceHealth) DeepCopyInto(out *PlatformAdminDeviceHealth) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformAdminDeviceHealth.
+func (in *PlatformAdminDeviceHealth) DeepCopy() *PlatformAdminDeviceHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformAdminDeviceHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlatformAdminList) DeepCopyInto(out *PlatformAdminList) {
 	*out = *in
@@ -114,6 +130,25 @@ func (in *PlatformAdminList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformAdminPoolVersion) DeepCopyInto(out *PlatformAdminPoolVersion) {
+	*out = *in
+	if in.UpgradeStartTime != nil {
+		in, out := &in.UpgradeStartTime, &out.UpgradeStartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformAdminPoolVersion.
+func (in *PlatformAdminPoolVersion) DeepCopy() *PlatformAdminPoolVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformAdminPoolVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlatformAdminSpec) DeepCopyInto(out *PlatformAdminSpec) {
 	*out = *in
@@ -127,6 +162,16 @@ func (in *PlatformAdminSpec) DeepCopyInto(out *PlatformAdminSpec) {
 		*out = make([]Component, len(*in))
 		copy(*out, *in)
 	}
+	if in.CustomComponentConfigMaps != nil {
+		in, out := &in.CustomComponentConfigMaps, &out.CustomComponentConfigMaps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UpgradeStrategy != nil {
+		in, out := &in.UpgradeStrategy, &out.UpgradeStrategy
+		*out = new(PlatformAdminUpgradeStrategy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformAdminSpec.
@@ -142,6 +187,20 @@ func (in *PlatformAdminSpec) DeepCopy() *PlatformAdminSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlatformAdminStatus) DeepCopyInto(out *PlatformAdminStatus) {
 	*out = *in
+	if in.PoolVersions != nil {
+		in, out := &in.PoolVersions, &out.PoolVersions
+		*out = make([]PlatformAdminPoolVersion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeviceHealth != nil {
+		in, out := &in.DeviceHealth, &out.DeviceHealth
+		*out = make([]PlatformAdminDeviceHealth, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]PlatformAdminCondition, len(*in))
@@ -160,3 +219,18 @@ func (in *PlatformAdminStatus) DeepCopy() *PlatformAdminStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformAdminUpgradeStrategy) DeepCopyInto(out *PlatformAdminUpgradeStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformAdminUpgradeStrategy.
+func (in *PlatformAdminUpgradeStrategy) DeepCopy() *PlatformAdminUpgradeStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformAdminUpgradeStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -16,7 +16,10 @@ limitations under the License.
 
 package v1beta1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
@@ -27,11 +30,40 @@ const (
 	EventActiveEndpointElected = "ActiveEndpointElected"
 	// EventActiveEndpointLost is the event indicating the active endpoint is lost.
 	EventActiveEndpointLost = "ActiveEndpointLost"
+	// EventActiveEndpointFailover is the event indicating the active endpoint failed the health probe and is replaced.
+	EventActiveEndpointFailover = "ActiveEndpointFailover"
+	// EventActiveEndpointUnhealthy is the event indicating the active endpoint failed the health probe
+	// but is kept, because no healthy standby endpoint is available.
+	EventActiveEndpointUnhealthy = "ActiveEndpointUnhealthy"
+)
+
+// Gateway condition types and reasons.
+const (
+	// GatewayConflicted indicates whether the nodes selected by the gateway are also selected by other gateways.
+	GatewayConflicted = "Conflicted"
+
+	// ReasonNodeSelectorOverlapped is the reason of Conflicted condition when the node selector overlaps with other gateways.
+	ReasonNodeSelectorOverlapped = "NodeSelectorOverlapped"
+	// ReasonNoConflict is the reason of Conflicted condition when no node is selected by other gateways.
+	ReasonNoConflict = "NoConflict"
+)
+
+// Node conditions reported by raven agent for the health probe of endpoints hosted by the node.
+// An endpoint is considered healthy if the condition of its type is not reported.
+const (
+	// NodeTunnelHealthy indicates whether the tunnel of the tunnel endpoint is healthy.
+	NodeTunnelHealthy corev1.NodeConditionType = "RavenTunnelHealthy"
+	// NodeProxyHealthy indicates whether the proxy of the proxy endpoint is healthy.
+	NodeProxyHealthy corev1.NodeConditionType = "RavenProxyHealthy"
 )
 
 const (
 	ExposeTypePublicIP     = "PublicIP"
 	ExposeTypeLoadBalancer = "LoadBalancer"
+	// ExposeTypeNodePort exposes the gateway by a NodePort service, the node port is allocated once and kept for the gateway.
+	ExposeTypeNodePort = "NodePort"
+	// ExposeTypeHostNetwork exposes the gateway by the port of endpoint on the host network of node directly.
+	ExposeTypeHostNetwork = "HostNetwork"
 )
 
 const (
@@ -44,6 +76,14 @@ const (
 	DefaultTunnelServerExposedPort = 4500
 )
 
+// NAT types of the endpoint.
+const (
+	NATTypeFullCone           = "FullCone"
+	NATTypeRestrictedCone     = "RestrictedCone"
+	NATTypePortRestrictedCone = "PortRestrictedCone"
+	NATTypeSymmetric          = "Symmetric"
+)
+
 // ProxyConfiguration is the configuration for raven l7 proxy
 type ProxyConfiguration struct {
 	// Replicas is the number of gateway active endpoints that enabled proxy
@@ -52,6 +92,13 @@ type ProxyConfiguration struct {
 	ProxyHTTPPort string `json:"proxyHTTPPort,omitempty"`
 	// ProxyHTTPSPort is the proxy https port of the cross-domain request
 	ProxyHTTPSPort string `json:"proxyHTTPSPort,omitempty"`
+	// EdgeProxy enables the active proxy endpoints of an edge gateway to accept proxy requests from components
+	// on its nodes, e.g. a local Prometheus, and route them via the cloud gateway to the nodes of other gateways.
+	// The components reach the active proxy endpoints of their own gateway by the x-raven-proxy-edge-svc service.
+	EdgeProxy bool `json:"edgeProxy,omitempty"`
+	// AllowedSourceGateways are the gateways whose edge-originated proxy requests may reach the nodes of the gateway.
+	// Edge-originated proxy requests to the gateway are denied if it is empty.
+	AllowedSourceGateways []string `json:"allowedSourceGateways,omitempty"`
 }
 
 // TunnelConfiguration is the configuration for raven l3 tunnel
@@ -71,8 +118,30 @@ type GatewaySpec struct {
 	TunnelConfig TunnelConfiguration `json:"tunnelConfig,omitempty"`
 	// Endpoints are a list of available Endpoint.
 	Endpoints []Endpoint `json:"endpoints,omitempty"`
-	// ExposeType determines how the Gateway is exposed.
+	// ExposeType determines how the Gateway is exposed, one of PublicIP, LoadBalancer, NodePort and HostNetwork.
+	// For NodePort and HostNetwork, the public address of active endpoints is the external address of the node.
 	ExposeType string `json:"exposeType,omitempty"`
+	// ElectionPolicy determines how the active endpoints are elected from the endpoints.
+	// If it is not set, the active endpoints are elected from ready nodes in the order of endpoints.
+	ElectionPolicy *ElectionPolicy `json:"electionPolicy,omitempty"`
+}
+
+// ElectionPolicy ranks the endpoints of ready nodes when electing active endpoints.
+// Endpoints are ranked by node conditions first, then by NAT type, and then by bandwidth.
+type ElectionPolicy struct {
+	// PreferredNATTypes lists NAT types from the most preferred to the least preferred.
+	// Endpoints not under NAT always rank first, and endpoints whose NAT type is not listed rank last.
+	// Defaults to FullCone, RestrictedCone, PortRestrictedCone and Symmetric.
+	PreferredNATTypes []string `json:"preferredNATTypes,omitempty"`
+	// AvoidNodeConditions are node conditions that lower the rank of an endpoint when their status is True.
+	// Defaults to MemoryPressure, DiskPressure, PIDPressure and NetworkUnavailable.
+	AvoidNodeConditions []corev1.NodeConditionType `json:"avoidNodeConditions,omitempty"`
+	// Preemption allows an endpoint of higher rank to replace an active endpoint whose node is still ready.
+	// By default, an active endpoint sticks to its node until the node is not ready.
+	Preemption bool `json:"preemption,omitempty"`
+	// MinDwellSeconds is the minimum duration in seconds that an active endpoint is kept after its node
+	// becomes NotReady, and that a node must stay Ready before its endpoint can preempt an active endpoint.
+	MinDwellSeconds int32 `json:"minDwellSeconds,omitempty"`
 }
 
 // Endpoint stores all essential data for establishing the VPN tunnel and Proxy
@@ -89,8 +158,13 @@ type Endpoint struct {
 	NATType string `json:"natType,omitempty"`
 	// PublicIP is the exposed IP of the node
 	PublicIP string `json:"publicIP,omitempty"`
+	// PublicIPs are the exposed IPs of the node for dual-stack, at most one for each IP family.
+	// PublicIP is the primary one and should be included in PublicIPs if both are set.
+	PublicIPs []string `json:"publicIPs,omitempty"`
 	// PublicPort is the port used for NAT traversal
 	PublicPort int `json:"publicPort,omitempty"`
+	// Bandwidth is the declared bandwidth capacity of the endpoint in Mbps
+	Bandwidth int `json:"bandwidth,omitempty"`
 	// Config is a map to record config for the raven agent of node
 	Config map[string]string `json:"config,omitempty"`
 }
@@ -101,6 +175,9 @@ type NodeInfo struct {
 	NodeName string `json:"nodeName"`
 	// PrivateIP is the node private ip address
 	PrivateIP string `json:"privateIP"`
+	// PrivateIPs are the node private ip addresses for dual-stack, at most one for each IP family.
+	// PrivateIP is the primary one and is always the first.
+	PrivateIPs []string `json:"privateIPs,omitempty"`
 	// Subnets is the pod ip range of the node
 	Subnets []string `json:"subnets"`
 }
@@ -111,6 +188,38 @@ type GatewayStatus struct {
 	Nodes []NodeInfo `json:"nodes,omitempty"`
 	// ActiveEndpoints is the reference of the active endpoint.
 	ActiveEndpoints []*Endpoint `json:"activeEndpoints,omitempty"`
+	// Traffic is the traffic statistics of active endpoints reported by raven agents.
+	Traffic *GatewayTraffic `json:"traffic,omitempty"`
+	// Conditions represent the latest available observations of the Gateway's state.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// GatewayTraffic is the traffic statistics of Gateway aggregated from its active endpoints.
+type GatewayTraffic struct {
+	// BytesIn is the total number of bytes received by active endpoints.
+	BytesIn int64 `json:"bytesIn,omitempty"`
+	// BytesOut is the total number of bytes sent by active endpoints.
+	BytesOut int64 `json:"bytesOut,omitempty"`
+	// ActiveConnections is the total number of active connections of active endpoints.
+	ActiveConnections int32 `json:"activeConnections,omitempty"`
+	// Endpoints contains the traffic statistics of each active endpoint.
+	Endpoints []EndpointTraffic `json:"endpoints,omitempty"`
+}
+
+// EndpointTraffic is the traffic statistics of an active endpoint.
+type EndpointTraffic struct {
+	// NodeName is the Node hosting the endpoint.
+	NodeName string `json:"nodeName,omitempty"`
+	// Type is the type of the endpoint, proxy or tunnel
+	Type string `json:"type"`
+	// BytesIn is the number of bytes received by the endpoint.
+	BytesIn int64 `json:"bytesIn,omitempty"`
+	// BytesOut is the number of bytes sent by the endpoint.
+	BytesOut int64 `json:"bytesOut,omitempty"`
+	// ActiveConnections is the number of active connections, i.e. tunnels or proxied connections, of the endpoint.
+	ActiveConnections int32 `json:"activeConnections,omitempty"`
+	// LastHandshakeTime is the last time the tunnel of the endpoint completed a handshake with its peers.
+	LastHandshakeTime *metav1.Time `json:"lastHandshakeTime,omitempty"`
 }
 
 // +genclient
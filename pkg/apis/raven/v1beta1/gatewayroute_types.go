@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Route modes of GatewayRoute.
+const (
+	// RouteModeFullMesh allows every pair of the selected gateways to talk to each other directly.
+	RouteModeFullMesh = "FullMesh"
+	// RouteModeHubAndSpoke allows the selected gateways to talk to the hub gateways directly, but not to each other.
+	RouteModeHubAndSpoke = "HubAndSpoke"
+	// RouteModePairs allows only the explicitly listed pairs of gateways to talk to each other directly.
+	RouteModePairs = "Pairs"
+)
+
+// EventGatewayRouteInvalid is the event indicating the links of the route can not be computed.
+const EventGatewayRouteInvalid = "GatewayRouteInvalid"
+
+// GatewayRouteSpec defines the desired state of GatewayRoute
+type GatewayRouteSpec struct {
+	// Mode is the topology of the route, the valid values are FullMesh, HubAndSpoke and Pairs.
+	Mode string `json:"mode"`
+	// GatewaySelector selects the gateways which take part in the FullMesh or HubAndSpoke route.
+	// All gateways are selected if it is not specified.
+	// +optional
+	GatewaySelector *metav1.LabelSelector `json:"gatewaySelector,omitempty"`
+	// Hubs are the names of hub gateways for the HubAndSpoke route.
+	// +optional
+	Hubs []string `json:"hubs,omitempty"`
+	// Pairs are the pairs of gateways for the Pairs route.
+	// +optional
+	Pairs []GatewayPair `json:"pairs,omitempty"`
+}
+
+// GatewayPair is a pair of gateways which may talk to each other directly, the link is bidirectional.
+type GatewayPair struct {
+	// Source is the name of a gateway.
+	Source string `json:"source"`
+	// Destination is the name of the peer gateway.
+	Destination string `json:"destination"`
+}
+
+// GatewayRouteStatus defines the observed state of GatewayRoute
+type GatewayRouteStatus struct {
+	// ObservedGeneration is the most recent generation observed for this GatewayRoute.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Gateways are the names of gateways which take part in the route.
+	// +optional
+	Gateways []string `json:"gateways,omitempty"`
+	// Links is the number of direct links between gateways declared by the route.
+	// +optional
+	Links int32 `json:"links,omitempty"`
+	// MissingGateways are the names of gateways referenced by the route but not found.
+	// +optional
+	MissingGateways []string `json:"missingGateways,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,path=gatewayroutes,shortName=gwr,categories=all
+// +kubebuilder:printcolumn:name="Mode",type="string",JSONPath=".spec.mode"
+// +kubebuilder:printcolumn:name="Links",type="integer",JSONPath=".status.links"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// GatewayRoute is the Schema for the gatewayroutes API, it declares which gateways may talk to
+// each other directly, so that the site-to-site traffic does not go through the cloud.
+type GatewayRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GatewayRouteSpec   `json:"spec,omitempty"`
+	Status GatewayRouteStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GatewayRouteList contains a list of GatewayRoute
+type GatewayRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GatewayRoute `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GatewayRoute{}, &GatewayRouteList{})
+}
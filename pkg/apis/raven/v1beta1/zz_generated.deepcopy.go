@@ -21,13 +21,44 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElectionPolicy) DeepCopyInto(out *ElectionPolicy) {
+	*out = *in
+	if in.PreferredNATTypes != nil {
+		in, out := &in.PreferredNATTypes, &out.PreferredNATTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AvoidNodeConditions != nil {
+		in, out := &in.AvoidNodeConditions, &out.AvoidNodeConditions
+		*out = make([]corev1.NodeConditionType, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElectionPolicy.
+func (in *ElectionPolicy) DeepCopy() *ElectionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ElectionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Endpoint) DeepCopyInto(out *Endpoint) {
 	*out = *in
+	if in.PublicIPs != nil {
+		in, out := &in.PublicIPs, &out.PublicIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = make(map[string]string, len(*in))
@@ -47,6 +78,25 @@ func (in *Endpoint) DeepCopy() *Endpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointTraffic) DeepCopyInto(out *EndpointTraffic) {
+	*out = *in
+	if in.LastHandshakeTime != nil {
+		in, out := &in.LastHandshakeTime, &out.LastHandshakeTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointTraffic.
+func (in *EndpointTraffic) DeepCopy() *EndpointTraffic {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointTraffic)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Gateway) DeepCopyInto(out *Gateway) {
 	*out = *in
@@ -106,6 +156,135 @@ func (in *GatewayList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayPair) DeepCopyInto(out *GatewayPair) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayPair.
+func (in *GatewayPair) DeepCopy() *GatewayPair {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayPair)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayRoute) DeepCopyInto(out *GatewayRoute) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayRoute.
+func (in *GatewayRoute) DeepCopy() *GatewayRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatewayRoute) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayRouteList) DeepCopyInto(out *GatewayRouteList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GatewayRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayRouteList.
+func (in *GatewayRouteList) DeepCopy() *GatewayRouteList {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayRouteList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatewayRouteList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayRouteSpec) DeepCopyInto(out *GatewayRouteSpec) {
+	*out = *in
+	if in.GatewaySelector != nil {
+		in, out := &in.GatewaySelector, &out.GatewaySelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hubs != nil {
+		in, out := &in.Hubs, &out.Hubs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pairs != nil {
+		in, out := &in.Pairs, &out.Pairs
+		*out = make([]GatewayPair, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayRouteSpec.
+func (in *GatewayRouteSpec) DeepCopy() *GatewayRouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayRouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayRouteStatus) DeepCopyInto(out *GatewayRouteStatus) {
+	*out = *in
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MissingGateways != nil {
+		in, out := &in.MissingGateways, &out.MissingGateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayRouteStatus.
+func (in *GatewayRouteStatus) DeepCopy() *GatewayRouteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayRouteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewaySpec) DeepCopyInto(out *GatewaySpec) {
 	*out = *in
@@ -114,7 +293,7 @@ func (in *GatewaySpec) DeepCopyInto(out *GatewaySpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
-	out.ProxyConfig = in.ProxyConfig
+	in.ProxyConfig.DeepCopyInto(&out.ProxyConfig)
 	out.TunnelConfig = in.TunnelConfig
 	if in.Endpoints != nil {
 		in, out := &in.Endpoints, &out.Endpoints
@@ -123,6 +302,11 @@ func (in *GatewaySpec) DeepCopyInto(out *GatewaySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ElectionPolicy != nil {
+		in, out := &in.ElectionPolicy, &out.ElectionPolicy
+		*out = new(ElectionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewaySpec.
@@ -156,6 +340,18 @@ func (in *GatewayStatus) DeepCopyInto(out *GatewayStatus) {
 			}
 		}
 	}
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = new(GatewayTraffic)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayStatus.
@@ -168,9 +364,36 @@ func (in *GatewayStatus) DeepCopy() *GatewayStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayTraffic) DeepCopyInto(out *GatewayTraffic) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]EndpointTraffic, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayTraffic.
+func (in *GatewayTraffic) DeepCopy() *GatewayTraffic {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayTraffic)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeInfo) DeepCopyInto(out *NodeInfo) {
 	*out = *in
+	if in.PrivateIPs != nil {
+		in, out := &in.PrivateIPs, &out.PrivateIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Subnets != nil {
 		in, out := &in.Subnets, &out.Subnets
 		*out = make([]string, len(*in))
@@ -191,6 +414,11 @@ func (in *NodeInfo) DeepCopy() *NodeInfo {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxyConfiguration) DeepCopyInto(out *ProxyConfiguration) {
 	*out = *in
+	if in.AllowedSourceGateways != nil {
+		in, out := &in.AllowedSourceGateways, &out.AllowedSourceGateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyConfiguration.
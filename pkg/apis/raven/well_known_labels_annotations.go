@@ -20,4 +20,17 @@ const (
 	// LabelCurrentGateway indicates which gateway the node is currently belonging to
 	LabelCurrentGateway     = "raven.openyurt.io/gateway"
 	LabelCurrentGatewayType = "raven.openyurt.io/gateway-type"
+
+	// LabelDNSExport indicates the service is published by records in the raven proxy dns, so that
+	// it can be addressed by edge workloads of every node pool, e.g. svc.ns.pool.local
+	LabelDNSExport = "raven.openyurt.io/dns-export"
+
+	// AnnotationTrafficStats is reported on the node by raven agent, it records the traffic statistics
+	// of endpoints hosted by the node in json, e.g. [{"type":"tunnel","bytesIn":1024,"bytesOut":2048}]
+	AnnotationTrafficStats = "raven.openyurt.io/traffic-stats"
+
+	// AnnotationNATDiscovery is reported on the node by raven agent, it records the NAT mapping of the tunnel
+	// endpoint hosted by the node in json, which is discovered by querying the reflector of exposed gateways,
+	// e.g. {"underNAT":true,"natType":"Symmetric","publicIP":"47.0.0.1","publicPort":4500}
+	AnnotationNATDiscovery = "raven.openyurt.io/nat-discovery"
 )
@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
+
+	"github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
 )
 
 const (
@@ -29,18 +31,20 @@ const (
 
 // Options has the information that required by static-pod-upgrade operation
 type Options struct {
-	name      string
-	namespace string
-	manifest  string
-	hash      string
-	mode      string
-	timeout   time.Duration
+	name       string
+	namespace  string
+	manifest   string
+	hash       string
+	mode       string
+	timeout    time.Duration
+	resultPath string
 }
 
 // NewUpgradeOptions creates a new Options
 func NewUpgradeOptions() *Options {
 	return &Options{
-		timeout: DefaultStaticPodRunningCheckTimeout,
+		timeout:    DefaultStaticPodRunningCheckTimeout,
+		resultPath: util.DefaultTerminationMessagePath,
 	}
 }
 
@@ -51,7 +55,13 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.manifest, "manifest", o.manifest, "The manifest file name of static pod which needs be upgraded")
 	fs.StringVar(&o.hash, "hash", o.hash, "The hash value of new static pod specification")
 	fs.StringVar(&o.mode, "mode", o.mode, "The upgrade mode which is used")
-	fs.DurationVar(&o.timeout, "timeout", o.timeout, "The timeout for upgrade success check.")
+	fs.DurationVar(&o.timeout, "timeout", o.timeout, "The timeout for upgrade success check, the manifest is rolled back if the new static pod is not ready in time.")
+	fs.StringVar(&o.resultPath, "result-path", o.resultPath, "The file which the upgrade result is written to, empty means not writing the result.")
+}
+
+// ResultPath returns the file which the upgrade result is written to
+func (o *Options) ResultPath() string {
+	return o.resultPath
 }
 
 // Validate validates Options
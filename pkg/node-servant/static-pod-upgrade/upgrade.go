@@ -23,6 +23,7 @@ import (
 	"strings"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
 	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
@@ -57,6 +58,9 @@ type Controller struct {
 	configMapDataPath string
 	// The latest manifest path, default `/etc/kubernetes/manifests/openyurtio-upgrade/manifestName.upgrade`
 	upgradeManifestPath string
+
+	// The outcome of the latest upgrade
+	result *util.UpgradeResult
 }
 
 func NewWithOptions(o *Options) (*Controller, error) {
@@ -72,6 +76,7 @@ func New(name, namespace, manifest, mode string) *Controller {
 		namespace:   namespace,
 		manifest:    manifest,
 		upgradeMode: mode,
+		timeout:     DefaultStaticPodRunningCheckTimeout,
 	}
 	ctrl.manifestPath = filepath.Join(DefaultManifestPath, util.WithYamlSuffix(ctrl.manifest))
 	ctrl.bakManifestPath = filepath.Join(DefaultUpgradePath, util.WithBackupSuffix(ctrl.manifest))
@@ -81,8 +86,24 @@ func New(name, namespace, manifest, mode string) *Controller {
 	return ctrl
 }
 
+// SetHash sets the hash of the latest static pod, which is used to verify the upgrade
+func (ctrl *Controller) SetHash(hash string) {
+	ctrl.hash = hash
+}
+
+// SetTimeout sets how long to wait for the latest static pod to be ready before rolling back
+func (ctrl *Controller) SetTimeout(timeout time.Duration) {
+	ctrl.timeout = timeout
+}
+
+// Result returns the outcome of the latest upgrade, it's nil if no upgrade is conducted
+func (ctrl *Controller) Result() *util.UpgradeResult {
+	return ctrl.result
+}
+
 func (ctrl *Controller) Upgrade() error {
 	if err := ctrl.createUpgradeSpace(); err != nil {
+		ctrl.setResult(util.UpgradeFailed, util.ReasonPrepareFailed, err)
 		return err
 	}
 	klog.Info("Create upgrade space success")
@@ -101,36 +122,29 @@ func (ctrl *Controller) Upgrade() error {
 func (ctrl *Controller) AutoUpgrade() error {
 	// (1) Prepare the latest manifest
 	if err := ctrl.prepareManifest(); err != nil {
+		ctrl.setResult(util.UpgradeFailed, util.ReasonPrepareFailed, err)
 		return err
 	}
 	klog.Info("Auto prepare upgrade manifest success")
 
 	// (2) Back up the old manifest in case of upgrade failure
 	if err := ctrl.backupManifest(); err != nil {
+		ctrl.setResult(util.UpgradeFailed, util.ReasonPrepareFailed, err)
 		return err
 	}
 	klog.Info("Auto upgrade backupManifest success")
 
 	// (3) Replace manifest and kubelet will upgrade the static pod automatically
 	if err := ctrl.replaceManifest(); err != nil {
+		ctrl.rollback(util.ReasonReplaceFailed, err)
 		return err
 	}
 	klog.Info("Auto upgrade replaceManifest success")
 
-	// (4) Verify the new static pod is running
-	ok, err := ctrl.verify()
-	if err != nil {
-		if err := ctrl.rollbackManifest(); err != nil {
-			klog.Errorf("could not rollback manifest when upgrade failed, %v", err)
-		}
+	// (4) Verify the new static pod is ready, roll back if not
+	if err := ctrl.verifyOrRollback(); err != nil {
 		return err
 	}
-	if !ok {
-		if err := ctrl.rollbackManifest(); err != nil {
-			klog.Errorf("could not rollback manifest when upgrade failed, %v", err)
-		}
-		return fmt.Errorf("the latest static pod is not running")
-	}
 	klog.Info("Auto upgrade verify success")
 
 	return nil
@@ -139,19 +153,66 @@ func (ctrl *Controller) AutoUpgrade() error {
 func (ctrl *Controller) OTAUpgrade() error {
 	// (1) Back up the old manifest in case of upgrade failure
 	if err := ctrl.backupManifest(); err != nil {
+		ctrl.setResult(util.UpgradeFailed, util.ReasonPrepareFailed, err)
 		return err
 	}
 	klog.Info("OTA upgrade backupManifest success")
 
 	// (2) Replace manifest and kubelet will upgrade the static pod automatically
 	if err := ctrl.replaceManifest(); err != nil {
+		ctrl.rollback(util.ReasonReplaceFailed, err)
 		return err
 	}
 	klog.Info("OTA upgrade replaceManifest success")
 
+	// (3) Verify the new static pod is ready, roll back if not
+	if err := ctrl.verifyOrRollback(); err != nil {
+		return err
+	}
+	klog.Info("OTA upgrade verify success")
+
+	return nil
+}
+
+// verifyOrRollback verifies the latest static pod is ready, and rolls back the manifest if it's not
+func (ctrl *Controller) verifyOrRollback() error {
+	ok, err := ctrl.verify()
+	if err == nil && !ok {
+		err = fmt.Errorf("the latest static pod is not running")
+	}
+	if err != nil {
+		ctrl.rollback(util.ReasonNotReady, err)
+		return err
+	}
+
+	ctrl.setResult(util.UpgradeSucceeded, "", nil)
 	return nil
 }
 
+// rollback restores the backup manifest and records the result
+func (ctrl *Controller) rollback(reason string, cause error) {
+	if err := ctrl.rollbackManifest(); err != nil {
+		klog.Errorf("could not rollback manifest when upgrade failed, %v", err)
+		ctrl.setResult(util.UpgradeFailed, util.ReasonRollbackFailed,
+			fmt.Errorf("%s: %v, and could not rollback manifest: %v", reason, cause, err))
+		return
+	}
+	klog.Infof("Rollback manifest %s success", ctrl.manifestPath)
+	ctrl.setResult(util.UpgradeRolledBack, reason, cause)
+}
+
+func (ctrl *Controller) setResult(result util.UpgradeResultType, reason string, err error) {
+	ctrl.result = &util.UpgradeResult{
+		Hash:   ctrl.hash,
+		Result: result,
+		Reason: reason,
+		Time:   metav1.Now(),
+	}
+	if err != nil {
+		ctrl.result.Message = err.Error()
+	}
+}
+
 // createUpgradeSpace creates DefaultUpgradePath if it doesn't exist
 func (ctrl *Controller) createUpgradeSpace() error {
 	// Make sure upgrade dir exist
@@ -183,8 +244,8 @@ func (ctrl *Controller) rollbackManifest() error {
 	return util.CopyFile(ctrl.bakManifestPath, ctrl.manifestPath)
 }
 
-// verify make sure the latest static pod is running
+// verify make sure the latest static pod is ready
 // return false when the latest static pod failed or check status time out
 func (ctrl *Controller) verify() (bool, error) {
-	return util.WaitForPodRunning(ctrl.namespace, ctrl.name, ctrl.hash, ctrl.timeout)
+	return util.WaitForPodReady(ctrl.namespace, ctrl.name, ctrl.hash, ctrl.timeout)
 }
@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// UpgradeResultsAnnotation is the annotation on node which records the results of OTA upgrades.
+	// Its value is a JSON map from `namespace/yurtStaticSetName` or `DaemonSet/namespace/daemonSetName`
+	// to UpgradeResult.
+	UpgradeResultsAnnotation = "openyurt.io/static-pod-upgrade-results"
+
+	// ReadyTimeoutAnnotation is the annotation on the configmap of YurtStaticSet which specifies
+	// how long to wait for the upgraded static pod to be ready before rolling back.
+	ReadyTimeoutAnnotation = "openyurt.io/static-pod-ready-timeout"
+
+	// DefaultTerminationMessagePath is the path which the upgrade worker writes its result to,
+	// and kubelet reports it in the container status of worker pod.
+	DefaultTerminationMessagePath = "/dev/termination-log"
+
+	// maxTerminationMessageLength is the max length of termination message supported by kubelet
+	maxTerminationMessageLength = 4096
+)
+
+// UpgradeResultType is the outcome of a static pod upgrade
+type UpgradeResultType string
+
+const (
+	// UpgradeInProgress means the upgrade has started and the new static pod is not verified yet
+	UpgradeInProgress UpgradeResultType = "InProgress"
+	// UpgradeSucceeded means the new static pod is ready
+	UpgradeSucceeded UpgradeResultType = "Succeeded"
+	// UpgradeRolledBack means the new static pod is not ready, and the old manifest has been restored
+	UpgradeRolledBack UpgradeResultType = "RolledBack"
+	// UpgradeFailed means the upgrade failed and the manifest could not be restored
+	UpgradeFailed UpgradeResultType = "Failed"
+)
+
+// Reasons of failed or rolled back upgrades
+const (
+	ReasonPrepareFailed  = "PrepareFailed"
+	ReasonReplaceFailed  = "ReplaceFailed"
+	ReasonNotReady       = "NotReady"
+	ReasonRollbackFailed = "RollbackFailed"
+)
+
+// UpgradeResult records the outcome of a static pod upgrade on a node
+type UpgradeResult struct {
+	// Hash of the static pod which is upgraded to. For daemon pods, it's the revision hash of
+	// the old pod which is upgraded from, since the new revision is decided by the DaemonSet.
+	Hash string `json:"hash"`
+	// Result of the upgrade
+	Result UpgradeResultType `json:"result"`
+	// Reason is a brief CamelCase string that describes why the upgrade is not succeeded
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the failure
+	Message string `json:"message,omitempty"`
+	// Time when the upgrade finished
+	Time metav1.Time `json:"time"`
+}
+
+// Succeeded returns whether the upgrade is succeeded
+func (r *UpgradeResult) Succeeded() bool {
+	return r.Result == UpgradeSucceeded
+}
+
+// Finished returns whether the upgrade is finished, no matter it's succeeded or not
+func (r *UpgradeResult) Finished() bool {
+	return r.Result != UpgradeInProgress
+}
+
+// WriteTerminationMessage writes the result to the given path, so that it can be read from pod status
+func (r *UpgradeResult) WriteTerminationMessage(path string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > maxTerminationMessageLength {
+		// drop the message and keep the result readable
+		short := *r
+		short.Message = ""
+		if data, err = json.Marshal(&short); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ParseUpgradeResult parses the result written by WriteTerminationMessage
+func ParseUpgradeResult(data string) (*UpgradeResult, error) {
+	result := &UpgradeResult{}
+	if err := json.Unmarshal([]byte(data), result); err != nil {
+		return nil, fmt.Errorf("could not parse static pod upgrade result, %v", err)
+	}
+	return result, nil
+}
+
+// UpgradeResultKey returns the key of UpgradeResultsAnnotation for the given YurtStaticSet
+func UpgradeResultKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// DaemonSetUpgradeResultKey returns the key of UpgradeResultsAnnotation for the given DaemonSet
+func DaemonSetUpgradeResultKey(namespace, name string) string {
+	return "DaemonSet/" + namespace + "/" + name
+}
+
+// GetUpgradeResults parses UpgradeResultsAnnotation from the given annotations
+func GetUpgradeResults(annotations map[string]string) (map[string]*UpgradeResult, error) {
+	results := make(map[string]*UpgradeResult)
+	v, ok := annotations[UpgradeResultsAnnotation]
+	if !ok || len(v) == 0 {
+		return results, nil
+	}
+
+	if err := json.Unmarshal([]byte(v), &results); err != nil {
+		return nil, fmt.Errorf("could not parse annotation %s, %v", UpgradeResultsAnnotation, err)
+	}
+	return results, nil
+}
+
+// SetUpgradeResult records the result of the given YurtStaticSet into UpgradeResultsAnnotation
+func SetUpgradeResult(annotations map[string]string, key string, result *UpgradeResult) (map[string]string, error) {
+	results, err := GetUpgradeResults(annotations)
+	if err != nil {
+		// the broken annotation will be overwritten
+		results = make(map[string]*UpgradeResult)
+	}
+	results[key] = result
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[UpgradeResultsAnnotation] = string(data)
+	return annotations, nil
+}
+
+// RemoveUpgradeResult removes the result with the given key from UpgradeResultsAnnotation,
+// it returns whether the result is found and removed.
+func RemoveUpgradeResult(annotations map[string]string, key string) (bool, error) {
+	results, err := GetUpgradeResults(annotations)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := results[key]; !ok {
+		return false, nil
+	}
+	delete(results, key)
+
+	if len(results) == 0 {
+		delete(annotations, UpgradeResultsAnnotation)
+		return true, nil
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return false, err
+	}
+	annotations[UpgradeResultsAnnotation] = string(data)
+	return true, nil
+}
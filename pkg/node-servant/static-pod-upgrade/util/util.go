@@ -68,11 +68,11 @@ func CopyFile(src, dst string) error {
 	return nil
 }
 
-// WaitForPodRunning waits static pod to run
-// Success: Static pod annotation `StaticPodHashAnnotation` value equals to function argument hash
+// WaitForPodReady waits static pod to be ready
+// Success: Static pod annotation `StaticPodHashAnnotation` value equals to function argument hash, and pod is ready
 // Failed: Receive PodFailed event
-func WaitForPodRunning(namespace, name, hash string, timeout time.Duration) (bool, error) {
-	klog.Infof("WaitForPodRunning namespace is %s, name is %s", namespace, name)
+func WaitForPodReady(namespace, name, hash string, timeout time.Duration) (bool, error) {
+	klog.Infof("WaitForPodReady namespace is %s, name is %s", namespace, name)
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -81,7 +81,7 @@ func WaitForPodRunning(namespace, name, hash string, timeout time.Duration) (boo
 
 	checkPod := func(pod *v1.Pod) (hasResult, result bool) {
 		h := pod.Annotations[StaticPodHashAnnotation]
-		if pod.Status.Phase == v1.PodRunning && h == hash {
+		if pod.Status.Phase == v1.PodRunning && isPodReady(pod) && h == hash {
 			return true, true
 		}
 
@@ -95,7 +95,7 @@ func WaitForPodRunning(namespace, name, hash string, timeout time.Duration) (boo
 	for {
 		select {
 		case <-ctx.Done():
-			return false, fmt.Errorf("timeout waiting for static pod %s/%s to be running", namespace, name)
+			return false, fmt.Errorf("timeout waiting for static pod %s/%s to be ready", namespace, name)
 		case <-ticker.C:
 			pod, err := GetPodFromYurtHub(namespace, name)
 			if err != nil {
@@ -110,3 +110,12 @@ func WaitForPodRunning(namespace, name, hash string, timeout time.Duration) (boo
 		}
 	}
 }
+
+func isPodReady(pod *v1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
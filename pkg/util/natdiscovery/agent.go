@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package natdiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+// DefaultDiscoveryPeriod is the default period of discovering the NAT mapping.
+const DefaultDiscoveryPeriod = 5 * time.Minute
+
+// TunnelConn lends the UDP socket which the tunnel of node binds to the discovery, the tunnel must not read from
+// the socket until release is called, so that the responses of reflector are received by the discovery. It returns
+// a nil conn and release if the tunnel is not up.
+type TunnelConn func() (conn *net.UDPConn, release func())
+
+// Agent runs the reflector and the discovery on the gateway nodes. It is not run by any component of this
+// repository, raven agent embeds it and lends the socket of its tunnel to it. On an active tunnel endpoint of an
+// exposed gateway it runs the reflector, and on the nodes of gateways which are not exposed it discovers the NAT
+// mapping of the tunnel by querying the reflector of an exposed gateway, and reports the result in the annotation
+// of node.
+type Agent struct {
+	client     client.Client
+	nodeName   string
+	tunnelConn TunnelConn
+	period     time.Duration
+
+	stopReflector context.CancelFunc
+}
+
+// NewAgent creates the agent of node, the discovery queries from the socket lent by tunnelConn.
+func NewAgent(c client.Client, nodeName string, tunnelConn TunnelConn, period time.Duration) *Agent {
+	if period <= 0 {
+		period = DefaultDiscoveryPeriod
+	}
+	return &Agent{client: c, nodeName: nodeName, tunnelConn: tunnelConn, period: period}
+}
+
+// Run runs the reflector or the discovery as the role of node in its gateway changes, until ctx is done.
+func (a *Agent) Run(ctx context.Context) {
+	wait.UntilWithContext(ctx, a.sync, a.period)
+	a.stopReflecting()
+}
+
+func (a *Agent) sync(ctx context.Context) {
+	var node corev1.Node
+	if err := a.client.Get(ctx, types.NamespacedName{Name: a.nodeName}, &node); err != nil {
+		klog.Errorf("could not get node %s, %v", a.nodeName, err)
+		return
+	}
+	gwName := node.Labels[raven.LabelCurrentGateway]
+	if gwName == "" {
+		a.stopReflecting()
+		return
+	}
+	var gw ravenv1beta1.Gateway
+	if err := a.client.Get(ctx, types.NamespacedName{Name: gwName}, &gw); err != nil {
+		klog.Errorf("could not get gateway %s, %v", gwName, err)
+		return
+	}
+
+	if gw.Spec.ExposeType != "" {
+		if activeTunnelEndpoint(&gw, a.nodeName) != nil {
+			a.startReflecting(ctx)
+		} else {
+			a.stopReflecting()
+		}
+		return
+	}
+	a.stopReflecting()
+
+	primary, alternate, checkFiltering, err := a.findReflector(ctx)
+	if err != nil {
+		klog.V(4).Infof("skip nat discovery of node %s, %v", a.nodeName, err)
+		return
+	}
+	conn, release := a.tunnelConn()
+	if conn == nil {
+		klog.V(4).Infof("skip nat discovery of node %s, the tunnel is not up", a.nodeName)
+		return
+	}
+	result, err := Discover(ctx, conn, primary, alternate, checkFiltering, DefaultTimeout)
+	release()
+	if err != nil {
+		klog.Errorf("could not discover nat mapping of node %s, %v", a.nodeName, err)
+		return
+	}
+	if err := a.report(ctx, &node, result); err != nil {
+		klog.Errorf("could not report nat discovery of node %s, %v", a.nodeName, err)
+	}
+}
+
+func (a *Agent) startReflecting(ctx context.Context) {
+	if a.stopReflector != nil {
+		return
+	}
+	reflector, err := NewReflector(fmt.Sprintf(":%d", DefaultPort), fmt.Sprintf(":%d", DefaultAlternatePort))
+	if err != nil {
+		klog.Errorf("could not start nat discovery reflector, %v", err)
+		return
+	}
+	reflectorCtx, cancel := context.WithCancel(ctx)
+	a.stopReflector = cancel
+	go reflector.Run(reflectorCtx)
+	klog.Infof("nat discovery reflector is started on %s and %s", reflector.PrimaryAddr(), reflector.AlternateAddr())
+}
+
+func (a *Agent) stopReflecting() {
+	if a.stopReflector == nil {
+		return
+	}
+	a.stopReflector()
+	a.stopReflector = nil
+	klog.Info("nat discovery reflector is stopped")
+}
+
+// findReflector returns the reflector addresses of an exposed gateway, the reflector is run by its active
+// tunnel endpoint and reachable at the public IP of the endpoint. The NAT filtering could not be checked through
+// a load balancer, which drops the response from the alternate port since it has no connection tracked for it.
+func (a *Agent) findReflector(ctx context.Context) (*net.UDPAddr, *net.UDPAddr, bool, error) {
+	var gws ravenv1beta1.GatewayList
+	if err := a.client.List(ctx, &gws); err != nil {
+		return nil, nil, false, err
+	}
+	for i := range gws.Items {
+		if gws.Items[i].Spec.ExposeType == "" {
+			continue
+		}
+		for _, aep := range gws.Items[i].Status.ActiveEndpoints {
+			if aep.Type != ravenv1beta1.Tunnel {
+				continue
+			}
+			ip := net.ParseIP(aep.PublicIP)
+			if ip == nil {
+				continue
+			}
+			checkFiltering := gws.Items[i].Spec.ExposeType != ravenv1beta1.ExposeTypeLoadBalancer
+			return &net.UDPAddr{IP: ip, Port: DefaultPort}, &net.UDPAddr{IP: ip, Port: DefaultAlternatePort}, checkFiltering, nil
+		}
+	}
+	return nil, nil, false, fmt.Errorf("no exposed gateway with a public tunnel endpoint")
+}
+
+// report records the result in the annotation of node, the node is not patched if only the discovery time changes.
+func (a *Agent) report(ctx context.Context, node *corev1.Node, result *Result) error {
+	if val, ok := node.Annotations[raven.AnnotationNATDiscovery]; ok {
+		var previous Result
+		if err := json.Unmarshal([]byte(val), &previous); err == nil {
+			previous.DiscoveryTime = result.DiscoveryTime
+			if previous == *result {
+				return nil
+			}
+		}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[raven.AnnotationNATDiscovery] = string(data)
+	return a.client.Patch(ctx, node, patch)
+}
+
+// activeTunnelEndpoint returns the active tunnel endpoint of the gateway on the node, nil if it is not active.
+func activeTunnelEndpoint(gw *ravenv1beta1.Gateway, nodeName string) *ravenv1beta1.Endpoint {
+	for _, aep := range gw.Status.ActiveEndpoints {
+		if aep.Type == ravenv1beta1.Tunnel && aep.NodeName == nodeName {
+			return aep
+		}
+	}
+	return nil
+}
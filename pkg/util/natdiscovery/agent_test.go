@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package natdiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+func TestAgentReport(t *testing.T) {
+	result := &Result{
+		UnderNAT:      true,
+		NATType:       ravenv1beta1.NATTypeRestrictedCone,
+		PublicIP:      "47.0.0.1",
+		PublicPort:    4500,
+		DiscoveryTime: metav1.NewTime(time.Now().Truncate(time.Second)),
+	}
+	reported, err := json.Marshal(result)
+	if !assert.NoError(t, err) {
+		return
+	}
+	testcases := map[string]struct {
+		annotation string
+		result     Result
+		patched    bool
+	}{
+		"not reported": {
+			result:  *result,
+			patched: true,
+		},
+		"only discovery time changes": {
+			annotation: string(reported),
+			result:     Result{UnderNAT: true, NATType: result.NATType, PublicIP: result.PublicIP, PublicPort: result.PublicPort, DiscoveryTime: metav1.NewTime(result.DiscoveryTime.Add(time.Minute))},
+			patched:    false,
+		},
+		"public port changes": {
+			annotation: string(reported),
+			result:     Result{UnderNAT: true, NATType: result.NATType, PublicIP: result.PublicIP, PublicPort: 4501, DiscoveryTime: result.DiscoveryTime},
+			patched:    true,
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", ResourceVersion: "1"}}
+			if tc.annotation != "" {
+				node.Annotations = map[string]string{raven.AnnotationNATDiscovery: tc.annotation}
+			}
+			c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(node).Build()
+			a := NewAgent(c, node.Name, nil, 0)
+			if !assert.NoError(t, a.report(context.Background(), node.DeepCopy(), &tc.result)) {
+				return
+			}
+
+			var got corev1.Node
+			if !assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: node.Name}, &got)) {
+				return
+			}
+			assert.Equal(t, tc.patched, got.ResourceVersion != node.ResourceVersion)
+			var gotResult Result
+			if assert.NoError(t, json.Unmarshal([]byte(got.Annotations[raven.AnnotationNATDiscovery]), &gotResult)) {
+				assert.Equal(t, tc.result.PublicPort, gotResult.PublicPort)
+			}
+		})
+	}
+}
+
+func TestActiveTunnelEndpoint(t *testing.T) {
+	gw := &ravenv1beta1.Gateway{
+		Status: ravenv1beta1.GatewayStatus{
+			ActiveEndpoints: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Proxy},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel},
+			},
+		},
+	}
+	assert.Nil(t, activeTunnelEndpoint(gw, "node-1"))
+	assert.NotNil(t, activeTunnelEndpoint(gw, "node-2"))
+}
+
+func TestAgentFindReflector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, ravenv1beta1.AddToScheme(scheme))
+	newGateway := func(name, exposeType string) *ravenv1beta1.Gateway {
+		return &ravenv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       ravenv1beta1.GatewaySpec{ExposeType: exposeType},
+			Status: ravenv1beta1.GatewayStatus{
+				ActiveEndpoints: []*ravenv1beta1.Endpoint{{NodeName: name, Type: ravenv1beta1.Tunnel, PublicIP: "47.0.0.1"}},
+			},
+		}
+	}
+	testcases := map[string]struct {
+		gateway        *ravenv1beta1.Gateway
+		checkFiltering bool
+	}{
+		"public ip": {
+			gateway:        newGateway("gw-public", ravenv1beta1.ExposeTypePublicIP),
+			checkFiltering: true,
+		},
+		"load balancer": {
+			gateway:        newGateway("gw-lb", ravenv1beta1.ExposeTypeLoadBalancer),
+			checkFiltering: false,
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.gateway).Build()
+			a := NewAgent(c, "node-1", nil, 0)
+			primary, alternate, checkFiltering, err := a.findReflector(context.Background())
+			if assert.NoError(t, err) {
+				assert.Equal(t, "47.0.0.1:3478", primary.String())
+				assert.Equal(t, "47.0.0.1:3479", alternate.String())
+				assert.Equal(t, tc.checkFiltering, checkFiltering)
+			}
+		})
+	}
+}
+
+func TestAgentSkipsDiscoveryWithoutTunnel(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, ravenv1beta1.AddToScheme(scheme))
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{raven.LabelCurrentGateway: "gw-edge"}}}
+	exposed := &ravenv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw-cloud"},
+		Spec:       ravenv1beta1.GatewaySpec{ExposeType: ravenv1beta1.ExposeTypePublicIP},
+		Status: ravenv1beta1.GatewayStatus{
+			ActiveEndpoints: []*ravenv1beta1.Endpoint{{NodeName: "node-2", Type: ravenv1beta1.Tunnel, PublicIP: "127.0.0.1"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(node, exposed, &ravenv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw-edge"}}).Build()
+
+	lent := 0
+	a := NewAgent(c, node.Name, func() (*net.UDPConn, func()) {
+		lent++
+		return nil, nil
+	}, 0)
+	a.sync(context.Background())
+	assert.Equal(t, 1, lent)
+
+	var got corev1.Node
+	if assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: node.Name}, &got)) {
+		assert.NotContains(t, got.Annotations, raven.AnnotationNATDiscovery)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package natdiscovery
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+const (
+	// DefaultTimeout is the default timeout to wait for a response of reflector.
+	DefaultTimeout = 3 * time.Second
+
+	retries = 3
+)
+
+// ErrNoResponse is returned if the reflector does not respond, e.g. UDP is blocked.
+var ErrNoResponse = errors.New("no response from reflector")
+
+// Discover queries the reflector from conn, which must be the socket the tunnel of endpoint binds, so that the
+// NAT mapping of the tunnel is discovered. conn is not closed by Discover. The NAT type is discovered by the
+// following tests:
+//  1. query the primary port, if the mapped address is the local address, the endpoint is not under NAT.
+//  2. query the primary port and ask for a response from the alternate port, if it is received, the NAT
+//     does not filter by port and is RestrictedCone. A reflector with a single IP could not tell FullCone
+//     from RestrictedCone, so FullCone is reported as RestrictedCone.
+//  3. query the alternate port, if the mapped address differs from test 1, the NAT is Symmetric,
+//     otherwise it is PortRestrictedCone if test 2 failed.
+//
+// Test 2 is skipped if checkFiltering is false, e.g. the reflector is behind a load balancer which drops the
+// response from the alternate port since it has no connection tracked for it, then the NAT which is not
+// Symmetric is reported as PortRestrictedCone, the most restrictive cone NAT.
+func Discover(ctx context.Context, conn *net.UDPConn, primary, alternate *net.UDPAddr, checkFiltering bool, timeout time.Duration) (*Result, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	localIP, err := outboundIP(conn, primary)
+	if err != nil {
+		return nil, err
+	}
+	localPort := conn.LocalAddr().(*net.UDPAddr).Port
+
+	// test 1
+	mapped, err := query(ctx, conn, primary, primary, false, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("could not query reflector %s, %w", primary.String(), err)
+	}
+	result := &Result{PublicIP: mapped.MappedIP, PublicPort: mapped.MappedPort, DiscoveryTime: metav1.Now()}
+	if net.ParseIP(mapped.MappedIP).Equal(localIP) && mapped.MappedPort == localPort {
+		return result, nil
+	}
+	result.UnderNAT = true
+
+	// test 2, it must be done before the alternate port is queried, which opens the NAT filter for it
+	filterByPort := true
+	if checkFiltering {
+		_, err = query(ctx, conn, primary, alternate, true, timeout)
+		if err != nil && !errors.Is(err, ErrNoResponse) {
+			return nil, fmt.Errorf("could not query reflector %s, %w", primary.String(), err)
+		}
+		filterByPort = err != nil
+	}
+
+	// test 3
+	altMapped, err := query(ctx, conn, alternate, alternate, false, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("could not query reflector %s, %w", alternate.String(), err)
+	}
+	result.NATType = classify(mapped, altMapped, filterByPort)
+	return result, nil
+}
+
+// classify returns the NAT type by the mapped addresses of the primary and alternate port of reflector.
+func classify(mapped, altMapped *response, filterByPort bool) string {
+	switch {
+	case mapped.MappedIP != altMapped.MappedIP || mapped.MappedPort != altMapped.MappedPort:
+		return ravenv1beta1.NATTypeSymmetric
+	case filterByPort:
+		return ravenv1beta1.NATTypePortRestrictedCone
+	default:
+		return ravenv1beta1.NATTypeRestrictedCone
+	}
+}
+
+// query sends a request to dst and waits for the response from expected address, the request is retried on timeout.
+func query(ctx context.Context, conn *net.UDPConn, dst, expected *net.UDPAddr, respondFromAlternate bool, timeout time.Duration) (*response, error) {
+	id, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+	data, err := encodeRequest(&request{ID: id, RespondFromAlternate: respondFromAlternate})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, maxMessageSize)
+	for i := 0; i < retries; i++ {
+		if _, err := conn.WriteToUDP(data, dst); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(timeout / retries)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		for {
+			n, src, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					break
+				}
+				return nil, err
+			}
+			if !src.IP.Equal(expected.IP) || src.Port != expected.Port {
+				continue
+			}
+			var resp response
+			if err := json.Unmarshal(buf[:n], &resp); err != nil || resp.ID != id {
+				continue
+			}
+			return &resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, ErrNoResponse
+}
+
+// encodeRequest marshals the request padded to requestSize, the reflector does not respond to
+// a request which is smaller than the response.
+func encodeRequest(req *request) ([]byte, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < requestSize {
+		// the padding field adds the length of `,"padding":""` besides the padding itself
+		padded := *req
+		padded.Padding = strings.Repeat("0", max(requestSize-len(data)-len(`,"padding":""`), 0))
+		return json.Marshal(&padded)
+	}
+	return data, nil
+}
+
+// outboundIP returns the local IP of conn to reach dst, it is the IP which conn binds if it is not unspecified.
+func outboundIP(conn *net.UDPConn, dst *net.UDPAddr) (net.IP, error) {
+	local := conn.LocalAddr().(*net.UDPAddr)
+	if !local.IP.IsUnspecified() {
+		return local.IP, nil
+	}
+	// no packet is sent by dialing an udp address
+	c, err := net.DialUDP("udp", nil, dst)
+	if err != nil {
+		return nil, fmt.Errorf("could not get local address to %s, %w", dst.String(), err)
+	}
+	defer c.Close()
+	return c.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+func newRequestID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
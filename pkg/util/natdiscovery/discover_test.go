@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package natdiscovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+func TestClassify(t *testing.T) {
+	mapped := &response{MappedIP: "47.0.0.1", MappedPort: 4500}
+	testcases := map[string]struct {
+		altMapped    *response
+		filterByPort bool
+		expect       string
+	}{
+		"symmetric": {
+			altMapped: &response{MappedIP: "47.0.0.1", MappedPort: 4501},
+			expect:    ravenv1beta1.NATTypeSymmetric,
+		},
+		"port restricted cone": {
+			altMapped:    &response{MappedIP: "47.0.0.1", MappedPort: 4500},
+			filterByPort: true,
+			expect:       ravenv1beta1.NATTypePortRestrictedCone,
+		},
+		"restricted cone": {
+			altMapped: &response{MappedIP: "47.0.0.1", MappedPort: 4500},
+			expect:    ravenv1beta1.NATTypeRestrictedCone,
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, tc.expect, classify(mapped, tc.altMapped, tc.filterByPort))
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	reflector, err := NewReflector("127.0.0.1:0", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reflector.Run(ctx)
+
+	t.Run("not under nat", func(t *testing.T) {
+		conn, err := listenUDP("127.0.0.1:0")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		result, err := Discover(ctx, conn, reflector.PrimaryAddr(), reflector.AlternateAddr(), true, time.Second)
+		if assert.NoError(t, err) {
+			assert.False(t, result.UnderNAT)
+			assert.Equal(t, "127.0.0.1", result.PublicIP)
+			assert.Equal(t, conn.LocalAddr().(*net.UDPAddr).Port, result.PublicPort)
+		}
+	})
+
+	t.Run("no response", func(t *testing.T) {
+		conn, err := listenUDP("127.0.0.1:0")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		silent, err := listenUDP("127.0.0.1:0")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer silent.Close()
+		addr := silent.LocalAddr().(*net.UDPAddr)
+		_, err = Discover(ctx, conn, addr, addr, true, 300*time.Millisecond)
+		assert.True(t, errors.Is(err, ErrNoResponse))
+	})
+}
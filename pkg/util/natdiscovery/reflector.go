@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package natdiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// sourceRate and sourceBurst limit the requests from each source IP, a discovery sends a few requests only.
+	sourceRate  = rate.Limit(5)
+	sourceBurst = 10
+	// maxSources is the max number of source IPs tracked by the rate limiter, the requests from new sources
+	// are dropped once it is reached and no source is idle for sourceIdleTimeout.
+	maxSources        = 4096
+	sourceIdleTimeout = time.Minute
+)
+
+// Reflector responds to each request with the source address of the request, from its primary port
+// or its alternate port as the request asks. The requests are rate limited per source IP, and the
+// response is never larger than the request.
+type Reflector struct {
+	primary   *net.UDPConn
+	alternate *net.UDPConn
+	limiter   *sourceLimiter
+}
+
+// NewReflector listens on the primary and the alternate address, which are expected to share the same IP.
+func NewReflector(primaryAddr, alternateAddr string) (*Reflector, error) {
+	primary, err := listenUDP(primaryAddr)
+	if err != nil {
+		return nil, err
+	}
+	alternate, err := listenUDP(alternateAddr)
+	if err != nil {
+		primary.Close()
+		return nil, err
+	}
+	return &Reflector{primary: primary, alternate: alternate, limiter: newSourceLimiter()}, nil
+}
+
+// PrimaryAddr returns the address of the primary port.
+func (r *Reflector) PrimaryAddr() *net.UDPAddr {
+	return r.primary.LocalAddr().(*net.UDPAddr)
+}
+
+// AlternateAddr returns the address of the alternate port.
+func (r *Reflector) AlternateAddr() *net.UDPAddr {
+	return r.alternate.LocalAddr().(*net.UDPAddr)
+}
+
+// Run serves the requests until ctx is done.
+func (r *Reflector) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, conn := range []*net.UDPConn{r.primary, r.alternate} {
+		wg.Add(1)
+		go func(conn *net.UDPConn) {
+			defer wg.Done()
+			r.serve(conn)
+		}(conn)
+	}
+	<-ctx.Done()
+	r.primary.Close()
+	r.alternate.Close()
+	wg.Wait()
+}
+
+func (r *Reflector) serve(conn *net.UDPConn) {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			klog.Errorf("could not read nat discovery request, %v", err)
+			continue
+		}
+		if !r.limiter.allow(src.IP, time.Now()) {
+			klog.V(4).Infof("drop nat discovery request from %s, rate limited", src.String())
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(buf[:n], &req); err != nil || req.ID == "" {
+			klog.V(4).Infof("ignore invalid nat discovery request from %s", src.String())
+			continue
+		}
+		data, err := json.Marshal(&response{ID: req.ID, MappedIP: src.IP.String(), MappedPort: src.Port})
+		if err != nil {
+			klog.Errorf("could not marshal nat discovery response, %v", err)
+			continue
+		}
+		if len(data) > n {
+			klog.V(4).Infof("drop nat discovery request from %s, it is smaller than the response", src.String())
+			continue
+		}
+		replyConn := conn
+		if req.RespondFromAlternate {
+			replyConn = r.alternate
+		}
+		if _, err := replyConn.WriteToUDP(data, src); err != nil {
+			klog.V(4).Infof("could not respond nat discovery request from %s, %v", src.String(), err)
+		}
+	}
+}
+
+// sourceLimiter limits the rate of requests from each source IP
+type sourceLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*sourceEntry
+}
+
+type sourceEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newSourceLimiter() *sourceLimiter {
+	return &sourceLimiter{limiters: make(map[string]*sourceEntry)}
+}
+
+// allow returns whether a request from the source IP is allowed at now
+func (l *sourceLimiter) allow(ip net.IP, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := ip.String()
+	entry, ok := l.limiters[key]
+	if !ok {
+		if len(l.limiters) >= maxSources {
+			l.evictIdle(now)
+			if len(l.limiters) >= maxSources {
+				return false
+			}
+		}
+		entry = &sourceEntry{limiter: rate.NewLimiter(sourceRate, sourceBurst)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
+
+func (l *sourceLimiter) evictIdle(now time.Time) {
+	for key, entry := range l.limiters {
+		if now.Sub(entry.lastSeen) > sourceIdleTimeout {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+func listenUDP(addr string) (*net.UDPConn, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve address %s, %w", addr, err)
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s, %w", addr, err)
+	}
+	return conn, nil
+}
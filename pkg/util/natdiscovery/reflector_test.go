@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package natdiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceLimiter(t *testing.T) {
+	now := time.Now()
+	l := newSourceLimiter()
+	src, other := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+	for i := 0; i < sourceBurst; i++ {
+		assert.True(t, l.allow(src, now))
+	}
+	assert.False(t, l.allow(src, now), "requests beyond the burst should be limited")
+	assert.True(t, l.allow(other, now), "other sources should not be limited")
+	assert.True(t, l.allow(src, now.Add(time.Second)), "requests should be allowed after the tokens refill")
+
+	t.Run("new sources are limited when tracked sources are full", func(t *testing.T) {
+		l := newSourceLimiter()
+		for i := 0; i < maxSources; i++ {
+			l.allow(net.IPv4(10, 1, byte(i>>8), byte(i)), now)
+		}
+		assert.False(t, l.allow(src, now))
+		assert.True(t, l.allow(src, now.Add(2*sourceIdleTimeout)), "idle sources should be evicted")
+	})
+}
+
+func TestReflectorResponseSize(t *testing.T) {
+	reflector, err := NewReflector("127.0.0.1:0", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reflector.Run(ctx)
+
+	conn, err := listenUDP("127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	buf := make([]byte, maxMessageSize)
+
+	t.Run("request smaller than response is dropped", func(t *testing.T) {
+		data, err := json.Marshal(&request{ID: "a"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = conn.WriteToUDP(data, reflector.PrimaryAddr())
+		assert.NoError(t, err)
+		conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		_, _, err = conn.ReadFromUDP(buf)
+		assert.Error(t, err)
+	})
+
+	t.Run("response is not larger than padded request", func(t *testing.T) {
+		data, err := encodeRequest(&request{ID: "b"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = conn.WriteToUDP(data, reflector.PrimaryAddr())
+		assert.NoError(t, err)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFromUDP(buf)
+		if assert.NoError(t, err) {
+			assert.LessOrEqual(t, n, len(data))
+		}
+	})
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package natdiscovery provides a STUN-like reflector and the client to discover the NAT mapping of
+// raven gateway endpoints. The reflector is run by the active endpoints of exposed gateways, and the
+// endpoints of gateways under NAT query it to learn their public address and NAT type. The result is
+// reported in the raven.openyurt.io/nat-discovery annotation of node.
+package natdiscovery
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultPort is the port of reflector on the active endpoints of exposed gateways.
+	DefaultPort = 3478
+	// DefaultAlternatePort is the alternate port of reflector, which is used to check the NAT filtering behavior.
+	DefaultAlternatePort = 3479
+
+	// maxMessageSize is the max size of request and response.
+	maxMessageSize = 512
+	// requestSize is the size which requests are padded to, so that the response is never larger than the
+	// request, and the reflector could not be used to amplify traffic to a spoofed source.
+	requestSize = 256
+)
+
+// Result is the NAT mapping of an endpoint discovered by querying a reflector.
+type Result struct {
+	// UnderNAT indicates whether the endpoint is under NAT.
+	UnderNAT bool `json:"underNAT"`
+	// NATType is the NAT type of the endpoint, empty if the endpoint is not under NAT.
+	NATType string `json:"natType,omitempty"`
+	// PublicIP is the IP address of the endpoint observed by the reflector.
+	PublicIP string `json:"publicIP,omitempty"`
+	// PublicPort is the port of the endpoint observed by the reflector.
+	PublicPort int `json:"publicPort,omitempty"`
+	// DiscoveryTime is the time the discovery is done.
+	DiscoveryTime metav1.Time `json:"discoveryTime,omitempty"`
+}
+
+// request is sent by client to the reflector.
+type request struct {
+	// ID is used to match the response with the request.
+	ID string `json:"id"`
+	// RespondFromAlternate asks the reflector to respond from its alternate port.
+	RespondFromAlternate bool `json:"respondFromAlternate,omitempty"`
+	// Padding pads the request to requestSize.
+	Padding string `json:"padding,omitempty"`
+}
+
+// response is sent by reflector with the address of client it observed.
+type response struct {
+	ID         string `json:"id"`
+	MappedIP   string `json:"mappedIP"`
+	MappedPort int    `json:"mappedPort"`
+}
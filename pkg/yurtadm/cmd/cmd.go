@@ -29,6 +29,7 @@ import (
 	"github.com/openyurtio/openyurt/pkg/yurtadm/cmd/config"
 	"github.com/openyurtio/openyurt/pkg/yurtadm/cmd/docs"
 	"github.com/openyurtio/openyurt/pkg/yurtadm/cmd/join"
+	"github.com/openyurtio/openyurt/pkg/yurtadm/cmd/ota"
 	"github.com/openyurtio/openyurt/pkg/yurtadm/cmd/renew"
 	"github.com/openyurtio/openyurt/pkg/yurtadm/cmd/reset"
 	"github.com/openyurtio/openyurt/pkg/yurtadm/cmd/staticpods"
@@ -54,6 +55,7 @@ func NewYurtadmCommand() *cobra.Command {
 	cmds.AddCommand(renew.NewCmdRenew(os.Stdin, os.Stdout, os.Stderr))
 	cmds.AddCommand(staticpods.NewCmdStaticPods(os.Stdin, os.Stdout, os.Stderr))
 	cmds.AddCommand(config.NewCmdConfig(os.Stdin, os.Stdout, os.Stderr))
+	cmds.AddCommand(ota.NewCmdOTA(os.Stdin, os.Stdout, os.Stderr))
 	klog.InitFlags(nil)
 	// goflag.Parse()
 	flag.CommandLine.AddGoFlagSet(goflag.CommandLine)
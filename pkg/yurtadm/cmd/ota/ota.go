@@ -0,0 +1,297 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ota
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	yurtconstants "github.com/openyurtio/openyurt/pkg/yurtadm/constants"
+	util "github.com/openyurtio/openyurt/pkg/yurtadm/util/error"
+	otautil "github.com/openyurtio/openyurt/pkg/yurthub/otaupdate/util"
+)
+
+type otaOptions struct {
+	yurthubServer string
+	all           bool
+	dryRun        bool
+	at            string
+	after         time.Duration
+}
+
+// NewCmdOTA returns "yurtadm ota" command.
+func NewCmdOTA(in io.Reader, out io.Writer, outErr io.Writer) *cobra.Command {
+	o := &otaOptions{yurthubServer: yurtconstants.DefaultYurtHubServerAddr}
+
+	cmd := &cobra.Command{
+		Use:   "ota",
+		Short: "Preview, approve or schedule OTA upgrades of pods on the local node",
+		Run:   subCmdRun(),
+	}
+	cmd.PersistentFlags().StringVar(&o.yurthubServer, yurtconstants.YurtHubServerAddr, o.yurthubServer,
+		"Sets the address for yurthub server addr")
+
+	cmd.AddCommand(newCmdList(o, out))
+	cmd.AddCommand(newCmdUpgrade(o, out))
+	cmd.AddCommand(newCmdScheduled(o, out))
+	cmd.AddCommand(newCmdCancel(o, out))
+	return cmd
+}
+
+// newCmdList returns "yurtadm ota list" command.
+func newCmdList(o *otaOptions, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the pods waiting for upgrade with the image changes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list := &otautil.PodUpgradeList{}
+			if err := o.do(http.MethodPost, "/openyurt.io/v1/pods/upgrade", url.Values{otautil.DryRunQuery: []string{"true"}}, list); err != nil {
+				return err
+			}
+			printUpgrades(out, list.Items)
+			return nil
+		},
+	}
+}
+
+// newCmdUpgrade returns "yurtadm ota upgrade" command.
+func newCmdUpgrade(o *otaOptions, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade [NAMESPACE/NAME ...]",
+		Short: "Upgrade the given pods, or all pods waiting for upgrade with --all",
+		Example: `  # preview the upgrade of all pods waiting for upgrade
+  yurtadm ota upgrade --all --dry-run
+
+  # upgrade a pod at 2am
+  yurtadm ota upgrade kube-system/yurt-hub-node1 --at 2024-01-01T02:00:00+08:00`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query, err := o.upgradeQuery()
+			if err != nil {
+				return usageErrorf(cmd, "%v", err)
+			}
+
+			if o.all {
+				if len(args) != 0 {
+					return usageErrorf(cmd, "pods should not be specified with --all")
+				}
+				list := &otautil.PodUpgradeList{}
+				if err := o.do(http.MethodPost, "/openyurt.io/v1/pods/upgrade", query, list); err != nil {
+					return err
+				}
+				printUpgrades(out, list.Items)
+				return nil
+			}
+
+			if len(args) == 0 {
+				return usageErrorf(cmd, "pods should be specified in the format of namespace/name, or use --all")
+			}
+			var upgrades []otautil.PodUpgrade
+			for _, arg := range args {
+				namespace, name, err := splitPod(arg)
+				if err != nil {
+					return usageErrorf(cmd, "%v", err)
+				}
+
+				upgrade := otautil.PodUpgrade{Namespace: namespace, Name: name, Status: otautil.PodUpgradeStarted}
+				if len(query) == 0 {
+					// the upgrade is applied right now, the response is an upgrade object only if the upgrade
+					// is accepted and conducted in the background
+					accepted := &otautil.PodUpgrade{}
+					if err := o.do(http.MethodPost, podUpgradePath(namespace, name), query, accepted); err != nil {
+						upgrade.Status, upgrade.Message = otautil.PodUpgradeFailed, err.Error()
+					} else if accepted.Status == otautil.PodUpgradeAccepted {
+						upgrade = *accepted
+					}
+				} else if err := o.do(http.MethodPost, podUpgradePath(namespace, name), query, &upgrade); err != nil {
+					upgrade.Status, upgrade.Message = otautil.PodUpgradeFailed, err.Error()
+				}
+				upgrades = append(upgrades, upgrade)
+			}
+			printUpgrades(out, upgrades)
+			return nil
+		},
+	}
+
+	addUpgradeFlags(cmd.Flags(), o)
+	return cmd
+}
+
+// newCmdScheduled returns "yurtadm ota scheduled" command.
+func newCmdScheduled(o *otaOptions, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "scheduled",
+		Short: "List the scheduled upgrades of pods",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list := &otautil.PodUpgradeList{}
+			if err := o.do(http.MethodGet, "/openyurt.io/v1/pods/upgrade", nil, list); err != nil {
+				return err
+			}
+			printUpgrades(out, list.Items)
+			return nil
+		},
+	}
+}
+
+// newCmdCancel returns "yurtadm ota cancel" command.
+func newCmdCancel(o *otaOptions, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel NAMESPACE/NAME ...",
+		Short: "Cancel the scheduled upgrades of pods",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return usageErrorf(cmd, "pods should be specified in the format of namespace/name")
+			}
+			for _, arg := range args {
+				namespace, name, err := splitPod(arg)
+				if err != nil {
+					return usageErrorf(cmd, "%v", err)
+				}
+				if err := o.do(http.MethodDelete, podUpgradePath(namespace, name), nil, nil); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "scheduled upgrade of pod %s/%s is canceled\n", namespace, name)
+			}
+			return nil
+		},
+	}
+}
+
+// addUpgradeFlags adds upgrade flags bound to the options to the specified flagset
+func addUpgradeFlags(flagSet *flag.FlagSet, o *otaOptions) {
+	flagSet.BoolVar(&o.all, "all", o.all, "Upgrade all pods waiting for upgrade on the node.")
+	flagSet.BoolVar(&o.dryRun, "dry-run", o.dryRun, "Only preview the upgrade without applying it.")
+	flagSet.StringVar(&o.at, "at", o.at, "Defer the upgrade to the given time in RFC3339 format, e.g. 2024-01-01T02:00:00+08:00.")
+	flagSet.DurationVar(&o.after, "after", o.after, "Defer the upgrade for the given duration, e.g. 2h.")
+}
+
+// upgradeQuery builds the query of upgrade request from options
+func (o *otaOptions) upgradeQuery() (url.Values, error) {
+	if len(o.at) != 0 && o.after != 0 {
+		return nil, fmt.Errorf("--at and --after are mutually exclusive")
+	}
+
+	query := url.Values{}
+	if o.dryRun {
+		query.Set(otautil.DryRunQuery, "true")
+	}
+	switch {
+	case len(o.at) != 0:
+		if _, err := time.Parse(time.RFC3339, o.at); err != nil {
+			return nil, fmt.Errorf("invalid --at %q, it should be in RFC3339 format", o.at)
+		}
+		query.Set(otautil.AtQuery, o.at)
+	case o.after < 0:
+		return nil, fmt.Errorf("--after should not be negative")
+	case o.after > 0:
+		query.Set(otautil.AtQuery, time.Now().Add(o.after).Format(time.RFC3339))
+	}
+	return query, nil
+}
+
+// do sends the request to the OTA API of yurthub server, and decodes the response into obj if it's not nil
+func (o *otaOptions) do(method, path string, query url.Values, obj interface{}) error {
+	u := url.URL{Scheme: "http", Host: fmt.Sprintf("%s:10267", o.yurthubServer), Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusAccepted && obj != nil:
+		return json.Unmarshal(body, obj)
+	case resp.StatusCode == http.StatusAccepted:
+		return nil
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s (%d)", strings.TrimSpace(string(body)), resp.StatusCode)
+	}
+	if obj == nil || !json.Valid(body) {
+		// the upgrade which is applied right now is responded with a message instead of an object
+		return nil
+	}
+	return json.Unmarshal(body, obj)
+}
+
+func printUpgrades(out io.Writer, upgrades []otautil.PodUpgrade) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tKIND\tSTATUS\tCURRENT\tTARGET\tIMAGES\tSCHEDULED\tMESSAGE")
+	for _, u := range upgrades {
+		var images []string
+		for _, image := range u.Images {
+			images = append(images, fmt.Sprintf("%s: %s -> %s", image.Container, image.Current, image.Target))
+		}
+		var scheduledAt string
+		if u.ScheduledAt != nil {
+			scheduledAt = u.ScheduledAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", u.Namespace, u.Name, u.Kind, u.Status,
+			u.CurrentHash, u.TargetHash, strings.Join(images, ", "), scheduledAt, u.Message)
+	}
+	w.Flush()
+}
+
+func podUpgradePath(namespace, name string) string {
+	return fmt.Sprintf("/openyurt.io/v1/namespaces/%s/pods/%s/upgrade", namespace, name)
+}
+
+func splitPod(s string) (string, string, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", fmt.Errorf("invalid pod %q, it should be in the format of namespace/name", s)
+	}
+	return parts[0], parts[1], nil
+}
+
+// subCmdRun returns a function that handles a case where a subcommand must be specified
+// Without this callback, if a user runs just the command without a subcommand,
+// or with an invalid subcommand, cobra will print usage information, but still exit cleanly.
+func subCmdRun() func(c *cobra.Command, args []string) {
+	return func(c *cobra.Command, args []string) {
+		if len(args) > 0 {
+			util.CheckErr(usageErrorf(c, "invalid subcommand %q", strings.Join(args, " ")))
+		}
+		err := c.Help()
+		if err != nil {
+			return
+		}
+		util.CheckErr(util.ErrExit)
+	}
+}
+
+func usageErrorf(c *cobra.Command, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	return errors.Errorf("%s\nSee '%s -h' for help and examples", msg, c.CommandPath())
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	otautil "github.com/openyurtio/openyurt/pkg/yurthub/otaupdate/util"
+)
+
+func TestUpgradeQuery(t *testing.T) {
+	testcases := map[string]struct {
+		options   otaOptions
+		expectErr bool
+		expectAt  bool
+		dryRun    string
+	}{
+		"apply right now": {},
+		"dry run": {
+			options: otaOptions{dryRun: true},
+			dryRun:  "true",
+		},
+		"at": {
+			options:  otaOptions{at: "2024-01-01T02:00:00+08:00"},
+			expectAt: true,
+		},
+		"invalid at": {
+			options:   otaOptions{at: "2am"},
+			expectErr: true,
+		},
+		"after": {
+			options:  otaOptions{after: time.Hour},
+			expectAt: true,
+		},
+		"negative after": {
+			options:   otaOptions{after: -time.Hour},
+			expectErr: true,
+		},
+		"both at and after": {
+			options:   otaOptions{at: "2024-01-01T02:00:00+08:00", after: time.Hour},
+			expectErr: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			query, err := tc.options.upgradeQuery()
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.dryRun, query.Get(otautil.DryRunQuery))
+			assert.Equal(t, tc.expectAt, len(query.Get(otautil.AtQuery)) != 0)
+		})
+	}
+}
+
+func TestSplitPod(t *testing.T) {
+	ns, name, err := splitPod("kube-system/yurt-hub-node1")
+	assert.NoError(t, err)
+	assert.Equal(t, "kube-system", ns)
+	assert.Equal(t, "yurt-hub-node1", name)
+
+	for _, s := range []string{"yurt-hub-node1", "/yurt-hub-node1", "kube-system/", "a/b/c"} {
+		_, _, err := splitPod(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -18,12 +18,18 @@ package otaupdate
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -89,13 +95,30 @@ func GetPods(store cachemanager.StorageWrapper) http.Handler {
 	})
 }
 
-// UpdatePod update a specific pod(namespace/podname) to the latest version
+// UpdatePod update a specific pod(namespace/podname) to the latest version.
+// The upgrade can be previewed without being applied by query `dryRun=true`.
 func UpdatePod(clientset kubernetes.Interface, nodeName string) http.Handler {
+	return updatePod(clientset, nodeName, nil)
+}
+
+// UpdatePods update all updatable pods on the node to the latest version in one shot.
+// The upgrades can be previewed without being applied by query `dryRun=true`.
+func UpdatePods(clientset kubernetes.Interface, nodeName string) http.Handler {
+	return updatePods(clientset, nodeName, nil)
+}
+
+func updatePod(clientset kubernetes.Interface, nodeName string, scheduler *Scheduler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		params := mux.Vars(r)
 		namespace := params["ns"]
 		podName := params["podname"]
 
+		dryRun, at, err := parseUpgradeQuery(r, scheduler)
+		if err != nil {
+			util.WriteErr(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		pod, ok := preCheck(clientset, namespace, podName, nodeName)
 		// Pod update is not allowed
 		if !ok {
@@ -103,43 +126,190 @@ func UpdatePod(clientset kubernetes.Interface, nodeName string) http.Handler {
 			return
 		}
 
-		var upgrader OTAUpgrader
-		kind := pod.GetOwnerReferences()[0].Kind
-		switch kind {
-		case StaticPod:
-			ok, staticName, err := upgrade.PreCheck(podName, nodeName, namespace, clientset)
-			if err != nil {
-				klog.Errorf("Static pod pre-check failed, %v", err)
-				util.WriteErr(w, "Static pod pre-check failed", http.StatusInternalServerError)
-				return
-			}
-			if !ok {
-				util.WriteErr(w, "Configmap for static pod does not exist", http.StatusForbidden)
-				return
-			}
-			upgrader = &upgrade.StaticPodUpgrader{Interface: clientset,
-				NamespacedName: types.NamespacedName{Namespace: namespace, Name: podName}, StaticName: staticName}
+		upgrade, uerr := upgradePod(clientset, pod, nodeName, dryRun, at, scheduler)
+		if uerr != nil {
+			util.WriteErr(w, uerr.reason, uerr.code)
+			return
+		}
 
-		case DaemonPod:
-			upgrader = &upgrade.DaemonPodUpgrader{Interface: clientset,
-				NamespacedName: types.NamespacedName{Namespace: namespace, Name: podName}}
+		switch upgrade.Status {
+		case util.PodUpgradeStarted:
+			// Successfully apply update, response 200
+			util.WriteJSONResponse(w, []byte(fmt.Sprintf("Start updating pod %v/%v", namespace, podName)))
+		case util.PodUpgradeAccepted:
+			// The upgrade is running in the background, response 202
+			writeJSON(w, upgrade, http.StatusAccepted)
 		default:
-			util.WriteErr(w, fmt.Sprintf("Not support ota upgrade pod type %v", kind), http.StatusBadRequest)
+			writeJSON(w, upgrade, http.StatusOK)
+		}
+	})
+}
+
+func updatePods(clientset kubernetes.Interface, nodeName string, scheduler *Scheduler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dryRun, at, err := parseUpgradeQuery(r, scheduler)
+		if err != nil {
+			util.WriteErr(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		if err := upgrader.Apply(); err != nil {
-			klog.Errorf("Apply update failed, %v", err)
-			// Pod update failed with error
-			util.WriteErr(w, "Apply update failed", http.StatusInternalServerError)
+		podList, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+		})
+		if err != nil {
+			klog.Errorf("could not list pods on node %s, %v", nodeName, err)
+			util.WriteErr(w, "List pods failed", http.StatusInternalServerError)
 			return
 		}
 
-		// Successfully apply update, response 200
-		util.WriteJSONResponse(w, []byte(fmt.Sprintf("Start updating pod %v/%v", namespace, podName)))
+		upgrades := util.PodUpgradeList{Items: []util.PodUpgrade{}}
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if pod.Spec.NodeName != nodeName || pod.DeletionTimestamp != nil || !daemonpodupdater.IsPodUpdatable(pod) {
+				continue
+			}
+
+			upgrade, uerr := upgradePod(clientset, pod, nodeName, dryRun, at, scheduler)
+			if uerr != nil {
+				upgrade = &util.PodUpgrade{Namespace: pod.Namespace, Name: pod.Name, Status: util.PodUpgradeFailed, Message: uerr.reason}
+			}
+			upgrades.Items = append(upgrades.Items, *upgrade)
+		}
+		writeJSON(w, &upgrades, http.StatusOK)
 	})
 }
 
+// upgradeError is an error of upgrade with the http status code
+type upgradeError struct {
+	code   int
+	reason string
+}
+
+// upgradePod previews, schedules or applies the upgrade of the given updatable pod
+func upgradePod(clientset kubernetes.Interface, pod *corev1.Pod, nodeName string, dryRun bool, at *metav1.Time,
+	scheduler *Scheduler) (*util.PodUpgrade, *upgradeError) {
+	upgrader, uerr := newUpgrader(clientset, pod, nodeName)
+	if uerr != nil {
+		return nil, uerr
+	}
+
+	switch {
+	case dryRun:
+		upgrade, err := previewUpgrade(clientset, pod, nodeName)
+		if err != nil {
+			klog.Errorf("Preview update failed, %v", err)
+			return nil, &upgradeError{code: http.StatusInternalServerError, reason: "Preview update failed"}
+		}
+		upgrade.Status = util.PodUpgradeDryRun
+		return upgrade, nil
+
+	case at != nil:
+		upgrade, err := previewUpgrade(clientset, pod, nodeName)
+		if err != nil {
+			// the preview is only informative for scheduled upgrades
+			klog.Warningf("could not preview update of pod %s/%s, %v", pod.Namespace, pod.Name, err)
+			upgrade = &util.PodUpgrade{Namespace: pod.Namespace, Name: pod.Name, Kind: podOwnerKind(pod)}
+		}
+		upgrade.Status = util.PodUpgradeScheduled
+		upgrade.ScheduledAt = at
+		scheduler.Schedule(*upgrade)
+		return upgrade, nil
+
+	default:
+		if err := upgrader.Apply(); errors.Is(err, upgrade.ErrUpgradeInProgress) {
+			return nil, &upgradeError{code: http.StatusConflict, reason: "Pod is being updated"}
+		} else if err != nil {
+			klog.Errorf("Apply update failed, %v", err)
+			// Pod update failed with error
+			return nil, &upgradeError{code: http.StatusInternalServerError, reason: "Apply update failed"}
+		}
+
+		status := util.PodUpgradeStarted
+		if _, ok := upgrader.(*upgrade.StaticPodUpgrader); ok {
+			// the static pod is verified and rolled back in the background
+			status = util.PodUpgradeAccepted
+		}
+		return &util.PodUpgrade{Namespace: pod.Namespace, Name: pod.Name, Kind: podOwnerKind(pod), Status: status}, nil
+	}
+}
+
+// newUpgrader returns the upgrader according to the owner kind of pod
+func newUpgrader(clientset kubernetes.Interface, pod *corev1.Pod, nodeName string) (OTAUpgrader, *upgradeError) {
+	namespace, podName := pod.Namespace, pod.Name
+
+	kind := podOwnerKind(pod)
+	switch kind {
+	case StaticPod:
+		ok, staticName, err := upgrade.PreCheck(podName, nodeName, namespace, clientset)
+		if err != nil {
+			klog.Errorf("Static pod pre-check failed, %v", err)
+			return nil, &upgradeError{code: http.StatusInternalServerError, reason: "Static pod pre-check failed"}
+		}
+		if !ok {
+			return nil, &upgradeError{code: http.StatusForbidden, reason: "Configmap for static pod does not exist"}
+		}
+		return &upgrade.StaticPodUpgrader{Interface: clientset,
+			NamespacedName: types.NamespacedName{Namespace: namespace, Name: podName}, StaticName: staticName, NodeName: nodeName}, nil
+
+	case DaemonPod:
+		return &upgrade.DaemonPodUpgrader{Interface: clientset,
+			NamespacedName: types.NamespacedName{Namespace: namespace, Name: podName}, NodeName: nodeName,
+			DaemonSetName: pod.GetOwnerReferences()[0].Name, RevisionHash: pod.Labels[appsv1.DefaultDaemonSetUniqueLabelKey]}, nil
+
+	default:
+		return nil, &upgradeError{code: http.StatusBadRequest, reason: fmt.Sprintf("Not support ota upgrade pod type %v", kind)}
+	}
+}
+
+func podOwnerKind(pod *corev1.Pod) string {
+	if len(pod.GetOwnerReferences()) == 0 {
+		return ""
+	}
+	return pod.GetOwnerReferences()[0].Kind
+}
+
+// parseUpgradeQuery parses the dry-run flag and the scheduled time from the query of request
+func parseUpgradeQuery(r *http.Request, scheduler *Scheduler) (bool, *metav1.Time, error) {
+	query := r.URL.Query()
+
+	var dryRun bool
+	if v := query.Get(util.DryRunQuery); len(v) != 0 {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			return false, nil, fmt.Errorf("invalid query %s=%s", util.DryRunQuery, v)
+		}
+	}
+
+	v := query.Get(util.AtQuery)
+	if len(v) == 0 || dryRun {
+		return dryRun, nil, nil
+	}
+	if scheduler == nil {
+		return false, nil, fmt.Errorf("deferred upgrade is not supported")
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid query %s=%s, it should be in RFC3339 format", util.AtQuery, v)
+	}
+	if !t.After(time.Now()) {
+		// the time has come, upgrade right now
+		return false, nil, nil
+	}
+	at := metav1.NewTime(t)
+	return false, &at, nil
+}
+
+// writeJSON encodes obj as the json response with the given http status
+func writeJSON(w http.ResponseWriter, obj interface{}, httpStatus int) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		klog.Errorf("could not encode response, %v", err)
+		util.WriteErr(w, "Encode response failed", http.StatusInternalServerError)
+		return
+	}
+	util.WriteJSONResponseWithStatus(w, data, httpStatus)
+}
+
 // preCheck will check the necessary requirements before apply upgrade
 // 1. target pod has not been deleted yet
 // 2. target pod belongs to current node
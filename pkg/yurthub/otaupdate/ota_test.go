@@ -18,6 +18,7 @@ package otaupdate
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -42,6 +44,7 @@ import (
 	"github.com/openyurtio/openyurt/pkg/yurthub/storage"
 	"github.com/openyurtio/openyurt/pkg/yurthub/storage/disk"
 	"github.com/openyurtio/openyurt/pkg/yurthub/transport"
+	k8sutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/daemonpodupdater/kubernetes"
 )
 
 func TestGetPods(t *testing.T) {
@@ -106,6 +109,120 @@ func TestUpdatePod(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
+func newDaemonPod(podName, dsName, hash, image string) *corev1.Pod {
+	pod := util.NewPodWithCondition(podName, DaemonPod, corev1.ConditionTrue)
+	pod.OwnerReferences[0].Name = dsName
+	pod.Labels = map[string]string{appsv1.DefaultDaemonSetUniqueLabelKey: hash}
+	pod.Spec.NodeName = "node"
+	pod.Spec.Containers = []corev1.Container{{Name: "app", Image: image}}
+	return pod
+}
+
+func newDaemonSet(dsName, image string) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: dsName, Namespace: metav1.NamespaceDefault},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}},
+			},
+		},
+	}
+}
+
+func TestUpdatePodDryRun(t *testing.T) {
+	ds := newDaemonSet("nginx", "nginx:v2")
+	pod := newDaemonPod("nginx-abc", "nginx", "old", "nginx:v1")
+	clientset := fake.NewSimpleClientset(ds, pod)
+
+	req, err := http.NewRequest("POST", "/openyurt.io/v1/namespaces/default/pods/nginx-abc/upgrade?dryRun=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"ns": "default", "podname": "nginx-abc"})
+	rr := httptest.NewRecorder()
+
+	UpdatePod(clientset, "node").ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	upgrade := &util.PodUpgrade{}
+	if err := json.Unmarshal(rr.Body.Bytes(), upgrade); err != nil {
+		t.Fatalf("could not decode response, %v", err)
+	}
+	assert.Equal(t, util.PodUpgradeDryRun, upgrade.Status)
+	assert.Equal(t, "old", upgrade.CurrentHash)
+	assert.Equal(t, k8sutil.ComputeHash(&ds.Spec.Template, nil), upgrade.TargetHash)
+	assert.Equal(t, []util.ImageChange{{Container: "app", Current: "nginx:v1", Target: "nginx:v2"}}, upgrade.Images)
+
+	// the pod is not deleted in dry-run mode
+	_, err = clientset.CoreV1().Pods(metav1.NamespaceDefault).Get(context.TODO(), "nginx-abc", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestUpdatePods(t *testing.T) {
+	ds := newDaemonSet("nginx", "nginx:v2")
+	updatable := newDaemonPod("nginx-abc", "nginx", "old", "nginx:v1")
+	notUpdatable := newDaemonPod("nginx-def", "nginx", "old", "nginx:v1")
+	notUpdatable.Status.Conditions = nil
+	otherNode := newDaemonPod("nginx-ghi", "nginx", "old", "nginx:v1")
+	otherNode.Spec.NodeName = "other"
+
+	testcases := map[string]struct {
+		query        string
+		expectCode   int
+		expectStatus util.PodUpgradeStatus
+		expectExist  bool
+	}{
+		"dry run": {
+			query:        "?dryRun=true",
+			expectCode:   http.StatusOK,
+			expectStatus: util.PodUpgradeDryRun,
+			expectExist:  true,
+		},
+		"apply": {
+			expectCode:   http.StatusOK,
+			expectStatus: util.PodUpgradeStarted,
+			expectExist:  false,
+		},
+		"invalid dry run": {
+			query:       "?dryRun=foo",
+			expectCode:  http.StatusBadRequest,
+			expectExist: true,
+		},
+		"deferred without scheduler": {
+			query:       "?at=" + time.Now().Add(time.Hour).Format(time.RFC3339),
+			expectCode:  http.StatusBadRequest,
+			expectExist: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(ds, updatable, notUpdatable, otherNode)
+			req, err := http.NewRequest("POST", "/openyurt.io/v1/pods/upgrade"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+
+			UpdatePods(clientset, "node").ServeHTTP(rr, req)
+			assert.Equal(t, tc.expectCode, rr.Code)
+
+			if tc.expectCode == http.StatusOK {
+				list := &util.PodUpgradeList{}
+				if err := json.Unmarshal(rr.Body.Bytes(), list); err != nil {
+					t.Fatalf("could not decode response, %v", err)
+				}
+				assert.Equal(t, 1, len(list.Items))
+				assert.Equal(t, "nginx-abc", list.Items[0].Name)
+				assert.Equal(t, tc.expectStatus, list.Items[0].Status)
+			}
+
+			_, err = clientset.CoreV1().Pods(metav1.NamespaceDefault).Get(context.TODO(), "nginx-abc", metav1.GetOptions{})
+			assert.Equal(t, tc.expectExist, err == nil)
+		})
+	}
+}
+
 func TestHealthyCheck(t *testing.T) {
 	testDir, err := os.MkdirTemp("", "test-client")
 	if err != nil {
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otaupdate
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
+	"github.com/openyurtio/openyurt/pkg/yurthub/otaupdate/util"
+	k8sutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/daemonpodupdater/kubernetes"
+	spctrlutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtstaticset/util"
+)
+
+// previewUpgrade figures out the target version of the given pod without applying the upgrade
+func previewUpgrade(clientset kubernetes.Interface, pod *corev1.Pod, nodeName string) (*util.PodUpgrade, error) {
+	upgrade := &util.PodUpgrade{Namespace: pod.Namespace, Name: pod.Name, Kind: podOwnerKind(pod)}
+
+	var target *corev1.PodSpec
+	switch upgrade.Kind {
+	case DaemonPod:
+		ds, err := clientset.AppsV1().DaemonSets(pod.Namespace).Get(context.TODO(),
+			pod.GetOwnerReferences()[0].Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		upgrade.CurrentHash = pod.Labels[appsv1.DefaultDaemonSetUniqueLabelKey]
+		upgrade.TargetHash = k8sutil.ComputeHash(&ds.Spec.Template, ds.Status.CollisionCount)
+		target = &ds.Spec.Template.Spec
+
+	case StaticPod:
+		ok, staticName := util.RemoveNodeNameFromStaticPod(pod.Name, nodeName)
+		if !ok {
+			return nil, fmt.Errorf("pod name doesn't meet static pod's format")
+		}
+		cm, err := clientset.CoreV1().ConfigMaps(pod.Namespace).Get(context.TODO(),
+			spctrlutil.WithConfigMapPrefix(staticName), metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		upgrade.CurrentHash = pod.Annotations[upgradeutil.StaticPodHashAnnotation]
+		upgrade.TargetHash = cm.Annotations[upgradeutil.StaticPodHashAnnotation]
+		if target, err = staticPodManifest(cm); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("not support ota upgrade pod type %v", upgrade.Kind)
+	}
+
+	upgrade.Images = imageChanges(&pod.Spec, target)
+	return upgrade, nil
+}
+
+// staticPodManifest parses the pod spec from the manifest in the configmap of static pod
+func staticPodManifest(cm *corev1.ConfigMap) (*corev1.PodSpec, error) {
+	if len(cm.Data) != 1 {
+		return nil, fmt.Errorf("invalid manifest in configmap %s", cm.Name)
+	}
+
+	pod := &corev1.Pod{}
+	for _, data := range cm.Data {
+		if err := yaml.Unmarshal([]byte(data), pod); err != nil {
+			return nil, fmt.Errorf("could not parse manifest in configmap %s, %v", cm.Name, err)
+		}
+	}
+	return &pod.Spec, nil
+}
+
+// imageChanges lists the containers whose image is different between the current and the target pod spec
+func imageChanges(current, target *corev1.PodSpec) []util.ImageChange {
+	currentContainers := append(append([]corev1.Container{}, current.InitContainers...), current.Containers...)
+	targetContainers := append(append([]corev1.Container{}, target.InitContainers...), target.Containers...)
+
+	images := make(map[string]string, len(currentContainers))
+	for _, c := range currentContainers {
+		images[c.Name] = c.Image
+	}
+
+	var changes []util.ImageChange
+	for _, c := range targetContainers {
+		if cur, ok := images[c.Name]; !ok || cur != c.Image {
+			changes = append(changes, util.ImageChange{Container: c.Name, Current: cur, Target: c.Image})
+		}
+		delete(images, c.Name)
+	}
+	for _, c := range currentContainers {
+		// the container is removed in the target version
+		if _, ok := images[c.Name]; ok {
+			changes = append(changes, util.ImageChange{Container: c.Name, Current: c.Image})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otaupdate
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/openyurtio/openyurt/pkg/yurthub/otaupdate/util"
+)
+
+// scheduledRetryInterval is the interval to retry a scheduled upgrade when node is disconnected to cloud
+const scheduledRetryInterval = time.Minute
+
+type scheduledUpgrade struct {
+	upgrade util.PodUpgrade
+	timer   *time.Timer
+}
+
+// Scheduler applies the deferred upgrades of pods at the scheduled time, so that the upgrades
+// can be approved in advance and applied in a maintenance window of the node.
+// Scheduled upgrades are kept in memory, and they are lost when YurtHub restarts.
+type Scheduler struct {
+	sync.Mutex
+	nodeName   string
+	clientFunc func() kubernetes.Interface
+	scheduled  map[string]*scheduledUpgrade
+}
+
+// NewScheduler creates a Scheduler, clientFunc returns nil when node is disconnected to cloud
+func NewScheduler(nodeName string, clientFunc func() kubernetes.Interface) *Scheduler {
+	return &Scheduler{
+		nodeName:   nodeName,
+		clientFunc: clientFunc,
+		scheduled:  make(map[string]*scheduledUpgrade),
+	}
+}
+
+// Schedule defers the given upgrade to upgrade.ScheduledAt, the previous scheduled upgrade of the same pod is replaced
+func (s *Scheduler) Schedule(upgrade util.PodUpgrade) {
+	key := upgradeKey(upgrade.Namespace, upgrade.Name)
+
+	s.Lock()
+	defer s.Unlock()
+	if old, ok := s.scheduled[key]; ok {
+		old.timer.Stop()
+	}
+	s.scheduled[key] = &scheduledUpgrade{
+		upgrade: upgrade,
+		timer:   time.AfterFunc(time.Until(upgrade.ScheduledAt.Time), func() { s.run(key) }),
+	}
+	klog.Infof("upgrade of pod %s is scheduled at %s", key, upgrade.ScheduledAt.Format(time.RFC3339))
+}
+
+// Cancel cancels the scheduled upgrade of pod, it returns false if there is no scheduled upgrade of the pod
+func (s *Scheduler) Cancel(namespace, name string) bool {
+	key := upgradeKey(namespace, name)
+
+	s.Lock()
+	defer s.Unlock()
+	old, ok := s.scheduled[key]
+	if !ok {
+		return false
+	}
+	old.timer.Stop()
+	delete(s.scheduled, key)
+	klog.Infof("scheduled upgrade of pod %s is canceled", key)
+	return true
+}
+
+// List returns the scheduled upgrades sorted by the namespace and name of pods
+func (s *Scheduler) List() []util.PodUpgrade {
+	s.Lock()
+	defer s.Unlock()
+
+	upgrades := make([]util.PodUpgrade, 0, len(s.scheduled))
+	for _, su := range s.scheduled {
+		upgrades = append(upgrades, su.upgrade)
+	}
+	sort.Slice(upgrades, func(i, j int) bool {
+		return upgradeKey(upgrades[i].Namespace, upgrades[i].Name) < upgradeKey(upgrades[j].Namespace, upgrades[j].Name)
+	})
+	return upgrades
+}
+
+func (s *Scheduler) run(key string) {
+	s.Lock()
+	su, ok := s.scheduled[key]
+	if !ok {
+		s.Unlock()
+		return
+	}
+	clientset := s.clientFunc()
+	if clientset == nil {
+		klog.Infof("node is disconnected to cloud, retry scheduled upgrade of pod %s after %v", key, scheduledRetryInterval)
+		su.timer.Reset(scheduledRetryInterval)
+		s.Unlock()
+		return
+	}
+	delete(s.scheduled, key)
+	s.Unlock()
+
+	namespace, name := su.upgrade.Namespace, su.upgrade.Name
+	pod, ok := preCheck(clientset, namespace, name, s.nodeName)
+	if !ok {
+		klog.Infof("skip scheduled upgrade of pod %s, pod is not-updatable", key)
+		return
+	}
+	upgrader, uerr := newUpgrader(clientset, pod, s.nodeName)
+	if uerr != nil {
+		klog.Errorf("could not apply scheduled upgrade of pod %s, %s", key, uerr.reason)
+		return
+	}
+	if err := upgrader.Apply(); err != nil {
+		klog.Errorf("could not apply scheduled upgrade of pod %s, %v", key, err)
+		return
+	}
+	klog.Infof("scheduled upgrade of pod %s is applied", key)
+}
+
+// UpdatePod is the OTAHandler to update a specific pod, the upgrade can be deferred by query `at`
+func (s *Scheduler) UpdatePod(clientset kubernetes.Interface, nodeName string) http.Handler {
+	return updatePod(clientset, nodeName, s)
+}
+
+// UpdatePods is the OTAHandler to update all updatable pods on the node, the upgrades can be deferred by query `at`
+func (s *Scheduler) UpdatePods(clientset kubernetes.Interface, nodeName string) http.Handler {
+	return updatePods(clientset, nodeName, s)
+}
+
+// ListScheduled returns the scheduled upgrades, it works even if node is disconnected to cloud
+func (s *Scheduler) ListScheduled() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &util.PodUpgradeList{Items: s.List()}, http.StatusOK)
+	})
+}
+
+// CancelScheduled cancels the scheduled upgrade of a specific pod(namespace/podname)
+func (s *Scheduler) CancelScheduled() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		namespace := params["ns"]
+		podName := params["podname"]
+
+		if !s.Cancel(namespace, podName) {
+			util.WriteErr(w, fmt.Sprintf("No scheduled upgrade of pod %v/%v", namespace, podName), http.StatusNotFound)
+			return
+		}
+		util.WriteJSONResponse(w, []byte(fmt.Sprintf("Cancel scheduled upgrade of pod %v/%v", namespace, podName)))
+	})
+}
+
+func upgradeKey(namespace, name string) string {
+	return namespace + "/" + name
+}
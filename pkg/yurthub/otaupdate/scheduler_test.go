@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otaupdate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openyurtio/openyurt/pkg/yurthub/otaupdate/util"
+)
+
+func TestSchedulerCancel(t *testing.T) {
+	ds := newDaemonSet("nginx", "nginx:v2")
+	pod := newDaemonPod("nginx-abc", "nginx", "old", "nginx:v1")
+	clientset := fake.NewSimpleClientset(ds, pod)
+	s := NewScheduler("node", func() kubernetes.Interface { return clientset })
+
+	at := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	req, err := http.NewRequest("POST", "/openyurt.io/v1/namespaces/default/pods/nginx-abc/upgrade?at="+at.Format(time.RFC3339), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"ns": "default", "podname": "nginx-abc"})
+	rr := httptest.NewRecorder()
+	s.UpdatePod(clientset, "node").ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	upgrade := &util.PodUpgrade{}
+	if err := json.Unmarshal(rr.Body.Bytes(), upgrade); err != nil {
+		t.Fatalf("could not decode response, %v", err)
+	}
+	assert.Equal(t, util.PodUpgradeScheduled, upgrade.Status)
+	assert.True(t, at.Equal(upgrade.ScheduledAt.Time))
+
+	// list scheduled upgrades
+	rr = httptest.NewRecorder()
+	s.ListScheduled().ServeHTTP(rr, httptest.NewRequest("GET", "/openyurt.io/v1/pods/upgrade", nil))
+	list := &util.PodUpgradeList{}
+	if err := json.Unmarshal(rr.Body.Bytes(), list); err != nil {
+		t.Fatalf("could not decode response, %v", err)
+	}
+	assert.Equal(t, 1, len(list.Items))
+
+	// cancel the scheduled upgrade
+	req = mux.SetURLVars(httptest.NewRequest("DELETE", "/openyurt.io/v1/namespaces/default/pods/nginx-abc/upgrade", nil),
+		map[string]string{"ns": "default", "podname": "nginx-abc"})
+	rr = httptest.NewRecorder()
+	s.CancelScheduled().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 0, len(s.List()))
+
+	rr = httptest.NewRecorder()
+	s.CancelScheduled().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// the pod is not deleted
+	_, err = clientset.CoreV1().Pods(metav1.NamespaceDefault).Get(context.TODO(), "nginx-abc", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestSchedulerRun(t *testing.T) {
+	ds := newDaemonSet("nginx", "nginx:v2")
+	pod := newDaemonPod("nginx-abc", "nginx", "old", "nginx:v1")
+	clientset := fake.NewSimpleClientset(ds, pod)
+	s := NewScheduler("node", func() kubernetes.Interface { return clientset })
+
+	at := metav1.NewTime(time.Now().Add(100 * time.Millisecond))
+	s.Schedule(util.PodUpgrade{Namespace: metav1.NamespaceDefault, Name: "nginx-abc", ScheduledAt: &at})
+
+	err := wait.PollUntilContextTimeout(context.Background(), 50*time.Millisecond, 5*time.Second, true,
+		func(ctx context.Context) (bool, error) {
+			_, err := clientset.CoreV1().Pods(metav1.NamespaceDefault).Get(ctx, "nginx-abc", metav1.GetOptions{})
+			return apierrors.IsNotFound(err), nil
+		})
+	assert.NoError(t, err, "scheduled upgrade is not applied")
+	assert.Equal(t, 0, len(s.List()))
+}
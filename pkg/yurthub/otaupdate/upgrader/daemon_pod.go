@@ -23,11 +23,19 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
 )
 
 type DaemonPodUpgrader struct {
 	kubernetes.Interface
 	types.NamespacedName
+	// NodeName is the node which the daemon pod is running on, the upgrade result is recorded on it
+	NodeName string
+	// DaemonSetName is the name of DaemonSet which owns the daemon pod
+	DaemonSetName string
+	// RevisionHash is the revision hash of the daemon pod which is upgraded from
+	RevisionHash string
 }
 
 // Apply execute pod update process by deleting pod under OnDelete update strategy
@@ -35,9 +43,25 @@ func (s *DaemonPodUpgrader) Apply() error {
 	err := s.CoreV1().Pods(s.Namespace).Delete(context.TODO(), s.Name, metav1.DeleteOptions{})
 	if err != nil {
 		klog.Errorf("couldn't update pod %s/%s because of can't delete, %v", s.Namespace, s.Name, err)
+		s.reportResult(&upgradeutil.UpgradeResult{Hash: s.RevisionHash, Result: upgradeutil.UpgradeFailed,
+			Reason: "DeleteFailed", Message: err.Error(), Time: metav1.Now()})
 		return err
 	}
 
 	klog.Infof("Start updating pod: %s/%s", s.Namespace, s.Name)
+	s.reportResult(&upgradeutil.UpgradeResult{Hash: s.RevisionHash, Result: upgradeutil.UpgradeInProgress, Time: metav1.Now()})
 	return nil
 }
+
+// reportResult records the upgrade result in the annotation of node, so that yurt-manager can
+// figure out the upgrade status of every node. The failure of reporting doesn't affect the upgrade.
+func (s *DaemonPodUpgrader) reportResult(result *upgradeutil.UpgradeResult) {
+	if len(s.DaemonSetName) == 0 {
+		return
+	}
+
+	key := upgradeutil.DaemonSetUpgradeResultKey(s.Namespace, s.DaemonSetName)
+	if err := reportUpgradeResult(s.Interface, s.NodeName, key, result); err != nil {
+		klog.Errorf("could not report upgrade result of pod %s/%s, %v", s.Namespace, s.Name, err)
+	}
+}
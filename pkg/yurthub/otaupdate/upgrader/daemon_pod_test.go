@@ -17,12 +17,15 @@ limitations under the License.
 package upgrader
 
 import (
+	"context"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
 	"github.com/openyurtio/openyurt/pkg/yurthub/otaupdate/util"
 )
 
@@ -46,3 +49,32 @@ func TestDaemonPodUpgrader_Apply(t *testing.T) {
 	})
 
 }
+
+func TestDaemonPodUpgrader_ApplyReportResult(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+	upgrader := DaemonPodUpgrader{
+		Interface:      fake.NewSimpleClientset(node),
+		NamespacedName: types.NamespacedName{Name: "nginx", Namespace: "default"},
+		NodeName:       "node",
+		DaemonSetName:  "nginx",
+		RevisionHash:   "789c7f9f47",
+	}
+
+	// the pod doesn't exist, so the upgrade fails
+	if err := upgrader.Apply(); err == nil {
+		t.Fatalf("Should fail to ota upgrade Daemonset pod")
+	}
+
+	got, err := upgrader.CoreV1().Nodes().Get(context.TODO(), "node", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Fail to get node, %v", err)
+	}
+	results, err := upgradeutil.GetUpgradeResults(got.Annotations)
+	if err != nil {
+		t.Fatalf("Fail to get upgrade results, %v", err)
+	}
+	r, ok := results[upgradeutil.DaemonSetUpgradeResultKey("default", "nginx")]
+	if !ok || r.Result != upgradeutil.UpgradeFailed || r.Hash != upgrader.RevisionHash {
+		t.Fatalf("Expect failed upgrade result, but got %v", r)
+	}
+}
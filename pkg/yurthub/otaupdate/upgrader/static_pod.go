@@ -18,15 +18,20 @@ package upgrader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
 	upgrade "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade"
@@ -39,6 +44,15 @@ const OTA = "OTA"
 
 var (
 	DefaultUpgradePath = "/tmp/manifests"
+
+	// ErrUpgradeInProgress is returned when the static pod is being upgraded by an earlier request
+	ErrUpgradeInProgress = errors.New("static pod is being upgraded")
+
+	// upgradingStaticPods records the static pods which are being upgraded, a static pod is upgraded by one request at a time
+	upgradingStaticPods = struct {
+		sync.Mutex
+		pods sets.Set[string]
+	}{pods: sets.New[string]()}
 )
 
 type StaticPodUpgrader struct {
@@ -46,13 +60,48 @@ type StaticPodUpgrader struct {
 	types.NamespacedName
 	// Name format of static pod is `staticName-nodeName`
 	StaticName string
+	// NodeName is the node which the static pod is running on, the upgrade result is recorded on it
+	NodeName string
 }
 
+// Apply starts to upgrade the static pod in the background, the result of the upgrade is reported to the node.
+// ErrUpgradeInProgress is returned if the static pod is being upgraded.
 func (s *StaticPodUpgrader) Apply() error {
+	key := s.NamespacedName.String()
+	if !startUpgrade(key) {
+		return ErrUpgradeInProgress
+	}
+	ctrl, err := s.prepare()
+	if err != nil {
+		finishUpgrade(key)
+		return err
+	}
+
+	// Waiting for the latest static pod to be ready takes a while, so upgrade asynchronously
+	// and report the result to the node.
+	go func() {
+		defer finishUpgrade(key)
+		if err := ctrl.Upgrade(); err != nil {
+			klog.Errorf("could not ota upgrade static pod %v, %v", s.NamespacedName, err)
+		} else {
+			klog.Infof("ota upgrade static pod %v success", s.NamespacedName)
+		}
+
+		if result := ctrl.Result(); result != nil {
+			if err := s.reportResult(result); err != nil {
+				klog.Errorf("could not report upgrade result of static pod %v, %v", s.NamespacedName, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// prepare generates the upgrade manifest and marks the upgrade in progress on the node
+func (s *StaticPodUpgrader) prepare() (*upgrade.Controller, error) {
 	cm, err := s.CoreV1().ConfigMaps(s.Namespace).Get(context.TODO(),
 		spctrlutil.WithConfigMapPrefix(s.StaticName), metav1.GetOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var manifest, data string
 	for k, v := range cm.Data {
@@ -60,24 +109,89 @@ func (s *StaticPodUpgrader) Apply() error {
 		data = v
 	}
 	if len(data) == 0 {
-		return fmt.Errorf("empty manifest in configmap %v", spctrlutil.WithConfigMapPrefix(s.StaticName))
+		return nil, fmt.Errorf("empty manifest in configmap %v", spctrlutil.WithConfigMapPrefix(s.StaticName))
+	}
+
+	// The hash is used to verify whether the latest static pod is ready
+	hash := cm.Annotations[upgradeutil.StaticPodHashAnnotation]
+	if len(hash) == 0 {
+		return nil, fmt.Errorf("no hash annotation in configmap %v", spctrlutil.WithConfigMapPrefix(s.StaticName))
+	}
+	timeout := upgrade.DefaultStaticPodRunningCheckTimeout
+	if v, ok := cm.Annotations[upgradeutil.ReadyTimeoutAnnotation]; ok {
+		if timeout, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid ready timeout %q in configmap %v, %v", v, spctrlutil.WithConfigMapPrefix(s.StaticName), err)
+		}
 	}
 
 	// Make sure upgrade dir exist
 	if _, err := os.Stat(DefaultUpgradePath); os.IsNotExist(err) {
 		if err = os.Mkdir(DefaultUpgradePath, 0755); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	upgradeManifestPath := filepath.Join(DefaultUpgradePath, upgradeutil.WithUpgradeSuffix(manifest))
 	if err := genUpgradeManifest(upgradeManifestPath, data); err != nil {
-		return err
+		return nil, err
 	}
 	klog.V(5).Info("Generate upgrade manifest")
 
 	ctrl := upgrade.New(s.Name, s.Namespace, manifest, OTA)
-	return ctrl.Upgrade()
+	ctrl.SetHash(hash)
+	ctrl.SetTimeout(timeout)
+
+	// Mark the upgrade in progress, so that the upgrade status of node can be tracked
+	inProgress := &upgradeutil.UpgradeResult{Hash: hash, Result: upgradeutil.UpgradeInProgress, Time: metav1.Now()}
+	if err := s.reportResult(inProgress); err != nil {
+		klog.Errorf("could not report upgrade of static pod %v in progress, %v", s.NamespacedName, err)
+	}
+	return ctrl, nil
+}
+
+// startUpgrade records the static pod is being upgraded, it returns false if the static pod is already being upgraded
+func startUpgrade(key string) bool {
+	upgradingStaticPods.Lock()
+	defer upgradingStaticPods.Unlock()
+	if upgradingStaticPods.pods.Has(key) {
+		return false
+	}
+	upgradingStaticPods.pods.Insert(key)
+	return true
+}
+
+func finishUpgrade(key string) {
+	upgradingStaticPods.Lock()
+	defer upgradingStaticPods.Unlock()
+	upgradingStaticPods.pods.Delete(key)
+}
+
+// reportResult records the upgrade result in the annotation of node, so that yurt-manager can
+// figure out the upgrade status of every node.
+func (s *StaticPodUpgrader) reportResult(result *upgradeutil.UpgradeResult) error {
+	return reportUpgradeResult(s.Interface, s.NodeName, upgradeutil.UpgradeResultKey(s.Namespace, s.StaticName), result)
+}
+
+// reportUpgradeResult records the upgrade result with the given key in the annotation of node
+func reportUpgradeResult(c kubernetes.Interface, nodeName, key string, result *upgradeutil.UpgradeResult) error {
+	if len(nodeName) == 0 {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := c.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		annotations, err := upgradeutil.SetUpgradeResult(node.Annotations, key, result)
+		if err != nil {
+			return err
+		}
+		node.Annotations = annotations
+		_, err = c.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+		return err
+	})
 }
 
 func PreCheck(name, nodename, namespace string, c kubernetes.Interface) (bool, string, error) {
@@ -17,6 +17,8 @@ limitations under the License.
 package upgrader
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -44,6 +46,9 @@ func TestStaticPodUpgrader_ApplyManifestNotExist(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: metav1.NamespaceDefault,
 			Name:      spctrlutil.WithConfigMapPrefix("nginx"),
+			Annotations: map[string]string{
+				upgradeutil.StaticPodHashAnnotation: "789c7f9f47",
+			},
 		},
 		Data: map[string]string{
 			"nginx": `
@@ -71,6 +76,74 @@ spec:
 			t.Fatalf("Fail to ota upgrade static pod, %v", err)
 		}
 	})
+
+	t.Run("TestStaticPodUpgrader_ApplyInProgress", func(t *testing.T) {
+		// the static pod is still being upgraded by the last request
+		if err := upgrader.Apply(); !errors.Is(err, ErrUpgradeInProgress) {
+			t.Fatalf("Expect ota upgrade to be rejected while the static pod is being upgraded, got %v", err)
+		}
+	})
+}
+
+func TestStaticPodUpgrader_ApplyWithoutHash(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      spctrlutil.WithConfigMapPrefix("nginx"),
+		},
+		Data: map[string]string{
+			"nginx": "apiVersion: v1",
+		},
+	}
+
+	clientset := fake.NewSimpleClientset(cm)
+	upgrader := StaticPodUpgrader{
+		Interface:      clientset,
+		NamespacedName: types.NamespacedName{Namespace: metav1.NamespaceDefault, Name: "nginx-other-node"},
+		StaticName:     "nginx",
+	}
+
+	if err := upgrader.Apply(); err == nil {
+		t.Fatalf("Expect ota upgrade to fail without hash annotation")
+	}
+	// the failed upgrade doesn't block the later requests
+	if err := upgrader.Apply(); errors.Is(err, ErrUpgradeInProgress) {
+		t.Fatalf("Expect ota upgrade not to be in progress after failure")
+	}
+}
+
+func TestStaticPodUpgrader_reportResult(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+	clientset := fake.NewSimpleClientset(node)
+	upgrader := StaticPodUpgrader{
+		Interface:      clientset,
+		NamespacedName: types.NamespacedName{Namespace: metav1.NamespaceDefault, Name: "nginx-node"},
+		StaticName:     "nginx",
+		NodeName:       "node",
+	}
+
+	result := &upgradeutil.UpgradeResult{
+		Hash:    "789c7f9f47",
+		Result:  upgradeutil.UpgradeRolledBack,
+		Reason:  upgradeutil.ReasonNotReady,
+		Message: "timeout waiting for static pod default/nginx-node to be ready",
+	}
+	if err := upgrader.reportResult(result); err != nil {
+		t.Fatalf("Fail to report upgrade result, %v", err)
+	}
+
+	got, err := clientset.CoreV1().Nodes().Get(context.TODO(), "node", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Fail to get node, %v", err)
+	}
+	results, err := upgradeutil.GetUpgradeResults(got.Annotations)
+	if err != nil {
+		t.Fatalf("Fail to get upgrade results, %v", err)
+	}
+	r, ok := results[upgradeutil.UpgradeResultKey(metav1.NamespaceDefault, "nginx")]
+	if !ok || r.Result != upgradeutil.UpgradeRolledBack || r.Hash != result.Hash {
+		t.Fatalf("Expect upgrade result %v, but got %v", result, r)
+	}
 }
 
 func Test_genUpgradeManifest(t *testing.T) {
@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DryRunQuery is the query parameter of upgrade requests to preview the upgrade without applying it
+	DryRunQuery = "dryRun"
+	// AtQuery is the query parameter of upgrade requests to defer the upgrade to the given time in RFC3339 format
+	AtQuery = "at"
+)
+
+// PodUpgradeStatus is the status of a pod upgrade requested through the OTA API
+type PodUpgradeStatus string
+
+const (
+	// PodUpgradeDryRun means the upgrade is previewed and not applied
+	PodUpgradeDryRun PodUpgradeStatus = "DryRun"
+	// PodUpgradeStarted means the upgrade has been applied
+	PodUpgradeStarted PodUpgradeStatus = "Started"
+	// PodUpgradeAccepted means the upgrade is conducted in the background, and its result is reported to the node
+	PodUpgradeAccepted PodUpgradeStatus = "Accepted"
+	// PodUpgradeScheduled means the upgrade will be applied at the scheduled time
+	PodUpgradeScheduled PodUpgradeStatus = "Scheduled"
+	// PodUpgradeFailed means the upgrade could not be applied
+	PodUpgradeFailed PodUpgradeStatus = "Failed"
+)
+
+// ImageChange describes how the image of a container changes in an upgrade
+type ImageChange struct {
+	Container string `json:"container"`
+	Current   string `json:"current,omitempty"`
+	Target    string `json:"target,omitempty"`
+}
+
+// PodUpgrade describes the upgrade of a pod through the OTA API
+type PodUpgrade struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Kind of the pod owner, "Node" for static pods and "DaemonSet" for daemon pods
+	Kind string `json:"kind,omitempty"`
+
+	// CurrentHash and TargetHash are the revision hashes of the running pod and the latest version
+	CurrentHash string `json:"currentHash,omitempty"`
+	TargetHash  string `json:"targetHash,omitempty"`
+	// Images lists the containers whose image is changed by the upgrade
+	Images []ImageChange `json:"images,omitempty"`
+
+	Status  PodUpgradeStatus `json:"status"`
+	Message string           `json:"message,omitempty"`
+	// ScheduledAt is the time when a deferred upgrade will be applied
+	ScheduledAt *metav1.Time `json:"scheduledAt,omitempty"`
+}
+
+// PodUpgradeList is a list of pod upgrades
+type PodUpgradeList struct {
+	Items []PodUpgrade `json:"items"`
+}
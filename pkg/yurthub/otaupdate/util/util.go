@@ -49,12 +49,18 @@ func WriteErr(w http.ResponseWriter, errReason string, httpStatus int) {
 
 // Derived from kubelet writeJSONResponse
 func WriteJSONResponse(w http.ResponseWriter, data []byte) {
+	WriteJSONResponseWithStatus(w, data, http.StatusOK)
+}
+
+// WriteJSONResponseWithStatus writes the json data on the response with the given http status,
+// the headers are set before the status is written.
+func WriteJSONResponseWithStatus(w http.ResponseWriter, data []byte, httpStatus int) {
 	if data == nil {
-		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(httpStatus)
 		return
 	}
 	w.Header().Set(yurtutil.HttpHeaderContentType, yurtutil.HttpContentTypeJson)
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatus)
 	n, err := w.Write(data)
 	if err != nil || n != len(data) {
 		klog.Errorf("Write resp for request, expect %d bytes but write %d bytes with error, %v", len(data), n, err)
@@ -98,6 +98,24 @@ func TestWriteJSONResponse(t *testing.T) {
 	}
 }
 
+func TestWriteJSONResponseWithStatus(t *testing.T) {
+	rr := httptest.NewRecorder()
+
+	data := []byte(`{"key": "value"}`)
+	WriteJSONResponseWithStatus(rr, data, http.StatusAccepted)
+
+	// Check the status code, headers and body
+	if status := rr.Code; status != http.StatusAccepted {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusAccepted)
+	}
+	if content := rr.Header().Get(yurtutil.HttpHeaderContentType); content != yurtutil.HttpContentTypeJson {
+		t.Errorf("handler returned wrong content type: got %v want %v", content, yurtutil.HttpContentTypeJson)
+	}
+	if rr.Body.String() != string(data) {
+		t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), string(data))
+	}
+}
+
 func TestNewPod(t *testing.T) {
 	podName := "test-pod"
 	kind := "DaemonSet"
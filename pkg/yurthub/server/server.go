@@ -25,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
 	"github.com/openyurtio/openyurt/cmd/yurthub/app/config"
@@ -98,8 +99,18 @@ func registerHandlers(c *mux.Router, cfg *config.YurtHubConfiguration, manager *
 	} else {
 		c.Handle("/pods", getPodList(cfg.SharedFactory)).Methods("GET")
 	}
+	scheduler := ota.NewScheduler(cfg.NodeName, func() kubernetes.Interface {
+		if clientset := manager.GetDirectClientset(true); clientset != nil {
+			return clientset
+		}
+		return nil
+	})
 	c.Handle("/openyurt.io/v1/namespaces/{ns}/pods/{podname}/upgrade",
-		ota.HealthyCheck(manager, cfg.NodeName, ota.UpdatePod)).Methods("POST")
+		ota.HealthyCheck(manager, cfg.NodeName, scheduler.UpdatePod)).Methods("POST")
+	c.Handle("/openyurt.io/v1/namespaces/{ns}/pods/{podname}/upgrade", scheduler.CancelScheduled()).Methods("DELETE")
+	c.Handle("/openyurt.io/v1/pods/upgrade",
+		ota.HealthyCheck(manager, cfg.NodeName, scheduler.UpdatePods)).Methods("POST")
+	c.Handle("/openyurt.io/v1/pods/upgrade", scheduler.ListScheduled()).Methods("GET")
 }
 
 // healthz returns ok for healthz request
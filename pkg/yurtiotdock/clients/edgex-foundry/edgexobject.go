@@ -17,7 +17,10 @@ limitations under the License.
 package edgex_foundry
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"sync"
 
 	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
 	edgexcliv3 "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients/edgex-foundry/v3"
@@ -28,16 +31,23 @@ type EdgeXObject interface {
 }
 
 type EdgexDock struct {
-	Version          string
-	CoreMetadataAddr string
-	CoreCommandAddr  string
+	Version               string
+	CoreMetadataAddr      string
+	CoreCommandAddr       string
+	MessageBusAddr        string
+	MessageBusTopicPrefix string
+
+	once              sync.Once
+	systemEventClient clients.SystemEventInterface
 }
 
-func NewEdgexDock(version string, coreMetadataAddr string, coreCommandAddr string) *EdgexDock {
+func NewEdgexDock(version string, coreMetadataAddr string, coreCommandAddr string, messageBusAddr string, messageBusTopicPrefix string) *EdgexDock {
 	return &EdgexDock{
-		Version:          version,
-		CoreMetadataAddr: coreMetadataAddr,
-		CoreCommandAddr:  coreCommandAddr,
+		Version:               version,
+		CoreMetadataAddr:      coreMetadataAddr,
+		CoreCommandAddr:       coreCommandAddr,
+		MessageBusAddr:        messageBusAddr,
+		MessageBusTopicPrefix: messageBusTopicPrefix,
 	}
 }
 
@@ -67,3 +77,21 @@ func (ep *EdgexDock) CreateDeviceServiceClient() (clients.DeviceServiceInterface
 		return nil, fmt.Errorf("unsupported Edgex version: %v", ep.Version)
 	}
 }
+
+// CreateSystemEventClient returns the client of the EdgeX message bus, which is shared by all the syncers
+func (ep *EdgexDock) CreateSystemEventClient() (clients.SystemEventInterface, error) {
+	if ep.MessageBusAddr == "" {
+		return nil, errors.New("the address of edgex message bus is not set")
+	}
+	switch ep.Version {
+	case "napa", "minnesota":
+		ep.once.Do(func() {
+			// the client id must be unique on the message bus, so the pod name is used
+			hostname, _ := os.Hostname()
+			ep.systemEventClient = edgexcliv3.NewEdgexSystemEventClient(ep.MessageBusAddr, ep.MessageBusTopicPrefix, fmt.Sprintf("yurt-iot-dock-%s", hostname))
+		})
+		return ep.systemEventClient, nil
+	default:
+		return nil, fmt.Errorf("unsupported Edgex version: %v", ep.Version)
+	}
+}
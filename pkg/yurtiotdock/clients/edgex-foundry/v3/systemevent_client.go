@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	"k8s.io/klog/v2"
+
+	edgeCli "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+const (
+	// DefaultMessageBusTopicPrefix is the default base topic of the EdgeX message bus
+	DefaultMessageBusTopicPrefix = "edgex"
+
+	messageBusTimeout = 10 * time.Second
+)
+
+// messageEnvelope is the message published on the EdgeX message bus, the payload is the JSON of the
+// system event, or its base64 encoding when the EdgeX services are configured with base64 payloads.
+type messageEnvelope struct {
+	ContentType string          `json:"contentType"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// EdgexSystemEventClient watches the system events published by core-metadata on the MQTT message bus of EdgeX
+type EdgexSystemEventClient struct {
+	MessageBusAddr string
+	TopicPrefix    string
+	ClientID       string
+
+	once       sync.Once
+	err        error
+	ready      chan struct{}
+	readyOnce  sync.Once
+	dispatcher *edgeCli.SystemEventDispatcher
+}
+
+func NewEdgexSystemEventClient(messageBusAddr, topicPrefix, clientID string) *EdgexSystemEventClient {
+	if topicPrefix == "" {
+		topicPrefix = DefaultMessageBusTopicPrefix
+	}
+	return &EdgexSystemEventClient{
+		MessageBusAddr: messageBusAddr,
+		TopicPrefix:    strings.TrimSuffix(topicPrefix, "/"),
+		ClientID:       clientID,
+		ready:          make(chan struct{}),
+		dispatcher:     edgeCli.NewSystemEventDispatcher(),
+	}
+}
+
+// Watch subscribes to the message bus on the first call and calls the handler with the system events of the eventType
+func (esc *EdgexSystemEventClient) Watch(ctx context.Context, eventType string, handler edgeCli.SystemEventHandler, resync edgeCli.ResyncHandler) error {
+	esc.once.Do(func() {
+		esc.err = esc.connect()
+	})
+	if esc.err != nil {
+		return esc.err
+	}
+	esc.dispatcher.Watch(ctx, eventType, handler, resync)
+	return nil
+}
+
+func (esc *EdgexSystemEventClient) connect() error {
+	opts := paho.NewClientOptions().
+		AddBroker(fmt.Sprintf("tcp://%s", esc.MessageBusAddr)).
+		SetClientID(esc.ClientID).
+		SetConnectTimeout(messageBusTimeout).
+		SetAutoReconnect(true).
+		SetOnConnectHandler(esc.subscribe)
+	token := paho.NewClient(opts).Connect()
+	if !token.WaitTimeout(messageBusTimeout) {
+		return fmt.Errorf("connect to edgex message bus timeout")
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("could not connect to edgex message bus, %v", err)
+	}
+	select {
+	case <-esc.ready:
+		return nil
+	case <-time.After(messageBusTimeout):
+		return fmt.Errorf("subscribe to edgex system events timeout")
+	}
+}
+
+// subscribe subscribes to the system events of core-metadata, it is called on each (re)connection.
+func (esc *EdgexSystemEventClient) subscribe(client paho.Client) {
+	topic := strings.Join([]string{esc.TopicPrefix, common.SystemEventPublishTopic, common.CoreMetaDataServiceKey, "#"}, "/")
+	token := client.Subscribe(topic, 1, esc.handle)
+	if !token.WaitTimeout(messageBusTimeout) || token.Error() != nil {
+		klog.Errorf("could not subscribe to edgex system events %s, %v", topic, token.Error())
+		return
+	}
+	esc.readyOnce.Do(func() { close(esc.ready) })
+}
+
+func (esc *EdgexSystemEventClient) handle(_ paho.Client, msg paho.Message) {
+	systemEvent, err := decodeSystemEvent(msg.Payload())
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not decode the system event", "topic", msg.Topic())
+		return
+	}
+	klog.V(5).InfoS("received system event", "type", systemEvent.Type, "action", systemEvent.Action)
+	esc.dispatcher.Dispatch(systemEvent)
+}
+
+// decodeSystemEvent decodes the system event from the message envelope
+func decodeSystemEvent(data []byte) (dtos.SystemEvent, error) {
+	var systemEvent dtos.SystemEvent
+	var envelope messageEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return systemEvent, err
+	}
+	payload := []byte(envelope.Payload)
+	var encoded string
+	if err := json.Unmarshal(envelope.Payload, &encoded); err == nil {
+		if payload, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return systemEvent, err
+		}
+	}
+	if err := json.Unmarshal(payload, &systemEvent); err != nil {
+		return systemEvent, err
+	}
+	return systemEvent, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+func Test_DecodeSystemEvent(t *testing.T) {
+	jsonEnvelope := fmt.Sprintf(`{"apiVersion":"v3","contentType":"application/json","payload":%s}`, ServiceSystemEvent)
+	base64Envelope := fmt.Sprintf(`{"apiVersion":"v3","contentType":"application/json","payload":"%s"}`,
+		base64.StdEncoding.EncodeToString([]byte(ServiceSystemEvent)))
+
+	for name, envelope := range map[string]string{"json payload": jsonEnvelope, "base64 payload": base64Envelope} {
+		t.Run(name, func(t *testing.T) {
+			systemEvent, err := decodeSystemEvent([]byte(envelope))
+			assert.NoError(t, err)
+			assert.Equal(t, common.DeviceServiceSystemEventType, systemEvent.Type)
+			assert.Equal(t, common.SystemEventActionAdd, systemEvent.Action)
+
+			ds, err := serviceClient.Convert(context.TODO(), systemEvent, clients.GetOptions{Namespace: "default"})
+			assert.NoError(t, err)
+			assert.Equal(t, "device-virtual", ds.Name)
+		})
+	}
+
+	_, err := decodeSystemEvent([]byte(`{"payload":"not base64"}`))
+	assert.Error(t, err)
+}
+
+func Test_WatchSystemEvent(t *testing.T) {
+	server := mochi.New(&mochi.Options{InlineClient: true})
+	require.NoError(t, server.AddHook(new(auth.AllowHook), nil))
+	tcp := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	require.NoError(t, server.AddListener(tcp))
+	go func() {
+		_ = server.Serve()
+	}()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	events := make(chan dtos.SystemEvent, 1)
+	eventClient := NewEdgexSystemEventClient(tcp.Address(), "", "yurt-iot-dock")
+	require.NoError(t, eventClient.Watch(ctx, common.DeviceServiceSystemEventType, func(systemEvent dtos.SystemEvent) {
+		events <- systemEvent
+	}, nil))
+	require.NoError(t, eventClient.Watch(ctx, common.DeviceSystemEventType, func(systemEvent dtos.SystemEvent) {
+		t.Errorf("unexpected system event %v", systemEvent)
+	}, nil))
+
+	envelope := fmt.Sprintf(`{"apiVersion":"v3","contentType":"application/json","payload":%s}`, ServiceSystemEvent)
+	require.NoError(t, server.Publish("edgex/system-events/core-metadata/deviceservice/add/device-virtual", []byte(envelope), false, 0))
+
+	select {
+	case systemEvent := <-events:
+		assert.Equal(t, common.SystemEventActionAdd, systemEvent.Action)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the system event")
+	}
+}
@@ -100,9 +100,23 @@ type DeviceProfileInterface interface {
 	Convert(ctx context.Context, systemEvent dtos.SystemEvent, options GetOptions) (*iotv1alpha1.DeviceProfile, error)
 }
 
-// IoTDock defines the interfaces which used to create deviceclient, deviceprofileclient, deviceserviceclient
+// SystemEventHandler handles a system event about the objects on edge-side platform
+type SystemEventHandler func(systemEvent dtos.SystemEvent)
+
+// ResyncHandler is called when system events were dropped and the objects on edge-side platform need a full synchronization
+type ResyncHandler func()
+
+// SystemEventInterface defines the interfaces which used to watch the system events of Device, DeviceProfile and DeviceService objects on edge-side platform
+type SystemEventInterface interface {
+	// Watch calls the handler with the system events of the eventType until the ctx is done,
+	// the resync is called once the events that could not be delivered in time have been dropped
+	Watch(ctx context.Context, eventType string, handler SystemEventHandler, resync ResyncHandler) error
+}
+
+// IoTDock defines the interfaces which used to create deviceclient, deviceprofileclient, deviceserviceclient and systemeventclient
 type IoTDock interface {
 	CreateDeviceClient() (DeviceInterface, error)
 	CreateDeviceProfileClient() (DeviceProfileInterface, error)
 	CreateDeviceServiceClient() (DeviceServiceInterface, error)
+	CreateSystemEventClient() (SystemEventInterface, error)
 }
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+type DeviceClient struct {
+	registry *Registry
+}
+
+func NewDeviceClient(registry *Registry) *DeviceClient {
+	return &DeviceClient{registry: registry}
+}
+
+// Create function publishes a new device to the registry
+func (dc *DeviceClient) Create(ctx context.Context, device *iotv1alpha1.Device, options clients.CreateOptions) (*iotv1alpha1.Device, error) {
+	name := getEdgeName(device)
+	klog.V(5).Infof("will add the Device: %s", name)
+	var existing Device
+	if err := dc.registry.Get(KindDevice, name, &existing); err == nil {
+		return nil, fmt.Errorf("device %s already exists", name)
+	}
+	rd := toRegistryDevice(device, string(uuid.NewUUID()))
+	if err := dc.registry.Put(KindDevice, name, rd); err != nil {
+		return nil, fmt.Errorf("create device on mqtt registry failed, %v", err)
+	}
+	createdDevice := device.DeepCopy()
+	createdDevice.Status.EdgeId = rd.Id
+	createdDevice.Status.Synced = true
+	return createdDevice, nil
+}
+
+// Delete function removes the device from the registry
+func (dc *DeviceClient) Delete(ctx context.Context, name string, options clients.DeleteOptions) error {
+	klog.V(5).Infof("will delete the Device: %s", name)
+	var existing Device
+	if err := dc.registry.Get(KindDevice, name, &existing); err != nil {
+		return err
+	}
+	return dc.registry.Remove(KindDevice, name)
+}
+
+// Update is used to set the admin or operating state of the device by unique name of the device.
+func (dc *DeviceClient) Update(ctx context.Context, device *iotv1alpha1.Device, options clients.UpdateOptions) (*iotv1alpha1.Device, error) {
+	if device == nil {
+		return nil, nil
+	}
+	name := getEdgeName(device)
+	var rd Device
+	if err := dc.registry.Get(KindDevice, name, &rd); err != nil {
+		return nil, err
+	}
+	if device.Spec.AdminState != "" {
+		rd.AdminState = string(device.Spec.AdminState)
+	}
+	if device.Spec.OperatingState != "" {
+		rd.OperatingState = string(device.Spec.OperatingState)
+	}
+	if err := dc.registry.Put(KindDevice, name, rd); err != nil {
+		return nil, fmt.Errorf("could not update device: %s, %v", name, err)
+	}
+	return device, nil
+}
+
+// Get is used to query the device information corresponding to the device name
+func (dc *DeviceClient) Get(ctx context.Context, deviceName string, options clients.GetOptions) (*iotv1alpha1.Device, error) {
+	klog.V(5).Infof("will get Devices: %s", deviceName)
+	var rd Device
+	if err := dc.registry.Get(KindDevice, deviceName, &rd); err != nil {
+		return nil, err
+	}
+	device := toKubeDevice(rd, options.Namespace)
+	return &device, nil
+}
+
+// List is used to get all device objects on the registry
+func (dc *DeviceClient) List(ctx context.Context, options clients.ListOptions) ([]iotv1alpha1.Device, error) {
+	var res []iotv1alpha1.Device
+	for _, payload := range dc.registry.List(KindDevice) {
+		var rd Device
+		if err := json.Unmarshal(payload, &rd); err != nil {
+			klog.V(5).ErrorS(err, "could not decode the device record")
+			continue
+		}
+		res = append(res, toKubeDevice(rd, options.Namespace))
+	}
+	return res, nil
+}
+
+// Convert is used to convert the device record in the details of systemEvent to the device object in the kubernetes cluster
+func (dc *DeviceClient) Convert(ctx context.Context, systemEvent dtos.SystemEvent, opts clients.GetOptions) (*iotv1alpha1.Device, error) {
+	var rd Device
+	if err := systemEvent.DecodeDetails(&rd); err != nil {
+		klog.V(3).ErrorS(err, "fail to decode device systemEvent details")
+		return nil, err
+	}
+	device := toKubeDevice(rd, opts.Namespace)
+	return &device, nil
+}
+
+// GetPropertyState returns the value of the property reported by the device
+func (dc *DeviceClient) GetPropertyState(ctx context.Context, propertyName string, d *iotv1alpha1.Device, options clients.GetOptions) (*iotv1alpha1.ActualPropertyState, error) {
+	name := getEdgeName(d)
+	value, ok := dc.registry.Property(name, propertyName)
+	if !ok {
+		return nil, &clients.NotFoundError{}
+	}
+	return &iotv1alpha1.ActualPropertyState{
+		Name:        propertyName,
+		GetURL:      dc.registry.PropertyTopic(name, propertyName),
+		ActualValue: value,
+	}, nil
+}
+
+// UpdatePropertyState publishes the desired value of the property to the device
+func (dc *DeviceClient) UpdatePropertyState(ctx context.Context, propertyName string, d *iotv1alpha1.Device, options clients.UpdateOptions) error {
+	dps, ok := d.Spec.DeviceProperties[propertyName]
+	if !ok {
+		return fmt.Errorf("the desired state of property %s is not found", propertyName)
+	}
+	klog.V(5).Info("setting the property to desired value", "propertyName", propertyName, "desiredValue", dps.DesiredValue)
+	return dc.registry.SetProperty(getEdgeName(d), propertyName, dps.DesiredValue)
+}
+
+// ListPropertiesState gets all the actual property information reported by the device
+func (dc *DeviceClient) ListPropertiesState(ctx context.Context, device *iotv1alpha1.Device, options clients.ListOptions) (map[string]iotv1alpha1.DesiredPropertyState, map[string]iotv1alpha1.ActualPropertyState, error) {
+	name := getEdgeName(device)
+	dpsm := map[string]iotv1alpha1.DesiredPropertyState{}
+	apsm := map[string]iotv1alpha1.ActualPropertyState{}
+	for propertyName, value := range dc.registry.Properties(name) {
+		apsm[propertyName] = iotv1alpha1.ActualPropertyState{
+			Name:        propertyName,
+			GetURL:      dc.registry.PropertyTopic(name, propertyName),
+			ActualValue: value,
+		}
+	}
+	return dpsm, apsm, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+func newTestDevice() *iotv1alpha1.Device {
+	return &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "random-boolean-device",
+			Namespace: "default",
+			Labels: map[string]string{
+				EdgeObjectName: "Random-Boolean-Device",
+			},
+		},
+		Spec: iotv1alpha1.DeviceSpec{
+			Description:    "Example of Device Virtual",
+			AdminState:     iotv1alpha1.UnLocked,
+			OperatingState: iotv1alpha1.Up,
+			Service:        "device-virtual",
+			Profile:        "Random-Boolean-Device",
+			Protocols: map[string]iotv1alpha1.ProtocolProperties{
+				"other": {"Address": "device-virtual-bool-01"},
+			},
+			DeviceProperties: map[string]iotv1alpha1.DesiredPropertyState{
+				"Bool": {Name: "Bool", DesiredValue: "true"},
+			},
+		},
+	}
+}
+
+func TestDeviceClient(t *testing.T) {
+	addr := startBroker(t)
+	dock := NewMQTTDock(addr, "", "dock")
+	deviceClient, err := dock.CreateDeviceClient()
+	require.NoError(t, err)
+
+	device := newTestDevice()
+	created, err := deviceClient.Create(context.TODO(), device, clients.CreateOptions{})
+	assert.NoError(t, err)
+	assert.True(t, created.Status.Synced)
+	assert.NotEmpty(t, created.Status.EdgeId)
+
+	_, err = deviceClient.Create(context.TODO(), device, clients.CreateOptions{})
+	assert.Error(t, err)
+
+	got, err := deviceClient.Get(context.TODO(), "Random-Boolean-Device", clients.GetOptions{Namespace: "default"})
+	assert.NoError(t, err)
+	assert.Equal(t, "random-boolean-device", got.Name)
+	assert.Equal(t, created.Status.EdgeId, got.Status.EdgeId)
+	assert.Equal(t, device.Spec.Protocols, got.Spec.Protocols)
+
+	device.Spec.AdminState = iotv1alpha1.Locked
+	_, err = deviceClient.Update(context.TODO(), device, clients.UpdateOptions{})
+	assert.NoError(t, err)
+
+	// another yurt-iot-dock converges on the records of the registry
+	other := NewDeviceClient(newTestRegistry(t, addr, "other"))
+	assert.Eventually(t, func() bool {
+		devices, err := other.List(context.TODO(), clients.ListOptions{Namespace: "default"})
+		return err == nil && len(devices) == 1 && devices[0].Status.AdminState == iotv1alpha1.Locked
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.NoError(t, deviceClient.Delete(context.TODO(), "Random-Boolean-Device", clients.DeleteOptions{}))
+	err = deviceClient.Delete(context.TODO(), "Random-Boolean-Device", clients.DeleteOptions{})
+	assert.True(t, clients.IsNotFoundErr(err))
+	assert.Eventually(t, func() bool {
+		devices, err := other.List(context.TODO(), clients.ListOptions{})
+		return err == nil && len(devices) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestDeviceClientPropertyState(t *testing.T) {
+	addr := startBroker(t)
+	registry := newTestRegistry(t, addr, "dock")
+	deviceClient := NewDeviceClient(registry)
+	device := newTestDevice()
+
+	_, err := deviceClient.GetPropertyState(context.TODO(), "Bool", device, clients.GetOptions{})
+	assert.True(t, clients.IsNotFoundErr(err))
+
+	// a device service which sets the property and reports the new value
+	service := paho.NewClient(paho.NewClientOptions().AddBroker("tcp://" + addr).SetClientID("device-virtual"))
+	token := service.Connect()
+	require.True(t, token.WaitTimeout(5*time.Second))
+	require.NoError(t, token.Error())
+	defer service.Disconnect(250)
+	topic := registry.PropertyTopic("Random-Boolean-Device", "Bool")
+	token = service.Subscribe(topic+"/set", qosAtLeastOnce, func(c paho.Client, msg paho.Message) {
+		c.Publish(topic, qosAtLeastOnce, true, msg.Payload())
+	})
+	require.True(t, token.WaitTimeout(5*time.Second))
+	require.NoError(t, token.Error())
+
+	assert.Error(t, deviceClient.UpdatePropertyState(context.TODO(), "Float", device, clients.UpdateOptions{}))
+	assert.NoError(t, deviceClient.UpdatePropertyState(context.TODO(), "Bool", device, clients.UpdateOptions{}))
+	assert.Eventually(t, func() bool {
+		aps, err := deviceClient.GetPropertyState(context.TODO(), "Bool", device, clients.GetOptions{})
+		return err == nil && aps.ActualValue == "true"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	_, apsm, err := deviceClient.ListPropertiesState(context.TODO(), device, clients.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "true", apsm["Bool"].ActualValue)
+	assert.Equal(t, topic, apsm["Bool"].GetURL)
+}
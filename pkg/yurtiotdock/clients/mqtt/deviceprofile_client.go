@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+type DeviceProfileClient struct {
+	registry *Registry
+}
+
+func NewDeviceProfileClient(registry *Registry) *DeviceProfileClient {
+	return &DeviceProfileClient{registry: registry}
+}
+
+// Create function publishes a new device profile to the registry
+func (dpc *DeviceProfileClient) Create(ctx context.Context, deviceProfile *iotv1alpha1.DeviceProfile, options clients.CreateOptions) (*iotv1alpha1.DeviceProfile, error) {
+	name := getEdgeName(deviceProfile)
+	klog.V(5).Infof("will add the DeviceProfile: %s", name)
+	var existing DeviceProfile
+	if err := dpc.registry.Get(KindDeviceProfile, name, &existing); err == nil {
+		return nil, fmt.Errorf("device profile %s already exists", name)
+	}
+	rdp := toRegistryDeviceProfile(deviceProfile, string(uuid.NewUUID()))
+	if err := dpc.registry.Put(KindDeviceProfile, name, rdp); err != nil {
+		return nil, fmt.Errorf("create device profile on mqtt registry failed, %v", err)
+	}
+	createdDeviceProfile := deviceProfile.DeepCopy()
+	createdDeviceProfile.Status.EdgeId = rdp.Id
+	createdDeviceProfile.Status.Synced = true
+	return createdDeviceProfile, nil
+}
+
+// Delete function removes the device profile from the registry
+func (dpc *DeviceProfileClient) Delete(ctx context.Context, name string, options clients.DeleteOptions) error {
+	klog.V(5).Infof("will delete the DeviceProfile: %s", name)
+	var existing DeviceProfile
+	if err := dpc.registry.Get(KindDeviceProfile, name, &existing); err != nil {
+		return err
+	}
+	return dpc.registry.Remove(KindDeviceProfile, name)
+}
+
+// Update replaces the device profile in the registry, the id of the device profile is kept
+func (dpc *DeviceProfileClient) Update(ctx context.Context, deviceProfile *iotv1alpha1.DeviceProfile, options clients.UpdateOptions) (*iotv1alpha1.DeviceProfile, error) {
+	name := getEdgeName(deviceProfile)
+	var existing DeviceProfile
+	if err := dpc.registry.Get(KindDeviceProfile, name, &existing); err != nil {
+		return nil, err
+	}
+	if err := dpc.registry.Put(KindDeviceProfile, name, toRegistryDeviceProfile(deviceProfile, existing.Id)); err != nil {
+		return nil, fmt.Errorf("could not update device profile: %s, %v", name, err)
+	}
+	return deviceProfile, nil
+}
+
+// Get is used to query the device profile information corresponding to the name
+func (dpc *DeviceProfileClient) Get(ctx context.Context, name string, options clients.GetOptions) (*iotv1alpha1.DeviceProfile, error) {
+	klog.V(5).Infof("will get DeviceProfile: %s", name)
+	var rdp DeviceProfile
+	if err := dpc.registry.Get(KindDeviceProfile, name, &rdp); err != nil {
+		return nil, err
+	}
+	deviceProfile := toKubeDeviceProfile(rdp, options.Namespace)
+	return &deviceProfile, nil
+}
+
+// List is used to get all device profile objects on the registry
+func (dpc *DeviceProfileClient) List(ctx context.Context, options clients.ListOptions) ([]iotv1alpha1.DeviceProfile, error) {
+	var res []iotv1alpha1.DeviceProfile
+	for _, payload := range dpc.registry.List(KindDeviceProfile) {
+		var rdp DeviceProfile
+		if err := json.Unmarshal(payload, &rdp); err != nil {
+			klog.V(5).ErrorS(err, "could not decode the device profile record")
+			continue
+		}
+		res = append(res, toKubeDeviceProfile(rdp, options.Namespace))
+	}
+	return res, nil
+}
+
+// Convert is used to convert the device profile record in the details of systemEvent to the device profile object in the kubernetes cluster
+func (dpc *DeviceProfileClient) Convert(ctx context.Context, systemEvent dtos.SystemEvent, opts clients.GetOptions) (*iotv1alpha1.DeviceProfile, error) {
+	var rdp DeviceProfile
+	if err := systemEvent.DecodeDetails(&rdp); err != nil {
+		klog.V(3).ErrorS(err, "fail to decode device profile systemEvent details")
+		return nil, err
+	}
+	deviceProfile := toKubeDeviceProfile(rdp, opts.Namespace)
+	return &deviceProfile, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+type DeviceServiceClient struct {
+	registry *Registry
+}
+
+func NewDeviceServiceClient(registry *Registry) *DeviceServiceClient {
+	return &DeviceServiceClient{registry: registry}
+}
+
+// Create function publishes a new device service to the registry
+func (dsc *DeviceServiceClient) Create(ctx context.Context, deviceService *iotv1alpha1.DeviceService, options clients.CreateOptions) (*iotv1alpha1.DeviceService, error) {
+	name := getEdgeName(deviceService)
+	klog.V(5).Infof("will add the DeviceService: %s", name)
+	var existing DeviceService
+	if err := dsc.registry.Get(KindDeviceService, name, &existing); err == nil {
+		return nil, fmt.Errorf("device service %s already exists", name)
+	}
+	rds := toRegistryDeviceService(deviceService, string(uuid.NewUUID()))
+	if err := dsc.registry.Put(KindDeviceService, name, rds); err != nil {
+		return nil, fmt.Errorf("create device service on mqtt registry failed, %v", err)
+	}
+	createdDeviceService := deviceService.DeepCopy()
+	createdDeviceService.Status.EdgeId = rds.Id
+	createdDeviceService.Status.Synced = true
+	return createdDeviceService, nil
+}
+
+// Delete function removes the device service from the registry
+func (dsc *DeviceServiceClient) Delete(ctx context.Context, name string, options clients.DeleteOptions) error {
+	klog.V(5).Infof("will delete the DeviceService: %s", name)
+	var existing DeviceService
+	if err := dsc.registry.Get(KindDeviceService, name, &existing); err != nil {
+		return err
+	}
+	return dsc.registry.Remove(KindDeviceService, name)
+}
+
+// Update is used to set the admin state of the device service by unique name of the device service.
+func (dsc *DeviceServiceClient) Update(ctx context.Context, deviceService *iotv1alpha1.DeviceService, options clients.UpdateOptions) (*iotv1alpha1.DeviceService, error) {
+	name := getEdgeName(deviceService)
+	var rds DeviceService
+	if err := dsc.registry.Get(KindDeviceService, name, &rds); err != nil {
+		return nil, err
+	}
+	if deviceService.Spec.AdminState != "" {
+		rds.AdminState = string(deviceService.Spec.AdminState)
+	}
+	if err := dsc.registry.Put(KindDeviceService, name, rds); err != nil {
+		return nil, fmt.Errorf("could not update device service: %s, %v", name, err)
+	}
+	return deviceService, nil
+}
+
+// Get is used to query the device service information corresponding to the name
+func (dsc *DeviceServiceClient) Get(ctx context.Context, name string, options clients.GetOptions) (*iotv1alpha1.DeviceService, error) {
+	klog.V(5).Infof("will get DeviceService: %s", name)
+	var rds DeviceService
+	if err := dsc.registry.Get(KindDeviceService, name, &rds); err != nil {
+		return nil, err
+	}
+	deviceService := toKubeDeviceService(rds, options.Namespace)
+	return &deviceService, nil
+}
+
+// List is used to get all device service objects on the registry
+func (dsc *DeviceServiceClient) List(ctx context.Context, options clients.ListOptions) ([]iotv1alpha1.DeviceService, error) {
+	var res []iotv1alpha1.DeviceService
+	for _, payload := range dsc.registry.List(KindDeviceService) {
+		var rds DeviceService
+		if err := json.Unmarshal(payload, &rds); err != nil {
+			klog.V(5).ErrorS(err, "could not decode the device service record")
+			continue
+		}
+		res = append(res, toKubeDeviceService(rds, options.Namespace))
+	}
+	return res, nil
+}
+
+// Convert is used to convert the device service record in the details of systemEvent to the device service object in the kubernetes cluster
+func (dsc *DeviceServiceClient) Convert(ctx context.Context, systemEvent dtos.SystemEvent, opts clients.GetOptions) (*iotv1alpha1.DeviceService, error) {
+	var rds DeviceService
+	if err := systemEvent.DecodeDetails(&rds); err != nil {
+		klog.V(3).ErrorS(err, "fail to decode device service systemEvent details")
+		return nil, err
+	}
+	deviceService := toKubeDeviceService(rds, opts.Namespace)
+	return &deviceService, nil
+}
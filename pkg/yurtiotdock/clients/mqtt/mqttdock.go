@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"sync"
+
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+// MQTTDock creates the clients of the registry on a MQTT broker, all the clients share one connection.
+type MQTTDock struct {
+	BrokerAddr  string
+	TopicPrefix string
+	ClientID    string
+
+	once     sync.Once
+	registry *Registry
+	err      error
+}
+
+func NewMQTTDock(brokerAddr, topicPrefix, clientID string) *MQTTDock {
+	return &MQTTDock{
+		BrokerAddr:  brokerAddr,
+		TopicPrefix: topicPrefix,
+		ClientID:    clientID,
+	}
+}
+
+func (md *MQTTDock) getRegistry() (*Registry, error) {
+	md.once.Do(func() {
+		registry := NewRegistry(md.BrokerAddr, md.TopicPrefix, md.ClientID)
+		if err := registry.Connect(); err != nil {
+			md.err = err
+			return
+		}
+		md.registry = registry
+	})
+	return md.registry, md.err
+}
+
+func (md *MQTTDock) CreateDeviceClient() (clients.DeviceInterface, error) {
+	registry, err := md.getRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return NewDeviceClient(registry), nil
+}
+
+func (md *MQTTDock) CreateDeviceProfileClient() (clients.DeviceProfileInterface, error) {
+	registry, err := md.getRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return NewDeviceProfileClient(registry), nil
+}
+
+func (md *MQTTDock) CreateDeviceServiceClient() (clients.DeviceServiceInterface, error) {
+	registry, err := md.getRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return NewDeviceServiceClient(registry), nil
+}
+
+func (md *MQTTDock) CreateSystemEventClient() (clients.SystemEventInterface, error) {
+	registry, err := md.getRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	"k8s.io/klog/v2"
+
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+const (
+	// KindDevice, KindDeviceProfile and KindDeviceService are the kinds of records in the registry.
+	KindDevice        = "device"
+	KindDeviceProfile = "deviceprofile"
+	KindDeviceService = "deviceservice"
+
+	// DefaultTopicPrefix is the default root topic of the registry.
+	DefaultTopicPrefix = "openyurt/iot"
+
+	defaultTimeout = 10 * time.Second
+	qosAtLeastOnce = 1
+)
+
+// Registry is a device registry on top of a MQTT broker. The records of devices, device profiles and device services
+// are the retained messages of the topics:
+//
+//	<prefix>/registry/<kind>/<name>
+//
+// and the reported property values of the devices are the retained messages of the topics:
+//
+//	<prefix>/device/<name>/property/<property>
+//
+// A property is set by publishing the desired value to the topic <prefix>/device/<name>/property/<property>/set.
+// The registry subscribes to the records and the property values, and serves the reads from the local cache.
+type Registry struct {
+	client      paho.Client
+	topicPrefix string
+	ready       chan struct{}
+	readyOnce   sync.Once
+
+	mu         sync.RWMutex
+	records    map[string]map[string][]byte
+	properties map[string]map[string]string
+	dispatcher *clients.SystemEventDispatcher
+}
+
+// NewRegistry creates a registry on the broker, brokerAddr is the host:port of the broker.
+func NewRegistry(brokerAddr, topicPrefix, clientID string) *Registry {
+	if topicPrefix == "" {
+		topicPrefix = DefaultTopicPrefix
+	}
+	r := &Registry{
+		topicPrefix: strings.TrimSuffix(topicPrefix, "/"),
+		ready:       make(chan struct{}),
+		records: map[string]map[string][]byte{
+			KindDevice:        {},
+			KindDeviceProfile: {},
+			KindDeviceService: {},
+		},
+		properties: map[string]map[string]string{},
+		dispatcher: clients.NewSystemEventDispatcher(),
+	}
+	opts := paho.NewClientOptions().
+		AddBroker(fmt.Sprintf("tcp://%s", brokerAddr)).
+		SetClientID(clientID).
+		SetConnectTimeout(defaultTimeout).
+		SetAutoReconnect(true).
+		SetOnConnectHandler(r.subscribe)
+	r.client = paho.NewClient(opts)
+	return r
+}
+
+// Connect connects to the broker and waits until the registry is subscribed.
+func (r *Registry) Connect() error {
+	token := r.client.Connect()
+	if !token.WaitTimeout(defaultTimeout) {
+		return fmt.Errorf("connect to mqtt broker timeout")
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("could not connect to mqtt broker, %v", err)
+	}
+	select {
+	case <-r.ready:
+		return nil
+	case <-time.After(defaultTimeout):
+		return fmt.Errorf("subscribe to mqtt registry timeout")
+	}
+}
+
+// Close disconnects from the broker.
+func (r *Registry) Close() {
+	r.client.Disconnect(250)
+}
+
+// subscribe subscribes to the records and property values, it is called on each (re)connection.
+func (r *Registry) subscribe(client paho.Client) {
+	filters := map[string]byte{
+		fmt.Sprintf("%s/registry/+/+", r.topicPrefix):        qosAtLeastOnce,
+		fmt.Sprintf("%s/device/+/property/+", r.topicPrefix): qosAtLeastOnce,
+	}
+	token := client.SubscribeMultiple(filters, r.handle)
+	if !token.WaitTimeout(defaultTimeout) || token.Error() != nil {
+		klog.Errorf("could not subscribe to mqtt registry %s, %v", r.topicPrefix, token.Error())
+		return
+	}
+	r.readyOnce.Do(func() { close(r.ready) })
+}
+
+// handle caches the retained records and property values, an empty payload removes the record.
+// The changes of the records made by others are dispatched as system events.
+func (r *Registry) handle(_ paho.Client, msg paho.Message) {
+	levels := strings.Split(strings.TrimPrefix(msg.Topic(), r.topicPrefix+"/"), "/")
+	switch {
+	case len(levels) == 3 && levels[0] == "registry":
+		if systemEvent := r.cacheRecord(levels[1], levels[2], msg.Payload()); systemEvent != nil {
+			r.dispatcher.Dispatch(*systemEvent)
+		}
+	case len(levels) == 4 && levels[0] == "device" && levels[2] == "property":
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if len(msg.Payload()) == 0 {
+			delete(r.properties[levels[1]], levels[3])
+			return
+		}
+		if r.properties[levels[1]] == nil {
+			r.properties[levels[1]] = map[string]string{}
+		}
+		r.properties[levels[1]][levels[3]] = string(msg.Payload())
+	}
+}
+
+// cacheRecord updates the cached record and returns the system event of the change, or nil if nothing changed.
+func (r *Registry) cacheRecord(kind, name string, payload []byte) *dtos.SystemEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records, ok := r.records[kind]
+	if !ok {
+		return nil
+	}
+	previous, exists := records[name]
+	if len(payload) == 0 {
+		if !exists {
+			return nil
+		}
+		delete(records, name)
+		delete(r.properties, name)
+		return newSystemEvent(kind, common.SystemEventActionDelete, previous)
+	}
+	records[name] = payload
+	if !exists {
+		return newSystemEvent(kind, common.SystemEventActionAdd, payload)
+	}
+	if !bytes.Equal(previous, payload) {
+		return newSystemEvent(kind, common.SystemEventActionUpdate, payload)
+	}
+	return nil
+}
+
+// Watch calls the handler with the system events of the records of eventType until the ctx is done.
+func (r *Registry) Watch(ctx context.Context, eventType string, handler clients.SystemEventHandler, resync clients.ResyncHandler) error {
+	r.dispatcher.Watch(ctx, eventType, handler, resync)
+	return nil
+}
+
+// Put publishes the record of kind as a retained message.
+func (r *Registry) Put(kind, name string, record interface{}) error {
+	if err := r.validateKind(kind); err != nil {
+		return err
+	}
+	if err := validateName(name); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := r.publish(r.recordTopic(kind, name), payload, true); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.records[kind][name] = payload
+	r.mu.Unlock()
+	return nil
+}
+
+// Remove clears the retained record of kind.
+func (r *Registry) Remove(kind, name string) error {
+	if err := r.validateKind(kind); err != nil {
+		return err
+	}
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := r.publish(r.recordTopic(kind, name), nil, true); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	delete(r.records[kind], name)
+	if kind == KindDevice {
+		delete(r.properties, name)
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// Get decodes the record of kind into record, it returns an error with not found if the record does not exist.
+func (r *Registry) Get(kind, name string, record interface{}) error {
+	r.mu.RLock()
+	payload, ok := r.records[kind][name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%s %s not found", kind, name)
+	}
+	return json.Unmarshal(payload, record)
+}
+
+// List returns the payloads of all records of kind.
+func (r *Registry) List(kind string) [][]byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	payloads := make([][]byte, 0, len(r.records[kind]))
+	for _, payload := range r.records[kind] {
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+// Property returns the reported value of the device property.
+func (r *Registry) Property(device, property string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	value, ok := r.properties[device][property]
+	return value, ok
+}
+
+// Properties returns the reported values of all properties of the device.
+func (r *Registry) Properties(device string) map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	properties := make(map[string]string, len(r.properties[device]))
+	for k, v := range r.properties[device] {
+		properties[k] = v
+	}
+	return properties
+}
+
+// SetProperty publishes the desired value of the device property, the device service sets the property
+// and reports the new value.
+func (r *Registry) SetProperty(device, property, value string) error {
+	if err := validateName(device); err != nil {
+		return err
+	}
+	if err := validateName(property); err != nil {
+		return err
+	}
+	return r.publish(r.PropertyTopic(device, property)+"/set", []byte(value), false)
+}
+
+// PropertyTopic returns the topic where the value of the device property is reported.
+func (r *Registry) PropertyTopic(device, property string) string {
+	return fmt.Sprintf("%s/device/%s/property/%s", r.topicPrefix, device, property)
+}
+
+func (r *Registry) recordTopic(kind, name string) string {
+	return fmt.Sprintf("%s/registry/%s/%s", r.topicPrefix, kind, name)
+}
+
+func (r *Registry) publish(topic string, payload []byte, retained bool) error {
+	token := r.client.Publish(topic, qosAtLeastOnce, retained, payload)
+	if !token.WaitTimeout(defaultTimeout) {
+		return fmt.Errorf("publish to %s timeout", topic)
+	}
+	return token.Error()
+}
+
+// newSystemEvent wraps the record as the details of a system event, so that the Convert of the clients decodes it.
+func newSystemEvent(kind, action string, record []byte) *dtos.SystemEvent {
+	systemEvent := dtos.NewSystemEvent(kind, action, "mqtt-registry", "", nil, json.RawMessage(record))
+	return &systemEvent
+}
+
+// validateKind checks the kind is one of the kinds of records.
+func (r *Registry) validateKind(kind string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.records[kind]; !ok {
+		return fmt.Errorf("unknown kind %s of record", kind)
+	}
+	return nil
+}
+
+// validateName makes sure the name is a single topic level.
+func validateName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/+#") {
+		return errors.New("invalid name " + name + ", it must not be empty or contain '/', '+' or '#'")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startBroker starts an embedded broker for the test and returns its address.
+func startBroker(t *testing.T) string {
+	server := mochi.New(&mochi.Options{InlineClient: true})
+	require.NoError(t, server.AddHook(new(auth.AllowHook), nil))
+	tcp := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	require.NoError(t, server.AddListener(tcp))
+	go func() {
+		_ = server.Serve()
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+	return tcp.Address()
+}
+
+// newTestRegistry connects a registry to the broker and closes it when the test finishes.
+func newTestRegistry(t *testing.T, brokerAddr, clientID string) *Registry {
+	registry := NewRegistry(brokerAddr, "", clientID)
+	require.NoError(t, registry.Connect())
+	t.Cleanup(registry.Close)
+	return registry
+}
+
+func TestRegistry(t *testing.T) {
+	addr := startBroker(t)
+	writer := newTestRegistry(t, addr, "writer")
+
+	assert.Error(t, writer.Put(KindDevice, "a/b", Device{}))
+	assert.Error(t, writer.Put(KindDevice, "", Device{}))
+	assert.Error(t, writer.Put("devicegroup", "sensors", Device{}))
+	assert.Error(t, writer.Remove("devicegroup", "sensors"))
+
+	assert.NoError(t, writer.Put(KindDevice, "sensor", Device{Id: "1", Name: "sensor"}))
+	var rd Device
+	assert.NoError(t, writer.Get(KindDevice, "sensor", &rd))
+	assert.Equal(t, "1", rd.Id)
+
+	// the retained records are delivered to a registry connected later
+	reader := newTestRegistry(t, addr, "reader")
+	assert.Eventually(t, func() bool {
+		return len(reader.List(KindDevice)) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.NoError(t, writer.Remove(KindDevice, "sensor"))
+	assert.Eventually(t, func() bool {
+		return len(reader.List(KindDevice)) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+	err := reader.Get(KindDevice, "sensor", &rd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestRegistryWatch(t *testing.T) {
+	addr := startBroker(t)
+	writer := newTestRegistry(t, addr, "writer")
+	reader := newTestRegistry(t, addr, "reader")
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	events := make(chan dtos.SystemEvent, 10)
+	require.NoError(t, reader.Watch(ctx, KindDeviceService, func(systemEvent dtos.SystemEvent) {
+		events <- systemEvent
+	}, nil))
+	expectEvent := func(action string) DeviceService {
+		select {
+		case systemEvent := <-events:
+			assert.Equal(t, action, systemEvent.Action)
+			var rds DeviceService
+			assert.NoError(t, systemEvent.DecodeDetails(&rds))
+			return rds
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for the %s system event", action)
+			return DeviceService{}
+		}
+	}
+
+	assert.NoError(t, writer.Put(KindDevice, "sensor", Device{Id: "1", Name: "sensor"}))
+	assert.NoError(t, writer.Put(KindDeviceService, "device-virtual", DeviceService{Id: "2", Name: "device-virtual"}))
+	assert.Equal(t, "device-virtual", expectEvent(common.SystemEventActionAdd).Name)
+
+	assert.NoError(t, writer.Put(KindDeviceService, "device-virtual", DeviceService{Id: "2", Name: "device-virtual", AdminState: "LOCKED"}))
+	assert.Equal(t, "LOCKED", expectEvent(common.SystemEventActionUpdate).AdminState)
+
+	// the details of the delete event is the last record
+	assert.NoError(t, writer.Remove(KindDeviceService, "device-virtual"))
+	assert.Equal(t, "2", expectEvent(common.SystemEventActionDelete).Id)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+const (
+	// EdgeObjectName is the label of the actual name of object on the edge platform, it is shared with the
+	// EdgeX Foundry driver so that the syncers work the same for each platform.
+	EdgeObjectName = "yurt-iot-dock/edgex-object.name"
+)
+
+// Device is the record of device in the registry.
+type Device struct {
+	Id             string                       `json:"id"`
+	Name           string                       `json:"name"`
+	Description    string                       `json:"description,omitempty"`
+	AdminState     string                       `json:"adminState,omitempty"`
+	OperatingState string                       `json:"operatingState,omitempty"`
+	Labels         []string                     `json:"labels,omitempty"`
+	Location       string                       `json:"location,omitempty"`
+	ServiceName    string                       `json:"serviceName"`
+	ProfileName    string                       `json:"profileName"`
+	Protocols      map[string]map[string]string `json:"protocols,omitempty"`
+}
+
+// DeviceProfile is the record of device profile in the registry.
+type DeviceProfile struct {
+	Id              string                       `json:"id"`
+	Name            string                       `json:"name"`
+	Description     string                       `json:"description,omitempty"`
+	Manufacturer    string                       `json:"manufacturer,omitempty"`
+	Model           string                       `json:"model,omitempty"`
+	Labels          []string                     `json:"labels,omitempty"`
+	DeviceResources []iotv1alpha1.DeviceResource `json:"deviceResources,omitempty"`
+	DeviceCommands  []iotv1alpha1.DeviceCommand  `json:"deviceCommands,omitempty"`
+}
+
+// DeviceService is the record of device service in the registry.
+type DeviceService struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	BaseAddress string   `json:"baseAddress"`
+	Labels      []string `json:"labels,omitempty"`
+	AdminState  string   `json:"adminState,omitempty"`
+}
+
+func getEdgeName(provider metav1.Object) string {
+	if name, ok := provider.GetLabels()[EdgeObjectName]; ok {
+		return name
+	}
+	return provider.GetName()
+}
+
+func toKubeName(edgeName string) string {
+	return strings.ReplaceAll(strings.ToLower(edgeName), "_", "-")
+}
+
+func toKubeObjectMeta(edgeName, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      toKubeName(edgeName),
+		Namespace: namespace,
+		Labels: map[string]string{
+			EdgeObjectName: edgeName,
+		},
+	}
+}
+
+// toRegistryDevice serialize the Kubernetes Device to the corresponding registry Device
+func toRegistryDevice(d *iotv1alpha1.Device, id string) Device {
+	protocols := make(map[string]map[string]string, len(d.Spec.Protocols))
+	for k, v := range d.Spec.Protocols {
+		protocols[k] = v
+	}
+	return Device{
+		Id:             id,
+		Name:           getEdgeName(d),
+		Description:    d.Spec.Description,
+		AdminState:     string(d.Spec.AdminState),
+		OperatingState: string(d.Spec.OperatingState),
+		Labels:         d.Spec.Labels,
+		Location:       d.Spec.Location,
+		ServiceName:    d.Spec.Service,
+		ProfileName:    d.Spec.Profile,
+		Protocols:      protocols,
+	}
+}
+
+// toKubeDevice serialize the registry Device to the corresponding Kubernetes Device
+func toKubeDevice(rd Device, namespace string) iotv1alpha1.Device {
+	protocols := make(map[string]iotv1alpha1.ProtocolProperties, len(rd.Protocols))
+	for k, v := range rd.Protocols {
+		protocols[k] = v
+	}
+	return iotv1alpha1.Device{
+		ObjectMeta: toKubeObjectMeta(rd.Name, namespace),
+		Spec: iotv1alpha1.DeviceSpec{
+			Description:    rd.Description,
+			AdminState:     iotv1alpha1.AdminState(rd.AdminState),
+			OperatingState: iotv1alpha1.OperatingState(rd.OperatingState),
+			Protocols:      protocols,
+			Labels:         rd.Labels,
+			Location:       rd.Location,
+			Service:        rd.ServiceName,
+			Profile:        rd.ProfileName,
+		},
+		Status: iotv1alpha1.DeviceStatus{
+			Synced:         true,
+			EdgeId:         rd.Id,
+			AdminState:     iotv1alpha1.AdminState(rd.AdminState),
+			OperatingState: iotv1alpha1.OperatingState(rd.OperatingState),
+		},
+	}
+}
+
+// toRegistryDeviceProfile serialize the Kubernetes DeviceProfile to the corresponding registry DeviceProfile
+func toRegistryDeviceProfile(dp *iotv1alpha1.DeviceProfile, id string) DeviceProfile {
+	return DeviceProfile{
+		Id:              id,
+		Name:            getEdgeName(dp),
+		Description:     dp.Spec.Description,
+		Manufacturer:    dp.Spec.Manufacturer,
+		Model:           dp.Spec.Model,
+		Labels:          dp.Spec.Labels,
+		DeviceResources: dp.Spec.DeviceResources,
+		DeviceCommands:  dp.Spec.DeviceCommands,
+	}
+}
+
+// toKubeDeviceProfile serialize the registry DeviceProfile to the corresponding Kubernetes DeviceProfile
+func toKubeDeviceProfile(rdp DeviceProfile, namespace string) iotv1alpha1.DeviceProfile {
+	return iotv1alpha1.DeviceProfile{
+		ObjectMeta: toKubeObjectMeta(rdp.Name, namespace),
+		Spec: iotv1alpha1.DeviceProfileSpec{
+			Description:     rdp.Description,
+			Manufacturer:    rdp.Manufacturer,
+			Model:           rdp.Model,
+			Labels:          rdp.Labels,
+			DeviceResources: rdp.DeviceResources,
+			DeviceCommands:  rdp.DeviceCommands,
+		},
+		Status: iotv1alpha1.DeviceProfileStatus{
+			EdgeId: rdp.Id,
+			Synced: true,
+		},
+	}
+}
+
+// toRegistryDeviceService serialize the Kubernetes DeviceService to the corresponding registry DeviceService
+func toRegistryDeviceService(ds *iotv1alpha1.DeviceService, id string) DeviceService {
+	return DeviceService{
+		Id:          id,
+		Name:        getEdgeName(ds),
+		Description: ds.Spec.Description,
+		BaseAddress: ds.Spec.BaseAddress,
+		Labels:      ds.Spec.Labels,
+		AdminState:  string(ds.Spec.AdminState),
+	}
+}
+
+// toKubeDeviceService serialize the registry DeviceService to the corresponding Kubernetes DeviceService
+func toKubeDeviceService(rds DeviceService, namespace string) iotv1alpha1.DeviceService {
+	return iotv1alpha1.DeviceService{
+		ObjectMeta: toKubeObjectMeta(rds.Name, namespace),
+		Spec: iotv1alpha1.DeviceServiceSpec{
+			Description: rds.Description,
+			BaseAddress: rds.BaseAddress,
+			Labels:      rds.Labels,
+			AdminState:  iotv1alpha1.AdminState(rds.AdminState),
+		},
+		Status: iotv1alpha1.DeviceServiceStatus{
+			EdgeId:     rds.Id,
+			Synced:     true,
+			AdminState: iotv1alpha1.AdminState(rds.AdminState),
+		},
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	"k8s.io/klog/v2"
+)
+
+// systemEventQueueSize is the number of system events buffered for each watcher
+const systemEventQueueSize = 1024
+
+type systemEventWatcher struct {
+	eventType string
+	events    chan dtos.SystemEvent
+	// dropped is set when an event is dropped because the queue is full
+	dropped atomic.Bool
+}
+
+// SystemEventDispatcher fans out the system events received by a driver to the watchers of the event types,
+// every watcher runs its handler in its own goroutine so that a slow handler does not block the driver.
+// The events are dropped when the queue of a watcher is full, and the watcher resyncs once its queue is drained.
+type SystemEventDispatcher struct {
+	mu       sync.RWMutex
+	watchers map[*systemEventWatcher]struct{}
+}
+
+func NewSystemEventDispatcher() *SystemEventDispatcher {
+	return &SystemEventDispatcher{
+		watchers: map[*systemEventWatcher]struct{}{},
+	}
+}
+
+// Watch registers the handler for the system events of the eventType until the ctx is done,
+// the resync is called after the events have been dropped, it can be nil.
+func (d *SystemEventDispatcher) Watch(ctx context.Context, eventType string, handler SystemEventHandler, resync ResyncHandler) {
+	w := &systemEventWatcher{
+		eventType: eventType,
+		events:    make(chan dtos.SystemEvent, systemEventQueueSize),
+	}
+	d.mu.Lock()
+	d.watchers[w] = struct{}{}
+	d.mu.Unlock()
+
+	go func() {
+		defer func() {
+			d.mu.Lock()
+			delete(d.watchers, w)
+			d.mu.Unlock()
+		}()
+		for {
+			select {
+			case systemEvent := <-w.events:
+				handler(systemEvent)
+				// resync after the queued events are handled, so that the full synchronization
+				// is not overtaken by the events which are older than it.
+				if len(w.events) == 0 && w.dropped.CompareAndSwap(true, false) && resync != nil {
+					resync()
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Dispatch delivers the system event to the watchers of its type without blocking,
+// the event is dropped for the watchers whose queue is full.
+func (d *SystemEventDispatcher) Dispatch(systemEvent dtos.SystemEvent) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for w := range d.watchers {
+		if w.eventType != systemEvent.Type {
+			continue
+		}
+		select {
+		case w.events <- systemEvent:
+		default:
+			if w.dropped.CompareAndSwap(false, true) {
+				klog.Warningf("the queue of %s system events is full, drop the events and resync later", w.eventType)
+			}
+		}
+	}
+}
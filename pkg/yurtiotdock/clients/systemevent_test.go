@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemEventDispatcherDropsEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	release := make(chan struct{})
+	resynced := make(chan struct{}, 1)
+	handled := 0
+	d := NewSystemEventDispatcher()
+	d.Watch(ctx, common.DeviceSystemEventType, func(systemEvent dtos.SystemEvent) {
+		<-release
+		handled++
+	}, func() {
+		resynced <- struct{}{}
+	})
+
+	// the handler is blocked, Dispatch must not block once the queue is full
+	dispatched := make(chan struct{})
+	go func() {
+		for i := 0; i < systemEventQueueSize+10; i++ {
+			d.Dispatch(dtos.SystemEvent{Type: common.DeviceSystemEventType})
+		}
+		close(dispatched)
+	}()
+	select {
+	case <-dispatched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatch is blocked by a slow watcher")
+	}
+
+	close(release)
+	select {
+	case <-resynced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the resync")
+	}
+	assert.Less(t, handled, systemEventQueueSize+10)
+}
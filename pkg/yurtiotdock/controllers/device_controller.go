@@ -34,7 +34,6 @@ import (
 	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
 	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
 	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
-	edgexobj "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients/edgex-foundry"
 	util "github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
 )
 
@@ -46,6 +45,10 @@ type DeviceReconciler struct {
 	// which nodePool deviceController is deployed in
 	NodePool  string
 	Namespace string
+	// the namespaces which the devices are synchronized into
+	namespaces []string
+	// journal of the writes which failed while the cloud is not reachable, nil if the writes are not journaled
+	Journal *DeviceJournal
 }
 
 //+kubebuilder:rbac:groups=iot.openyurt.io,resources=devices,verbs=get;list;watch;create;update;patch;delete
@@ -109,14 +112,15 @@ func (r *DeviceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *DeviceReconciler) SetupWithManager(mgr ctrl.Manager, opts *options.YurtIoTDockOptions, edgexdock *edgexobj.EdgexDock) error {
-	deviceclient, err := edgexdock.CreateDeviceClient()
+func (r *DeviceReconciler) SetupWithManager(mgr ctrl.Manager, opts *options.YurtIoTDockOptions, iotdock clients.IoTDock) error {
+	deviceclient, err := iotdock.CreateDeviceClient()
 	if err != nil {
 		return err
 	}
 	r.deviceCli = deviceclient
 	r.NodePool = opts.Nodepool
 	r.Namespace = opts.Namespace
+	r.namespaces = opts.DeviceNamespaces()
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&iotv1alpha1.Device{}).
@@ -162,6 +166,17 @@ func (r *DeviceReconciler) reconcileCreateDevice(ctx context.Context, d *iotv1al
 	// get the actual name of the device on the Edge platform from the Label of the device
 	edgeDeviceName := util.GetEdgeDeviceName(d, EdgeXObjectName)
 	newDeviceStatus := d.Status.DeepCopy()
+	// the device on the edge platform can only be claimed by one device of the nodepool
+	claims, err := listDeviceClaims(ctx, r.Client, r.NodePool, edgeDeviceName, r.namespaces)
+	if err != nil {
+		return err
+	}
+	if claim := pickDeviceClaim(claims); claim != nil && client.ObjectKeyFromObject(claim) != client.ObjectKeyFromObject(d) {
+		klog.V(3).Infof("Device %s is already claimed by %s on the edge platform: %s", d.GetName(), klog.KObj(claim), edgeDeviceName)
+		util.SetDeviceCondition(&d.Status, util.NewDeviceCondition(iotv1alpha1.DeviceSyncedCondition, corev1.ConditionFalse, iotv1alpha1.DeviceNameConflictReason,
+			fmt.Sprintf("the device %s on edge platform is already claimed by %s", edgeDeviceName, klog.KObj(claim))))
+		return r.Status().Update(ctx, d)
+	}
 	klog.V(4).Infof("Checking if device already exist on the edge platform: %s", d.GetName())
 	// Checking if device already exist on the edge platform
 	edgeDevice, err := r.deviceCli.Get(context.TODO(), edgeDeviceName, clients.GetOptions{Namespace: r.Namespace})
@@ -190,7 +205,8 @@ func (r *DeviceReconciler) reconcileCreateDevice(ctx context.Context, d *iotv1al
 	d.Status = *newDeviceStatus
 	util.SetDeviceCondition(deviceStatus, util.NewDeviceCondition(iotv1alpha1.DeviceSyncedCondition, corev1.ConditionTrue, "", ""))
 
-	return r.Status().Update(ctx, d)
+	// the device has been added to the edge platform, keep its status until the cloud is reachable
+	return r.Journal.RecordFailure(JournalStatusUpdate, d, r.Status().Update(ctx, d))
 }
 
 func (r *DeviceReconciler) reconcileUpdateDevice(ctx context.Context, d *iotv1alpha1.Device, deviceStatus *iotv1alpha1.DeviceStatus) error {
@@ -230,7 +246,7 @@ func (r *DeviceReconciler) reconcileUpdateDevice(ctx context.Context, d *iotv1al
 
 	// 3. update the device status on OpenYurt
 	klog.V(3).Infof("DeviceName: %s, update the device status", d.GetName())
-	if err := r.Status().Update(ctx, d); err != nil {
+	if err := r.Journal.RecordFailure(JournalStatusUpdate, d, r.Status().Update(ctx, d)); err != nil {
 		util.SetDeviceCondition(deviceStatus, util.NewDeviceCondition(iotv1alpha1.DeviceManagingCondition, corev1.ConditionFalse, iotv1alpha1.DeviceUpdateStateReason, err.Error()))
 		return err
 	} else if len(failedPropertyNames) != 0 {
@@ -307,13 +323,17 @@ func (r *DeviceReconciler) reconcileDeviceProperties(d *iotv1alpha1.Device, devi
 }
 
 func DeleteDevicesOnControllerShutdown(ctx context.Context, cli client.Client, opts *options.YurtIoTDockOptions) error {
-	var deviceList iotv1alpha1.DeviceList
-	if err := cli.List(ctx, &deviceList, client.InNamespace(opts.Namespace)); err != nil {
-		return err
+	var devices []iotv1alpha1.Device
+	for _, namespace := range opts.DeviceNamespaces() {
+		var deviceList iotv1alpha1.DeviceList
+		if err := cli.List(ctx, &deviceList, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		devices = append(devices, deviceList.Items...)
 	}
 	klog.V(4).Infof("DeviceList, successfully get the list")
 
-	for _, device := range deviceList.Items {
+	for _, device := range devices {
 		controllerutil.RemoveFinalizer(&device, iotv1alpha1.DeviceFinalizer)
 		if err := cli.Update(ctx, &device); err != nil {
 			klog.Errorf("DeviceName: %s, update device err:%v", device.GetName(), err)
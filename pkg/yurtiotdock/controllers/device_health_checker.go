@@ -0,0 +1,264 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	iotv1beta1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
+)
+
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=iot.openyurt.io,resources=platformadmins,verbs=get;list;watch
+//+kubebuilder:rbac:groups=iot.openyurt.io,resources=platformadmins/status,verbs=get;update;patch
+
+// healthThresholds are the thresholds to derive the health conditions of the devices of a DeviceProfile,
+// zero disables the corresponding condition
+type healthThresholds struct {
+	unreachable time.Duration
+	stale       time.Duration
+}
+
+// DeviceHealthChecker derives the Reachable and ReportingStale conditions of the devices from their last
+// connected and reported time, and reports the number of unhealthy devices of the nodepool to PlatformAdmin.
+type DeviceHealthChecker struct {
+	// kubernetes client
+	client.Client
+	recorder record.EventRecorder
+	// checking period in seconds
+	checkPeriod time.Duration
+	// the default thresholds, they are overridden by the annotations of DeviceProfile
+	thresholds healthThresholds
+	NodePool   string
+	Namespace  string
+	// the namespaces which the devices are synchronized into
+	namespaces []string
+}
+
+// NewDeviceHealthChecker initialize a New DeviceHealthChecker
+func NewDeviceHealthChecker(client client.Client, recorder record.EventRecorder, opts *options.YurtIoTDockOptions) *DeviceHealthChecker {
+	return &DeviceHealthChecker{
+		Client:      client,
+		recorder:    recorder,
+		checkPeriod: time.Duration(opts.HealthCheckPeriod) * time.Second,
+		thresholds: healthThresholds{
+			unreachable: time.Duration(opts.UnreachableThreshold) * time.Second,
+			stale:       time.Duration(opts.StaleThreshold) * time.Second,
+		},
+		NodePool:   opts.Nodepool,
+		Namespace:  opts.Namespace,
+		namespaces: opts.DeviceNamespaces(),
+	}
+}
+
+// NewDeviceHealthCheckerRunnable initialize a controller-runtime manager runnable
+func (hc *DeviceHealthChecker) NewDeviceHealthCheckerRunnable() ctrlmgr.RunnableFunc {
+	return func(ctx context.Context) error {
+		hc.Run(ctx.Done())
+		return nil
+	}
+}
+
+func (hc *DeviceHealthChecker) Run(stop <-chan struct{}) {
+	klog.V(1).Info("[DeviceHealth] Starting the checker...")
+	for {
+		select {
+		case <-time.After(hc.checkPeriod):
+			hc.check(time.Now())
+		case <-stop:
+			klog.V(1).Info("[DeviceHealth] Stopping the checker")
+			return
+		}
+	}
+}
+
+// check updates the health conditions of the devices in the nodepool and reports the summary to PlatformAdmin
+func (hc *DeviceHealthChecker) check(now time.Time) {
+	kDevs, err := listNodePoolDevices(context.TODO(), hc.Client, hc.NodePool, hc.namespaces)
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not list the devices object on the OpenYurt")
+		return
+	}
+	profileThresholds, err := hc.getProfileThresholds()
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not list the deviceProfiles object on the OpenYurt")
+		return
+	}
+
+	health := iotv1beta1.PlatformAdminDeviceHealth{NodePool: hc.NodePool, Devices: int32(len(kDevs))}
+	for i := range kDevs {
+		device := &kDevs[i]
+		thresholds, ok := profileThresholds[device.Spec.Profile]
+		if !ok {
+			thresholds = hc.thresholds
+		}
+
+		newDevice := device.DeepCopy()
+		hc.setHealthConditions(newDevice, thresholds, now)
+		if cond := util.GetDeviceCondition(newDevice.Status, iotv1alpha1.DeviceReachableCondition); cond != nil && cond.Status == corev1.ConditionFalse {
+			health.UnreachableDevices++
+		}
+		if cond := util.GetDeviceCondition(newDevice.Status, iotv1alpha1.DeviceReportingStaleCondition); cond != nil && cond.Status == corev1.ConditionTrue {
+			health.ReportingStaleDevices++
+		}
+		if apiequality.Semantic.DeepEqual(device.Status.Conditions, newDevice.Status.Conditions) {
+			continue
+		}
+		if err := hc.Status().Patch(context.TODO(), newDevice, client.MergeFrom(device)); err != nil {
+			klog.V(4).ErrorS(err, "could not update the health conditions of device", "DeviceName", device.Name)
+		}
+	}
+
+	if err := hc.reportHealth(health, now); err != nil {
+		klog.V(3).ErrorS(err, "could not report the health of devices to PlatformAdmin", "NodePool", hc.NodePool)
+	}
+}
+
+// getProfileThresholds returns the thresholds of the DeviceProfiles in the nodepool by their names on the edge platform
+func (hc *DeviceHealthChecker) getProfileThresholds() (map[string]healthThresholds, error) {
+	var kDps iotv1alpha1.DeviceProfileList
+	listOptions := client.MatchingFields{util.IndexerPathForNodepool: hc.NodePool}
+	if err := hc.List(context.TODO(), &kDps, listOptions, client.InNamespace(hc.Namespace)); err != nil {
+		return nil, err
+	}
+
+	profileThresholds := map[string]healthThresholds{}
+	for i := range kDps.Items {
+		dp := &kDps.Items[i]
+		thresholds := hc.thresholds
+		if value, ok := dp.Annotations[UnreachableThresholdAnnotation]; ok {
+			if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+				thresholds.unreachable = d
+			} else {
+				klog.V(3).InfoS("[DeviceHealth] invalid unreachable threshold of deviceProfile", "DeviceProfile", dp.Name, "value", value)
+			}
+		}
+		if value, ok := dp.Annotations[ReportingStaleThresholdAnnotation]; ok {
+			if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+				thresholds.stale = d
+			} else {
+				klog.V(3).InfoS("[DeviceHealth] invalid reporting stale threshold of deviceProfile", "DeviceProfile", dp.Name, "value", value)
+			}
+		}
+		profileThresholds[util.GetEdgeDeviceProfileName(dp, EdgeXObjectName)] = thresholds
+	}
+	return profileThresholds, nil
+}
+
+// setHealthConditions derives the health conditions of the device, an event is recorded when the health changes
+func (hc *DeviceHealthChecker) setHealthConditions(device *iotv1alpha1.Device, thresholds healthThresholds, now time.Time) {
+	if thresholds.unreachable > 0 {
+		cond := util.NewDeviceCondition(iotv1alpha1.DeviceReachableCondition, corev1.ConditionTrue, iotv1alpha1.DeviceConnectedReason, "")
+		if device.Status.LastConnected == 0 {
+			cond = util.NewDeviceCondition(iotv1alpha1.DeviceReachableCondition, corev1.ConditionUnknown, iotv1alpha1.DeviceNeverConnectedReason, "the device has never connected")
+		} else if now.Sub(time.UnixMilli(device.Status.LastConnected)) > thresholds.unreachable {
+			cond = util.NewDeviceCondition(iotv1alpha1.DeviceReachableCondition, corev1.ConditionFalse, iotv1alpha1.DeviceUnreachableReason,
+				fmt.Sprintf("the device has not connected for more than %s", thresholds.unreachable))
+		}
+		hc.setCondition(device, cond, corev1.ConditionFalse)
+	}
+
+	if thresholds.stale > 0 {
+		cond := util.NewDeviceCondition(iotv1alpha1.DeviceReportingStaleCondition, corev1.ConditionFalse, iotv1alpha1.DeviceReportingReason, "")
+		if device.Status.LastReported == 0 {
+			cond = util.NewDeviceCondition(iotv1alpha1.DeviceReportingStaleCondition, corev1.ConditionUnknown, iotv1alpha1.DeviceNeverReportedReason, "the device has never reported")
+		} else if now.Sub(time.UnixMilli(device.Status.LastReported)) > thresholds.stale {
+			cond = util.NewDeviceCondition(iotv1alpha1.DeviceReportingStaleCondition, corev1.ConditionTrue, iotv1alpha1.DeviceReportingStaleReason,
+				fmt.Sprintf("the device has not reported for more than %s", thresholds.stale))
+		}
+		hc.setCondition(device, cond, corev1.ConditionTrue)
+	}
+}
+
+// setCondition sets the condition of the device and records an event if its status changes,
+// the unhealthy status is recorded as a warning and the recovery from it as normal.
+func (hc *DeviceHealthChecker) setCondition(device *iotv1alpha1.Device, cond *iotv1alpha1.DeviceCondition, unhealthy corev1.ConditionStatus) {
+	oldCond := util.GetDeviceCondition(device.Status, cond.Type)
+	util.SetDeviceCondition(&device.Status, cond)
+	if oldCond != nil && oldCond.Status == cond.Status {
+		return
+	}
+
+	if cond.Status == unhealthy {
+		hc.recorder.Event(device, corev1.EventTypeWarning, cond.Reason, cond.Message)
+	} else if oldCond != nil && oldCond.Status == unhealthy {
+		hc.recorder.Event(device, corev1.EventTypeNormal, cond.Reason, fmt.Sprintf("the condition %s of device recovered", cond.Type))
+	}
+}
+
+// reportHealth writes the health of the devices of the nodepool into the status of the PlatformAdmins which
+// manage the nodepool, it is skipped if the numbers don't change.
+func (hc *DeviceHealthChecker) reportHealth(health iotv1beta1.PlatformAdminDeviceHealth, now time.Time) error {
+	var platformAdmins iotv1beta1.PlatformAdminList
+	if err := hc.List(context.TODO(), &platformAdmins, client.InNamespace(hc.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range platformAdmins.Items {
+		if !util.IsInStringLst(platformAdmins.Items[i].Spec.NodePools, hc.NodePool) {
+			continue
+		}
+		key := client.ObjectKeyFromObject(&platformAdmins.Items[i])
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			platformAdmin := &iotv1beta1.PlatformAdmin{}
+			if err := hc.Get(context.TODO(), key, platformAdmin); err != nil {
+				return err
+			}
+			if !setPoolDeviceHealth(&platformAdmin.Status, health, now) {
+				return nil
+			}
+			return hc.Status().Update(context.TODO(), platformAdmin)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPoolDeviceHealth sets the health of the devices of the nodepool, it returns false if nothing changes
+func setPoolDeviceHealth(status *iotv1beta1.PlatformAdminStatus, health iotv1beta1.PlatformAdminDeviceHealth, now time.Time) bool {
+	health.LastUpdateTime = metav1.NewTime(now)
+	for i := range status.DeviceHealth {
+		current := &status.DeviceHealth[i]
+		if current.NodePool != health.NodePool {
+			continue
+		}
+		if current.Devices == health.Devices && current.UnreachableDevices == health.UnreachableDevices &&
+			current.ReportingStaleDevices == health.ReportingStaleDevices {
+			return false
+		}
+		*current = health
+		return true
+	}
+	status.DeviceHealth = append(status.DeviceHealth, health)
+	return true
+}
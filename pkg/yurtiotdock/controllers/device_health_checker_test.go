@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	iotv1beta1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
+)
+
+func newHealthDevice(name, profile string, lastConnected, lastReported time.Time) *iotv1alpha1.Device {
+	return &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       iotv1alpha1.DeviceSpec{NodePool: "hangzhou", Profile: profile},
+		Status:     iotv1alpha1.DeviceStatus{LastConnected: lastConnected.UnixMilli(), LastReported: lastReported.UnixMilli()},
+	}
+}
+
+func TestDeviceHealthChecker(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+	_ = iotv1beta1.AddToScheme(scheme)
+	now := time.Now()
+	objects := []client.Object{
+		newHealthDevice("healthy", "default-profile", now, now),
+		newHealthDevice("unreachable", "default-profile", now.Add(-10*time.Minute), now),
+		newHealthDevice("stale", "default-profile", now, now.Add(-20*time.Minute)),
+		// the thresholds of the slow profile are longer than the defaults
+		newHealthDevice("slow", "slow-profile", now.Add(-10*time.Minute), now.Add(-20*time.Minute)),
+		&iotv1alpha1.DeviceProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "hangzhou-slow-profile",
+				Namespace:   "default",
+				Labels:      map[string]string{EdgeXObjectName: "slow-profile"},
+				Annotations: map[string]string{UnreachableThresholdAnnotation: "1h", ReportingStaleThresholdAnnotation: "0"},
+			},
+			Spec: iotv1alpha1.DeviceProfileSpec{NodePool: "hangzhou"},
+		},
+		&iotv1beta1.PlatformAdmin{
+			ObjectMeta: metav1.ObjectMeta{Name: "edgex", Namespace: "default"},
+			Spec:       iotv1beta1.PlatformAdminSpec{NodePools: []string{"hangzhou", "beijing"}},
+			Status: iotv1beta1.PlatformAdminStatus{DeviceHealth: []iotv1beta1.PlatformAdminDeviceHealth{
+				{NodePool: "beijing", Devices: 3, UnreachableDevices: 1},
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+		WithStatusSubresource(&iotv1alpha1.Device{}, &iotv1beta1.PlatformAdmin{}).
+		WithIndex(&iotv1alpha1.Device{}, util.IndexerPathForNodepool, func(obj client.Object) []string {
+			return []string{obj.(*iotv1alpha1.Device).Spec.NodePool}
+		}).
+		WithIndex(&iotv1alpha1.DeviceProfile{}, util.IndexerPathForNodepool, func(obj client.Object) []string {
+			return []string{obj.(*iotv1alpha1.DeviceProfile).Spec.NodePool}
+		}).Build()
+	recorder := record.NewFakeRecorder(10)
+	opts := options.NewYurtIoTDockOptions()
+	opts.Nodepool = "hangzhou"
+	hc := NewDeviceHealthChecker(c, recorder, opts)
+
+	getDevice := func(name string) *iotv1alpha1.Device {
+		device := &iotv1alpha1.Device{}
+		require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: name}, device))
+		return device
+	}
+	conditionStatus := func(name string, condType iotv1alpha1.DeviceConditionType) corev1.ConditionStatus {
+		cond := util.GetDeviceCondition(getDevice(name).Status, condType)
+		if cond == nil {
+			return ""
+		}
+		return cond.Status
+	}
+
+	hc.check(now)
+	assert.Equal(t, corev1.ConditionTrue, conditionStatus("healthy", iotv1alpha1.DeviceReachableCondition))
+	assert.Equal(t, corev1.ConditionFalse, conditionStatus("healthy", iotv1alpha1.DeviceReportingStaleCondition))
+	assert.Equal(t, corev1.ConditionFalse, conditionStatus("unreachable", iotv1alpha1.DeviceReachableCondition))
+	assert.Equal(t, corev1.ConditionTrue, conditionStatus("stale", iotv1alpha1.DeviceReportingStaleCondition))
+	assert.Equal(t, corev1.ConditionTrue, conditionStatus("slow", iotv1alpha1.DeviceReachableCondition))
+	assert.Equal(t, corev1.ConditionStatus(""), conditionStatus("slow", iotv1alpha1.DeviceReportingStaleCondition))
+	require.Len(t, recorder.Events, 2)
+	events := []string{<-recorder.Events, <-recorder.Events}
+	sort.Strings(events)
+	assert.Equal(t, []string{
+		"Warning DeviceReportingStale the device has not reported for more than 10m0s",
+		"Warning DeviceUnreachable the device has not connected for more than 5m0s",
+	}, events)
+
+	platformAdmin := &iotv1beta1.PlatformAdmin{}
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "edgex"}, platformAdmin))
+	require.Len(t, platformAdmin.Status.DeviceHealth, 2)
+	assert.Equal(t, iotv1beta1.PlatformAdminDeviceHealth{NodePool: "beijing", Devices: 3, UnreachableDevices: 1}, platformAdmin.Status.DeviceHealth[0])
+	health := platformAdmin.Status.DeviceHealth[1]
+	assert.Equal(t, "hangzhou", health.NodePool)
+	assert.Equal(t, int32(4), health.Devices)
+	assert.Equal(t, int32(1), health.UnreachableDevices)
+	assert.Equal(t, int32(1), health.ReportingStaleDevices)
+
+	// the recovery of the device is recorded once
+	unreachable := getDevice("unreachable")
+	unreachable.Status.LastConnected = now.Add(time.Minute).UnixMilli()
+	require.NoError(t, c.Status().Update(context.TODO(), unreachable))
+	hc.check(now.Add(time.Minute))
+	hc.check(now.Add(2 * time.Minute))
+	assert.Equal(t, corev1.ConditionTrue, conditionStatus("unreachable", iotv1alpha1.DeviceReachableCondition))
+	assert.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Normal DeviceConnected")
+
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "edgex"}, platformAdmin))
+	assert.Equal(t, int32(0), platformAdmin.Status.DeviceHealth[1].UnreachableDevices)
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+var deviceJournalBacklog = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Subsystem: "yurt_iot_dock",
+		Name:      "device_journal_backlog",
+		Help:      "The number of device writes journaled while the cloud is not reachable and waiting to be replayed.",
+	},
+)
+
+func init() {
+	metrics.Registry.MustRegister(deviceJournalBacklog)
+}
+
+// JournalEntryKind is the kind of the device write recorded in the journal
+type JournalEntryKind string
+
+const (
+	// JournalDeviceCreate records a device found on the edge platform which should be created on OpenYurt
+	JournalDeviceCreate JournalEntryKind = "Create"
+	// JournalStatusUpdate records the status of a device which should be updated on OpenYurt
+	JournalStatusUpdate JournalEntryKind = "StatusUpdate"
+	// JournalSpecUpdate records the desired state of a device which should be updated on OpenYurt
+	JournalSpecUpdate JournalEntryKind = "SpecUpdate"
+
+	// JournalReplayFailedReason is the reason of the event emitted when a journaled write is dropped
+	JournalReplayFailedReason = "JournalReplayFailed"
+)
+
+// journalEntry is a device write which failed because the cloud was not reachable
+type journalEntry struct {
+	Kind   JournalEntryKind    `json:"kind"`
+	Device *iotv1alpha1.Device `json:"device"`
+	// Timestamp is the last time the device reported to or connected with the edge platform, in milliseconds.
+	// The desired state of device has no timestamp on the edge platform, the time of the write is used instead.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// DeviceJournal keeps the device writes which failed while the cloud is not reachable through yurthub,
+// and replays them on reconnection. Only the latest write of a device is kept, the writes are compared
+// by the timestamps of the edge platform, and the last writer wins.
+type DeviceJournal struct {
+	// kubernetes client
+	client.Client
+	// recorder of the events about the journaled writes which are dropped
+	recorder record.EventRecorder
+	// the file which the journal is persisted to, the journal is kept in memory only if it is empty
+	path string
+	// replaying period in seconds
+	replayPeriod time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*journalEntry
+}
+
+// NewDeviceJournal initialize a New DeviceJournal, the entries persisted by the last run are loaded
+func NewDeviceJournal(client client.Client, recorder record.EventRecorder, opts *options.YurtIoTDockOptions) (*DeviceJournal, error) {
+	j := &DeviceJournal{
+		Client:       client,
+		recorder:     recorder,
+		path:         opts.JournalPath,
+		replayPeriod: time.Duration(opts.JournalReplayPeriod) * time.Second,
+		entries:      map[string]*journalEntry{},
+	}
+	if j.path != "" {
+		data, err := os.ReadFile(j.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if len(data) != 0 {
+			var entries []*journalEntry
+			if err := json.Unmarshal(data, &entries); err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				j.entries[journalKey(entry.Kind, entry.Device)] = entry
+			}
+		}
+	}
+	deviceJournalBacklog.Set(float64(len(j.entries)))
+	return j, nil
+}
+
+// NewDeviceJournalRunnable initialize a controller-runtime manager runnable
+func (j *DeviceJournal) NewDeviceJournalRunnable() ctrlmgr.RunnableFunc {
+	return func(ctx context.Context) error {
+		j.Run(ctx.Done())
+		return nil
+	}
+}
+
+func (j *DeviceJournal) Run(stop <-chan struct{}) {
+	klog.V(1).Info("[DeviceJournal] Starting the journal...")
+	for {
+		select {
+		case <-time.After(j.replayPeriod):
+			j.Replay(context.TODO())
+		case <-stop:
+			klog.V(1).Info("[DeviceJournal] Stopping the journal")
+			return
+		}
+	}
+}
+
+// RecordFailure journals the write of the device if it failed because the cloud is not reachable. It returns nil
+// if the write is journaled and will be replayed on reconnection, otherwise the original error is returned.
+func (j *DeviceJournal) RecordFailure(kind JournalEntryKind, device *iotv1alpha1.Device, err error) error {
+	if j == nil || !isDisconnectedErr(err) {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	key := journalKey(kind, device)
+	entry := &journalEntry{Kind: kind, Device: device.DeepCopy(), Timestamp: writeTimestamp(kind, device)}
+	if existing, ok := j.entries[key]; ok && existing.Timestamp > entry.Timestamp {
+		klog.V(5).InfoS("[DeviceJournal] a newer write of device is journaled", "kind", kind, "DeviceName", device.Name)
+		return nil
+	}
+	j.entries[key] = entry
+	klog.V(4).InfoS("[DeviceJournal] journal the write of device", "kind", kind, "DeviceName", device.Name, "reason", err.Error())
+	j.persist()
+	return nil
+}
+
+// Forget removes the journaled write of the device once a write which is not older succeeded.
+func (j *DeviceJournal) Forget(kind JournalEntryKind, device *iotv1alpha1.Device) {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	key := journalKey(kind, device)
+	if entry, ok := j.entries[key]; ok && entry.Timestamp <= writeTimestamp(kind, device) {
+		delete(j.entries, key)
+		j.persist()
+	}
+}
+
+// Len returns the number of journaled writes
+func (j *DeviceJournal) Len() int {
+	if j == nil {
+		return 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.entries)
+}
+
+// Replay writes the journaled devices to OpenYurt in the order of their timestamps, it stops at the
+// first write which fails because the cloud is still not reachable. The lock is not held while the
+// writes are replayed, so that the writes of the controllers are not blocked by a slow cloud.
+func (j *DeviceJournal) Replay(ctx context.Context) {
+	j.mu.Lock()
+	entries := make(map[string]*journalEntry, len(j.entries))
+	for key, entry := range j.entries {
+		entries[key] = entry
+	}
+	j.mu.Unlock()
+	if len(entries) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if entries[keys[a]].Timestamp != entries[keys[b]].Timestamp {
+			return entries[keys[a]].Timestamp < entries[keys[b]].Timestamp
+		}
+		return keys[a] < keys[b]
+	})
+
+	klog.V(2).Infof("[DeviceJournal] Replaying %d journaled writes of devices", len(keys))
+	var done []string
+	for _, key := range keys {
+		entry := entries[key]
+		err := j.replay(ctx, entry)
+		if isDisconnectedErr(err) {
+			klog.V(3).ErrorS(err, "the cloud is still not reachable, stop replaying the journal")
+			break
+		} else if apierrors.IsConflict(err) {
+			klog.V(5).InfoS("replay Conflicts", "Device", entry.Device.Name)
+			continue
+		} else if isPermanentErr(err) {
+			// the write will never succeed, keeping it would only retry it forever
+			klog.V(3).ErrorS(err, "drop the journaled write of device which could not be replayed", "kind", entry.Kind, "DeviceName", entry.Device.Name)
+			if j.recorder != nil {
+				j.recorder.Eventf(entry.Device, corev1.EventTypeWarning, JournalReplayFailedReason,
+					"the journaled %s of device is dropped: %v", entry.Kind, err)
+			}
+		} else if err != nil {
+			klog.V(3).ErrorS(err, "could not replay the journaled write of device", "DeviceName", entry.Device.Name)
+			continue
+		}
+		done = append(done, key)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, key := range done {
+		// the device may be journaled again while the journal is replayed, the newer write is kept
+		if j.entries[key] == entries[key] {
+			delete(j.entries, key)
+		}
+	}
+	j.persist()
+}
+
+// replay applies a journaled write, the write is skipped if the device on OpenYurt is newer than it
+func (j *DeviceJournal) replay(ctx context.Context, entry *journalEntry) error {
+	switch entry.Kind {
+	case JournalDeviceCreate:
+		device := entry.Device.DeepCopy()
+		device.ResourceVersion = ""
+		if err := j.Create(ctx, device); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	case JournalStatusUpdate:
+		current := &iotv1alpha1.Device{}
+		if err := j.Get(ctx, client.ObjectKeyFromObject(entry.Device), current); err != nil {
+			return err
+		}
+		if edgeTimestamp(current) > entry.Timestamp {
+			klog.V(4).InfoS("[DeviceJournal] the device on OpenYurt is newer, drop the journaled status", "DeviceName", current.Name)
+			return nil
+		}
+		// the readings are summarized by the reading collector, which has its own pace
+		status := entry.Device.Status.DeepCopy()
+		status.Readings = current.Status.Readings
+		current.Status = *status
+		return j.Status().Update(ctx, current)
+	case JournalSpecUpdate:
+		current := &iotv1alpha1.Device{}
+		if err := j.Get(ctx, client.ObjectKeyFromObject(entry.Device), current); err != nil {
+			return err
+		}
+		if lastWriteTimestamp(current) > entry.Timestamp {
+			klog.V(4).InfoS("[DeviceJournal] the device on OpenYurt is newer, drop the journaled desired state", "DeviceName", current.Name)
+			return nil
+		}
+		for k, v := range entry.Device.Labels {
+			metav1.SetMetaDataLabel(&current.ObjectMeta, k, v)
+		}
+		for k, v := range entry.Device.Annotations {
+			metav1.SetMetaDataAnnotation(&current.ObjectMeta, k, v)
+		}
+		current.Spec = entry.Device.Spec
+		return j.Update(ctx, current)
+	}
+	return nil
+}
+
+// persist writes the entries to the journal file, it must be called with the lock held
+func (j *DeviceJournal) persist() {
+	deviceJournalBacklog.Set(float64(len(j.entries)))
+	if j.path == "" {
+		return
+	}
+
+	entries := make([]*journalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entries = append(entries, entry)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not marshal the device journal")
+		return
+	}
+	// write to a temporary file first, so that the journal is never left half written
+	tmp := j.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		klog.V(3).ErrorS(err, "could not create the directory of device journal", "path", j.path)
+		return
+	}
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		klog.V(3).ErrorS(err, "could not write the device journal", "path", tmp)
+		return
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		klog.V(3).ErrorS(err, "could not write the device journal", "path", j.path)
+	}
+}
+
+func journalKey(kind JournalEntryKind, device *iotv1alpha1.Device) string {
+	return strings.Join([]string{string(kind), device.Namespace, device.Name}, "/")
+}
+
+// writeTimestamp returns the timestamp which the writes of device are compared by
+func writeTimestamp(kind JournalEntryKind, device *iotv1alpha1.Device) int64 {
+	if kind == JournalSpecUpdate {
+		return time.Now().UnixMilli()
+	}
+	return edgeTimestamp(device)
+}
+
+// lastWriteTimestamp returns the last time the device was written on OpenYurt, in milliseconds
+func lastWriteTimestamp(device *iotv1alpha1.Device) int64 {
+	last := device.CreationTimestamp.Time
+	for _, field := range device.ManagedFields {
+		if field.Time != nil && field.Time.After(last) {
+			last = field.Time.Time
+		}
+	}
+	return last.UnixMilli()
+}
+
+// edgeTimestamp returns the last time the device reported to or connected with the edge platform
+func edgeTimestamp(device *iotv1alpha1.Device) int64 {
+	if device.Status.LastReported > device.Status.LastConnected {
+		return device.Status.LastReported
+	}
+	return device.Status.LastConnected
+}
+
+// isDisconnectedErr checks whether the request failed because the cloud is not reachable, yurthub answers
+// with service unavailable or timeouts when the cloud is down, otherwise only the network errors and the
+// timeouts are regarded as disconnection.
+func isDisconnectedErr(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		return apierrors.IsServiceUnavailable(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) || utilnet.IsTimeout(err) || utilnet.IsConnectionRefused(err) ||
+		utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) || utilnet.IsHTTP2ConnectionLost(err) {
+		return true
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return errors.As(err, &opErr) || errors.As(err, &dnsErr)
+}
+
+// isPermanentErr checks whether the write is rejected by the cloud and will never succeed by retrying
+func isPermanentErr(err error) bool {
+	return apierrors.IsNotFound(err) || apierrors.IsForbidden(err) || apierrors.IsInvalid(err) ||
+		apierrors.IsBadRequest(err) || apierrors.IsMethodNotSupported(err)
+}
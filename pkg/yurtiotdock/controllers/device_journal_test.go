@@ -0,0 +1,240 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+func newJournalDevice(name string, lastReported int64, operatingState iotv1alpha1.OperatingState) *iotv1alpha1.Device {
+	return &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       iotv1alpha1.DeviceSpec{NodePool: "hangzhou"},
+		Status:     iotv1alpha1.DeviceStatus{LastReported: lastReported, OperatingState: operatingState},
+	}
+}
+
+func newConnectionRefusedErr() error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+}
+
+func TestIsDisconnectedErr(t *testing.T) {
+	gr := schema.GroupResource{Group: "iot.openyurt.io", Resource: "devices"}
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"no error":            {err: nil, want: false},
+		"connection refused":  {err: newConnectionRefusedErr(), want: true},
+		"deadline exceeded":   {err: context.DeadlineExceeded, want: true},
+		"unexpected eof":      {err: io.ErrUnexpectedEOF, want: true},
+		"unknown error":       {err: errors.New("could not marshal the device"), want: false},
+		"forbidden":           {err: apierrors.NewForbidden(gr, "device", errors.New("forbidden")), want: false},
+		"service unavailable": {err: apierrors.NewServiceUnavailable("cloud is unhealthy"), want: true},
+		"server timeout":      {err: apierrors.NewServerTimeout(gr, "update", 1), want: true},
+		"conflict":            {err: apierrors.NewConflict(gr, "device", errors.New("conflict")), want: false},
+		"not found":           {err: apierrors.NewNotFound(gr, "device"), want: false},
+		"context canceled":    {err: context.Canceled, want: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isDisconnectedErr(tt.err))
+		})
+	}
+}
+
+func TestDeviceJournalRecordFailure(t *testing.T) {
+	opts := options.NewYurtIoTDockOptions()
+	opts.JournalPath = filepath.Join(t.TempDir(), "journal.json")
+	j, err := NewDeviceJournal(nil, nil, opts)
+	require.NoError(t, err)
+
+	disconnected := apierrors.NewServiceUnavailable("cloud is unhealthy")
+	conflict := apierrors.NewConflict(schema.GroupResource{}, "device", errors.New("conflict"))
+	assert.Equal(t, conflict, j.RecordFailure(JournalStatusUpdate, newJournalDevice("device1", 100, iotv1alpha1.Up), conflict))
+	assert.Equal(t, 0, j.Len())
+
+	// only the latest write of the device is kept
+	assert.NoError(t, j.RecordFailure(JournalStatusUpdate, newJournalDevice("device1", 200, iotv1alpha1.Down), disconnected))
+	assert.NoError(t, j.RecordFailure(JournalStatusUpdate, newJournalDevice("device1", 100, iotv1alpha1.Up), disconnected))
+	assert.NoError(t, j.RecordFailure(JournalDeviceCreate, newJournalDevice("device2", 100, iotv1alpha1.Up), disconnected))
+	assert.Equal(t, 2, j.Len())
+	assert.Equal(t, iotv1alpha1.Down, j.entries["StatusUpdate/default/device1"].Device.Status.OperatingState)
+	assert.Equal(t, float64(2), testutil.ToFloat64(deviceJournalBacklog))
+
+	// the journal survives the restart of yurt-iot-dock
+	restarted, err := NewDeviceJournal(nil, nil, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restarted.Len())
+	assert.Equal(t, int64(200), restarted.entries["StatusUpdate/default/device1"].Timestamp)
+
+	// an older successful write doesn't remove the journaled one
+	restarted.Forget(JournalStatusUpdate, newJournalDevice("device1", 100, iotv1alpha1.Up))
+	assert.Equal(t, 2, restarted.Len())
+	restarted.Forget(JournalStatusUpdate, newJournalDevice("device1", 300, iotv1alpha1.Up))
+	assert.Equal(t, 1, restarted.Len())
+	assert.Equal(t, float64(1), testutil.ToFloat64(deviceJournalBacklog))
+
+	var nilJournal *DeviceJournal
+	assert.Equal(t, disconnected, nilJournal.RecordFailure(JournalStatusUpdate, newJournalDevice("device1", 100, iotv1alpha1.Up), disconnected))
+}
+
+func TestDeviceJournalReplay(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+	disconnected := true
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(newJournalDevice("stale", 100, iotv1alpha1.Up), newJournalDevice("newer", 300, iotv1alpha1.Up)).
+		WithStatusSubresource(&iotv1alpha1.Device{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if disconnected {
+					return newConnectionRefusedErr()
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if disconnected {
+					return apierrors.NewServiceUnavailable("cloud is unhealthy")
+				}
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			},
+		}).Build()
+	opts := options.NewYurtIoTDockOptions()
+	opts.JournalPath = filepath.Join(t.TempDir(), "journal.json")
+	j, err := NewDeviceJournal(c, nil, opts)
+	require.NoError(t, err)
+
+	unavailable := apierrors.NewServiceUnavailable("cloud is unhealthy")
+	require.NoError(t, j.RecordFailure(JournalStatusUpdate, newJournalDevice("stale", 200, iotv1alpha1.Down), unavailable))
+	require.NoError(t, j.RecordFailure(JournalStatusUpdate, newJournalDevice("newer", 200, iotv1alpha1.Down), unavailable))
+	require.NoError(t, j.RecordFailure(JournalDeviceCreate, newJournalDevice("created", 250, iotv1alpha1.Up), unavailable))
+
+	// the journal is kept while the cloud is not reachable
+	j.Replay(context.TODO())
+	assert.Equal(t, 3, j.Len())
+
+	// the last writer wins once the cloud is reachable again
+	disconnected = false
+	j.Replay(context.TODO())
+	assert.Equal(t, 0, j.Len())
+	assert.Equal(t, float64(0), testutil.ToFloat64(deviceJournalBacklog))
+
+	got := &iotv1alpha1.Device{}
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "stale"}, got))
+	assert.Equal(t, iotv1alpha1.Down, got.Status.OperatingState)
+	assert.Equal(t, int64(200), got.Status.LastReported)
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "newer"}, got))
+	assert.Equal(t, iotv1alpha1.Up, got.Status.OperatingState)
+	assert.Equal(t, int64(300), got.Status.LastReported)
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "created"}, got))
+}
+
+func TestDeviceJournalReplayPermanentErr(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+	var j *DeviceJournal
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(newJournalDevice("forbidden", 100, iotv1alpha1.Up), newJournalDevice("rewritten", 100, iotv1alpha1.Up)).
+		WithStatusSubresource(&iotv1alpha1.Device{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if obj.GetName() == "forbidden" {
+					return apierrors.NewForbidden(schema.GroupResource{Resource: "devices"}, obj.GetName(), errors.New("forbidden"))
+				}
+				// the journal is not locked while it is replayed
+				newer := newJournalDevice("rewritten", 300, iotv1alpha1.Down)
+				if err := j.RecordFailure(JournalStatusUpdate, newer, apierrors.NewServiceUnavailable("cloud is unhealthy")); err != nil {
+					return err
+				}
+				return apierrors.NewServiceUnavailable("cloud is unhealthy")
+			},
+		}).Build()
+	recorder := record.NewFakeRecorder(10)
+	opts := options.NewYurtIoTDockOptions()
+	opts.JournalPath = filepath.Join(t.TempDir(), "journal.json")
+	j, err := NewDeviceJournal(c, recorder, opts)
+	require.NoError(t, err)
+
+	unavailable := apierrors.NewServiceUnavailable("cloud is unhealthy")
+	require.NoError(t, j.RecordFailure(JournalStatusUpdate, newJournalDevice("forbidden", 200, iotv1alpha1.Down), unavailable))
+	require.NoError(t, j.RecordFailure(JournalStatusUpdate, newJournalDevice("missing", 200, iotv1alpha1.Down), unavailable))
+	require.NoError(t, j.RecordFailure(JournalStatusUpdate, newJournalDevice("rewritten", 250, iotv1alpha1.Down), unavailable))
+
+	// the writes rejected by the cloud are dropped with an event, the device journaled during replaying is kept
+	j.Replay(context.TODO())
+	assert.Equal(t, 1, j.Len())
+	assert.Equal(t, int64(300), j.entries["StatusUpdate/default/rewritten"].Timestamp)
+	require.Len(t, recorder.Events, 2)
+	for i := 0; i < 2; i++ {
+		assert.Contains(t, <-recorder.Events, JournalReplayFailedReason)
+	}
+}
+
+func TestDeviceJournalReplaySpecUpdate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+	outdated := newJournalDevice("outdated", 0, iotv1alpha1.Up)
+	outdated.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl", Time: &metav1.Time{Time: time.Now().Add(time.Hour)}}}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(newJournalDevice("desired", 0, iotv1alpha1.Up), outdated).Build()
+	opts := options.NewYurtIoTDockOptions()
+	opts.JournalPath = filepath.Join(t.TempDir(), "journal.json")
+	j, err := NewDeviceJournal(c, nil, opts)
+	require.NoError(t, err)
+
+	unavailable := apierrors.NewServiceUnavailable("cloud is unhealthy")
+	for _, name := range []string{"desired", "outdated"} {
+		device := newJournalDevice(name, 0, iotv1alpha1.Up)
+		device.Labels = map[string]string{"line": "a"}
+		device.Spec.Description = "journaled"
+		require.NoError(t, j.RecordFailure(JournalSpecUpdate, device, unavailable))
+	}
+	j.Replay(context.TODO())
+	assert.Equal(t, 0, j.Len())
+
+	got := &iotv1alpha1.Device{}
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "desired"}, got))
+	assert.Equal(t, "journaled", got.Spec.Description)
+	assert.Equal(t, "a", got.Labels["line"])
+	// the device written on OpenYurt after the journaled write wins
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "outdated"}, got))
+	assert.Empty(t, got.Spec.Description)
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	edgeCli "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+var deviceReading = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Subsystem: "yurt_iot_dock",
+		Name:      "device_reading",
+		Help:      "The last numeric reading of the exported device property, boolean readings are exported as 0 or 1.",
+	},
+	[]string{"device", "profile", "nodepool", "property"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(deviceReading)
+}
+
+// readingWindow accumulates the readings of the properties of a device between two status updates
+type readingWindow struct {
+	readings   map[string]*iotv1alpha1.DeviceReading
+	sums       map[string]float64
+	numerics   map[string]int32
+	lastUpdate time.Time
+}
+
+func newReadingWindow(lastUpdate time.Time) *readingWindow {
+	return &readingWindow{
+		readings:   map[string]*iotv1alpha1.DeviceReading{},
+		sums:       map[string]float64{},
+		numerics:   map[string]int32{},
+		lastUpdate: lastUpdate,
+	}
+}
+
+// add records a reading of the property into the window
+func (w *readingWindow) add(property, value string, now metav1.Time) {
+	reading, ok := w.readings[property]
+	if !ok {
+		reading = &iotv1alpha1.DeviceReading{Name: property}
+		w.readings[property] = reading
+	}
+	reading.LastValue = value
+	reading.LastUpdateTime = now
+	reading.Samples++
+
+	number, ok := parseReading(value)
+	if !ok {
+		return
+	}
+	if w.numerics[property] == 0 || number < parseFloat(reading.Min) {
+		reading.Min = formatFloat(number)
+	}
+	if w.numerics[property] == 0 || number > parseFloat(reading.Max) {
+		reading.Max = formatFloat(number)
+	}
+	w.sums[property] += number
+	w.numerics[property]++
+	reading.Average = formatFloat(w.sums[property] / float64(w.numerics[property]))
+}
+
+// summary returns the readings of the window sorted by the property name
+func (w *readingWindow) summary() []iotv1alpha1.DeviceReading {
+	readings := make([]iotv1alpha1.DeviceReading, 0, len(w.readings))
+	for _, reading := range w.readings {
+		readings = append(readings, *reading)
+	}
+	sort.Slice(readings, func(i, j int) bool {
+		return readings[i].Name < readings[j].Name
+	})
+	return readings
+}
+
+type DeviceReadingCollector struct {
+	// kubernetes client
+	client.Client
+	// edge platform's client
+	deviceCli edgeCli.DeviceInterface
+	// collecting period in seconds
+	collectPeriod time.Duration
+	// the minimum period in seconds between two status updates of a device, 0 disables the status updates
+	statusPeriod time.Duration
+	// limits the status updates of all devices to protect the WAN between edge and cloud
+	statusLimiter *rate.Limiter
+	NodePool      string
+	Namespace     string
+	// the namespaces which the devices are synchronized into
+	namespaces []string
+
+	windows map[string]*readingWindow
+	// the label values of the metrics exported in the last round
+	exported map[string][]string
+}
+
+// NewDeviceReadingCollector initialize a New DeviceReadingCollector
+func NewDeviceReadingCollector(client client.Client, opts *options.YurtIoTDockOptions, iotdock edgeCli.IoTDock) (*DeviceReadingCollector, error) {
+	deviceclient, err := iotdock.CreateDeviceClient()
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceReadingCollector{
+		Client:        client,
+		deviceCli:     deviceclient,
+		collectPeriod: time.Duration(opts.ReadingCollectPeriod) * time.Second,
+		statusPeriod:  time.Duration(opts.ReadingStatusPeriod) * time.Second,
+		statusLimiter: rate.NewLimiter(rate.Limit(opts.ReadingStatusQPS), 1),
+		NodePool:      opts.Nodepool,
+		Namespace:     opts.Namespace,
+		namespaces:    opts.DeviceNamespaces(),
+		windows:       map[string]*readingWindow{},
+		exported:      map[string][]string{},
+	}, nil
+}
+
+// NewDeviceReadingCollectorRunnable initialize a controller-runtime manager runnable
+func (rc *DeviceReadingCollector) NewDeviceReadingCollectorRunnable() ctrlmgr.RunnableFunc {
+	return func(ctx context.Context) error {
+		rc.Run(ctx.Done())
+		return nil
+	}
+}
+
+func (rc *DeviceReadingCollector) Run(stop <-chan struct{}) {
+	klog.V(1).Info("[DeviceReading] Starting the collector...")
+	for {
+		select {
+		case <-time.After(rc.collectPeriod):
+			rc.collect(time.Now())
+		case <-stop:
+			klog.V(1).Info("[DeviceReading] Stopping the collector")
+			return
+		}
+	}
+}
+
+// collect reads the exported properties of the devices in the nodepool, exports them as metrics and
+// summarizes them into the status of devices.
+func (rc *DeviceReadingCollector) collect(now time.Time) {
+	kDevs, err := listNodePoolDevices(context.TODO(), rc.Client, rc.NodePool, rc.namespaces)
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not list the devices object on the OpenYurt")
+		return
+	}
+
+	exported := map[string][]string{}
+	windows := map[string]*readingWindow{}
+	for i := range kDevs {
+		device := &kDevs[i]
+		properties, ok := exportedProperties(device)
+		if !ok {
+			continue
+		}
+		_, aps, err := rc.deviceCli.ListPropertiesState(context.TODO(), device, edgeCli.ListOptions{Namespace: rc.Namespace})
+		if err != nil {
+			klog.V(4).ErrorS(err, "could not list the properties state of device", "DeviceName", device.Name)
+			continue
+		}
+
+		window, ok := rc.windows[device.Name]
+		if !ok {
+			window = newReadingWindow(time.Time{})
+		}
+		windows[device.Name] = window
+		for name, state := range aps {
+			if properties != nil && !properties[name] {
+				continue
+			}
+			window.add(name, state.ActualValue, metav1.NewTime(now))
+			if number, ok := parseReading(state.ActualValue); ok {
+				labels := []string{device.Name, device.Spec.Profile, rc.NodePool, name}
+				deviceReading.WithLabelValues(labels...).Set(number)
+				exported[strings.Join(labels, "/")] = labels
+			}
+		}
+		rc.updateStatus(device, window, now)
+	}
+
+	// remove the metrics of the devices or properties which are not exported any more
+	for key, labels := range rc.exported {
+		if _, ok := exported[key]; !ok {
+			deviceReading.DeleteLabelValues(labels...)
+		}
+	}
+	rc.exported = exported
+	rc.windows = windows
+}
+
+// updateStatus writes the summary of the reading window into the status of device, it is rate limited both
+// per device by the status period and for all devices by the status limiter.
+func (rc *DeviceReadingCollector) updateStatus(device *iotv1alpha1.Device, window *readingWindow, now time.Time) {
+	if rc.statusPeriod == 0 || len(window.readings) == 0 || now.Sub(window.lastUpdate) < rc.statusPeriod {
+		return
+	}
+	if !rc.statusLimiter.Allow() {
+		klog.V(5).InfoS("[DeviceReading] the status update is throttled", "DeviceName", device.Name)
+		return
+	}
+	patched := device.DeepCopy()
+	patched.Status.Readings = window.summary()
+	if err := rc.Status().Patch(context.TODO(), patched, client.MergeFrom(device)); err != nil {
+		klog.V(4).ErrorS(err, "could not update the readings of device", "DeviceName", device.Name)
+		return
+	}
+	*window = *newReadingWindow(now)
+}
+
+// exportedProperties returns the properties of the device selected by the export annotation, nil means all the properties.
+func exportedProperties(device *iotv1alpha1.Device) (map[string]bool, bool) {
+	value, ok := device.Annotations[ExportReadingsAnnotation]
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil, false
+	}
+	if strings.TrimSpace(value) == "*" {
+		return nil, true
+	}
+	properties := map[string]bool{}
+	for _, property := range strings.Split(value, ",") {
+		if property = strings.TrimSpace(property); property != "" {
+			properties[property] = true
+		}
+	}
+	return properties, true
+}
+
+// parseReading parses the numeric value of the reading, boolean readings are parsed as 0 or 1.
+func parseReading(value string) (float64, bool) {
+	if b, err := strconv.ParseBool(value); err == nil {
+		if b {
+			return 1, true
+		}
+		return 0, true
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
+
+func parseFloat(value string) float64 {
+	number, _ := strconv.ParseFloat(value, 64)
+	return number
+}
+
+func formatFloat(number float64) string {
+	return strconv.FormatFloat(number, 'g', -1, 64)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	edgeCli "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
+)
+
+// fakeDeviceClient returns the devices on the edge platform and the readings of properties by device name
+type fakeDeviceClient struct {
+	edgeCli.DeviceInterface
+	devices  []iotv1alpha1.Device
+	readings map[string]map[string]string
+}
+
+func (f *fakeDeviceClient) List(ctx context.Context, options edgeCli.ListOptions) ([]iotv1alpha1.Device, error) {
+	return f.devices, nil
+}
+
+func (f *fakeDeviceClient) ListPropertiesState(ctx context.Context, device *iotv1alpha1.Device, options edgeCli.ListOptions) (map[string]iotv1alpha1.DesiredPropertyState, map[string]iotv1alpha1.ActualPropertyState, error) {
+	apsm := map[string]iotv1alpha1.ActualPropertyState{}
+	for name, value := range f.readings[device.Name] {
+		apsm[name] = iotv1alpha1.ActualPropertyState{Name: name, ActualValue: value}
+	}
+	return nil, apsm, nil
+}
+
+func newReadingDevice(name, exported string) *iotv1alpha1.Device {
+	device := &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: iotv1alpha1.DeviceSpec{
+			NodePool: "hangzhou",
+			Profile:  "Random-Integer-Device",
+		},
+	}
+	if exported != "" {
+		device.Annotations = map[string]string{ExportReadingsAnnotation: exported}
+	}
+	return device
+}
+
+func TestDeviceReadingCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+	devices := []client.Object{
+		newReadingDevice("all", "*"),
+		newReadingDevice("selected", "Int8, Bool"),
+		newReadingDevice("none", ""),
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(devices...).
+		WithStatusSubresource(&iotv1alpha1.Device{}).
+		WithIndex(&iotv1alpha1.Device{}, util.IndexerPathForNodepool, func(obj client.Object) []string {
+			return []string{obj.(*iotv1alpha1.Device).Spec.NodePool}
+		}).Build()
+	deviceCli := &fakeDeviceClient{readings: map[string]map[string]string{
+		"all":      {"Int8": "3", "Bool": "true", "Text": "hello"},
+		"selected": {"Int8": "-1", "Bool": "false", "Int16": "100"},
+		"none":     {"Int8": "7"},
+	}}
+	rc := &DeviceReadingCollector{
+		Client:        c,
+		deviceCli:     deviceCli,
+		statusPeriod:  time.Minute,
+		statusLimiter: rate.NewLimiter(rate.Inf, 1),
+		NodePool:      "hangzhou",
+		Namespace:     "default",
+		namespaces:    []string{"default"},
+		windows:       map[string]*readingWindow{},
+		exported:      map[string][]string{},
+	}
+
+	now := time.Now()
+	rc.collect(now)
+	assert.Equal(t, float64(3), testutil.ToFloat64(deviceReading.WithLabelValues("all", "Random-Integer-Device", "hangzhou", "Int8")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(deviceReading.WithLabelValues("all", "Random-Integer-Device", "hangzhou", "Bool")))
+	assert.Equal(t, float64(-1), testutil.ToFloat64(deviceReading.WithLabelValues("selected", "Random-Integer-Device", "hangzhou", "Int8")))
+	assert.Len(t, rc.exported, 4)
+
+	var all iotv1alpha1.Device
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "all"}, &all))
+	assert.Len(t, all.Status.Readings, 3)
+	assert.Equal(t, "Bool", all.Status.Readings[0].Name)
+	assert.Equal(t, "Text", all.Status.Readings[2].Name)
+	assert.Equal(t, "hello", all.Status.Readings[2].LastValue)
+	assert.Empty(t, all.Status.Readings[2].Average)
+
+	// the readings are summarized in the window and the status is not updated until the status period elapses
+	deviceCli.readings["all"]["Int8"] = "5"
+	rc.collect(now.Add(10 * time.Second))
+	deviceCli.readings["all"]["Int8"] = "1"
+	rc.collect(now.Add(20 * time.Second))
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "all"}, &all))
+	assert.Equal(t, "3", all.Status.Readings[1].LastValue)
+
+	rc.collect(now.Add(time.Minute))
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "all"}, &all))
+	assert.Equal(t, iotv1alpha1.DeviceReading{
+		Name:           "Int8",
+		LastValue:      "1",
+		Min:            "1",
+		Max:            "5",
+		Average:        "2.3333333333333335",
+		Samples:        3,
+		LastUpdateTime: all.Status.Readings[1].LastUpdateTime,
+	}, all.Status.Readings[1])
+
+	var none iotv1alpha1.Device
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "none"}, &none))
+	assert.Empty(t, none.Status.Readings)
+
+	// the metrics are removed once the device is not exported
+	selected := newReadingDevice("selected", "")
+	assert.NoError(t, c.Delete(context.TODO(), selected))
+	rc.collect(now.Add(2 * time.Minute))
+	assert.Len(t, rc.exported, 2)
+	assert.Equal(t, 2, testutil.CollectAndCount(deviceReading))
+}
+
+func TestParseReading(t *testing.T) {
+	tests := map[string]struct {
+		value  string
+		number float64
+		ok     bool
+	}{
+		"integer": {value: "42", number: 42, ok: true},
+		"float":   {value: "-1.5", number: -1.5, ok: true},
+		"true":    {value: "true", number: 1, ok: true},
+		"false":   {value: "false", number: 0, ok: true},
+		"text":    {value: "hello", ok: false},
+		"nan":     {value: "NaN", ok: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			number, ok := parseReading(tt.value)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.number, number)
+		})
+	}
+}
@@ -22,8 +22,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
@@ -31,7 +34,6 @@ import (
 	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
 	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
 	edgeCli "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
-	edgexobj "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients/edgex-foundry"
 	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
 )
 
@@ -42,23 +44,34 @@ type DeviceSyncer struct {
 	NodePool string
 	// edge platform's client
 	deviceCli edgeCli.DeviceInterface
+	// edge platform's system events client, nil if the system events are not available
+	eventCli edgeCli.SystemEventInterface
 	// syncing period in seconds
 	syncPeriod time.Duration
-	Namespace  string
+	// full syncing period in seconds when the system events are watched
+	resyncPeriod time.Duration
+	Namespace    string
+	// decides the namespaces of the devices synchronized from edge platform
+	mapper *NamespaceMapper
+	// journal of the writes which failed while the cloud is not reachable, nil if the writes are not journaled
+	Journal *DeviceJournal
 }
 
 // NewDeviceSyncer initialize a New DeviceSyncer
-func NewDeviceSyncer(client client.Client, opts *options.YurtIoTDockOptions, edgexdock *edgexobj.EdgexDock) (DeviceSyncer, error) {
-	devicelient, err := edgexdock.CreateDeviceClient()
+func NewDeviceSyncer(client client.Client, opts *options.YurtIoTDockOptions, iotdock edgeCli.IoTDock) (DeviceSyncer, error) {
+	devicelient, err := iotdock.CreateDeviceClient()
 	if err != nil {
 		return DeviceSyncer{}, err
 	}
 	return DeviceSyncer{
-		syncPeriod: time.Duration(opts.EdgeSyncPeriod) * time.Second,
-		deviceCli:  devicelient,
-		Client:     client,
-		NodePool:   opts.Nodepool,
-		Namespace:  opts.Namespace,
+		syncPeriod:   time.Duration(opts.EdgeSyncPeriod) * time.Second,
+		resyncPeriod: time.Duration(opts.EdgeResyncPeriod) * time.Second,
+		deviceCli:    devicelient,
+		eventCli:     newSystemEventClient(iotdock),
+		Client:       client,
+		NodePool:     opts.Nodepool,
+		Namespace:    opts.Namespace,
+		mapper:       NewNamespaceMapper(opts),
 	}, nil
 }
 
@@ -72,9 +85,16 @@ func (ds *DeviceSyncer) NewDeviceSyncerRunnable() ctrlmgr.RunnableFunc {
 
 func (ds *DeviceSyncer) Run(stop <-chan struct{}) {
 	klog.V(1).Info("[Device] Starting the syncer...")
+	ctx := wait.ContextForChannel(stop)
+	syncPeriod, resync := watchSystemEvents(ctx, ds.eventCli, common.DeviceSystemEventType, ds.handleSystemEvent, ds.syncPeriod, ds.resyncPeriod)
 	go func() {
 		for {
-			<-time.After(ds.syncPeriod)
+			select {
+			case <-time.After(syncPeriod):
+			case <-resync:
+			case <-stop:
+				return
+			}
 			klog.V(2).Info("[Device] Start a round of synchronization.")
 			// 1. get device on edge platform and OpenYurt
 			edgeDevices, kubeDevices, err := ds.getAllDevices()
@@ -112,6 +132,47 @@ func (ds *DeviceSyncer) Run(stop <-chan struct{}) {
 	klog.V(1).Info("[Device] Stopping the syncer")
 }
 
+// handleSystemEvent applies the change of a device on the edge platform to OpenYurt
+func (ds *DeviceSyncer) handleSystemEvent(systemEvent dtos.SystemEvent) {
+	ed, err := ds.deviceCli.Convert(context.TODO(), systemEvent, edgeCli.GetOptions{Namespace: ds.Namespace})
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not convert the system event of device", "action", systemEvent.Action)
+		return
+	}
+	edName := util.GetEdgeDeviceName(ed, EdgeXObjectName)
+	kd, err := ds.getKubeDevice(edName)
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not get the device on OpenYurt", "DeviceName", edName)
+		return
+	}
+	klog.V(4).InfoS("[Device] apply the system event", "action", systemEvent.Action, "DeviceName", edName)
+
+	switch systemEvent.Action {
+	case common.SystemEventActionAdd, common.SystemEventActionUpdate:
+		if kd == nil {
+			err = ds.syncEdgeToKube(map[string]*iotv1alpha1.Device{edName: ds.completeCreateContent(ed)})
+		} else {
+			err = ds.updateDevices(map[string]*iotv1alpha1.Device{edName: ds.completeUpdateContent(kd, ed)})
+		}
+	case common.SystemEventActionDelete:
+		if kd != nil && kd.Status.Synced {
+			err = ds.deleteDevices(map[string]*iotv1alpha1.Device{edName: kd})
+		}
+	}
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not apply the system event of device", "action", systemEvent.Action, "DeviceName", edName)
+	}
+}
+
+// getKubeDevice gets the device on OpenYurt by the actual name on the edge platform, it returns nil if the device does not exist
+func (ds *DeviceSyncer) getKubeDevice(edgeName string) (*iotv1alpha1.Device, error) {
+	claims, err := listDeviceClaims(context.TODO(), ds.Client, ds.NodePool, edgeName, ds.mapper.Namespaces())
+	if err != nil {
+		return nil, err
+	}
+	return pickDeviceClaim(claims), nil
+}
+
 // Get the existing Device on the Edge platform, as well as OpenYurt existing Device
 // edgeDevice：map[actualName]device
 // kubeDevice：map[actualName]device
@@ -125,9 +186,8 @@ func (ds *DeviceSyncer) getAllDevices() (map[string]iotv1alpha1.Device, map[stri
 		return edgeDevice, kubeDevice, err
 	}
 	// 2. list devices on OpenYurt (filter objects belonging to edgeServer)
-	var kDevs iotv1alpha1.DeviceList
-	listOptions := client.MatchingFields{util.IndexerPathForNodepool: ds.NodePool}
-	if err = ds.List(context.TODO(), &kDevs, listOptions, client.InNamespace(ds.Namespace)); err != nil {
+	kDevs, err := listNodePoolDevices(context.TODO(), ds.Client, ds.NodePool, ds.mapper.Namespaces())
+	if err != nil {
 		klog.V(4).ErrorS(err, "could not list the devices object on the OpenYurt")
 		return edgeDevice, kubeDevice, err
 	}
@@ -136,9 +196,17 @@ func (ds *DeviceSyncer) getAllDevices() (map[string]iotv1alpha1.Device, map[stri
 		edgeDevice[deviceName] = eDevs[i]
 	}
 
-	for i := range kDevs.Items {
-		deviceName := util.GetEdgeDeviceName(&kDevs.Items[i], EdgeXObjectName)
-		kubeDevice[deviceName] = kDevs.Items[i]
+	claims := map[string][]*iotv1alpha1.Device{}
+	for i := range kDevs {
+		deviceName := util.GetEdgeDeviceName(&kDevs[i], EdgeXObjectName)
+		claims[deviceName] = append(claims[deviceName], &kDevs[i])
+	}
+	for deviceName := range claims {
+		// only one of the devices which claim the same device on the edge platform is synchronized
+		if len(claims[deviceName]) > 1 {
+			klog.V(3).InfoS("[Device] the device on edge platform is claimed by more than one device", "DeviceName", deviceName, "claims", len(claims[deviceName]))
+		}
+		kubeDevice[deviceName] = *pickDeviceClaim(claims[deviceName])
 	}
 	return edgeDevice, kubeDevice, nil
 }
@@ -185,6 +253,9 @@ func (ds *DeviceSyncer) syncEdgeToKube(edgeDevs map[string]*iotv1alpha1.Device)
 			if apierrors.IsAlreadyExists(err) {
 				continue
 			}
+			if err = ds.Journal.RecordFailure(JournalDeviceCreate, ed, err); err == nil {
+				continue
+			}
 			klog.V(5).ErrorS(err, "could not create device on OpenYurt", "DeviceName", strings.ToLower(ed.Name))
 			return err
 		}
@@ -221,8 +292,12 @@ func (ds *DeviceSyncer) updateDevices(syncedDevices map[string]*iotv1alpha1.Devi
 				klog.V(5).InfoS("update Conflicts", "Device", syncedDevices[n].Name)
 				continue
 			}
+			if err = ds.Journal.RecordFailure(JournalStatusUpdate, syncedDevices[n], err); err == nil {
+				continue
+			}
 			return err
 		}
+		ds.Journal.Forget(JournalStatusUpdate, syncedDevices[n])
 	}
 	return nil
 }
@@ -232,7 +307,7 @@ func (ds *DeviceSyncer) completeCreateContent(edgeDevice *iotv1alpha1.Device) *i
 	createDevice := edgeDevice.DeepCopy()
 	createDevice.Spec.NodePool = ds.NodePool
 	createDevice.Name = strings.Join([]string{ds.NodePool, createDevice.Name}, "-")
-	createDevice.Namespace = ds.Namespace
+	createDevice.Namespace = ds.mapper.NamespaceOf(edgeDevice)
 	createDevice.Spec.Managed = false
 
 	return createDevice
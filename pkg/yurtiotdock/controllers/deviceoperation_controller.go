@@ -0,0 +1,266 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+const (
+	// the delay of the first retry of a failed operation, it is doubled on each retry
+	deviceOperationRetryDelay = 5 * time.Second
+	// the maximum delay between two retries of a failed operation
+	deviceOperationMaxRetryDelay = 5 * time.Minute
+)
+
+// DeviceOperationReconciler reconciles a DeviceOperation object
+type DeviceOperationReconciler struct {
+	client.Client
+	Scheme    *runtime.Scheme
+	deviceCli clients.DeviceInterface
+	// the time to keep the finished operations which don't set the ttl, 0 keeps them forever
+	defaultTTL time.Duration
+	clock      clock.Clock
+	// which nodePool deviceOperationController is deployed in
+	NodePool  string
+	Namespace string
+}
+
+//+kubebuilder:rbac:groups=iot.openyurt.io,resources=deviceoperations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=iot.openyurt.io,resources=deviceoperations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=iot.openyurt.io,resources=deviceoperations/finalizers,verbs=update
+
+func (r *DeviceOperationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var op iotv1alpha1.DeviceOperation
+	if err := r.Get(ctx, req.NamespacedName, &op); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var d iotv1alpha1.Device
+	err := r.Get(ctx, client.ObjectKey{Namespace: op.Namespace, Name: op.Spec.DeviceName}, &d)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	// If the device doesn't belong to the Edge platform to which the controller is connected, the controller does not handle the operation
+	if err == nil && d.Spec.NodePool != r.NodePool {
+		return ctrl.Result{}, nil
+	}
+
+	// 1. Delete the finished operation once its ttl expires
+	if op.IsFinished() {
+		return r.reconcileFinishedOperation(ctx, &op)
+	}
+	klog.V(3).Infof("Reconciling the DeviceOperation: %s", op.GetName())
+
+	newStatus := op.Status.DeepCopy()
+	now := metav1.NewTime(r.clock.Now())
+	if apierrors.IsNotFound(err) {
+		newStatus.Phase = iotv1alpha1.DeviceOperationFailed
+		newStatus.CompletionTime = &now
+		newStatus.Message = fmt.Sprintf("device %s is not found", op.Spec.DeviceName)
+		return r.updateStatus(ctx, &op, newStatus)
+	}
+	if !d.Status.Synced {
+		// the operation is not attempted until the device is added to the edge platform
+		newStatus.Phase = iotv1alpha1.DeviceOperationPending
+		newStatus.Message = "waiting for the device to be synced to the edge platform"
+		if _, err := r.updateStatus(ctx, &op, newStatus); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: deviceOperationRetryDelay}, nil
+	}
+
+	// 2. Wait for the backoff of the failed attempt, the operation is requeued immediately by the update of its status
+	if op.Status.Phase == iotv1alpha1.DeviceOperationPending && op.Status.Retries > 0 && op.Status.LastAttemptTime != nil {
+		if remaining := op.Status.LastAttemptTime.Add(retryDelay(op.Status.Retries)).Sub(now.Time); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	// 3. Record the attempt before the command is sent. If the result of an attempt was not recorded, the command
+	// may have reached the device, so the operation is marked as failed instead of being executed again.
+	if op.Status.Phase == iotv1alpha1.DeviceOperationInProgress {
+		newStatus.Phase = iotv1alpha1.DeviceOperationFailed
+		newStatus.CompletionTime = &now
+		newStatus.Message = fmt.Sprintf("the result of attempt %d is unknown, the operation is not executed again", op.Status.Attempts)
+		return r.updateStatus(ctx, &op, newStatus)
+	}
+	if newStatus.StartTime == nil {
+		newStatus.StartTime = &now
+	}
+	newStatus.LastAttemptTime = &now
+	newStatus.Attempts++
+	newStatus.Phase = iotv1alpha1.DeviceOperationInProgress
+	newStatus.Message = "the operation is being executed"
+	op.Status = *newStatus
+	if err := r.Status().Update(ctx, &op); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// 4. Set the property of the device on the edge platform
+	newStatus = op.Status.DeepCopy()
+	err = r.executeOperation(ctx, &op, &d)
+	if err == nil {
+		klog.V(4).Infof("DeviceName: %s, successfully set the property %s by operation %s", d.Name, op.Spec.PropertyName, op.Name)
+		newStatus.Phase = iotv1alpha1.DeviceOperationSucceeded
+		newStatus.CompletionTime = &now
+		newStatus.Message = "the property is set successfully"
+		return r.recordResult(ctx, &op, newStatus)
+	}
+
+	// 5. Retry the failed operation with backoff until the retries are used up
+	klog.V(4).ErrorS(err, "could not execute the device operation", "DeviceOperation", op.Name, "DeviceName", d.Name)
+	newStatus.Retries++
+	newStatus.Message = err.Error()
+	maxRetries := iotv1alpha1.DefaultDeviceOperationMaxRetries
+	if op.Spec.MaxRetries != nil {
+		maxRetries = *op.Spec.MaxRetries
+	}
+	if newStatus.Retries > maxRetries {
+		newStatus.Phase = iotv1alpha1.DeviceOperationFailed
+		newStatus.CompletionTime = &now
+		return r.recordResult(ctx, &op, newStatus)
+	}
+	newStatus.Phase = iotv1alpha1.DeviceOperationPending
+	if _, err := r.recordResult(ctx, &op, newStatus); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: retryDelay(newStatus.Retries)}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DeviceOperationReconciler) SetupWithManager(mgr ctrl.Manager, opts *options.YurtIoTDockOptions, iotdock clients.IoTDock) error {
+	deviceclient, err := iotdock.CreateDeviceClient()
+	if err != nil {
+		return err
+	}
+	r.deviceCli = deviceclient
+	r.defaultTTL = time.Duration(opts.OperationTTL) * time.Second
+	r.clock = clock.RealClock{}
+	r.NodePool = opts.Nodepool
+	r.Namespace = opts.Namespace
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&iotv1alpha1.DeviceOperation{}).
+		Complete(r)
+}
+
+// executeOperation sets the property of the device to the value of the operation, the desired state
+// of the device spec is not changed.
+func (r *DeviceOperationReconciler) executeOperation(ctx context.Context, op *iotv1alpha1.DeviceOperation, d *iotv1alpha1.Device) error {
+	device := d.DeepCopy()
+	if device.Spec.DeviceProperties == nil {
+		device.Spec.DeviceProperties = map[string]iotv1alpha1.DesiredPropertyState{}
+	}
+	dps := device.Spec.DeviceProperties[op.Spec.PropertyName]
+	dps.Name = op.Spec.PropertyName
+	dps.DesiredValue = op.Spec.Value
+	device.Spec.DeviceProperties[op.Spec.PropertyName] = dps
+	return r.deviceCli.UpdatePropertyState(ctx, op.Spec.PropertyName, device, clients.UpdateOptions{})
+}
+
+// reconcileFinishedOperation deletes the finished operation when its ttl expires, or requeues it until then
+func (r *DeviceOperationReconciler) reconcileFinishedOperation(ctx context.Context, op *iotv1alpha1.DeviceOperation) (ctrl.Result, error) {
+	ttl := r.defaultTTL
+	if op.Spec.TTLSecondsAfterFinished != nil {
+		ttl = time.Duration(*op.Spec.TTLSecondsAfterFinished) * time.Second
+	} else if ttl == 0 {
+		return ctrl.Result{}, nil
+	}
+	if op.Status.CompletionTime != nil {
+		if remaining := op.Status.CompletionTime.Add(ttl).Sub(r.clock.Now()); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+	klog.V(4).Infof("Deleting the finished DeviceOperation: %s", op.Name)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, op))
+}
+
+func (r *DeviceOperationReconciler) updateStatus(ctx context.Context, op *iotv1alpha1.DeviceOperation, newStatus *iotv1alpha1.DeviceOperationStatus) (ctrl.Result, error) {
+	if equality.Semantic.DeepEqual(op.Status, *newStatus) {
+		return ctrl.Result{}, nil
+	}
+	op.Status = *newStatus
+	if err := r.Status().Update(ctx, op); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if op.IsFinished() {
+		return r.reconcileFinishedOperation(ctx, op)
+	}
+	return ctrl.Result{}, nil
+}
+
+// recordResult records the result of the attempt which is in progress. The conflicts are retried with the latest
+// operation, because the operation would be marked as failed if the result was lost.
+func (r *DeviceOperationReconciler) recordResult(ctx context.Context, op *iotv1alpha1.DeviceOperation, newStatus *iotv1alpha1.DeviceOperationStatus) (ctrl.Result, error) {
+	attempts := op.Status.Attempts
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if op.Status.Phase != iotv1alpha1.DeviceOperationInProgress || op.Status.Attempts != attempts {
+			return fmt.Errorf("the attempt %d of operation %s is not in progress", attempts, op.Name)
+		}
+		op.Status = *newStatus
+		err := r.Status().Update(ctx, op)
+		if apierrors.IsConflict(err) {
+			if getErr := r.Get(ctx, client.ObjectKeyFromObject(op), op); getErr != nil {
+				return getErr
+			}
+		}
+		return err
+	})
+	if err != nil {
+		klog.ErrorS(err, "could not record the result of the device operation", "DeviceOperation", op.Name, "Phase", newStatus.Phase)
+		return ctrl.Result{}, err
+	}
+	if op.IsFinished() {
+		return r.reconcileFinishedOperation(ctx, op)
+	}
+	return ctrl.Result{}, nil
+}
+
+// retryDelay returns the delay before the next attempt of an operation which failed the given times
+func retryDelay(retries int32) time.Duration {
+	delay := deviceOperationRetryDelay
+	for i := int32(1); i < retries && delay < deviceOperationMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > deviceOperationMaxRetryDelay {
+		return deviceOperationMaxRetryDelay
+	}
+	return delay
+}
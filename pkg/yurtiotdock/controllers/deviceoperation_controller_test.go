@@ -0,0 +1,259 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	edgeCli "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+// fakeOperationDeviceClient records the property values set on the devices and fails with the given errors in turn
+type fakeOperationDeviceClient struct {
+	edgeCli.DeviceInterface
+	errs []error
+	set  []string
+}
+
+func (f *fakeOperationDeviceClient) UpdatePropertyState(ctx context.Context, propertyName string, device *iotv1alpha1.Device, options edgeCli.UpdateOptions) error {
+	if len(f.errs) != 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	f.set = append(f.set, device.Name+"/"+propertyName+"="+device.Spec.DeviceProperties[propertyName].DesiredValue)
+	return nil
+}
+
+func newOperation(name string, maxRetries int32) *iotv1alpha1.DeviceOperation {
+	return &iotv1alpha1.DeviceOperation{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: iotv1alpha1.DeviceOperationSpec{
+			DeviceName:   "sensor",
+			PropertyName: "Int8",
+			Value:        "42",
+			MaxRetries:   &maxRetries,
+			Requester:    "alice",
+		},
+	}
+}
+
+func TestDeviceOperationReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+
+	device := &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: "sensor", Namespace: "default"},
+		Spec: iotv1alpha1.DeviceSpec{
+			NodePool: "hangzhou",
+			DeviceProperties: map[string]iotv1alpha1.DesiredPropertyState{
+				"Int8": {Name: "Int8", DesiredValue: "1"},
+			},
+		},
+		Status: iotv1alpha1.DeviceStatus{Synced: true},
+	}
+	missing := newOperation("missing", 1)
+	missing.Spec.DeviceName = "missing"
+	// the result of the first attempt was not recorded
+	unknown := newOperation("unknown", 1)
+	unknown.Status = iotv1alpha1.DeviceOperationStatus{Phase: iotv1alpha1.DeviceOperationInProgress, Attempts: 1}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(device, newOperation("succeed", 1), newOperation("fail", 1), missing, unknown).
+		WithStatusSubresource(&iotv1alpha1.DeviceOperation{}, &iotv1alpha1.Device{}).Build()
+	// the time is serialized in seconds
+	now := time.Now().Truncate(time.Second)
+	fakeClock := clocktesting.NewFakeClock(now)
+	deviceCli := &fakeOperationDeviceClient{}
+	r := &DeviceOperationReconciler{
+		Client:     c,
+		Scheme:     scheme,
+		deviceCli:  deviceCli,
+		defaultTTL: time.Hour,
+		clock:      fakeClock,
+		NodePool:   "hangzhou",
+		Namespace:  "default",
+	}
+	reconcile := func(name string) (ctrl.Result, *iotv1alpha1.DeviceOperation) {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+		result, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		var op iotv1alpha1.DeviceOperation
+		if err := c.Get(context.TODO(), req.NamespacedName, &op); err != nil {
+			require.True(t, apierrors.IsNotFound(err))
+			return result, nil
+		}
+		return result, &op
+	}
+
+	// the operation is executed once and deleted after the ttl
+	deviceCli.errs = []error{errors.New("timeout")}
+	result, op := reconcile("succeed")
+	assert.Equal(t, iotv1alpha1.DeviceOperationPending, op.Status.Phase)
+	assert.Equal(t, int32(1), op.Status.Retries)
+	assert.Equal(t, "timeout", op.Status.Message)
+	assert.Equal(t, deviceOperationRetryDelay, result.RequeueAfter)
+
+	fakeClock.Step(result.RequeueAfter)
+	result, op = reconcile("succeed")
+	assert.Equal(t, iotv1alpha1.DeviceOperationSucceeded, op.Status.Phase)
+	assert.Equal(t, int32(2), op.Status.Attempts)
+	assert.Equal(t, []string{"sensor/Int8=42"}, deviceCli.set)
+	assert.Equal(t, now.Unix(), op.Status.StartTime.Unix())
+	assert.Equal(t, fakeClock.Now().Unix(), op.Status.CompletionTime.Unix())
+	assert.Equal(t, time.Hour, result.RequeueAfter)
+
+	// the desired state of the device is not changed
+	var d iotv1alpha1.Device
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(device), &d))
+	assert.Equal(t, "1", d.Spec.DeviceProperties["Int8"].DesiredValue)
+
+	_, _ = reconcile("succeed")
+	assert.Len(t, deviceCli.set, 1)
+	fakeClock.Step(time.Hour)
+	_, op = reconcile("succeed")
+	assert.Nil(t, op)
+
+	// the operation fails when the retries are used up
+	deviceCli.errs = []error{errors.New("timeout"), errors.New("out of range")}
+	result, _ = reconcile("fail")
+	fakeClock.Step(result.RequeueAfter)
+	_, op = reconcile("fail")
+	assert.Equal(t, iotv1alpha1.DeviceOperationFailed, op.Status.Phase)
+	assert.Equal(t, int32(2), op.Status.Retries)
+	assert.Equal(t, "out of range", op.Status.Message)
+
+	// the operation is not executed again when the result of an attempt is unknown
+	_, op = reconcile("unknown")
+	assert.Equal(t, iotv1alpha1.DeviceOperationFailed, op.Status.Phase)
+	assert.Equal(t, int32(1), op.Status.Attempts)
+	assert.Contains(t, op.Status.Message, "unknown")
+	assert.Len(t, deviceCli.set, 1)
+
+	_, op = reconcile("missing")
+	assert.Equal(t, iotv1alpha1.DeviceOperationFailed, op.Status.Phase)
+	assert.Contains(t, op.Status.Message, "not found")
+}
+
+func TestDeviceOperationNotExecutedWithoutRecordedAttempt(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+
+	device := &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: "sensor", Namespace: "default"},
+		Spec:       iotv1alpha1.DeviceSpec{NodePool: "hangzhou"},
+		Status:     iotv1alpha1.DeviceStatus{Synced: true},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(device, newOperation("conflict", 1)).
+		WithStatusSubresource(&iotv1alpha1.DeviceOperation{}, &iotv1alpha1.Device{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, client client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				return apierrors.NewConflict(iotv1alpha1.GroupVersion.WithResource("deviceoperations").GroupResource(), obj.GetName(), errors.New("conflict"))
+			},
+		}).Build()
+	deviceCli := &fakeOperationDeviceClient{}
+	r := &DeviceOperationReconciler{
+		Client:    c,
+		Scheme:    scheme,
+		deviceCli: deviceCli,
+		clock:     clocktesting.NewFakeClock(time.Now()),
+		NodePool:  "hangzhou",
+		Namespace: "default",
+	}
+
+	result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "conflict"}})
+	require.NoError(t, err)
+	assert.True(t, result.Requeue)
+	assert.Empty(t, deviceCli.set)
+}
+
+func TestDeviceOperationRetryAfterDelay(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+
+	device := &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: "sensor", Namespace: "default"},
+		Spec:       iotv1alpha1.DeviceSpec{NodePool: "hangzhou"},
+		Status:     iotv1alpha1.DeviceStatus{Synced: true},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(device, newOperation("retry", 3)).
+		WithStatusSubresource(&iotv1alpha1.DeviceOperation{}, &iotv1alpha1.Device{}).Build()
+	fakeClock := clocktesting.NewFakeClock(time.Now().Truncate(time.Second))
+	deviceCli := &fakeOperationDeviceClient{errs: []error{errors.New("timeout")}}
+	r := &DeviceOperationReconciler{
+		Client:    c,
+		Scheme:    scheme,
+		deviceCli: deviceCli,
+		clock:     fakeClock,
+		NodePool:  "hangzhou",
+		Namespace: "default",
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "retry"}}
+
+	result, err := r.Reconcile(context.TODO(), req)
+	require.NoError(t, err)
+	assert.Equal(t, deviceOperationRetryDelay, result.RequeueAfter)
+
+	// the operation is requeued by the update of its status right after the failure, it is not executed
+	// until the delay passes
+	fakeClock.Step(2 * time.Second)
+	result, err = r.Reconcile(context.TODO(), req)
+	require.NoError(t, err)
+	assert.Equal(t, deviceOperationRetryDelay-2*time.Second, result.RequeueAfter)
+	assert.Empty(t, deviceCli.set)
+	var op iotv1alpha1.DeviceOperation
+	require.NoError(t, c.Get(context.TODO(), req.NamespacedName, &op))
+	assert.Equal(t, int32(1), op.Status.Attempts)
+
+	fakeClock.Step(result.RequeueAfter)
+	_, err = r.Reconcile(context.TODO(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sensor/Int8=42"}, deviceCli.set)
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := map[string]struct {
+		retries int32
+		delay   time.Duration
+	}{
+		"first retry":  {retries: 1, delay: 5 * time.Second},
+		"third retry":  {retries: 3, delay: 20 * time.Second},
+		"capped delay": {retries: 20, delay: 5 * time.Minute},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.delay, retryDelay(tt.retries))
+		})
+	}
+}
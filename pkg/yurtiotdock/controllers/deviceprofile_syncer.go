@@ -22,8 +22,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
@@ -31,15 +34,18 @@ import (
 	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
 	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
 	devcli "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
-	edgexobj "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients/edgex-foundry"
 	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
 )
 
 type DeviceProfileSyncer struct {
 	// syncing period in seconds
 	syncPeriod time.Duration
+	// full syncing period in seconds when the system events are watched
+	resyncPeriod time.Duration
 	// edge platform client
 	edgeClient devcli.DeviceProfileInterface
+	// edge platform's system events client, nil if the system events are not available
+	eventCli devcli.SystemEventInterface
 	// Kubernetes client
 	client.Client
 	NodePool  string
@@ -47,17 +53,19 @@ type DeviceProfileSyncer struct {
 }
 
 // NewDeviceProfileSyncer initialize a New DeviceProfileSyncer
-func NewDeviceProfileSyncer(client client.Client, opts *options.YurtIoTDockOptions, edgexdock *edgexobj.EdgexDock) (DeviceProfileSyncer, error) {
-	edgeclient, err := edgexdock.CreateDeviceProfileClient()
+func NewDeviceProfileSyncer(client client.Client, opts *options.YurtIoTDockOptions, iotdock devcli.IoTDock) (DeviceProfileSyncer, error) {
+	edgeclient, err := iotdock.CreateDeviceProfileClient()
 	if err != nil {
 		return DeviceProfileSyncer{}, err
 	}
 	return DeviceProfileSyncer{
-		syncPeriod: time.Duration(opts.EdgeSyncPeriod) * time.Second,
-		edgeClient: edgeclient,
-		Client:     client,
-		NodePool:   opts.Nodepool,
-		Namespace:  opts.Namespace,
+		syncPeriod:   time.Duration(opts.EdgeSyncPeriod) * time.Second,
+		resyncPeriod: time.Duration(opts.EdgeResyncPeriod) * time.Second,
+		edgeClient:   edgeclient,
+		eventCli:     newSystemEventClient(iotdock),
+		Client:       client,
+		NodePool:     opts.Nodepool,
+		Namespace:    opts.Namespace,
 	}, nil
 }
 
@@ -71,9 +79,16 @@ func (dps *DeviceProfileSyncer) NewDeviceProfileSyncerRunnable() ctrlmgr.Runnabl
 
 func (dps *DeviceProfileSyncer) Run(stop <-chan struct{}) {
 	klog.V(1).Info("[DeviceProfile] Starting the syncer...")
+	ctx := wait.ContextForChannel(stop)
+	syncPeriod, resync := watchSystemEvents(ctx, dps.eventCli, common.DeviceProfileSystemEventType, dps.handleSystemEvent, dps.syncPeriod, dps.resyncPeriod)
 	go func() {
 		for {
-			<-time.After(dps.syncPeriod)
+			select {
+			case <-time.After(syncPeriod):
+			case <-resync:
+			case <-stop:
+				return
+			}
 			klog.V(2).Info("[DeviceProfile] Start a round of synchronization.")
 
 			// 1. get deviceProfiles on edge platform and OpenYurt
@@ -110,6 +125,52 @@ func (dps *DeviceProfileSyncer) Run(stop <-chan struct{}) {
 	klog.V(1).Info("[DeviceProfile] Stopping the syncer")
 }
 
+// handleSystemEvent applies the change of a deviceProfile on the edge platform to OpenYurt
+func (dps *DeviceProfileSyncer) handleSystemEvent(systemEvent dtos.SystemEvent) {
+	edp, err := dps.edgeClient.Convert(context.TODO(), systemEvent, devcli.GetOptions{Namespace: dps.Namespace})
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not convert the system event of deviceProfile", "action", systemEvent.Action)
+		return
+	}
+	edpName := util.GetEdgeDeviceProfileName(edp, EdgeXObjectName)
+	kdp, err := dps.getKubeDeviceProfile(edpName)
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not get the deviceProfile on OpenYurt", "DeviceProfile", edpName)
+		return
+	}
+	klog.V(4).InfoS("[DeviceProfile] apply the system event", "action", systemEvent.Action, "DeviceProfile", edpName)
+
+	switch systemEvent.Action {
+	case common.SystemEventActionAdd, common.SystemEventActionUpdate:
+		// the update of deviceProfiles is not synchronized yet, same as the full synchronization
+		if kdp == nil {
+			err = dps.syncEdgeToKube(map[string]*iotv1alpha1.DeviceProfile{edpName: dps.completeCreateContent(edp)})
+		}
+	case common.SystemEventActionDelete:
+		if kdp != nil && kdp.Status.Synced {
+			err = dps.deleteDeviceProfiles(map[string]*iotv1alpha1.DeviceProfile{edpName: kdp})
+		}
+	}
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not apply the system event of deviceProfile", "action", systemEvent.Action, "DeviceProfile", edpName)
+	}
+}
+
+// getKubeDeviceProfile gets the deviceProfile on OpenYurt by the actual name on the edge platform, it returns nil if the deviceProfile does not exist
+func (dps *DeviceProfileSyncer) getKubeDeviceProfile(edgeName string) (*iotv1alpha1.DeviceProfile, error) {
+	var kDps iotv1alpha1.DeviceProfileList
+	listOptions := client.MatchingFields{util.IndexerPathForNodepool: dps.NodePool}
+	if err := dps.List(context.TODO(), &kDps, listOptions, client.InNamespace(dps.Namespace)); err != nil {
+		return nil, err
+	}
+	for i := range kDps.Items {
+		if util.GetEdgeDeviceProfileName(&kDps.Items[i], EdgeXObjectName) == edgeName {
+			return &kDps.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
 // Get the existing DeviceProfile on the Edge platform, as well as OpenYurt existing DeviceProfile
 // edgeDeviceProfiles：map[actualName]DeviceProfile
 // kubeDeviceProfiles：map[actualName]DeviceProfile
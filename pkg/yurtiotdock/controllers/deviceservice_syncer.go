@@ -21,8 +21,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v3/dtos"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
@@ -30,7 +33,6 @@ import (
 	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
 	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
 	iotcli "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
-	edgexobj "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients/edgex-foundry"
 	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
 )
 
@@ -38,20 +40,26 @@ type DeviceServiceSyncer struct {
 	// Kubernetes client
 	client.Client
 	// syncing period in seconds
-	syncPeriod       time.Duration
+	syncPeriod time.Duration
+	// full syncing period in seconds when the system events are watched
+	resyncPeriod     time.Duration
 	deviceServiceCli iotcli.DeviceServiceInterface
-	NodePool         string
-	Namespace        string
+	// edge platform's system events client, nil if the system events are not available
+	eventCli  iotcli.SystemEventInterface
+	NodePool  string
+	Namespace string
 }
 
-func NewDeviceServiceSyncer(client client.Client, opts *options.YurtIoTDockOptions, edgexdock *edgexobj.EdgexDock) (DeviceServiceSyncer, error) {
-	deviceserviceclient, err := edgexdock.CreateDeviceServiceClient()
+func NewDeviceServiceSyncer(client client.Client, opts *options.YurtIoTDockOptions, iotdock iotcli.IoTDock) (DeviceServiceSyncer, error) {
+	deviceserviceclient, err := iotdock.CreateDeviceServiceClient()
 	if err != nil {
 		return DeviceServiceSyncer{}, err
 	}
 	return DeviceServiceSyncer{
 		syncPeriod:       time.Duration(opts.EdgeSyncPeriod) * time.Second,
+		resyncPeriod:     time.Duration(opts.EdgeResyncPeriod) * time.Second,
 		deviceServiceCli: deviceserviceclient,
+		eventCli:         newSystemEventClient(iotdock),
 		Client:           client,
 		NodePool:         opts.Nodepool,
 		Namespace:        opts.Namespace,
@@ -67,9 +75,16 @@ func (ds *DeviceServiceSyncer) NewDeviceServiceSyncerRunnable() ctrlmgr.Runnable
 
 func (ds *DeviceServiceSyncer) Run(stop <-chan struct{}) {
 	klog.V(1).Info("[DeviceService] Starting the syncer...")
+	ctx := wait.ContextForChannel(stop)
+	syncPeriod, resync := watchSystemEvents(ctx, ds.eventCli, common.DeviceServiceSystemEventType, ds.handleSystemEvent, ds.syncPeriod, ds.resyncPeriod)
 	go func() {
 		for {
-			<-time.After(ds.syncPeriod)
+			select {
+			case <-time.After(syncPeriod):
+			case <-resync:
+			case <-stop:
+				return
+			}
 			klog.V(2).Info("[DeviceService] Start a round of synchronization.")
 			// 1. get deviceServices on edge platform and OpenYurt
 			edgeDeviceServices, kubeDeviceServices, err := ds.getAllDeviceServices()
@@ -108,6 +123,53 @@ func (ds *DeviceServiceSyncer) Run(stop <-chan struct{}) {
 	klog.V(1).Info("[DeviceService] Stopping the syncer")
 }
 
+// handleSystemEvent applies the change of a deviceService on the edge platform to OpenYurt
+func (ds *DeviceServiceSyncer) handleSystemEvent(systemEvent dtos.SystemEvent) {
+	eds, err := ds.deviceServiceCli.Convert(context.TODO(), systemEvent, iotcli.GetOptions{Namespace: ds.Namespace})
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not convert the system event of deviceService", "action", systemEvent.Action)
+		return
+	}
+	edsName := util.GetEdgeDeviceServiceName(eds, EdgeXObjectName)
+	kds, err := ds.getKubeDeviceService(edsName)
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not get the deviceService on OpenYurt", "DeviceService", edsName)
+		return
+	}
+	klog.V(4).InfoS("[DeviceService] apply the system event", "action", systemEvent.Action, "DeviceService", edsName)
+
+	switch systemEvent.Action {
+	case common.SystemEventActionAdd, common.SystemEventActionUpdate:
+		if kds == nil {
+			err = ds.syncEdgeToKube(map[string]*iotv1alpha1.DeviceService{edsName: ds.completeCreateContent(eds)})
+		} else {
+			err = ds.updateDeviceServices(map[string]*iotv1alpha1.DeviceService{edsName: ds.completeUpdateContent(kds, eds)})
+		}
+	case common.SystemEventActionDelete:
+		if kds != nil && kds.Status.Synced {
+			err = ds.deleteDeviceServices(map[string]*iotv1alpha1.DeviceService{edsName: kds})
+		}
+	}
+	if err != nil {
+		klog.V(3).ErrorS(err, "could not apply the system event of deviceService", "action", systemEvent.Action, "DeviceService", edsName)
+	}
+}
+
+// getKubeDeviceService gets the deviceService on OpenYurt by the actual name on the edge platform, it returns nil if the deviceService does not exist
+func (ds *DeviceServiceSyncer) getKubeDeviceService(edgeName string) (*iotv1alpha1.DeviceService, error) {
+	var kDevSs iotv1alpha1.DeviceServiceList
+	listOptions := client.MatchingFields{util.IndexerPathForNodepool: ds.NodePool}
+	if err := ds.List(context.TODO(), &kDevSs, listOptions, client.InNamespace(ds.Namespace)); err != nil {
+		return nil, err
+	}
+	for i := range kDevSs.Items {
+		if util.GetEdgeDeviceServiceName(&kDevSs.Items[i], EdgeXObjectName) == edgeName {
+			return &kDevSs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
 // Get the existing DeviceService on the Edge platform, as well as OpenYurt existing DeviceService
 // edgeDeviceServices：map[actualName]DeviceService
 // kubeDeviceServices：map[actualName]DeviceService
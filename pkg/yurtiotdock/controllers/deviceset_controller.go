@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	util "github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
+)
+
+// DeviceSetReconciler reconciles a DeviceSet object
+type DeviceSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// which nodePool deviceSetController is deployed in
+	NodePool  string
+	Namespace string
+	// journal of the writes which failed while the cloud is not reachable, nil if the writes are not journaled
+	Journal *DeviceJournal
+}
+
+//+kubebuilder:rbac:groups=iot.openyurt.io,resources=devicesets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=iot.openyurt.io,resources=devicesets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=iot.openyurt.io,resources=devicesets/finalizers,verbs=update
+
+func (r *DeviceSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var ds iotv1alpha1.DeviceSet
+	if err := r.Get(ctx, req.NamespacedName, &ds); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// If objects doesn't belong to the Edge platform to which the controller is connected, the controller does not handle events for that object
+	if ds.Spec.NodePool != r.NodePool {
+		return ctrl.Result{}, nil
+	}
+	// the devices of the DeviceSet are deleted by the garbage collector through their owner references
+	if !ds.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	klog.V(3).Infof("Reconciling the DeviceSet: %s", ds.GetName())
+
+	var ownedDevices iotv1alpha1.DeviceList
+	if err := r.List(ctx, &ownedDevices, client.InNamespace(ds.Namespace), client.MatchingLabels{iotv1alpha1.DeviceSetLabel: ds.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+	owned := map[string]*iotv1alpha1.Device{}
+	for i := range ownedDevices.Items {
+		if metav1.IsControlledBy(&ownedDevices.Items[i], &ds) {
+			owned[ownedDevices.Items[i].Name] = &ownedDevices.Items[i]
+		}
+	}
+
+	// 1. Create or update the devices listed by the DeviceSet
+	desiredDevices, provisionErrors := expandDeviceSet(&ds)
+	newStatus := iotv1alpha1.DeviceSetStatus{
+		ObservedGeneration: ds.Generation,
+		DesiredDevices:     int32(len(desiredDevices) + len(provisionErrors)),
+	}
+	for _, desired := range desiredDevices {
+		device, err := r.reconcileDevice(ctx, &ds, desired, owned[desired.Name])
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		} else if err != nil {
+			klog.V(4).ErrorS(err, "could not provision the device of DeviceSet", "DeviceSet", ds.Name, "DeviceName", desired.Name)
+			provisionErrors = append(provisionErrors, iotv1alpha1.DeviceProvisionError{Name: desired.Name, Message: err.Error()})
+			continue
+		}
+		delete(owned, desired.Name)
+
+		if device.Status.Synced {
+			newStatus.ProvisionedDevices++
+		} else if cond := util.GetDeviceCondition(device.Status, iotv1alpha1.DeviceSyncedCondition); cond != nil && cond.Status == corev1.ConditionFalse {
+			provisionErrors = append(provisionErrors, iotv1alpha1.DeviceProvisionError{Name: device.Name, Message: fmt.Sprintf("%s %s", cond.Reason, cond.Message)})
+		}
+	}
+
+	// 2. Delete the devices which are not listed by the DeviceSet any more
+	for _, device := range owned {
+		klog.V(4).Infof("Deleting the device %s which is removed from DeviceSet %s", device.Name, ds.Name)
+		if err := r.Delete(ctx, device); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// 3. Report the provisioning status of the devices
+	newStatus.Errors = truncateProvisionErrors(provisionErrors)
+	if equality.Semantic.DeepEqual(ds.Status, newStatus) {
+		return ctrl.Result{}, nil
+	}
+	ds.Status = newStatus
+	if err := r.Status().Update(ctx, &ds); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DeviceSetReconciler) SetupWithManager(mgr ctrl.Manager, opts *options.YurtIoTDockOptions) error {
+	r.NodePool = opts.Nodepool
+	r.Namespace = opts.Namespace
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&iotv1alpha1.DeviceSet{}).
+		Owns(&iotv1alpha1.Device{}).
+		Complete(r)
+}
+
+// reconcileDevice creates the desired device if it does not exist, or updates the device owned by the DeviceSet
+// if it differs from the template.
+func (r *DeviceSetReconciler) reconcileDevice(ctx context.Context, ds *iotv1alpha1.DeviceSet, desired, current *iotv1alpha1.Device) (*iotv1alpha1.Device, error) {
+	if current == nil {
+		var existing iotv1alpha1.Device
+		err := r.Get(ctx, client.ObjectKeyFromObject(desired), &existing)
+		if err == nil {
+			return nil, fmt.Errorf("device already exists and is not managed by DeviceSet %s", ds.Name)
+		} else if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		if err := controllerutil.SetControllerReference(ds, desired, r.Scheme); err != nil {
+			return nil, err
+		}
+		klog.V(4).Infof("Creating the device %s of DeviceSet %s", desired.Name, ds.Name)
+		if err := r.Journal.RecordFailure(JournalDeviceCreate, desired, r.Create(ctx, desired)); err != nil {
+			return nil, err
+		}
+		return desired, nil
+	}
+
+	updated := current.DeepCopy()
+	for k, v := range desired.Labels {
+		metav1.SetMetaDataLabel(&updated.ObjectMeta, k, v)
+	}
+	for k, v := range desired.Annotations {
+		metav1.SetMetaDataAnnotation(&updated.ObjectMeta, k, v)
+	}
+	updated.Spec = desired.Spec
+	if equality.Semantic.DeepEqual(current.ObjectMeta, updated.ObjectMeta) && equality.Semantic.DeepEqual(current.Spec, updated.Spec) {
+		return current, nil
+	}
+	klog.V(4).Infof("Updating the device %s of DeviceSet %s", updated.Name, ds.Name)
+	// the desired state is kept until the cloud is reachable
+	if err := r.Update(ctx, updated); err != nil {
+		if err = r.Journal.RecordFailure(JournalSpecUpdate, updated, err); err != nil {
+			return nil, err
+		}
+		return updated, nil
+	}
+	r.Journal.Forget(JournalSpecUpdate, updated)
+	return updated, nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+func newDeviceSet() *iotv1alpha1.DeviceSet {
+	return &iotv1alpha1.DeviceSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "line",
+			Namespace:  "default",
+			UID:        types.UID("deviceset-uid"),
+			Generation: 1,
+		},
+		Spec: iotv1alpha1.DeviceSetSpec{
+			NodePool: "hangzhou",
+			Template: iotv1alpha1.DeviceTemplate{
+				Labels: map[string]string{"line": "${line}"},
+				Spec: iotv1alpha1.DeviceSpec{
+					Description: "sensor ${index} of ${name}",
+					Service:     "device-modbus",
+					Profile:     "${profile}",
+					Protocols: map[string]iotv1alpha1.ProtocolProperties{
+						"modbus-tcp": {"Address": "${address}", "Port": "502"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestExpandDeviceSet(t *testing.T) {
+	tests := map[string]struct {
+		items     []iotv1alpha1.DeviceSetItem
+		rng       *iotv1alpha1.DeviceSetRange
+		csv       string
+		devices   []string
+		errs      []string
+		checkFunc func(t *testing.T, devices []*iotv1alpha1.Device)
+	}{
+		"items": {
+			items: []iotv1alpha1.DeviceSetItem{
+				{Name: "sensor-a", Parameters: map[string]string{"line": "1", "profile": "temp", "address": "10.0.0.1"}},
+				{Name: "sensor-b", Parameters: map[string]string{"line": "1", "profile": "temp", "address": "10.0.0.2"}},
+			},
+			devices: []string{"sensor-a", "sensor-b"},
+			checkFunc: func(t *testing.T, devices []*iotv1alpha1.Device) {
+				d := devices[1]
+				assert.Equal(t, "default", d.Namespace)
+				assert.Equal(t, map[string]string{"line": "1", iotv1alpha1.DeviceSetLabel: "line"}, d.Labels)
+				assert.Equal(t, "sensor 1 of sensor-b", d.Spec.Description)
+				assert.Equal(t, "temp", d.Spec.Profile)
+				assert.Equal(t, "hangzhou", d.Spec.NodePool)
+				assert.Equal(t, "10.0.0.2", d.Spec.Protocols["modbus-tcp"]["Address"])
+			},
+		},
+		"csv": {
+			csv:     "name, line, profile, address\nsensor-a, 2, temp, \"10.0.0.1\"\nsensor-b, 2, \"say \"\"hi\"\"\", 10.0.0.2\n",
+			devices: []string{"sensor-a", "sensor-b"},
+			checkFunc: func(t *testing.T, devices []*iotv1alpha1.Device) {
+				assert.Equal(t, "sensor 0 of sensor-a", devices[0].Spec.Description)
+				assert.Equal(t, `say "hi"`, devices[1].Spec.Profile)
+			},
+		},
+		"range with undefined parameters": {
+			rng:  &iotv1alpha1.DeviceSetRange{Start: 1, End: 2},
+			errs: []string{"line-1", "line-2"},
+		},
+		"range ending at the max index": {
+			rng:  &iotv1alpha1.DeviceSetRange{Start: iotv1alpha1.DeviceSetMaxIndex, End: iotv1alpha1.DeviceSetMaxIndex},
+			errs: []string{"line-1000000000"},
+		},
+		"range ending beyond the max index": {
+			rng:  &iotv1alpha1.DeviceSetRange{Start: 2147483647, End: 2147483647},
+			errs: []string{"range"},
+		},
+		"range listing too many devices": {
+			rng:  &iotv1alpha1.DeviceSetRange{Start: 0, End: 2147483647},
+			errs: []string{"range"},
+		},
+		"range with end before start": {
+			rng:  &iotv1alpha1.DeviceSetRange{Start: 2, End: 1},
+			errs: []string{"range"},
+		},
+		"csv without name column": {
+			csv:  "line,profile,address\n1,temp,10.0.0.1\n",
+			errs: []string{"csv"},
+		},
+		"invalid and duplicated names": {
+			items: []iotv1alpha1.DeviceSetItem{
+				{Name: "Sensor_A", Parameters: map[string]string{"line": "1", "profile": "temp", "address": "10.0.0.1"}},
+				{Name: "sensor-b", Parameters: map[string]string{"line": "1", "profile": "temp", "address": "10.0.0.2"}},
+			},
+			csv:     "name,line,profile,address\nsensor-b,1,temp,10.0.0.3\n",
+			devices: []string{"sensor-b"},
+			errs:    []string{"Sensor_A", "sensor-b"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ds := newDeviceSet()
+			ds.Spec.Items = tt.items
+			ds.Spec.Range = tt.rng
+			ds.Spec.CSV = tt.csv
+			devices, errs := expandDeviceSet(ds)
+
+			var deviceNames, errNames []string
+			for _, d := range devices {
+				deviceNames = append(deviceNames, d.Name)
+			}
+			for _, e := range errs {
+				errNames = append(errNames, e.Name)
+			}
+			assert.Equal(t, tt.devices, deviceNames)
+			assert.Equal(t, tt.errs, errNames)
+			if tt.checkFunc != nil {
+				tt.checkFunc(t, devices)
+			}
+		})
+	}
+}
+
+func TestTruncateProvisionErrors(t *testing.T) {
+	var errs []iotv1alpha1.DeviceProvisionError
+	for i := 0; i < iotv1alpha1.DeviceSetMaxStatusErrors+10; i++ {
+		errs = append(errs, iotv1alpha1.DeviceProvisionError{Name: fmt.Sprintf("sensor-%d", i), Message: "failed"})
+	}
+	assert.Equal(t, errs[:5], truncateProvisionErrors(errs[:5]))
+
+	truncated := truncateProvisionErrors(errs)
+	require.Len(t, truncated, iotv1alpha1.DeviceSetMaxStatusErrors)
+	assert.Equal(t, errs[:iotv1alpha1.DeviceSetMaxStatusErrors-1], truncated[:iotv1alpha1.DeviceSetMaxStatusErrors-1])
+	assert.Equal(t, "11 more devices could not be provisioned", truncated[iotv1alpha1.DeviceSetMaxStatusErrors-1].Message)
+}
+
+func TestDeviceSetReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+
+	ds := newDeviceSet()
+	ds.Spec.Items = []iotv1alpha1.DeviceSetItem{
+		{Name: "sensor-a", Parameters: map[string]string{"line": "1", "profile": "temp", "address": "10.0.0.1"}},
+		{Name: "sensor-b", Parameters: map[string]string{"line": "1", "profile": "temp", "address": "10.0.0.2"}},
+		{Name: "sensor-c", Parameters: map[string]string{"line": "1", "profile": "temp"}},
+		{Name: "manual", Parameters: map[string]string{"line": "1", "profile": "temp", "address": "10.0.0.4"}},
+	}
+	manual := &iotv1alpha1.Device{ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: "default"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ds, manual).
+		WithStatusSubresource(&iotv1alpha1.DeviceSet{}, &iotv1alpha1.Device{}).Build()
+	r := &DeviceSetReconciler{Client: c, Scheme: scheme, NodePool: "hangzhou", Namespace: "default"}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "line"}}
+
+	reconcile := func() *iotv1alpha1.DeviceSet {
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		var got iotv1alpha1.DeviceSet
+		require.NoError(t, c.Get(context.TODO(), req.NamespacedName, &got))
+		return &got
+	}
+
+	got := reconcile()
+	assert.Equal(t, int32(4), got.Status.DesiredDevices)
+	assert.Equal(t, int32(0), got.Status.ProvisionedDevices)
+	assert.Equal(t, int64(1), got.Status.ObservedGeneration)
+	require.Len(t, got.Status.Errors, 2)
+	assert.Equal(t, "sensor-c", got.Status.Errors[0].Name)
+	assert.Contains(t, got.Status.Errors[0].Message, "address")
+	assert.Equal(t, "manual", got.Status.Errors[1].Name)
+	assert.Contains(t, got.Status.Errors[1].Message, "not managed")
+
+	var a iotv1alpha1.Device
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "sensor-a"}, &a))
+	assert.True(t, metav1.IsControlledBy(&a, ds))
+	assert.Equal(t, "hangzhou", a.Spec.NodePool)
+
+	// the provisioned devices are counted once they are synced to the edge platform
+	a.Status.Synced = true
+	require.NoError(t, c.Status().Update(context.TODO(), &a))
+	got = reconcile()
+	assert.Equal(t, int32(1), got.Status.ProvisionedDevices)
+
+	// the devices are updated with the template and removed when they are not listed any more
+	got.Spec.Template.Spec.Service = "device-modbus-v2"
+	got.Spec.Items = got.Spec.Items[:1]
+	require.NoError(t, c.Update(context.TODO(), got))
+	got = reconcile()
+	assert.Equal(t, int32(1), got.Status.DesiredDevices)
+	assert.Empty(t, got.Status.Errors)
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "sensor-a"}, &a))
+	assert.Equal(t, "device-modbus-v2", a.Spec.Service)
+	var devices iotv1alpha1.DeviceList
+	require.NoError(t, c.List(context.TODO(), &devices))
+	assert.Len(t, devices.Items, 2)
+
+	// the DeviceSet of another nodepool is ignored
+	r.NodePool = "beijing"
+	got.Spec.Items = nil
+	require.NoError(t, c.Update(context.TODO(), got))
+	_, err := r.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	require.NoError(t, c.List(context.TODO(), &devices))
+	assert.Len(t, devices.Items, 2)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+const (
+	// the name of the csv column and the template parameter of the device name
+	deviceSetNameParameter = "name"
+	// the template parameter of the device index
+	deviceSetIndexParameter = "index"
+)
+
+var templatePlaceholder = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// deviceSetEntry is a device listed by the DeviceSet with the parameters used to expand the template
+type deviceSetEntry struct {
+	name       string
+	parameters map[string]string
+}
+
+// expandDeviceSet expands the template of the DeviceSet into the devices listed by the DeviceSet,
+// the devices which could not be expanded are returned as provisioning errors.
+func expandDeviceSet(ds *iotv1alpha1.DeviceSet) ([]*iotv1alpha1.Device, []iotv1alpha1.DeviceProvisionError) {
+	entries, errs := listDeviceSetEntries(ds)
+	devices := make([]*iotv1alpha1.Device, 0, len(entries))
+	listed := map[string]bool{}
+	for _, entry := range entries {
+		if listed[entry.name] {
+			errs = append(errs, iotv1alpha1.DeviceProvisionError{Name: entry.name, Message: "device is listed more than once"})
+			continue
+		}
+		listed[entry.name] = true
+		device, err := expandDeviceTemplate(ds, entry)
+		if err != nil {
+			errs = append(errs, iotv1alpha1.DeviceProvisionError{Name: entry.name, Message: err.Error()})
+			continue
+		}
+		devices = append(devices, device)
+	}
+	return devices, errs
+}
+
+// truncateProvisionErrors keeps at most DeviceSetMaxStatusErrors errors, the last one of them
+// reports how many errors are omitted.
+func truncateProvisionErrors(errs []iotv1alpha1.DeviceProvisionError) []iotv1alpha1.DeviceProvisionError {
+	if len(errs) <= iotv1alpha1.DeviceSetMaxStatusErrors {
+		return errs
+	}
+	truncated := append([]iotv1alpha1.DeviceProvisionError{}, errs[:iotv1alpha1.DeviceSetMaxStatusErrors-1]...)
+	return append(truncated, iotv1alpha1.DeviceProvisionError{
+		Name:    "...",
+		Message: fmt.Sprintf("%d more devices could not be provisioned", len(errs)-len(truncated)),
+	})
+}
+
+// listDeviceSetEntries lists the devices of the items, the range and the csv of the DeviceSet
+func listDeviceSetEntries(ds *iotv1alpha1.DeviceSet) ([]deviceSetEntry, []iotv1alpha1.DeviceProvisionError) {
+	var entries []deviceSetEntry
+	var errs []iotv1alpha1.DeviceProvisionError
+	for i, item := range ds.Spec.Items {
+		entries = append(entries, newDeviceSetEntry(item.Name, i, item.Parameters))
+	}
+	if r := ds.Spec.Range; r != nil {
+		// the indexes are counted in int64, so the loop ends even if the end of the range is the max int32
+		start, end := int64(r.Start), int64(r.End)
+		if start < 0 || end < start || end-start >= iotv1alpha1.DeviceSetMaxRangeSize {
+			errs = append(errs, iotv1alpha1.DeviceProvisionError{Name: "range",
+				Message: fmt.Sprintf("invalid range [%d, %d], the range must list at most %d devices", start, end, iotv1alpha1.DeviceSetMaxRangeSize)})
+		} else if end > iotv1alpha1.DeviceSetMaxIndex {
+			errs = append(errs, iotv1alpha1.DeviceProvisionError{Name: "range",
+				Message: fmt.Sprintf("invalid range [%d, %d], the index must not be greater than %d", start, end, iotv1alpha1.DeviceSetMaxIndex)})
+		} else {
+			for i := start; i <= end; i++ {
+				entries = append(entries, newDeviceSetEntry(fmt.Sprintf("%s-%d", ds.Name, i), int(i), nil))
+			}
+		}
+	}
+	if strings.TrimSpace(ds.Spec.CSV) != "" {
+		csvEntries, err := parseDeviceSetCSV(ds.Spec.CSV)
+		if err != nil {
+			errs = append(errs, iotv1alpha1.DeviceProvisionError{Name: "csv", Message: err.Error()})
+		}
+		entries = append(entries, csvEntries...)
+	}
+	return entries, errs
+}
+
+// parseDeviceSetCSV parses the devices from the csv, the first line is the header which must contain the name column
+func parseDeviceSetCSV(data string) ([]deviceSetEntry, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the csv header, %v", err)
+	}
+	nameColumn := -1
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+		if header[i] == deviceSetNameParameter {
+			nameColumn = i
+		}
+	}
+	if nameColumn < 0 {
+		return nil, fmt.Errorf("the csv header must contain the %s column", deviceSetNameParameter)
+	}
+
+	var entries []deviceSetEntry
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return entries, err
+		}
+		parameters := make(map[string]string, len(header))
+		for column, value := range record {
+			parameters[header[column]] = strings.TrimSpace(value)
+		}
+		entries = append(entries, newDeviceSetEntry(parameters[deviceSetNameParameter], i, parameters))
+	}
+}
+
+func newDeviceSetEntry(name string, index int, parameters map[string]string) deviceSetEntry {
+	entry := deviceSetEntry{name: name, parameters: map[string]string{}}
+	for k, v := range parameters {
+		entry.parameters[k] = v
+	}
+	entry.parameters[deviceSetNameParameter] = name
+	entry.parameters[deviceSetIndexParameter] = strconv.Itoa(index)
+	return entry
+}
+
+// expandDeviceTemplate replaces the placeholders of the template with the parameters of the entry
+func expandDeviceTemplate(ds *iotv1alpha1.DeviceSet, entry deviceSetEntry) (*iotv1alpha1.Device, error) {
+	if errs := validation.IsDNS1123Subdomain(entry.name); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid device name, %s", strings.Join(errs, ", "))
+	}
+	data, err := json.Marshal(ds.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	var undefined []string
+	data = templatePlaceholder.ReplaceAllFunc(data, func(placeholder []byte) []byte {
+		key := string(templatePlaceholder.FindSubmatch(placeholder)[1])
+		value, ok := entry.parameters[key]
+		if !ok {
+			undefined = append(undefined, key)
+			return placeholder
+		}
+		// the placeholders are in the json strings of the template, so the values are escaped as json strings
+		escaped, _ := json.Marshal(value)
+		return escaped[1 : len(escaped)-1]
+	})
+	if len(undefined) != 0 {
+		return nil, fmt.Errorf("undefined template parameters: %s", strings.Join(undefined, ", "))
+	}
+
+	var template iotv1alpha1.DeviceTemplate
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, err
+	}
+	labels := map[string]string{}
+	for k, v := range template.Labels {
+		labels[k] = v
+	}
+	labels[iotv1alpha1.DeviceSetLabel] = ds.Name
+
+	device := &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        entry.name,
+			Namespace:   ds.Namespace,
+			Labels:      labels,
+			Annotations: template.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	device.Spec.NodePool = ds.Spec.NodePool
+	return device, nil
+}
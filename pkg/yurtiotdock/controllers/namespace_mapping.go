@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
+)
+
+// NamespaceMapper decides the namespaces of the devices synchronized from the edge platform,
+// the devices which are not mapped by the policy are synchronized into the default namespace.
+type NamespaceMapper struct {
+	policy           string
+	mapping          map[string]string
+	defaultNamespace string
+	namespaces       []string
+}
+
+// NewNamespaceMapper initialize a New NamespaceMapper
+func NewNamespaceMapper(opts *options.YurtIoTDockOptions) *NamespaceMapper {
+	return &NamespaceMapper{
+		policy:           opts.NamespaceMappingPolicy,
+		mapping:          opts.NamespaceMapping,
+		defaultNamespace: opts.Namespace,
+		namespaces:       opts.DeviceNamespaces(),
+	}
+}
+
+// NamespaceOf returns the namespace of the device on the edge platform, the first label of device which is
+// mapped decides the namespace for the label policy.
+func (m *NamespaceMapper) NamespaceOf(edgeDevice *iotv1alpha1.Device) string {
+	var keys []string
+	switch m.policy {
+	case options.NamespaceMappingByLabel:
+		keys = edgeDevice.Spec.Labels
+	case options.NamespaceMappingByProfile:
+		keys = []string{edgeDevice.Spec.Profile}
+	case options.NamespaceMappingByService:
+		keys = []string{edgeDevice.Spec.Service}
+	}
+	for _, key := range keys {
+		if namespace, ok := m.mapping[key]; ok {
+			return namespace
+		}
+	}
+	return m.defaultNamespace
+}
+
+// Namespaces returns the namespaces which the devices are synchronized into
+func (m *NamespaceMapper) Namespaces() []string {
+	return m.namespaces
+}
+
+// listNodePoolDevices lists the devices of the nodepool in the namespaces which the devices are synchronized into
+func listNodePoolDevices(ctx context.Context, c client.Client, nodePool string, namespaces []string) ([]iotv1alpha1.Device, error) {
+	var devices []iotv1alpha1.Device
+	listOptions := client.MatchingFields{util.IndexerPathForNodepool: nodePool}
+	for _, namespace := range namespaces {
+		var kDevs iotv1alpha1.DeviceList
+		if err := c.List(ctx, &kDevs, listOptions, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		devices = append(devices, kDevs.Items...)
+	}
+	return devices, nil
+}
+
+// listDeviceClaims lists the devices of the nodepool which claim the device of the edge platform
+func listDeviceClaims(ctx context.Context, c client.Client, nodePool, edgeName string, namespaces []string) ([]*iotv1alpha1.Device, error) {
+	var claims []*iotv1alpha1.Device
+	listOptions := client.MatchingFields{util.IndexerPathForEdgeDeviceName: edgeName}
+	for _, namespace := range namespaces {
+		var kDevs iotv1alpha1.DeviceList
+		if err := c.List(ctx, &kDevs, listOptions, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		for i := range kDevs.Items {
+			if kDevs.Items[i].Spec.NodePool == nodePool {
+				claims = append(claims, &kDevs.Items[i])
+			}
+		}
+	}
+	return claims, nil
+}
+
+// pickDeviceClaim returns the device which claimed the device of the edge platform first, it returns nil if there is no claim
+func pickDeviceClaim(claims []*iotv1alpha1.Device) *iotv1alpha1.Device {
+	var picked *iotv1alpha1.Device
+	for _, claim := range claims {
+		if picked == nil || claim.CreationTimestamp.Before(&picked.CreationTimestamp) ||
+			(claim.CreationTimestamp.Equal(&picked.CreationTimestamp) && client.ObjectKeyFromObject(claim).String() < client.ObjectKeyFromObject(picked).String()) {
+			picked = claim
+		}
+	}
+	return picked
+}
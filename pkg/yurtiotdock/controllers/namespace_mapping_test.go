@@ -0,0 +1,216 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/openyurt/cmd/yurt-iot-dock/app/options"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
+)
+
+func TestNamespaceMapper(t *testing.T) {
+	edgeDevice := &iotv1alpha1.Device{
+		Spec: iotv1alpha1.DeviceSpec{Labels: []string{"sensor", "line-b"}, Profile: "thermometer", Service: "device-modbus"},
+	}
+	tests := map[string]struct {
+		policy     string
+		mapping    map[string]string
+		namespace  string
+		namespaces []string
+	}{
+		"no policy": {
+			namespace:  "default",
+			namespaces: []string{"default"},
+		},
+		"by label": {
+			policy:     options.NamespaceMappingByLabel,
+			mapping:    map[string]string{"line-a": "team-a", "line-b": "team-b"},
+			namespace:  "team-b",
+			namespaces: []string{"default", "team-a", "team-b"},
+		},
+		"by profile": {
+			policy:     options.NamespaceMappingByProfile,
+			mapping:    map[string]string{"thermometer": "team-a", "camera": "default"},
+			namespace:  "team-a",
+			namespaces: []string{"default", "team-a"},
+		},
+		"by service": {
+			policy:     options.NamespaceMappingByService,
+			mapping:    map[string]string{"device-rest": "team-a"},
+			namespace:  "default",
+			namespaces: []string{"default", "team-a"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := options.NewYurtIoTDockOptions()
+			opts.NamespaceMappingPolicy = tt.policy
+			if tt.mapping != nil {
+				opts.NamespaceMapping = tt.mapping
+			}
+			assert.NoError(t, options.ValidateNamespaceMapping(opts))
+			mapper := NewNamespaceMapper(opts)
+			assert.Equal(t, tt.namespace, mapper.NamespaceOf(edgeDevice))
+			assert.Equal(t, tt.namespaces, mapper.Namespaces())
+		})
+	}
+}
+
+func TestValidateNamespaceMapping(t *testing.T) {
+	tests := map[string]struct {
+		policy  string
+		mapping map[string]string
+		err     bool
+	}{
+		"mapping without policy": {mapping: map[string]string{"line-a": "team-a"}, err: true},
+		"unsupported policy":     {policy: "annotation", err: true},
+		"invalid namespace":      {policy: options.NamespaceMappingByLabel, mapping: map[string]string{"line-a": "Team_A"}, err: true},
+		"empty key":              {policy: options.NamespaceMappingByProfile, mapping: map[string]string{"": "team-a"}, err: true},
+		"valid mapping":          {policy: options.NamespaceMappingByService, mapping: map[string]string{"device-rest": "team-a"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := options.NewYurtIoTDockOptions()
+			opts.NamespaceMappingPolicy = tt.policy
+			opts.NamespaceMapping = tt.mapping
+			assert.Equal(t, tt.err, options.ValidateNamespaceMapping(opts) != nil)
+		})
+	}
+}
+
+func newClaimDevice(namespace, name, edgeName string, created time.Time) *iotv1alpha1.Device {
+	return &iotv1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			Labels:            map[string]string{EdgeXObjectName: edgeName},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: iotv1alpha1.DeviceSpec{NodePool: "hangzhou", Profile: "thermometer"},
+	}
+}
+
+func newMappingClient(objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = iotv1alpha1.AddToScheme(scheme)
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+		WithStatusSubresource(&iotv1alpha1.Device{}).
+		WithIndex(&iotv1alpha1.Device{}, util.IndexerPathForNodepool, func(obj client.Object) []string {
+			return []string{obj.(*iotv1alpha1.Device).Spec.NodePool}
+		}).
+		WithIndex(&iotv1alpha1.Device{}, util.IndexerPathForEdgeDeviceName, func(obj client.Object) []string {
+			return []string{util.GetEdgeDeviceName(obj.(*iotv1alpha1.Device), EdgeXObjectName)}
+		}).Build()
+}
+
+func TestDeviceSyncerWithNamespaceMapping(t *testing.T) {
+	created := time.Now().Truncate(time.Second)
+	c := newMappingClient(
+		newClaimDevice("team-a", "hangzhou-thermometer-1", "thermometer-1", created),
+		newClaimDevice("team-b", "thermometer-1", "thermometer-1", created.Add(time.Minute)),
+		// the devices out of the mapped namespaces are not synchronized
+		newClaimDevice("team-c", "hangzhou-thermometer-2", "thermometer-2", created),
+	)
+	opts := options.NewYurtIoTDockOptions()
+	opts.Nodepool = "hangzhou"
+	opts.NamespaceMappingPolicy = options.NamespaceMappingByProfile
+	opts.NamespaceMapping = map[string]string{"thermometer": "team-a", "camera": "team-b"}
+	ds := DeviceSyncer{
+		Client:    c,
+		NodePool:  opts.Nodepool,
+		Namespace: opts.Namespace,
+		deviceCli: &fakeDeviceClient{devices: []iotv1alpha1.Device{
+			{ObjectMeta: metav1.ObjectMeta{Name: "thermometer-1", Labels: map[string]string{EdgeXObjectName: "thermometer-1"}}, Spec: iotv1alpha1.DeviceSpec{Profile: "thermometer"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "camera-1", Labels: map[string]string{EdgeXObjectName: "camera-1"}}, Spec: iotv1alpha1.DeviceSpec{Profile: "camera"}},
+		}},
+		mapper: NewNamespaceMapper(opts),
+	}
+
+	edgeDevices, kubeDevices, err := ds.getAllDevices()
+	require.NoError(t, err)
+	assert.Len(t, edgeDevices, 2)
+	// the device which claimed the edge device first is synchronized
+	require.Len(t, kubeDevices, 1)
+	assert.Equal(t, "team-a", kubeDevices["thermometer-1"].Namespace)
+
+	redundantEdgeDevices, redundantKubeDevices, _ := ds.findDiffDevice(edgeDevices, kubeDevices)
+	assert.Empty(t, redundantKubeDevices)
+	require.Len(t, redundantEdgeDevices, 1)
+	assert.Equal(t, "team-b", redundantEdgeDevices["camera-1"].Namespace)
+	assert.Equal(t, "hangzhou-camera-1", redundantEdgeDevices["camera-1"].Name)
+}
+
+func TestReconcileDeviceWithNameConflict(t *testing.T) {
+	created := time.Now().Truncate(time.Second)
+	claimed := newClaimDevice("team-a", "thermometer", "thermometer-1", created)
+	conflicted := newClaimDevice("team-b", "thermometer", "thermometer-1", created.Add(time.Minute))
+	c := newMappingClient(claimed, conflicted)
+	// the edge platform is not visited for the conflicted device
+	r := &DeviceReconciler{
+		Client:     c,
+		deviceCli:  &fakeDeviceClient{},
+		NodePool:   "hangzhou",
+		namespaces: []string{"team-a", "team-b"},
+	}
+
+	require.NoError(t, r.reconcileCreateDevice(context.TODO(), conflicted, conflicted.Status.DeepCopy()))
+	got := &iotv1alpha1.Device{}
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(conflicted), got))
+	cond := util.GetDeviceCondition(got.Status, iotv1alpha1.DeviceSyncedCondition)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, iotv1alpha1.DeviceNameConflictReason, cond.Reason)
+	assert.False(t, got.Status.Synced)
+}
+
+func TestGetKubeDevice(t *testing.T) {
+	created := time.Now().Truncate(time.Second)
+	claimed := newClaimDevice("team-a", "thermometer", "thermometer-1", created.Add(time.Minute))
+	// the claims of other nodepools are ignored
+	otherPool := newClaimDevice("team-b", "thermometer", "thermometer-1", created)
+	otherPool.Spec.NodePool = "beijing"
+	opts := options.NewYurtIoTDockOptions()
+	opts.Nodepool = "hangzhou"
+	opts.NamespaceMappingPolicy = options.NamespaceMappingByProfile
+	opts.NamespaceMapping = map[string]string{"thermometer": "team-a", "camera": "team-b"}
+	ds := DeviceSyncer{
+		Client:   newMappingClient(claimed, otherPool, newClaimDevice("team-a", "camera", "camera-1", created)),
+		NodePool: opts.Nodepool,
+		mapper:   NewNamespaceMapper(opts),
+	}
+
+	kd, err := ds.getKubeDevice("thermometer-1")
+	require.NoError(t, err)
+	require.NotNil(t, kd)
+	assert.Equal(t, client.ObjectKeyFromObject(claimed), client.ObjectKeyFromObject(kd))
+
+	kd, err = ds.getKubeDevice("thermometer-2")
+	require.NoError(t, err)
+	assert.Nil(t, kd)
+}
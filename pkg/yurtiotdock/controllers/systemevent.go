@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	iotcli "github.com/openyurtio/openyurt/pkg/yurtiotdock/clients"
+)
+
+// newSystemEventClient creates the client of the system events on the edge platform, the syncers poll the edge platform
+// if the system events are not available.
+func newSystemEventClient(iotdock iotcli.IoTDock) iotcli.SystemEventInterface {
+	eventclient, err := iotdock.CreateSystemEventClient()
+	if err != nil {
+		klog.V(2).InfoS("system events of the edge platform are not available, fall back to polling", "reason", err.Error())
+		return nil
+	}
+	return eventclient
+}
+
+// watchSystemEvents watches the system events of eventType with the handler and returns the period of the full
+// synchronization, which is prolonged to the resync period as a safety net once the system events are watched.
+// The returned channel is signaled when system events were dropped and a full synchronization is needed at once.
+func watchSystemEvents(ctx context.Context, eventCli iotcli.SystemEventInterface, eventType string, handler iotcli.SystemEventHandler,
+	syncPeriod, resyncPeriod time.Duration) (time.Duration, <-chan struct{}) {
+	resyncCh := make(chan struct{}, 1)
+	if eventCli == nil {
+		return syncPeriod, resyncCh
+	}
+	resync := func() {
+		klog.V(2).InfoS("system events were dropped, resync the objects", "type", eventType)
+		select {
+		case resyncCh <- struct{}{}:
+		default:
+		}
+	}
+	if err := eventCli.Watch(ctx, eventType, handler, resync); err != nil {
+		klog.V(2).ErrorS(err, "could not watch the system events, fall back to polling", "type", eventType)
+		return syncPeriod, resyncCh
+	}
+	klog.V(1).InfoS("watching the system events of the edge platform", "type", eventType, "resyncPeriod", resyncPeriod)
+	return resyncPeriod, resyncCh
+}
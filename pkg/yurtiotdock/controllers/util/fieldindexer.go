@@ -27,6 +27,11 @@ import (
 
 const (
 	IndexerPathForNodepool = "spec.nodePool"
+	// IndexerPathForEdgeDeviceName indexes the devices by the actual name of the device on the edge platform
+	IndexerPathForEdgeDeviceName = "edgeDeviceName"
+
+	// EdgeXObjectName is the label of the actual name of the object on the edge platform
+	EdgeXObjectName = "yurt-iot-dock/edgex-object.name"
 )
 
 var registerOnce sync.Once
@@ -42,6 +47,14 @@ func RegisterFieldIndexers(fi client.FieldIndexer) error {
 			return
 		}
 
+		// register the fieldIndexer for the actual name of device on the edge platform
+		if err = fi.IndexField(context.TODO(), &iotv1alpha1.Device{}, IndexerPathForEdgeDeviceName, func(rawObj client.Object) []string {
+			device := rawObj.(*iotv1alpha1.Device)
+			return []string{GetEdgeDeviceName(device, EdgeXObjectName)}
+		}); err != nil {
+			return
+		}
+
 		// register the fieldIndexer for deviceService
 		if err = fi.IndexField(context.TODO(), &iotv1alpha1.DeviceService{}, IndexerPathForNodepool, func(rawObj client.Object) []string {
 			deviceService := rawObj.(*iotv1alpha1.DeviceService)
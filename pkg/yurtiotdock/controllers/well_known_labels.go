@@ -16,6 +16,17 @@ limitations under the License.
 
 package controllers
 
+import "github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
+
 const (
-	EdgeXObjectName = "yurt-iot-dock/edgex-object.name"
+	EdgeXObjectName = util.EdgeXObjectName
+	// ExportReadingsAnnotation selects the properties of the device whose readings are exported as metrics and
+	// summarized in the status, the value is a comma-separated list of properties or "*" for all properties.
+	ExportReadingsAnnotation = "yurt-iot-dock/export-readings"
+	// UnreachableThresholdAnnotation overrides the unreachable threshold for the devices of the DeviceProfile,
+	// the value is a duration such as "5m", "0" disables the Reachable condition.
+	UnreachableThresholdAnnotation = "yurt-iot-dock/unreachable-threshold"
+	// ReportingStaleThresholdAnnotation overrides the reporting stale threshold for the devices of the DeviceProfile,
+	// the value is a duration such as "10m", "0" disables the ReportingStale condition.
+	ReportingStaleThresholdAnnotation = "yurt-iot-dock/reporting-stale-threshold"
 )
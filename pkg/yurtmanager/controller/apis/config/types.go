@@ -32,6 +32,7 @@ import (
 	gatewayinternalsvcconfig "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewayinternalservice/config"
 	gatewaypickupconfig "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewaypickup/config"
 	gatewaypublicsvcconfig "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewaypublicservice/config"
+	gatewayrouteconfig "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewayroute/config"
 	endpointsconfig "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/servicetopology/endpoints/config"
 	endpointsliceconfig "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/servicetopology/endpointslice/config"
 	yurtappdaemonconfig "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappdaemon/config"
@@ -107,6 +108,9 @@ type YurtManagerConfiguration struct {
 	// GatewayPublicSvcController holds configuration for GatewayPublicSvcController related features.
 	GatewayPublicSvcController gatewaypublicsvcconfig.GatewayPublicSvcControllerConfiguration
 
+	// GatewayRouteController holds configuration for GatewayRouteController related features.
+	GatewayRouteController gatewayrouteconfig.GatewayRouteControllerConfiguration
+
 	// HubLeaderController holds configuration for HubLeaderController related features.
 	HubLeaderController hubleaderconfig.HubLeaderControllerConfiguration
 }
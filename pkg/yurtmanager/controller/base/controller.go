@@ -41,6 +41,7 @@ import (
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewayinternalservice"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewaypickup"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewaypublicservice"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewayroute"
 	servicetopologyendpoints "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/servicetopology/endpoints"
 	servicetopologyendpointslice "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/servicetopology/endpointslice"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappdaemon"
@@ -96,6 +97,7 @@ func NewControllerInitializers() map[string]InitFunc {
 	register(names.GatewayDNSController, dns.Add)
 	register(names.GatewayInternalServiceController, gatewayinternalservice.Add)
 	register(names.GatewayPublicServiceController, gatewaypublicservice.Add)
+	register(names.GatewayRouteController, gatewayroute.Add)
 	register(names.NodeLifeCycleController, nodelifecycle.Add)
 	register(names.NodeBucketController, nodebucket.Add)
 	register(names.LoadBalancerSetController, loadbalancerset.Add)
@@ -126,6 +128,7 @@ func NewControllerInitializers() map[string]InitFunc {
 // +kubebuilder:rbac:groups=core,resources=endpoints,verbs=list;watch
 // +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=list;watch
 // +kubebuilder:rbac:groups=raven.openyurt.io,resources=gateways,verbs=list;watch
+// +kubebuilder:rbac:groups=raven.openyurt.io,resources=gatewayroutes,verbs=list;watch
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappdaemons,verbs=list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=list;watch
 // +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=list;watch
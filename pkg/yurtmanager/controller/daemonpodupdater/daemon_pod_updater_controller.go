@@ -50,7 +50,9 @@ import (
 	yurtClient "github.com/openyurtio/openyurt/cmd/yurt-manager/app/client"
 	appconfig "github.com/openyurtio/openyurt/cmd/yurt-manager/app/config"
 	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
 	k8sutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/daemonpodupdater/kubernetes"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/maintenancewindow"
 	podutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/pod"
 )
 
@@ -82,6 +84,16 @@ const (
 	MaxUnavailableAnnotation = "apps.openyurt.io/max-unavailable"
 	DefaultMaxUnavailable    = "10%"
 
+	// PausedAnnotation is the annotation key added to DaemonSet to pause the update.
+	// When it's set to "true", no pods are deleted in AdvancedRollingUpdate mode and
+	// no pods are marked as upgradable in OTA mode.
+	PausedAnnotation = "apps.openyurt.io/update-paused"
+
+	// MaintenanceWindowsAnnotation is the annotation key added to DaemonSet to restrict the update
+	// to maintenance windows. Its value is a JSON list of maintenance windows, for example:
+	// [{"schedule":"0 2 * * *","duration":"2h","timeZone":"Asia/Shanghai","nodePools":["hangzhou"]}]
+	MaintenanceWindowsAnnotation = "apps.openyurt.io/maintenance-windows"
+
 	// BurstReplicas is a rate limiter for booting pods on a lot of pods.
 	// The value of 250 is chosen b/c values that are too high can cause registry DoS issues.
 	BurstReplicas = 250
@@ -149,7 +161,9 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 			return false
 		},
 		DeleteFunc: func(evt event.DeleteEvent) bool {
-			return false
+			// the upgrade results of the deleted DaemonSet are removed from nodes
+			ds, ok := evt.Object.(*appsv1.DaemonSet)
+			return ok && checkPrerequisites(ds)
 		},
 		UpdateFunc: func(evt event.UpdateEvent) bool {
 			return daemonsetUpdate(evt)
@@ -165,12 +179,35 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 
 	// 2. Watch for deletion of pods. The reason we watch is that we don't want a daemon set to delete
 	// more pods until all the effects (expectations) of a daemon set's delete have been observed.
+	// The creation and update of pods are watched to sync the upgrade status of DaemonSet.
 	updater := r.(*ReconcileDaemonpodupdater)
 	if err := c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Pod{}, &handler.Funcs{
+		CreateFunc: updater.createPod,
+		UpdateFunc: updater.updatePod,
 		DeleteFunc: updater.deletePod,
 	})); err != nil {
 		return err
 	}
+
+	// 3. Watch for the upgrade results which are reported by YurtHub in the annotation of nodes
+	if err := c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Node{},
+		handler.EnqueueRequestsFromMapFunc(upgradeResultsToDaemonSets), predicate.Funcs{
+			CreateFunc: func(evt event.CreateEvent) bool {
+				return false
+			},
+			DeleteFunc: func(evt event.DeleteEvent) bool {
+				return false
+			},
+			UpdateFunc: func(evt event.UpdateEvent) bool {
+				return evt.ObjectOld.GetAnnotations()[upgradeutil.UpgradeResultsAnnotation] !=
+					evt.ObjectNew.GetAnnotations()[upgradeutil.UpgradeResultsAnnotation]
+			},
+			GenericFunc: func(evt event.GenericEvent) bool {
+				return false
+			},
+		})); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -196,9 +233,9 @@ func daemonsetUpdate(evt event.UpdateEvent) bool {
 	return true
 }
 
-// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;update
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;update;patch
 
 // Reconcile reads that state of the cluster for a DaemonSet object and makes changes based on the state read
 // and what is in the DaemonSet.Spec
@@ -215,8 +252,10 @@ func (r *ReconcileDaemonpodupdater) Reconcile(_ context.Context, request reconci
 		klog.Errorf("could not get DaemonSet %v, %v", request.NamespacedName, err)
 		if apierrors.IsNotFound(err) {
 			r.expectations.DeleteExpectations(request.NamespacedName.String())
+			// the upgrade results of the deleted DaemonSet are never pruned by the status sync
+			return ctrl.Result{}, r.removeUpgradeResults(request.Namespace, request.Name)
 		}
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		return ctrl.Result{}, err
 	}
 
 	if instance.DeletionTimestamp != nil {
@@ -237,15 +276,24 @@ func (r *ReconcileDaemonpodupdater) Reconcile(_ context.Context, request reconci
 		return reconcile.Result{}, nil
 	}
 
+	// The paused flag and maintenance windows decide on which nodes the update can be conducted now
+	checker, err := GetMaintenanceWindowChecker(instance)
+	if err != nil {
+		r.recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidMaintenanceWindow", err.Error())
+		klog.Error(Format("could not parse maintenance windows of DaemonSet %v: %v", request.NamespacedName, err))
+		return reconcile.Result{}, nil
+	}
+	now := time.Now()
+
 	switch strings.ToLower(v) {
 	case strings.ToLower(OTAUpdate):
-		if err := r.otaUpdate(instance); err != nil {
+		if err := r.otaUpdate(instance, checker, now); err != nil {
 			klog.Error(Format("could not OTA update DaemonSet %v pod: %v", request.NamespacedName, err))
 			return reconcile.Result{}, err
 		}
 
 	case strings.ToLower(AutoUpdate), strings.ToLower(AdvancedRollingUpdate):
-		if err := r.advancedRollingUpdate(instance); err != nil {
+		if err := r.advancedRollingUpdate(instance, checker, now); err != nil {
 			klog.Error(Format("could not advanced rolling update DaemonSet %v pod: %v", request.NamespacedName, err))
 			return reconcile.Result{}, err
 		}
@@ -254,10 +302,66 @@ func (r *ReconcileDaemonpodupdater) Reconcile(_ context.Context, request reconci
 		return reconcile.Result{}, fmt.Errorf("unknown update type %v", v)
 	}
 
-	return reconcile.Result{}, nil
+	// Record the upgrade status of nodes, it's synced again on the events of pods and nodes
+	availableAfter, err := r.syncUpgradeStatus(instance, metav1.NewTime(now))
+	if err != nil {
+		klog.Error(Format("could not sync upgrade status of DaemonSet %v: %v", request.NamespacedName, err))
+		return reconcile.Result{}, err
+	}
+
+	requeueAfter := checker.RequeueAfter(now)
+	if availableAfter > 0 && (requeueAfter == 0 || requeueAfter > availableAfter) {
+		requeueAfter = availableAfter
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func (r *ReconcileDaemonpodupdater) createPod(ctx context.Context, evt event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	if pod, ok := evt.Object.(*corev1.Pod); ok {
+		r.enqueueDaemonSetOfPod(pod, q)
+	}
+}
+
+func (r *ReconcileDaemonpodupdater) updatePod(ctx context.Context, evt event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	if evt.ObjectOld.GetResourceVersion() == evt.ObjectNew.GetResourceVersion() {
+		return
+	}
+	if pod, ok := evt.ObjectNew.(*corev1.Pod); ok {
+		r.enqueueDaemonSetOfPod(pod, q)
+	}
+}
+
+// enqueueDaemonSetOfPod enqueues the DaemonSet which controls the pod and meets prerequisites
+func (r *ReconcileDaemonpodupdater) enqueueDaemonSetOfPod(pod *corev1.Pod, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	controllerRef := metav1.GetControllerOf(pod)
+	if controllerRef == nil {
+		return
+	}
+	ds := r.resolveControllerRef(pod.Namespace, controllerRef)
+	if ds == nil || !checkPrerequisites(ds) {
+		return
+	}
+	q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: ds.Namespace, Name: ds.Name}})
 }
 
-func (r *ReconcileDaemonpodupdater) deletePod(ctx context.Context, evt event.DeleteEvent, _ workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+// upgradeResultsToDaemonSets maps the node to the DaemonSets whose upgrade results are recorded on it
+func upgradeResultsToDaemonSets(_ context.Context, obj client.Object) []reconcile.Request {
+	results, err := upgradeutil.GetUpgradeResults(obj.GetAnnotations())
+	if err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for key := range results {
+		parts := strings.Split(key, "/")
+		if len(parts) != 3 || parts[0] != "DaemonSet" {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: parts[1], Name: parts[2]}})
+	}
+	return requests
+}
+
+func (r *ReconcileDaemonpodupdater) deletePod(ctx context.Context, evt event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
 	pod, ok := evt.Object.(*corev1.Pod)
 	if !ok {
 		utilruntime.HandleError(fmt.Errorf("deletepod could not deal with object that is not a pod %#v", evt.Object))
@@ -287,19 +391,25 @@ func (r *ReconcileDaemonpodupdater) deletePod(ctx context.Context, evt event.Del
 	}
 
 	r.expectations.DeletionObserved(dsKey)
+	q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: ds.Namespace, Name: ds.Name}})
 }
 
 // otaUpdate compare every pod to its owner DaemonSet to check if pod is updatable
 // If pod is in line with the latest DaemonSet spec, set pod condition "PodNeedUpgrade" to "false"
 // while not, set pod condition "PodNeedUpgrade" to "true"
-func (r *ReconcileDaemonpodupdater) otaUpdate(ds *appsv1.DaemonSet) error {
+// Pods on nodes out of maintenance windows are not updatable.
+func (r *ReconcileDaemonpodupdater) otaUpdate(ds *appsv1.DaemonSet, checker *maintenancewindow.Checker, now time.Time) error {
 	pods, err := GetDaemonsetPods(r.Client, ds)
 	if err != nil {
 		return err
 	}
 
 	for _, pod := range pods {
-		if err := SetPodUpgradeCondition(r.Client, ds, pod); err != nil {
+		allowed, err := UpgradeAllowedOnNode(r.Client, checker, pod.Spec.NodeName, now)
+		if err != nil {
+			return err
+		}
+		if err := SetPodUpgradeCondition(r.Client, ds, pod, allowed); err != nil {
 			return err
 		}
 	}
@@ -307,8 +417,8 @@ func (r *ReconcileDaemonpodupdater) otaUpdate(ds *appsv1.DaemonSet) error {
 }
 
 // advancedRollingUpdate identifies the set of old pods to delete within the constraints imposed by the max-unavailable number.
-// Just ignore and do not calculate not-ready nodes.
-func (r *ReconcileDaemonpodupdater) advancedRollingUpdate(ds *appsv1.DaemonSet) error {
+// Just ignore and do not calculate not-ready nodes. Old pods on nodes out of maintenance windows are not deleted.
+func (r *ReconcileDaemonpodupdater) advancedRollingUpdate(ds *appsv1.DaemonSet, checker *maintenancewindow.Checker, now time.Time) error {
 	nodeToDaemonPods, err := r.getNodesToDaemonPods(ds)
 	if err != nil {
 		return fmt.Errorf("couldn't get node to daemon pod mapping for daemon set %q: %v", ds.Name, err)
@@ -335,6 +445,11 @@ func (r *ReconcileDaemonpodupdater) advancedRollingUpdate(ds *appsv1.DaemonSet)
 			continue
 		}
 
+		allowed, err := UpgradeAllowedOnNode(r.Client, checker, nodeName, now)
+		if err != nil {
+			return fmt.Errorf("couldn't check maintenance windows of node %q, %v", nodeName, err)
+		}
+
 		newPod, oldPod, ok := findUpdatedPodsOnNode(ds, pods)
 		if !ok {
 			// Let the manage loop clean up this node, and treat it as an unavailable node
@@ -355,6 +470,10 @@ func (r *ReconcileDaemonpodupdater) advancedRollingUpdate(ds *appsv1.DaemonSet)
 		default:
 			// This pod is old, it is an update candidate
 			switch {
+			case !allowed:
+				// The node is out of maintenance windows, skip it in this round
+				klog.V(5).Infof("DaemonSet %s/%s pod %s on node %s is out of date, but node is out of maintenance windows", ds.Namespace, ds.Name, oldPod.Name, nodeName)
+				continue
 			case !podutil.IsPodAvailable(oldPod, ds.Spec.MinReadySeconds, metav1.Time{Time: time.Now()}):
 				// The old pod isn't available, so it needs to be replaced
 				klog.V(5).Infof("DaemonSet %s/%s pod %s on node %s is out of date and not available, allowing replacement", ds.Namespace, ds.Name, oldPod.Name, nodeName)
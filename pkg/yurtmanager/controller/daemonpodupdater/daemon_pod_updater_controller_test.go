@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
@@ -30,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -374,3 +376,77 @@ func TestController_maxUnavailableCounts(t *testing.T) {
 		})
 	}
 }
+
+func TestDaemonsetPodUpdaterMaintenanceWindows(t *testing.T) {
+	now := time.Now().UTC()
+	// a window which opened half an hour ago and a window which opens in an hour
+	openWindow := fmt.Sprintf(`[{"schedule":"%d %d * * *","duration":"1h"}]`, now.Add(-30*time.Minute).Minute(), now.Add(-30*time.Minute).Hour())
+	closedWindow := fmt.Sprintf(`[{"schedule":"%d %d * * *","duration":"1h"}]`, now.Add(time.Hour).Minute(), now.Add(time.Hour).Hour())
+
+	tcases := []struct {
+		name        string
+		annotations map[string]string
+		wantDelete  bool
+		wantRequeue bool
+	}{
+		{
+			name:        "paused",
+			annotations: map[string]string{PausedAnnotation: "true"},
+			wantDelete:  false,
+		},
+		{
+			name:        "in maintenance window",
+			annotations: map[string]string{MaintenanceWindowsAnnotation: openWindow},
+			wantDelete:  true,
+			wantRequeue: true,
+		},
+		{
+			name:        "out of maintenance window",
+			annotations: map[string]string{MaintenanceWindowsAnnotation: closedWindow},
+			wantDelete:  false,
+			wantRequeue: true,
+		},
+		{
+			name:        "invalid maintenance window",
+			annotations: map[string]string{MaintenanceWindowsAnnotation: `[{"schedule":"0 2 * *","duration":"1h"}]`},
+			wantDelete:  false,
+		},
+	}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ds := newDaemonSet("ds", "foo/bar:v1")
+			setOnDelete(ds)
+			setAutoUpdateAnnotation(ds)
+			setMaxUnavailableAnnotation(ds, SingleMaxUnavailable)
+			for k, v := range tcase.annotations {
+				metav1.SetMetaDataAnnotation(&ds.ObjectMeta, k, v)
+			}
+
+			nodesWithPods, err := addNodesWithPods(1, 3, ds, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Update daemonset specification
+			ds.Spec.Template.Spec.Containers[0].Image = "foo/bar:v2"
+
+			c := fakeclient.NewClientBuilder().WithObjects(ds).WithObjects(nodesWithPods...).Build()
+			podControl := &k8sutil.FakePodControl{}
+			r := &ReconcileDaemonpodupdater{
+				Client:       c,
+				expectations: k8sutil.NewControllerExpectations(),
+				podControl:   podControl,
+				recorder:     record.NewFakeRecorder(10),
+			}
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: ds.Namespace, Name: ds.Name}}
+			result, err := r.Reconcile(context.TODO(), req)
+			if err != nil {
+				t.Fatalf("Failed to reconcile daemonpodupdater controller, %v", err)
+			}
+			assert.Equal(t, tcase.wantDelete, len(podControl.DeletePodName) != 0)
+			assert.Equal(t, tcase.wantRequeue, result.RequeueAfter > 0)
+		})
+	}
+}
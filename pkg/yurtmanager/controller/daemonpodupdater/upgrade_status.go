@@ -0,0 +1,415 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonpodupdater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
+	podutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/pod"
+)
+
+const (
+	// UpgradeStatusAnnotation is the annotation key added to DaemonSet by daemonPodUpdater controller
+	// to record the upgrade status of nodes. Its value is a JSON object of UpgradeStatus.
+	UpgradeStatusAnnotation = "apps.openyurt.io/daemonset-upgrade-status"
+
+	// MaxNodesPerPhaseInUpgradeStatus is the max number of nodes of each phase listed in UpgradeStatusAnnotation,
+	// so that the annotation stays far below the size limit of metadata in a cluster with thousands of nodes.
+	MaxNodesPerPhaseInUpgradeStatus = 100
+
+	// maxMessageLengthInUpgradeStatus is the max length of the message of a node listed in UpgradeStatusAnnotation
+	maxMessageLengthInUpgradeStatus = 256
+)
+
+// failedContainerReasons are the waiting reasons of containers which need manual intervention
+var failedContainerReasons = sets.New[string](
+	"CrashLoopBackOff",
+	"ImagePullBackOff",
+	"ErrImagePull",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+)
+
+// UpgradeStatus is the upgrade status of a DaemonSet which is recorded in UpgradeStatusAnnotation
+type UpgradeStatus struct {
+	// The number of nodes in each phase
+	Pending    int32 `json:"pending"`
+	InProgress int32 `json:"inProgress"`
+	Succeeded  int32 `json:"succeeded"`
+	Failed     int32 `json:"failed"`
+
+	// The number of nodes in each phase which are not listed in Nodes
+	OmittedPending    int32 `json:"omittedPending,omitempty"`
+	OmittedInProgress int32 `json:"omittedInProgress,omitempty"`
+	OmittedFailed     int32 `json:"omittedFailed,omitempty"`
+
+	// Nodes lists the upgrade status of nodes which are not succeeded. Failed nodes come first,
+	// then nodes in progress and pending nodes, and at most MaxNodesPerPhaseInUpgradeStatus nodes
+	// of each phase are listed.
+	Nodes []appsv1alpha1.NodeUpgradeStatus `json:"nodes,omitempty"`
+}
+
+// Done returns whether the DaemonSet has been upgraded on all nodes
+func (s *UpgradeStatus) Done() bool {
+	return s.Pending == 0 && s.InProgress == 0 && s.Failed == 0
+}
+
+// GetUpgradeStatus parses UpgradeStatusAnnotation of the given DaemonSet
+func GetUpgradeStatus(ds *appsv1.DaemonSet) (*UpgradeStatus, error) {
+	status := &UpgradeStatus{}
+	v, ok := ds.Annotations[UpgradeStatusAnnotation]
+	if !ok || len(v) == 0 {
+		return status, nil
+	}
+	if err := json.Unmarshal([]byte(v), status); err != nil {
+		return nil, fmt.Errorf("could not unmarshal annotation %s, %v", UpgradeStatusAnnotation, err)
+	}
+	return status, nil
+}
+
+// syncUpgradeStatus computes the upgrade status of nodes and records it in the annotation of DaemonSet if it's changed,
+// and prunes the stale upgrade results which are reported by YurtHub from the annotation of nodes.
+// It returns when the status needs to be synced again, since a new pod becomes available after minReadySeconds
+// without any event. Zero means the status is synced again on the events of DaemonSet, pods and nodes.
+func (r *ReconcileDaemonpodupdater) syncUpgradeStatus(ds *appsv1.DaemonSet, now metav1.Time) (time.Duration, error) {
+	nodeToDaemonPods, err := r.getNodesToDaemonPods(ds)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.pruneUpgradeResults(ds, nodeToDaemonPods); err != nil {
+		return 0, err
+	}
+
+	previous, err := GetUpgradeStatus(ds)
+	if err != nil {
+		// the broken annotation will be overwritten
+		previous = nil
+	}
+
+	status, err := computeUpgradeStatus(r.Client, ds, nodeToDaemonPods, previous, now)
+	if err != nil {
+		return 0, err
+	}
+	requeueAfter := nextAvailableCheck(ds, nodeToDaemonPods, now)
+	if previous != nil && upgradeStatusEqual(previous, status) {
+		return requeueAfter, nil
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return 0, err
+	}
+	patch := client.MergeFrom(ds.DeepCopy())
+	if ds.Annotations == nil {
+		ds.Annotations = make(map[string]string)
+	}
+	ds.Annotations[UpgradeStatusAnnotation] = string(data)
+	if err := r.Patch(context.TODO(), ds, patch); err != nil {
+		if apierrors.IsInvalid(err) || apierrors.IsRequestEntityTooLargeError(err) {
+			// the status is only informative, it must not block the upgrade of pods
+			klog.Warning(Format("could not record upgrade status of DaemonSet %s/%s: %v", ds.Namespace, ds.Name, err))
+			return requeueAfter, nil
+		}
+		return 0, err
+	}
+	return requeueAfter, nil
+}
+
+// upgradeStatusEqual returns whether the upgrade status is not changed. The transition time is ignored, since
+// it's kept for the listed nodes whose phase is not changed, and the nodes which are not listed don't have one.
+func upgradeStatusEqual(previous, status *UpgradeStatus) bool {
+	if previous.Pending != status.Pending || previous.InProgress != status.InProgress ||
+		previous.Succeeded != status.Succeeded || previous.Failed != status.Failed ||
+		previous.OmittedPending != status.OmittedPending || previous.OmittedInProgress != status.OmittedInProgress ||
+		previous.OmittedFailed != status.OmittedFailed || len(previous.Nodes) != len(status.Nodes) {
+		return false
+	}
+	for i := range status.Nodes {
+		p, s := previous.Nodes[i], status.Nodes[i]
+		if p.NodeName != s.NodeName || p.Phase != s.Phase || p.Reason != s.Reason || p.Message != s.Message {
+			return false
+		}
+	}
+	return true
+}
+
+// nextAvailableCheck returns how long it takes for the first ready pod of the latest revision to become available,
+// zero if there is no such pod.
+func nextAvailableCheck(ds *appsv1.DaemonSet, nodeToDaemonPods map[string][]*corev1.Pod, now metav1.Time) time.Duration {
+	if ds.Spec.MinReadySeconds == 0 {
+		return 0
+	}
+
+	var next time.Duration
+	for _, pods := range nodeToDaemonPods {
+		newPod, _, ok := findUpdatedPodsOnNode(ds, pods)
+		if !ok || newPod == nil || !podutil.IsPodReady(newPod) || podutil.IsPodAvailable(newPod, ds.Spec.MinReadySeconds, now) {
+			continue
+		}
+		c := podutil.GetPodReadyCondition(newPod.Status)
+		if c.LastTransitionTime.IsZero() {
+			continue
+		}
+		// the pod is available once the time is strictly after the deadline
+		after := c.LastTransitionTime.Add(time.Duration(ds.Spec.MinReadySeconds)*time.Second).Sub(now.Time) + time.Second
+		if next == 0 || after < next {
+			next = after
+		}
+	}
+	return next
+}
+
+// pruneUpgradeResults removes the upgrade results of DaemonSet from the annotation of nodes, once the pods which are
+// upgraded from don't exist anymore, since the results only matter while the old pods are running.
+func (r *ReconcileDaemonpodupdater) pruneUpgradeResults(ds *appsv1.DaemonSet, nodeToDaemonPods map[string][]*corev1.Pod) error {
+	key := upgradeutil.DaemonSetUpgradeResultKey(ds.Namespace, ds.Name)
+	for nodeName, pods := range nodeToDaemonPods {
+		node := &corev1.Node{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		results, err := upgradeutil.GetUpgradeResults(node.Annotations)
+		if err != nil {
+			continue
+		}
+		result, ok := results[key]
+		if !ok || hasPodOfRevision(pods, result.Hash) {
+			continue
+		}
+		if err := r.removeUpgradeResult(nodeName, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeUpgradeResults removes the upgrade results of the deleted DaemonSet from the annotation of all nodes
+func (r *ReconcileDaemonpodupdater) removeUpgradeResults(namespace, name string) error {
+	nodes := &corev1.NodeList{}
+	if err := r.List(context.TODO(), nodes); err != nil {
+		return err
+	}
+
+	key := upgradeutil.DaemonSetUpgradeResultKey(namespace, name)
+	for i := range nodes.Items {
+		results, err := upgradeutil.GetUpgradeResults(nodes.Items[i].Annotations)
+		if err != nil {
+			continue
+		}
+		if _, ok := results[key]; !ok {
+			continue
+		}
+		if err := r.removeUpgradeResult(nodes.Items[i].Name, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ReconcileDaemonpodupdater) removeUpgradeResult(nodeName, key string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node := &corev1.Node{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
+			return err
+		}
+		if removed, err := upgradeutil.RemoveUpgradeResult(node.Annotations, key); err != nil || !removed {
+			return err
+		}
+		return r.Update(context.TODO(), node)
+	})
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	klog.V(4).Info(Format("Remove upgrade result %s from node %s", key, nodeName))
+	return nil
+}
+
+func hasPodOfRevision(pods []*corev1.Pod, hash string) bool {
+	for _, pod := range pods {
+		if pod.Labels[extensions.DefaultDaemonSetUniqueLabelKey] == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// computeUpgradeStatus computes the upgrade status of DaemonSet from the daemon pods on every node.
+// The transition time in previous status is kept if the phase of node is not changed.
+func computeUpgradeStatus(c client.Client, ds *appsv1.DaemonSet, nodeToDaemonPods map[string][]*corev1.Pod,
+	previous *UpgradeStatus, now metav1.Time) (*UpgradeStatus, error) {
+	if previous == nil {
+		previous = &UpgradeStatus{}
+	}
+	previousNodes := make(map[string]*appsv1alpha1.NodeUpgradeStatus, len(previous.Nodes))
+	for i := range previous.Nodes {
+		previousNodes[previous.Nodes[i].NodeName] = &previous.Nodes[i]
+	}
+
+	status := &UpgradeStatus{}
+	var nodes []appsv1alpha1.NodeUpgradeStatus
+	for nodeName, pods := range nodeToDaemonPods {
+		node := &corev1.Node{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			node = nil
+		}
+
+		s := nodeUpgradeStatus(ds, nodeName, node, pods, now)
+		switch s.Phase {
+		case appsv1alpha1.NodeUpgradeSucceeded:
+			status.Succeeded++
+			continue
+		case appsv1alpha1.NodeUpgradeFailed:
+			status.Failed++
+		case appsv1alpha1.NodeUpgradeInProgress:
+			status.InProgress++
+		default:
+			status.Pending++
+		}
+
+		if p, ok := previousNodes[nodeName]; ok && p.Phase == s.Phase && !p.LastTransitionTime.IsZero() {
+			s.LastTransitionTime = p.LastTransitionTime
+		}
+		nodes = append(nodes, s)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if pi, pj := phaseOrder(nodes[i].Phase), phaseOrder(nodes[j].Phase); pi != pj {
+			return pi < pj
+		}
+		return nodes[i].NodeName < nodes[j].NodeName
+	})
+	listed := make(map[appsv1alpha1.NodeUpgradePhase]int)
+	for i := range nodes {
+		if listed[nodes[i].Phase] >= MaxNodesPerPhaseInUpgradeStatus {
+			switch nodes[i].Phase {
+			case appsv1alpha1.NodeUpgradeFailed:
+				status.OmittedFailed++
+			case appsv1alpha1.NodeUpgradeInProgress:
+				status.OmittedInProgress++
+			default:
+				status.OmittedPending++
+			}
+			continue
+		}
+		listed[nodes[i].Phase]++
+		if len(nodes[i].Message) > maxMessageLengthInUpgradeStatus {
+			nodes[i].Message = nodes[i].Message[:maxMessageLengthInUpgradeStatus]
+		}
+		status.Nodes = append(status.Nodes, nodes[i])
+	}
+	return status, nil
+}
+
+// nodeUpgradeStatus figures out the upgrade phase of DaemonSet on the node from the daemon pods,
+// and the upgrade result reported by YurtHub in OTA mode.
+func nodeUpgradeStatus(ds *appsv1.DaemonSet, nodeName string, node *corev1.Node, pods []*corev1.Pod,
+	now metav1.Time) appsv1alpha1.NodeUpgradeStatus {
+	status := appsv1alpha1.NodeUpgradeStatus{NodeName: nodeName, LastTransitionTime: now}
+
+	newPod, oldPod, ok := findUpdatedPodsOnNode(ds, pods)
+	switch {
+	case !ok, newPod != nil && oldPod != nil:
+		// The manage loop of DaemonSet will clean up the excess pods
+		status.Phase = appsv1alpha1.NodeUpgradeInProgress
+	case newPod != nil:
+		if podutil.IsPodAvailable(newPod, ds.Spec.MinReadySeconds, now) {
+			status.Phase = appsv1alpha1.NodeUpgradeSucceeded
+		} else if reason, message, failed := podFailure(newPod); failed {
+			status.Phase = appsv1alpha1.NodeUpgradeFailed
+			status.Reason = reason
+			status.Message = message
+		} else {
+			status.Phase = appsv1alpha1.NodeUpgradeInProgress
+			status.Reason = "PodNotAvailable"
+		}
+	case oldPod != nil:
+		if result := upgradeResult(ds, node); result != nil && result.Hash == oldPod.Labels[extensions.DefaultDaemonSetUniqueLabelKey] &&
+			result.Result == upgradeutil.UpgradeFailed {
+			status.Phase = appsv1alpha1.NodeUpgradeFailed
+			status.Reason = result.Reason
+			status.Message = result.Message
+		} else if node != nil && !NodeReady(&node.Status) {
+			status.Phase = appsv1alpha1.NodeUpgradePending
+			status.Reason = "NodeNotReady"
+		} else {
+			status.Phase = appsv1alpha1.NodeUpgradePending
+		}
+	default:
+		// The old pod is being deleted and the new pod is not created yet
+		status.Phase = appsv1alpha1.NodeUpgradeInProgress
+	}
+	return status
+}
+
+// podFailure checks whether the containers of pod are blocked by errors which need manual intervention
+func podFailure(pod *corev1.Pod) (string, string, bool) {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.State.Waiting != nil && failedContainerReasons.Has(cs.State.Waiting.Reason) {
+			return cs.State.Waiting.Reason, fmt.Sprintf("container %s: %s", cs.Name, cs.State.Waiting.Message), true
+		}
+	}
+	return "", "", false
+}
+
+// upgradeResult gets the upgrade result of DaemonSet reported by YurtHub from the annotation of node
+func upgradeResult(ds *appsv1.DaemonSet, node *corev1.Node) *upgradeutil.UpgradeResult {
+	if node == nil {
+		return nil
+	}
+	results, err := upgradeutil.GetUpgradeResults(node.Annotations)
+	if err != nil {
+		return nil
+	}
+	return results[upgradeutil.DaemonSetUpgradeResultKey(ds.Namespace, ds.Name)]
+}
+
+func phaseOrder(phase appsv1alpha1.NodeUpgradePhase) int {
+	switch phase {
+	case appsv1alpha1.NodeUpgradeFailed:
+		return 0
+	case appsv1alpha1.NodeUpgradeInProgress:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonpodupdater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
+	k8sutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/daemonpodupdater/kubernetes"
+)
+
+func TestSyncUpgradeStatus(t *testing.T) {
+	oldDS := newDaemonSet("ds", "foo/bar:v1")
+	setOnDelete(oldDS)
+	setAutoUpdateAnnotation(oldDS)
+	ds := oldDS.DeepCopy()
+	ds.Spec.Template.Spec.Containers[0].Image = "foo/bar:v2"
+
+	// node-1 is running the latest available pod, and the result of upgrading from the old pod is stale
+	node1 := newNode("node-1", true)
+	pod1 := newPod("pod-1", "node-1", simpleDaemonSetLabel, ds)
+	node1.Annotations, _ = upgradeutil.SetUpgradeResult(nil, upgradeutil.DaemonSetUpgradeResultKey(ds.Namespace, ds.Name),
+		&upgradeutil.UpgradeResult{
+			Hash:   newPod("pod-0", "node-1", simpleDaemonSetLabel, oldDS).Labels[appsv1.DefaultDaemonSetUniqueLabelKey],
+			Result: upgradeutil.UpgradeInProgress,
+		})
+
+	// node-2 is running the latest pod which is crashing
+	node2 := newNode("node-2", true)
+	pod2 := newPod("pod-2", "node-2", simpleDaemonSetLabel, ds)
+	pod2.Status.Conditions = nil
+	pod2.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "app",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off"}},
+	}}
+
+	// node-3 is running the old pod
+	node3 := newNode("node-3", true)
+	pod3 := newPod("pod-3", "node-3", simpleDaemonSetLabel, oldDS)
+
+	// node-4 is running the old pod which YurtHub failed to upgrade
+	node4 := newNode("node-4", true)
+	pod4 := newPod("pod-4", "node-4", simpleDaemonSetLabel, oldDS)
+	node4.Annotations, _ = upgradeutil.SetUpgradeResult(nil, upgradeutil.DaemonSetUpgradeResultKey(ds.Namespace, ds.Name),
+		&upgradeutil.UpgradeResult{
+			Hash:   pod4.Labels[appsv1.DefaultDaemonSetUniqueLabelKey],
+			Result: upgradeutil.UpgradeFailed,
+			Reason: "DeleteFailed",
+		})
+
+	// node-5 is running the latest pod which is not ready yet
+	node5 := newNode("node-5", true)
+	pod5 := newPod("pod-5", "node-5", simpleDaemonSetLabel, ds)
+	pod5.Status.Conditions = nil
+
+	// node-6 is not ready
+	node6 := newNode("node-6", false)
+	pod6 := newPod("pod-6", "node-6", simpleDaemonSetLabel, oldDS)
+
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	previous, _ := json.Marshal(&UpgradeStatus{
+		Nodes: []appsv1alpha1.NodeUpgradeStatus{{NodeName: "node-3", Phase: appsv1alpha1.NodeUpgradePending, LastTransitionTime: earlier}},
+	})
+	metav1.SetMetaDataAnnotation(&ds.ObjectMeta, UpgradeStatusAnnotation, string(previous))
+
+	c := fakeclient.NewClientBuilder().WithObjects(ds, node1, node2, node3, node4, node5, node6,
+		pod1, pod2, pod3, pod4, pod5, pod6).Build()
+	r := &ReconcileDaemonpodupdater{
+		Client:       c,
+		expectations: k8sutil.NewControllerExpectations(),
+		podControl:   &k8sutil.FakePodControl{},
+		recorder:     record.NewFakeRecorder(10),
+	}
+
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	requeueAfter, err := r.syncUpgradeStatus(ds, now)
+	if err != nil {
+		t.Fatalf("could not sync upgrade status, %v", err)
+	}
+	assert.Equal(t, time.Duration(0), requeueAfter)
+
+	got := &appsv1.DaemonSet{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: ds.Namespace, Name: ds.Name}, got); err != nil {
+		t.Fatalf("could not get DaemonSet, %v", err)
+	}
+	status, err := GetUpgradeStatus(got)
+	if err != nil {
+		t.Fatalf("could not get upgrade status, %v", err)
+	}
+
+	assert.Equal(t, int32(1), status.Succeeded)
+	assert.Equal(t, int32(2), status.Failed)
+	assert.Equal(t, int32(1), status.InProgress)
+	assert.Equal(t, int32(2), status.Pending)
+
+	expect := []appsv1alpha1.NodeUpgradeStatus{
+		{NodeName: "node-2", Phase: appsv1alpha1.NodeUpgradeFailed, Reason: "CrashLoopBackOff", Message: "container app: back-off", LastTransitionTime: now},
+		{NodeName: "node-4", Phase: appsv1alpha1.NodeUpgradeFailed, Reason: "DeleteFailed", LastTransitionTime: now},
+		{NodeName: "node-5", Phase: appsv1alpha1.NodeUpgradeInProgress, Reason: "PodNotAvailable", LastTransitionTime: now},
+		{NodeName: "node-3", Phase: appsv1alpha1.NodeUpgradePending, LastTransitionTime: earlier},
+		{NodeName: "node-6", Phase: appsv1alpha1.NodeUpgradePending, Reason: "NodeNotReady", LastTransitionTime: now},
+	}
+	assert.Equal(t, len(expect), len(status.Nodes))
+	for i := range expect {
+		assert.Equal(t, expect[i].NodeName, status.Nodes[i].NodeName)
+		assert.Equal(t, expect[i].Phase, status.Nodes[i].Phase)
+		assert.Equal(t, expect[i].Reason, status.Nodes[i].Reason)
+		assert.Equal(t, expect[i].Message, status.Nodes[i].Message)
+		assert.True(t, expect[i].LastTransitionTime.Equal(&status.Nodes[i].LastTransitionTime),
+			"unexpected transition time of %s", expect[i].NodeName)
+	}
+
+	// the stale result is pruned, and the result of the running old pod is kept
+	for nodeName, expect := range map[string]bool{"node-1": false, "node-4": true} {
+		node := &corev1.Node{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
+			t.Fatalf("could not get node, %v", err)
+		}
+		results, err := upgradeutil.GetUpgradeResults(node.Annotations)
+		assert.NoError(t, err)
+		_, ok := results[upgradeutil.DaemonSetUpgradeResultKey(ds.Namespace, ds.Name)]
+		assert.Equal(t, expect, ok, "unexpected upgrade result on %s", nodeName)
+	}
+
+	// the annotation is not patched again if the status is not changed
+	_, err = r.syncUpgradeStatus(got, metav1.NewTime(now.Add(time.Minute)))
+	assert.NoError(t, err)
+	synced := &appsv1.DaemonSet{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: ds.Namespace, Name: ds.Name}, synced); err != nil {
+		t.Fatalf("could not get DaemonSet, %v", err)
+	}
+	assert.Equal(t, got.ResourceVersion, synced.ResourceVersion)
+
+	// the results are removed from all nodes once the DaemonSet is deleted
+	assert.NoError(t, r.removeUpgradeResults(ds.Namespace, ds.Name))
+	node := &corev1.Node{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "node-4"}, node); err != nil {
+		t.Fatalf("could not get node, %v", err)
+	}
+	assert.NotContains(t, node.Annotations, upgradeutil.UpgradeResultsAnnotation)
+}
+
+func TestNextAvailableCheck(t *testing.T) {
+	ds := newDaemonSet("ds", "foo/bar:v1")
+	ds.Spec.MinReadySeconds = 60
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+
+	pod := newPod("pod-1", "node-1", simpleDaemonSetLabel, ds)
+	pod.Status.Conditions = []corev1.PodCondition{{
+		Type:               corev1.PodReady,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(now.Add(-20 * time.Second)),
+	}}
+	nodeToDaemonPods := map[string][]*corev1.Pod{"node-1": {pod}}
+	assert.Equal(t, 41*time.Second, nextAvailableCheck(ds, nodeToDaemonPods, now))
+
+	// the pod becomes available
+	assert.Equal(t, time.Duration(0), nextAvailableCheck(ds, nodeToDaemonPods, metav1.NewTime(now.Add(time.Minute))))
+}
+
+func TestComputeUpgradeStatusCapsNodesPerPhase(t *testing.T) {
+	oldDS := newDaemonSet("ds", "foo/bar:v1")
+	ds := oldDS.DeepCopy()
+	ds.Spec.Template.Spec.Containers[0].Image = "foo/bar:v2"
+
+	var objs []client.Object
+	nodeToDaemonPods := make(map[string][]*corev1.Pod)
+	addNode := func(nodeName string, pod *corev1.Pod) {
+		objs = append(objs, newNode(nodeName, true), pod)
+		nodeToDaemonPods[nodeName] = []*corev1.Pod{pod}
+	}
+	// more failed nodes and pending nodes than the limit
+	for i := 0; i < MaxNodesPerPhaseInUpgradeStatus+10; i++ {
+		pod := newPod(fmt.Sprintf("failed-%d", i), fmt.Sprintf("failed-node-%03d", i), simpleDaemonSetLabel, ds)
+		pod.Status.Conditions = nil
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "app",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: strings.Repeat("x", 1024)}},
+		}}
+		addNode(pod.Spec.NodeName, pod)
+
+		pod = newPod(fmt.Sprintf("pending-%d", i), fmt.Sprintf("pending-node-%03d", i), simpleDaemonSetLabel, oldDS)
+		addNode(pod.Spec.NodeName, pod)
+	}
+	c := fakeclient.NewClientBuilder().WithObjects(objs...).Build()
+
+	status, err := computeUpgradeStatus(c, ds, nodeToDaemonPods, nil, metav1.Now())
+	if err != nil {
+		t.Fatalf("could not compute upgrade status, %v", err)
+	}
+	assert.Equal(t, int32(MaxNodesPerPhaseInUpgradeStatus+10), status.Failed)
+	assert.Equal(t, int32(MaxNodesPerPhaseInUpgradeStatus+10), status.Pending)
+	assert.Equal(t, int32(10), status.OmittedFailed)
+	assert.Equal(t, int32(10), status.OmittedPending)
+	assert.Equal(t, int32(0), status.OmittedInProgress)
+	assert.Equal(t, 2*MaxNodesPerPhaseInUpgradeStatus, len(status.Nodes))
+	for i := 0; i < MaxNodesPerPhaseInUpgradeStatus; i++ {
+		assert.Equal(t, appsv1alpha1.NodeUpgradeFailed, status.Nodes[i].Phase)
+		assert.Equal(t, maxMessageLengthInUpgradeStatus, len(status.Nodes[i].Message))
+	}
+}
+
+func TestSyncUpgradeStatusIgnoresTooLargeAnnotation(t *testing.T) {
+	ds := newDaemonSet("ds", "foo/bar:v1")
+	node := newNode("node-1", true)
+	pod := newPod("pod-1", "node-1", simpleDaemonSetLabel, ds)
+	c := fakeclient.NewClientBuilder().WithObjects(ds, node, pod).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, client client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				return apierrors.NewInvalid(appsv1.SchemeGroupVersion.WithKind("DaemonSet").GroupKind(), obj.GetName(), nil)
+			},
+		}).Build()
+	r := &ReconcileDaemonpodupdater{
+		Client:       c,
+		expectations: k8sutil.NewControllerExpectations(),
+		podControl:   &k8sutil.FakePodControl{},
+		recorder:     record.NewFakeRecorder(10),
+	}
+
+	_, err := r.syncUpgradeStatus(ds, metav1.Now())
+	assert.NoError(t, err)
+}
@@ -18,19 +18,25 @@ package daemonpodupdater
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/projectinfo"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/daemonpodupdater/kubernetes"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/maintenancewindow"
 	podutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/pod"
 )
 
@@ -111,9 +117,10 @@ func NodeReady(nodeStatus *corev1.NodeStatus) bool {
 	return false
 }
 
-// SetPodUpgradeCondition calculate and set pod condition "PodNeedUpgrade"
-func SetPodUpgradeCondition(c client.Client, ds *appsv1.DaemonSet, pod *corev1.Pod) error {
-	isUpdatable := IsDaemonsetPodLatest(ds, pod)
+// SetPodUpgradeCondition calculate and set pod condition "PodNeedUpgrade".
+// If upgrade is not allowed on the node of pod now, pod is not updatable even if it's not the latest.
+func SetPodUpgradeCondition(c client.Client, ds *appsv1.DaemonSet, pod *corev1.Pod, allowed bool) error {
+	isUpdatable := IsDaemonsetPodLatest(ds, pod) || !allowed
 
 	// Comply with K8s, use constant ConditionTrue and ConditionFalse
 	var status corev1.ConditionStatus
@@ -139,6 +146,42 @@ func SetPodUpgradeCondition(c client.Client, ds *appsv1.DaemonSet, pod *corev1.P
 	return nil
 }
 
+// GetMaintenanceWindowChecker parses annotation "apps.openyurt.io/update-paused" and
+// "apps.openyurt.io/maintenance-windows" of the given daemonset
+func GetMaintenanceWindowChecker(ds *appsv1.DaemonSet) (*maintenancewindow.Checker, error) {
+	paused := strings.EqualFold(ds.Annotations[PausedAnnotation], "true")
+
+	var windows []appsv1alpha1.MaintenanceWindow
+	if v, ok := ds.Annotations[MaintenanceWindowsAnnotation]; ok && len(v) != 0 {
+		if err := json.Unmarshal([]byte(v), &windows); err != nil {
+			return nil, fmt.Errorf("could not unmarshal annotation %s, %v", MaintenanceWindowsAnnotation, err)
+		}
+	}
+
+	return maintenancewindow.NewChecker(paused, windows)
+}
+
+// UpgradeAllowedOnNode check if pods on the given node can be upgraded at now,
+// the node pool of node is looked up only when the update is restricted.
+func UpgradeAllowedOnNode(c client.Client, checker *maintenancewindow.Checker, nodeName string, now time.Time) (bool, error) {
+	if !checker.Restricted() {
+		return true, nil
+	}
+
+	var nodePool string
+	if len(nodeName) != 0 {
+		node := &corev1.Node{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		nodePool = node.Labels[projectinfo.GetNodePoolLabel()]
+	}
+
+	return checker.Allowed(nodePool, now), nil
+}
+
 // checkPrerequisites checks that daemonset meets two conditions
 // 1. annotation "apps.openyurt.io/update-strategy"="AdvancedRollingUpdate" or "OTA"
 // 2. update strategy is "OnDelete"
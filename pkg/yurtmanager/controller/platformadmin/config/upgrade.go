@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// release is an EdgeX release, the releases are listed from the oldest to the latest
+type release struct {
+	name  string
+	major int
+}
+
+var edgeXReleases = []release{
+	{name: "hanoi", major: 1},
+	{name: "ireland", major: 2},
+	{name: "jakarta", major: 2},
+	{name: "kamakura", major: 2},
+	{name: "levski", major: 2},
+	{name: "minnesota", major: 3},
+	{name: "napa", major: 3},
+}
+
+func findRelease(name string) (int, *release) {
+	for i := range edgeXReleases {
+		if edgeXReleases[i].name == name {
+			return i, &edgeXReleases[i]
+		}
+	}
+	return -1, nil
+}
+
+// CheckUpgradeCompatibility checks whether the components of version from can be upgraded to version to in place.
+// Downgrades are not supported, and an upgrade can not skip a major version of EdgeX because the storage
+// and configuration layout changes between the major versions.
+func CheckUpgradeCompatibility(from, to string) error {
+	if from == to {
+		return nil
+	}
+	fromIndex, fromRelease := findRelease(from)
+	if fromRelease == nil {
+		return fmt.Errorf("version %s is unknown", from)
+	}
+	toIndex, toRelease := findRelease(to)
+	if toRelease == nil {
+		return fmt.Errorf("version %s is unknown", to)
+	}
+	if toIndex < fromIndex {
+		return fmt.Errorf("downgrade from %s to %s is not supported", from, to)
+	}
+	if toRelease.major-fromRelease.major > 1 {
+		return fmt.Errorf("upgrade from %s to %s skips a major version, upgrade to a release of major version %d first", from, to, fromRelease.major+1)
+	}
+	return nil
+}
+
+// MigrationHook migrates the configmaps and components of the framework before the nodepools are upgraded,
+// the configmaps and components are modified in place.
+type MigrationHook func(configMaps []corev1.ConfigMap, components []*Component) error
+
+type upgradePath struct {
+	from string
+	to   string
+}
+
+var (
+	migrationHooksLock sync.RWMutex
+	migrationHooks     = map[upgradePath][]MigrationHook{}
+)
+
+// RegisterMigrationHook registers a hook which runs when the framework is upgraded from version from to version to
+func RegisterMigrationHook(from, to string, hook MigrationHook) {
+	migrationHooksLock.Lock()
+	defer migrationHooksLock.Unlock()
+	path := upgradePath{from: from, to: to}
+	migrationHooks[path] = append(migrationHooks[path], hook)
+}
+
+// RunMigrationHooks runs the hooks registered for the upgrade from version from to version to in order
+func RunMigrationHooks(from, to string, configMaps []corev1.ConfigMap, components []*Component) error {
+	migrationHooksLock.RLock()
+	defer migrationHooksLock.RUnlock()
+	for _, hook := range migrationHooks[upgradePath{from: from, to: to}] {
+		if err := hook(configMaps, components); err != nil {
+			return fmt.Errorf("could not migrate from %s to %s, %v", from, to, err)
+		}
+	}
+	return nil
+}
+
+// MigrateConfigMaps merges the configmaps customized by the user into the standard configmaps of the target version.
+// The values which are modified by the user and the keys which are unknown to the target version are kept.
+// The configmaps are shared by all the nodepools, so the migrated ones are used by the nodepools which have
+// not been upgraded yet as well.
+func MigrateConfigMaps(current, oldStandard, newStandard []corev1.ConfigMap) []corev1.ConfigMap {
+	oldStandardData := make(map[string]map[string]string, len(oldStandard))
+	for _, cm := range oldStandard {
+		oldStandardData[cm.Name] = cm.Data
+	}
+	currentData := make(map[string]map[string]string, len(current))
+	for _, cm := range current {
+		currentData[cm.Name] = cm.Data
+	}
+
+	migrated := make([]corev1.ConfigMap, 0, len(newStandard))
+	standardNames := make(map[string]struct{}, len(newStandard))
+	for _, standard := range newStandard {
+		cm := *standard.DeepCopy()
+		standardNames[cm.Name] = struct{}{}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		oldData := oldStandardData[cm.Name]
+		for k, v := range currentData[cm.Name] {
+			if _, ok := standard.Data[k]; !ok {
+				cm.Data[k] = v
+			} else if oldValue, ok := oldData[k]; !ok || oldValue != v {
+				cm.Data[k] = v
+			}
+		}
+		migrated = append(migrated, cm)
+	}
+
+	// The configmaps unknown to the target version are kept as they are
+	for _, cm := range current {
+		if _, ok := standardNames[cm.Name]; ok {
+			continue
+		}
+		migrated = append(migrated, *cm.DeepCopy())
+	}
+	return migrated
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckUpgradeCompatibility(t *testing.T) {
+	tests := map[string]struct {
+		from, to string
+		err      bool
+	}{
+		"same version":           {from: "levski", to: "levski"},
+		"minor upgrade":          {from: "jakarta", to: "levski"},
+		"major upgrade":          {from: "levski", to: "minnesota"},
+		"skip a major version":   {from: "hanoi", to: "minnesota", err: true},
+		"downgrade":              {from: "napa", to: "minnesota", err: true},
+		"unknown target version": {from: "levski", to: "unknown", err: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := CheckUpgradeCompatibility(tt.from, tt.to)
+			assert.Equal(t, tt.err, err != nil)
+		})
+	}
+}
+
+func TestMigrateConfigMaps(t *testing.T) {
+	newConfigMap := func(name string, data map[string]string) corev1.ConfigMap {
+		return corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name}, Data: data}
+	}
+	oldStandard := []corev1.ConfigMap{newConfigMap("common-variables", map[string]string{"HOST": "redis", "PORT": "6379", "OBSOLETE": "true"})}
+	newStandard := []corev1.ConfigMap{newConfigMap("common-variables", map[string]string{"HOST": "redis", "PORT": "6380"})}
+	current := []corev1.ConfigMap{
+		newConfigMap("common-variables", map[string]string{"HOST": "my-redis", "PORT": "6379", "OBSOLETE": "true", "EXTRA": "1"}),
+		newConfigMap("custom", map[string]string{"KEY": "value"}),
+	}
+
+	migrated := MigrateConfigMaps(current, oldStandard, newStandard)
+	assert.Equal(t, []corev1.ConfigMap{
+		newConfigMap("common-variables", map[string]string{"HOST": "my-redis", "PORT": "6380", "OBSOLETE": "true", "EXTRA": "1"}),
+		newConfigMap("custom", map[string]string{"KEY": "value"}),
+	}, migrated)
+	assert.Equal(t, "6380", newStandard[0].Data["PORT"])
+	assert.Len(t, newStandard[0].Data, 2)
+}
+
+func TestRunMigrationHooks(t *testing.T) {
+	RegisterMigrationHook("kamakura", "levski", func(configMaps []corev1.ConfigMap, components []*Component) error {
+		configMaps[0].Data["MIGRATED"] = "true"
+		return nil
+	})
+	RegisterMigrationHook("kamakura", "levski", func(configMaps []corev1.ConfigMap, components []*Component) error {
+		return errors.New("failed")
+	})
+	defer delete(migrationHooks, upgradePath{from: "kamakura", to: "levski"})
+
+	configMaps := []corev1.ConfigMap{{Data: map[string]string{}}}
+	assert.NoError(t, RunMigrationHooks("jakarta", "levski", configMaps, nil))
+	assert.Empty(t, configMaps[0].Data)
+	assert.Error(t, RunMigrationHooks("kamakura", "levski", configMaps, nil))
+	assert.Equal(t, "true", configMaps[0].Data["MIGRATED"])
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	iotv1beta1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/platformadmin/config"
+)
+
+// readCustomComponents reads the components defined by the user in the configmaps which are named by
+// spec.customComponentConfigMaps of PlatformAdmin. Each value of the configmap data defines a component
+// in yaml, with the same format as the components of the framework.
+func (r *ReconcilePlatformAdmin) readCustomComponents(ctx context.Context, platformAdmin *iotv1beta1.PlatformAdmin) (map[string]*config.Component, error) {
+	components := make(map[string]*config.Component)
+	for _, name := range platformAdmin.Spec.CustomComponentConfigMaps {
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: platformAdmin.Namespace, Name: name}, cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				klog.Error(Format("custom component configmap %s/%s of PlatformAdmin %s is not found", platformAdmin.Namespace, name, platformAdmin.Name))
+				r.recorder.Eventf(platformAdmin, corev1.EventTypeWarning, "CustomComponentNotFound", "custom component configmap %s is not found", name)
+				continue
+			}
+			return nil, err
+		}
+
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			component, err := parseCustomComponent(cm.Data[key])
+			if err != nil {
+				klog.Error(Format("could not parse custom component %s of configmap %s/%s, %v", key, cm.Namespace, cm.Name, err))
+				r.recorder.Eventf(platformAdmin, corev1.EventTypeWarning, "InvalidCustomComponent", "custom component %s of configmap %s is invalid, %v", key, cm.Name, err)
+				continue
+			}
+			if _, ok := components[component.Name]; ok {
+				klog.Error(Format("custom component %s of configmap %s/%s is defined more than once", component.Name, cm.Namespace, cm.Name))
+				continue
+			}
+			components[component.Name] = component
+		}
+	}
+	return components, nil
+}
+
+// parseCustomComponent parses and validates a component definition,
+// the pods of the component are selected by the app label like the standard components if no selector is set.
+func parseCustomComponent(data string) (*config.Component, error) {
+	component := &config.Component{}
+	if err := yaml.Unmarshal([]byte(data), component); err != nil {
+		return nil, err
+	}
+	if errs := validation.IsDNS1123Label(component.Name); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid name %q, %s", component.Name, strings.Join(errs, ","))
+	}
+	if component.Deployment == nil {
+		return nil, fmt.Errorf("deployment of component %s is required", component.Name)
+	}
+
+	if component.Deployment.Selector == nil {
+		component.Deployment.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": component.Name}}
+		if component.Deployment.Template.Labels == nil {
+			component.Deployment.Template.Labels = make(map[string]string)
+		}
+		component.Deployment.Template.Labels["app"] = component.Name
+	}
+	if component.Service != nil && len(component.Service.Selector) == 0 {
+		component.Service.Selector = map[string]string{"app": component.Name}
+	}
+	if err := validateCustomComponent(component); err != nil {
+		return nil, fmt.Errorf("invalid component %s, %v", component.Name, err)
+	}
+	return component, nil
+}
+
+// validateCustomComponent validates the deployment and service of the component before it is deployed. The components
+// are deployed by yurt-manager, so the pods must not use the host namespaces, host paths, privileges or the service
+// accounts other than the default one.
+func validateCustomComponent(component *config.Component) error {
+	deployment := component.Deployment
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector, %v", err)
+	}
+	if selector.Empty() || !selector.Matches(labels.Set(deployment.Template.Labels)) {
+		return fmt.Errorf("the selector does not match the labels of the pod template")
+	}
+
+	podSpec := &deployment.Template.Spec
+	if len(podSpec.Containers) == 0 {
+		return fmt.Errorf("at least one container is required")
+	}
+	if podSpec.HostNetwork || podSpec.HostPID || podSpec.HostIPC {
+		return fmt.Errorf("the host namespaces are not allowed")
+	}
+	if (podSpec.ServiceAccountName != "" && podSpec.ServiceAccountName != "default") ||
+		(podSpec.DeprecatedServiceAccount != "" && podSpec.DeprecatedServiceAccount != "default") {
+		return fmt.Errorf("only the default service account is allowed")
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			return fmt.Errorf("the hostPath volume %s is not allowed", volume.Name)
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	for _, container := range containers {
+		if errs := validation.IsDNS1123Label(container.Name); len(errs) != 0 {
+			return fmt.Errorf("invalid container name %q, %s", container.Name, strings.Join(errs, ","))
+		}
+		if container.Image == "" {
+			return fmt.Errorf("the image of container %s is required", container.Name)
+		}
+		if sc := container.SecurityContext; sc != nil {
+			if (sc.Privileged != nil && *sc.Privileged) || (sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation) {
+				return fmt.Errorf("the privileged container %s is not allowed", container.Name)
+			}
+			if sc.Capabilities != nil && len(sc.Capabilities.Add) != 0 {
+				return fmt.Errorf("adding capabilities to container %s is not allowed", container.Name)
+			}
+		}
+	}
+	if len(podSpec.EphemeralContainers) != 0 {
+		return fmt.Errorf("the ephemeral containers are not allowed")
+	}
+
+	if component.Service != nil {
+		for _, port := range component.Service.Ports {
+			if port.Port <= 0 || port.Port > 65535 {
+				return fmt.Errorf("invalid service port %d", port.Port)
+			}
+		}
+	}
+	return nil
+}
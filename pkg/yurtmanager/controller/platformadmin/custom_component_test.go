@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
+	iotv1beta1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/platformadmin/config"
+)
+
+const deviceVendorComponent = `
+name: device-vendor
+service:
+  ports:
+  - name: http
+    port: 59999
+deployment:
+  template:
+    spec:
+      containers:
+      - name: device-vendor
+        image: vendor/device-service:1.0.0
+`
+
+func TestParseCustomComponent(t *testing.T) {
+	tests := map[string]struct {
+		data      string
+		err       bool
+		checkFunc func(t *testing.T, component *config.Component)
+	}{
+		"component with defaulted selectors": {
+			data: deviceVendorComponent,
+			checkFunc: func(t *testing.T, component *config.Component) {
+				assert.Equal(t, map[string]string{"app": "device-vendor"}, component.Deployment.Selector.MatchLabels)
+				assert.Equal(t, "device-vendor", component.Deployment.Template.Labels["app"])
+				assert.Equal(t, map[string]string{"app": "device-vendor"}, component.Service.Selector)
+				assert.Equal(t, "vendor/device-service:1.0.0", component.Deployment.Template.Spec.Containers[0].Image)
+			},
+		},
+		"invalid name": {
+			data: "name: Device_Vendor\ndeployment: {}\n",
+			err:  true,
+		},
+		"component without deployment": {
+			data: "name: device-vendor\n",
+			err:  true,
+		},
+		"invalid yaml": {
+			data: "name: [",
+			err:  true,
+		},
+		"component without containers": {
+			data: "name: device-vendor\ndeployment:\n  template:\n    spec: {}\n",
+			err:  true,
+		},
+		"container without image": {
+			data: strings.ReplaceAll(deviceVendorComponent, "image: vendor/device-service:1.0.0", "image: \"\""),
+			err:  true,
+		},
+		"privileged container": {
+			data: deviceVendorComponent + "        securityContext:\n          privileged: true\n",
+			err:  true,
+		},
+		"host network": {
+			data: strings.ReplaceAll(deviceVendorComponent, "      containers:", "      hostNetwork: true\n      containers:"),
+			err:  true,
+		},
+		"host path volume": {
+			data: deviceVendorComponent + "      volumes:\n      - name: root\n        hostPath:\n          path: /\n",
+			err:  true,
+		},
+		"service account": {
+			data: strings.ReplaceAll(deviceVendorComponent, "      containers:", "      serviceAccountName: yurt-manager\n      containers:"),
+			err:  true,
+		},
+		"selector not matching the pod labels": {
+			data: strings.ReplaceAll(deviceVendorComponent, "deployment:\n", "deployment:\n  selector:\n    matchLabels:\n      app: other\n"),
+			err:  true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			component, err := parseCustomComponent(tt.data)
+			assert.Equal(t, tt.err, err != nil)
+			if tt.checkFunc != nil {
+				tt.checkFunc(t, component)
+			}
+		})
+	}
+}
+
+func TestReconcileCustomComponent(t *testing.T) {
+	platformAdmin := &iotv1beta1.PlatformAdmin{
+		TypeMeta:   metav1.TypeMeta{Kind: "PlatformAdmin", APIVersion: iotv1beta1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "edgex", Namespace: "default"},
+		Spec: iotv1beta1.PlatformAdminSpec{
+			Version:    "minnesota",
+			Platform:   iotv1beta1.PlatformAdminPlatformEdgeX,
+			NodePools:  []string{"pool1"},
+			Components: []iotv1beta1.Component{{Name: "device-vendor"}, {Name: "device-unknown"}, {Name: "device-unlisted"}},
+			// the configmaps which are not listed are never deployed
+			CustomComponentConfigMaps: []string{"vendor-components", "missing-components"},
+		},
+	}
+	customComponents := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vendor-components",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"device-vendor": deviceVendorComponent,
+			"invalid":       "name: [",
+		},
+	}
+	unlistedComponents := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unlisted-components",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"device-unlisted": strings.ReplaceAll(deviceVendorComponent, "device-vendor", "device-unlisted"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(fakeScheme).WithObjects(platformAdmin, customComponents, unlistedComponents).
+		WithStatusSubresource(&iotv1beta1.PlatformAdmin{}).Build()
+	r := &ReconcilePlatformAdmin{
+		Client:         c,
+		scheme:         fakeScheme,
+		recorder:       &fakeEventRecorder{},
+		yamlSerializer: kjson.NewSerializerWithOptions(kjson.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, kjson.SerializerOptions{Yaml: true, Pretty: true}),
+		Configuration:  *config.NewPlatformAdminControllerConfiguration(),
+	}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(platformAdmin)}
+	getYas := func() *v1beta1.YurtAppSet {
+		yas := &v1beta1.YurtAppSet{}
+		require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "edgex-device-vendor"}, yas))
+		return yas
+	}
+
+	// the custom component is deployed to the nodepools with a service like the standard components
+	_, err := r.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	yas := getYas()
+	assert.Equal(t, []string{"pool1"}, yas.Spec.Pools)
+	assert.Equal(t, "vendor/device-service:1.0.0", yas.Spec.Workload.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image)
+	svc := &corev1.Service{}
+	require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "device-vendor"}, svc))
+	assert.Equal(t, int32(59999), svc.Spec.Ports[0].Port)
+	err = c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "edgex-device-unknown"}, &v1beta1.YurtAppSet{})
+	assert.True(t, apierrors.IsNotFound(err))
+	err = c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "edgex-device-unlisted"}, &v1beta1.YurtAppSet{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// the changes of the definition are applied to the component
+	customComponents.Data["device-vendor"] = `
+name: device-vendor
+deployment:
+  template:
+    spec:
+      containers:
+      - name: device-vendor
+        image: vendor/device-service:1.1.0
+`
+	require.NoError(t, c.Update(context.TODO(), customComponents))
+	_, err = r.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	yas = getYas()
+	assert.Equal(t, "vendor/device-service:1.1.0", yas.Spec.Workload.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image)
+}
@@ -31,6 +31,12 @@ import (
 	utils "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/platformadmin/utils"
 )
 
+const (
+	// the volume and the directory which the journal of device writes is persisted to
+	iotDockJournalVolume = "journal"
+	iotDockJournalDir    = "/var/lib/yurt-iot-dock"
+)
+
 // newYurtIoTDockComponent initialize the configuration of yurt-iot-dock component
 func newYurtIoTDockComponent(platformAdmin *iotv1beta1.PlatformAdmin, platformAdminFramework *PlatformAdminFramework) (*config.Component, error) {
 	var yurtIotDockComponent config.Component
@@ -49,6 +55,22 @@ func newYurtIoTDockComponent(platformAdmin *iotv1beta1.PlatformAdmin, platformAd
 		return nil, err
 	}
 
+	args := []string{
+		"--health-probe-bind-address=:8081",
+		"--metrics-bind-address=127.0.0.1:8080",
+		"--leader-elect=false",
+		fmt.Sprintf("--namespace=%s", ns),
+		fmt.Sprintf("--version=%s", platformAdmin.Spec.Version),
+		fmt.Sprintf("--platform=%s", platformAdmin.Spec.Platform),
+		fmt.Sprintf("--journal-path=%s/journal.json", iotDockJournalDir),
+	}
+	if platformAdmin.Spec.MessageBusAddress != "" {
+		args = append(args, fmt.Sprintf("--message-bus-address=%s", platformAdmin.Spec.MessageBusAddress))
+	}
+	if platformAdmin.Spec.MQTTBrokerAddress != "" {
+		args = append(args, fmt.Sprintf("--mqtt-broker-address=%s", platformAdmin.Spec.MQTTBrokerAddress))
+	}
+
 	yurtIotDockComponent.Name = utils.IotDockName
 	yurtIotDockComponent.Deployment = &appsv1.DeploymentSpec{
 		Selector: &metav1.LabelSelector{
@@ -71,12 +93,12 @@ func newYurtIoTDockComponent(platformAdmin *iotv1beta1.PlatformAdmin, platformAd
 						Name:            utils.IotDockName,
 						Image:           fmt.Sprintf("%s:%s", utils.IotDockImage, ver),
 						ImagePullPolicy: corev1.PullAlways,
-						Args: []string{
-							"--health-probe-bind-address=:8081",
-							"--metrics-bind-address=127.0.0.1:8080",
-							"--leader-elect=false",
-							fmt.Sprintf("--namespace=%s", ns),
-							fmt.Sprintf("--version=%s", platformAdmin.Spec.Version),
+						Args:            args,
+						VolumeMounts: []corev1.VolumeMount{
+							{
+								Name:      iotDockJournalVolume,
+								MountPath: iotDockJournalDir,
+							},
 						},
 						LivenessProbe: &corev1.Probe{
 							InitialDelaySeconds: 15,
@@ -113,6 +135,16 @@ func newYurtIoTDockComponent(platformAdmin *iotv1beta1.PlatformAdmin, platformAd
 						},
 					},
 				},
+				// the journal of device writes outlives the restarts of yurt-iot-dock while the cloud is not reachable,
+				// the pod itself is not recreated then since the nodepool is disconnected from the cloud
+				Volumes: []corev1.Volume{
+					{
+						Name: iotDockJournalVolume,
+						VolumeSource: corev1.VolumeSource{
+							EmptyDir: &corev1.EmptyDirVolumeSource{},
+						},
+					},
+				},
 				TerminationGracePeriodSeconds: ptr.To[int64](10),
 				SecurityContext: &corev1.PodSecurityContext{
 					RunAsUser: ptr.To[int64](65532),
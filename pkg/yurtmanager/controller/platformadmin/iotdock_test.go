@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iotv1beta1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1beta1"
+)
+
+func TestNewYurtIoTDockComponentAddresses(t *testing.T) {
+	tests := map[string]struct {
+		platform          string
+		messageBusAddress string
+		mqttBrokerAddress string
+		expectArgs        []string
+	}{
+		"message bus address is set": {
+			platform:          iotv1beta1.PlatformAdminPlatformEdgeX,
+			messageBusAddress: "edgex-mqtt-broker:1883",
+			expectArgs:        []string{"--message-bus-address=edgex-mqtt-broker:1883"},
+		},
+		"mqtt broker address is set": {
+			platform:          iotv1beta1.PlatformAdminPlatformMQTT,
+			mqttBrokerAddress: "emqx.iot:1883",
+			expectArgs:        []string{"--mqtt-broker-address=emqx.iot:1883"},
+		},
+		"addresses are empty": {
+			platform: iotv1beta1.PlatformAdminPlatformEdgeX,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			platformAdmin := &iotv1beta1.PlatformAdmin{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-platformadmin", Namespace: "default"},
+				Spec: iotv1beta1.PlatformAdminSpec{
+					Version:           "minnesota",
+					Platform:          tc.platform,
+					MessageBusAddress: tc.messageBusAddress,
+					MQTTBrokerAddress: tc.mqttBrokerAddress,
+				},
+			}
+			component, err := newYurtIoTDockComponent(platformAdmin, &PlatformAdminFramework{})
+			assert.NoError(t, err)
+			args := component.Deployment.Template.Spec.Containers[0].Args
+			var addressArgs []string
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "--message-bus-address=") || strings.HasPrefix(arg, "--mqtt-broker-address=") {
+					addressArgs = append(addressArgs, arg)
+				}
+			}
+			assert.Equal(t, tc.expectArgs, addressArgs)
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -81,8 +82,13 @@ const (
 type PlatformAdminFramework struct {
 	runtime.TypeMeta `json:",inline"`
 
-	name       string
-	security   bool
+	name     string
+	security bool
+	// customComponents are the components defined by the user, they are not part of the framework
+	// until they are required by PlatformAdmin
+	customComponents map[string]*config.Component
+	// Version is the version of the standard configuration which the framework is built from
+	Version    string              `yaml:"version,omitempty" json:"version,omitempty"`
 	Components []*config.Component `yaml:"components,omitempty" json:"components,omitempty"`
 	ConfigMaps []corev1.ConfigMap  `yaml:"configMaps,omitempty" json:"configMaps,omitempty"`
 }
@@ -167,6 +173,26 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 		return err
 	}
 
+	// Watch for changes to the custom components which are named by the PlatformAdmins in the same namespace
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.ConfigMap{},
+		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			platformAdmins := &iotv1beta1.PlatformAdminList{}
+			if err := mgr.GetCache().List(ctx, platformAdmins, client.InNamespace(obj.GetNamespace())); err != nil {
+				klog.Error(Format("could not list PlatformAdmins in namespace %s, %v", obj.GetNamespace(), err))
+				return nil
+			}
+			var requests []reconcile.Request
+			for _, platformAdmin := range platformAdmins.Items {
+				if slices.Contains(platformAdmin.Spec.CustomComponentConfigMaps, obj.GetName()) {
+					requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&platformAdmin)})
+				}
+			}
+			return requests
+		})))
+	if err != nil {
+		return err
+	}
+
 	klog.V(4).Info(Format("registering the field indexers of platformadmin controller"))
 	if err := util.RegisterFieldIndexers(mgr.GetFieldIndexer()); err != nil {
 		klog.Error(Format("could not register field indexers for platformadmin controller, %v", err))
@@ -307,6 +333,8 @@ func (r *ReconcilePlatformAdmin) reconcileNormal(ctx context.Context, platformAd
 	controllerutil.AddFinalizer(platformAdmin, iotv1beta1.PlatformAdminFinalizer)
 
 	platformAdminStatus.Initialized = true
+	// The device health is reported by yurt-iot-dock of each nodepool, drop the ones of the removed nodepools
+	pruneDeviceHealth(platformAdmin, platformAdminStatus)
 
 	// Note that this configmap is different from the one below, which is used to customize the edgex framework
 	// Sync configmap of edgex confiruation during initialization
@@ -316,6 +344,16 @@ func (r *ReconcilePlatformAdmin) reconcileNormal(ctx context.Context, platformAd
 		return reconcile.Result{}, errors.Wrapf(err, "unexpected error while synchronizing customize framework for %s", platformAdmin.Namespace+"/"+platformAdmin.Name)
 	}
 
+	// Check the upgrade of version and migrate the framework to the new version
+	klog.V(4).Info(Format("PrepareUpgrade PlatformAdmin %s/%s", platformAdmin.Namespace, platformAdmin.Name))
+	if ok, err := r.prepareUpgrade(ctx, platformAdmin, platformAdminStatus, platformAdminFramework); !ok {
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err,
+				"unexpected error while preparing upgrade for %s", platformAdmin.Namespace+"/"+platformAdmin.Name)
+		}
+		return reconcile.Result{}, nil
+	}
+
 	// Reconcile configmap of edgex confiruation
 	klog.V(4).Info(Format("ReconcileConfigmap PlatformAdmin %s/%s", platformAdmin.Namespace, platformAdmin.Name))
 	if ok, err := r.reconcileConfigmap(ctx, platformAdmin, platformAdminStatus, platformAdminFramework); !ok {
@@ -348,6 +386,11 @@ func (r *ReconcilePlatformAdmin) reconcileNormal(ctx context.Context, platformAd
 		return reconcile.Result{}, err
 	}
 
+	// Wait for the nodepools to be upgraded, the stalled upgrade is reconciled again when the workloads change
+	if isUpgrading(platformAdmin, platformAdminStatus) && !isUpgradeStalled(platformAdminStatus) {
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
 	return reconcile.Result{}, nil
 }
 
@@ -443,6 +486,11 @@ func (r *ReconcilePlatformAdmin) reconcileComponent(ctx context.Context, platfor
 		} else {
 			oldYas := yas.DeepCopy()
 
+			// Keep the nodepools which have not started upgrading on the previous pod template
+			if err := pinNodePools(platformAdmin, platformAdminStatus, yas, desiredComponent.Deployment); err != nil {
+				return false, err
+			}
+
 			// Refresh the YurtAppSet according to the user-defined configuration
 			yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec = *desiredComponent.Deployment
 
@@ -507,6 +555,11 @@ func (r *ReconcilePlatformAdmin) reconcileComponent(ctx context.Context, platfor
 		}
 	}
 
+	// Roll the version out to the nodepools according to the upgrade strategy
+	if err := r.reconcilePoolVersions(ctx, platformAdmin, platformAdminStatus, platformAdminFramework); err != nil {
+		return false, err
+	}
+
 	return readyComponent == int32(len(platformAdminFramework.Components)), nil
 }
 
@@ -632,6 +685,14 @@ func (r *ReconcilePlatformAdmin) readFramework(ctx context.Context, platformAdmi
 		name: FrameworkName,
 	}
 
+	// The custom components are read before the framework is initialized so that they can be required at creation
+	customComponents, err := r.readCustomComponents(ctx, platformAdmin)
+	if err != nil {
+		klog.Error(Format("Get custom components for PlatformAdmin %s/%s error %v", platformAdmin.Namespace, platformAdmin.Name, err))
+		return nil, err
+	}
+	platformAdminFramework.customComponents = customComponents
+
 	// Check if the configmap that represents framework is found
 	cm := &corev1.ConfigMap{}
 	if err := r.Get(ctx, types.NamespacedName{Namespace: platformAdmin.Namespace, Name: platformAdminFramework.name}, cm); err != nil {
@@ -650,7 +711,7 @@ func (r *ReconcilePlatformAdmin) readFramework(ctx context.Context, platformAdmi
 	}
 
 	// For better serialization, the serialization method of the Kubernetes runtime library is used
-	err := runtime.DecodeInto(r.yamlSerializer, []byte(cm.Data["framework"]), platformAdminFramework)
+	err = runtime.DecodeInto(r.yamlSerializer, []byte(cm.Data["framework"]), platformAdminFramework)
 	if err != nil {
 		klog.Error(Format("Decode framework for PlatformAdmin %s/%s error %v", platformAdmin.Namespace, platformAdmin.Name, err))
 		return nil, err
@@ -733,7 +794,11 @@ func (r *ReconcilePlatformAdmin) initFramework(ctx context.Context, platformAdmi
 
 	// Use standard configurations to build the framework
 	platformAdminFramework.security = platformAdmin.Spec.Security
-	if platformAdminFramework.security {
+	platformAdminFramework.Version = platformAdmin.Spec.Version
+	if platformAdmin.Spec.Platform == iotv1beta1.PlatformAdminPlatformMQTT {
+		// The mqtt platform has no configmaps, yurt-iot-dock connects to the broker directly
+		r.calculateDesiredComponents(platformAdmin, platformAdminFramework)
+	} else if platformAdminFramework.security {
 		platformAdminFramework.ConfigMaps = r.Configuration.SecurityConfigMaps[platformAdmin.Spec.Version]
 		r.calculateDesiredComponents(platformAdmin, platformAdminFramework)
 	} else {
@@ -780,15 +845,35 @@ func (r *ReconcilePlatformAdmin) calculateDesiredComponents(platformAdmin *iotv1
 
 	// Find all the required components from spec and manifest
 	requiredComponentSet := config.ExtractRequiredComponentsName(&r.Configuration.Manifest, platformAdmin.Spec.Version)
+	if platformAdmin.Spec.Platform == iotv1beta1.PlatformAdminPlatformMQTT {
+		// The mqtt platform only requires the yurt-iot-dock, the broker is provided by the user
+		requiredComponentSet = sets.New[string](util.IotDockName)
+	}
 	for _, component := range platformAdmin.Spec.Components {
 		requiredComponentSet.Insert(component.Name)
 	}
 
+	// The standard components take precedence over the custom components with the same name
+	standardComponents := r.Configuration.NoSectyComponents[platformAdmin.Spec.Version]
+	if platformAdmin.Spec.Security {
+		standardComponents = r.Configuration.SecurityComponents[platformAdmin.Spec.Version]
+	}
+	standardComponentSet := sets.New[string](util.IotDockName)
+	for _, component := range standardComponents {
+		standardComponentSet.Insert(component.Name)
+	}
+
 	// Find all existing components and filter removed components
 	frameworkComponentSet := sets.NewString()
 	for _, component := range platformAdminFramework.Components {
 		if requiredComponentSet.Has(component.Name) {
 			frameworkComponentSet.Insert(component.Name)
+			// The custom components follow the latest definitions of the user
+			if custom, ok := platformAdminFramework.customComponents[component.Name]; ok && !standardComponentSet.Has(component.Name) &&
+				!apiequality.Semantic.DeepEqual(component, custom) {
+				component = custom
+				needWriteFramework = true
+			}
 			desiredComponents = append(desiredComponents, component)
 		} else {
 			needWriteFramework = true
@@ -806,18 +891,23 @@ func (r *ReconcilePlatformAdmin) calculateDesiredComponents(platformAdmin *iotv1
 
 	// If a component needs to be added,
 	// check whether the corresponding template exists in the standard configuration library
-	if platformAdmin.Spec.Security {
-		for _, component := range r.Configuration.SecurityComponents[platformAdmin.Spec.Version] {
-			if addedComponentSet.Has(component.Name) {
-				desiredComponents = append(desiredComponents, component)
-			}
+	for _, component := range standardComponents {
+		if addedComponentSet.Has(component.Name) {
+			desiredComponents = append(desiredComponents, component)
 		}
-	} else {
-		for _, component := range r.Configuration.NoSectyComponents[platformAdmin.Spec.Version] {
-			if addedComponentSet.Has(component.Name) {
-				desiredComponents = append(desiredComponents, component)
-			}
+	}
+
+	// Otherwise, check whether the component is defined by the user
+	for _, componentName := range addedComponentSet.List() {
+		if standardComponentSet.Has(componentName) {
+			continue
+		}
+		if custom, ok := platformAdminFramework.customComponents[componentName]; ok {
+			desiredComponents = append(desiredComponents, custom)
+			continue
 		}
+		klog.Error(Format("component %s of PlatformAdmin %s/%s is neither a standard component nor a custom component", componentName, platformAdmin.Namespace, platformAdmin.Name))
+		r.recorder.Eventf(platformAdmin, corev1.EventTypeWarning, "ComponentNotFound", "component %s is not found in the standard or custom components", componentName)
 	}
 
 	// The yurt-iot-dock is maintained by openyurt and is not obtained through an auto-collector.
@@ -834,3 +924,17 @@ func (r *ReconcilePlatformAdmin) calculateDesiredComponents(platformAdmin *iotv1
 
 	return needWriteFramework
 }
+
+// pruneDeviceHealth removes the device health of the nodepools which are not managed by PlatformAdmin any more
+func pruneDeviceHealth(platformAdmin *iotv1beta1.PlatformAdmin, platformAdminStatus *iotv1beta1.PlatformAdminStatus) {
+	deviceHealth := make([]iotv1beta1.PlatformAdminDeviceHealth, 0, len(platformAdminStatus.DeviceHealth))
+	for _, health := range platformAdminStatus.DeviceHealth {
+		if util.Contains(platformAdmin.Spec.NodePools, health.NodePool) {
+			deviceHealth = append(deviceHealth, health)
+		}
+	}
+	if len(deviceHealth) == 0 {
+		deviceHealth = nil
+	}
+	platformAdminStatus.DeviceHealth = deviceHealth
+}
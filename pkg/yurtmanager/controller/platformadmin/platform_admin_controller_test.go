@@ -180,3 +180,18 @@ func TestReconcilePlatformAdmin(t *testing.T) {
 		})
 	}
 }
+
+func TestPruneDeviceHealth(t *testing.T) {
+	platformAdmin := &iotv1beta1.PlatformAdmin{Spec: iotv1beta1.PlatformAdminSpec{NodePools: []string{"pool1"}}}
+	status := &iotv1beta1.PlatformAdminStatus{DeviceHealth: []iotv1beta1.PlatformAdminDeviceHealth{
+		{NodePool: "pool1", Devices: 2, UnreachableDevices: 1},
+		{NodePool: "pool2", Devices: 1},
+	}}
+
+	pruneDeviceHealth(platformAdmin, status)
+	assert.Equal(t, []iotv1beta1.PlatformAdminDeviceHealth{{NodePool: "pool1", Devices: 2, UnreachableDevices: 1}}, status.DeviceHealth)
+
+	platformAdmin.Spec.NodePools = nil
+	pruneDeviceHealth(platformAdmin, status)
+	assert.Nil(t, status.DeviceHealth)
+}
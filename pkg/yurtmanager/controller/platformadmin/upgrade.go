@@ -0,0 +1,435 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps"
+	appsv1beta1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
+	iotv1beta1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/platformadmin/utils"
+)
+
+// The nodepools which have not been upgraded yet are pinned to the pod template of the previous version
+// by a tweak which replaces the pod template of their workloads. The configmaps are not pinned, so only the
+// versions whose configuration does not change are upgraded in stages.
+const pinnedTemplatePath = "/spec/template"
+
+// defaultProgressDeadline is the maximum time for a nodepool to be upgraded if the upgrade strategy doesn't set it
+const defaultProgressDeadline = 600 * time.Second
+
+// prepareUpgrade records the version of the nodepools, and checks and migrates the framework
+// when the version of PlatformAdmin changes. It returns false if the components should not be reconciled.
+func (r *ReconcilePlatformAdmin) prepareUpgrade(ctx context.Context, platformAdmin *iotv1beta1.PlatformAdmin, platformAdminStatus *iotv1beta1.PlatformAdminStatus, platformAdminFramework *PlatformAdminFramework) (bool, error) {
+	version := platformAdmin.Spec.Version
+	if platformAdminStatus.Version == "" {
+		platformAdminStatus.Version = version
+	}
+	syncPoolVersions(platformAdmin, platformAdminStatus)
+
+	// Rolling back to the version which all the nodepools ran is applied to all the nodepools at once
+	rollback := version == platformAdminStatus.Version
+	if rollback {
+		for i := range platformAdminStatus.PoolVersions {
+			pv := &platformAdminStatus.PoolVersions[i]
+			if pv.TargetVersion != version {
+				klog.V(4).Info(Format("Roll back NodePool %s of PlatformAdmin %s/%s to %s", pv.NodePool, platformAdmin.Namespace, platformAdmin.Name, version))
+				pv.TargetVersion = version
+				pv.UpgradeStartTime = nil
+			}
+		}
+	}
+
+	// The framework created before the version is recorded runs the version of the nodepools
+	if platformAdminFramework.Version == "" {
+		platformAdminFramework.Version = platformAdminStatus.Version
+	}
+	if platformAdminFramework.Version == version {
+		return true, nil
+	}
+
+	from := platformAdminFramework.Version
+	if platformAdmin.Spec.Platform != iotv1beta1.PlatformAdminPlatformMQTT && !rollback {
+		if err := config.CheckUpgradeCompatibility(from, version); err != nil {
+			// The components keep running the current version until the version is corrected
+			klog.Error(Format("PlatformAdmin %s/%s could not be upgraded, %v", platformAdmin.Namespace, platformAdmin.Name, err))
+			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1beta1.VersionUpgradedCondition, corev1.ConditionFalse, iotv1beta1.VersionIncompatibleReason, err.Error()))
+			return false, nil
+		}
+	}
+
+	klog.V(4).Info(Format("Migrate the framework of PlatformAdmin %s/%s from %s to %s", platformAdmin.Namespace, platformAdmin.Name, from, version))
+	configMaps := platformAdminFramework.ConfigMaps
+	if err := r.migrateFramework(platformAdmin, platformAdminFramework, from, version); err != nil {
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1beta1.VersionUpgradedCondition, corev1.ConditionFalse, iotv1beta1.VersionMigrationFailedReason, err.Error()))
+		return false, err
+	}
+
+	// The configmaps are shared by all the nodepools and are not migrated in stages, the nodepools which are pinned
+	// to the previous version would run against the configuration of the new version. So the versions whose
+	// configuration changes are only rolled out to all the nodepools at once.
+	if !rollback && isStagedUpgrade(platformAdmin) && isConfigMapsChanged(configMaps, platformAdminFramework.ConfigMaps) {
+		message := fmt.Sprintf("the configuration shared by all the nodepools changes from %s to %s, it could not be upgraded in stages, "+
+			"set upgradeStrategy.maxUnavailable to the number of nodepools to upgrade them at once", from, version)
+		klog.Error(Format("PlatformAdmin %s/%s could not be upgraded, %s", platformAdmin.Namespace, platformAdmin.Name, message))
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1beta1.VersionUpgradedCondition, corev1.ConditionFalse, iotv1beta1.VersionIncompatibleReason, message))
+		return false, nil
+	}
+	if err := r.writeFramework(ctx, platformAdmin, platformAdminFramework); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// migrateFramework replaces the standard configmaps and components of the framework with the ones of the target version,
+// the values of the configmaps customized by the user are kept and the registered migration hooks are run afterwards.
+func (r *ReconcilePlatformAdmin) migrateFramework(platformAdmin *iotv1beta1.PlatformAdmin, platformAdminFramework *PlatformAdminFramework, from, to string) error {
+	var (
+		oldConfigMaps, newConfigMaps []corev1.ConfigMap
+		newComponents                []*config.Component
+	)
+	if platformAdmin.Spec.Security {
+		oldConfigMaps = r.Configuration.SecurityConfigMaps[from]
+		newConfigMaps = r.Configuration.SecurityConfigMaps[to]
+		newComponents = r.Configuration.SecurityComponents[to]
+	} else {
+		oldConfigMaps = r.Configuration.NoSectyConfigMaps[from]
+		newConfigMaps = r.Configuration.NoSectyConfigMaps[to]
+		newComponents = r.Configuration.NoSectyComponents[to]
+	}
+	if platformAdmin.Spec.Platform != iotv1beta1.PlatformAdminPlatformMQTT {
+		platformAdminFramework.ConfigMaps = config.MigrateConfigMaps(platformAdminFramework.ConfigMaps, oldConfigMaps, newConfigMaps)
+	}
+
+	standardComponents := make(map[string]*config.Component, len(newComponents))
+	for _, component := range newComponents {
+		standardComponents[component.Name] = component
+	}
+	components := make([]*config.Component, 0, len(platformAdminFramework.Components))
+	hasIotDock := false
+	for _, component := range platformAdminFramework.Components {
+		if component.Name == util.IotDockName {
+			hasIotDock = true
+			continue
+		}
+		if standard, ok := standardComponents[component.Name]; ok {
+			// The standard components are shared by all the PlatformAdmins and must not be modified by the hooks
+			component = &config.Component{
+				Name:       standard.Name,
+				Service:    standard.Service.DeepCopy(),
+				Deployment: standard.Deployment.DeepCopy(),
+			}
+		}
+		components = append(components, component)
+	}
+	platformAdminFramework.Components = components
+
+	// The yurt-iot-dock is generated for the target version
+	if hasIotDock {
+		yurtIotDock, err := newYurtIoTDockComponent(platformAdmin, platformAdminFramework)
+		if err != nil {
+			return err
+		}
+		platformAdminFramework.Components = append(platformAdminFramework.Components, yurtIotDock)
+	}
+
+	if err := config.RunMigrationHooks(from, to, platformAdminFramework.ConfigMaps, platformAdminFramework.Components); err != nil {
+		return err
+	}
+	platformAdminFramework.Version = to
+	return nil
+}
+
+// isStagedUpgrade returns whether the nodepools of PlatformAdmin are not upgraded at once
+func isStagedUpgrade(platformAdmin *iotv1beta1.PlatformAdmin) bool {
+	maxUnavailable := 1
+	if strategy := platformAdmin.Spec.UpgradeStrategy; strategy != nil && strategy.MaxUnavailable > 0 {
+		maxUnavailable = int(strategy.MaxUnavailable)
+	}
+	return len(platformAdmin.Spec.NodePools) > maxUnavailable
+}
+
+// isConfigMapsChanged returns whether the data of the configmaps is changed by the migration
+func isConfigMapsChanged(current, migrated []corev1.ConfigMap) bool {
+	if len(current) != len(migrated) {
+		return true
+	}
+	currentData := make(map[string]map[string]string, len(current))
+	for _, cm := range current {
+		currentData[cm.Name] = cm.Data
+	}
+	for _, cm := range migrated {
+		data, ok := currentData[cm.Name]
+		if !ok || len(data) != len(cm.Data) {
+			return true
+		}
+		for k, v := range cm.Data {
+			if value, ok := data[k]; !ok || value != v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// syncPoolVersions keeps a version entry for each nodepool of PlatformAdmin in the order of the spec,
+// the nodepools which are added run the version of PlatformAdmin directly.
+func syncPoolVersions(platformAdmin *iotv1beta1.PlatformAdmin, platformAdminStatus *iotv1beta1.PlatformAdminStatus) {
+	poolVersions := make([]iotv1beta1.PlatformAdminPoolVersion, 0, len(platformAdmin.Spec.NodePools))
+	for _, poolName := range platformAdmin.Spec.NodePools {
+		poolVersion := iotv1beta1.PlatformAdminPoolVersion{NodePool: poolName, TargetVersion: platformAdmin.Spec.Version}
+		for _, pv := range platformAdminStatus.PoolVersions {
+			if pv.NodePool == poolName {
+				poolVersion = pv
+				break
+			}
+		}
+		poolVersions = append(poolVersions, poolVersion)
+	}
+	platformAdminStatus.PoolVersions = poolVersions
+}
+
+// isPoolPinned returns whether the nodepool has not started upgrading to the version of PlatformAdmin
+func isPoolPinned(platformAdmin *iotv1beta1.PlatformAdmin, platformAdminStatus *iotv1beta1.PlatformAdminStatus, poolName string) bool {
+	for _, pv := range platformAdminStatus.PoolVersions {
+		if pv.NodePool == poolName {
+			return pv.TargetVersion != platformAdmin.Spec.Version
+		}
+	}
+	return false
+}
+
+func isPinnedTweak(tweak *appsv1beta1.WorkloadTweak) bool {
+	return len(tweak.Pools) == 1 && len(tweak.Patches) == 1 && tweak.Patches[0].Path == pinnedTemplatePath
+}
+
+// pinNodePools pins the nodepools which have not started upgrading to the pod template which the YurtAppSet runs
+// before it is refreshed with the desired deployment, and unpins the nodepools which have started upgrading.
+func pinNodePools(platformAdmin *iotv1beta1.PlatformAdmin, platformAdminStatus *iotv1beta1.PlatformAdminStatus, yas *appsv1beta1.YurtAppSet, desired *appsv1.DeploymentSpec) error {
+	pinnedPools := make(map[string]struct{})
+	tweaks := make([]appsv1beta1.WorkloadTweak, 0, len(yas.Spec.Workload.WorkloadTweaks))
+	for i := range yas.Spec.Workload.WorkloadTweaks {
+		tweak := yas.Spec.Workload.WorkloadTweaks[i]
+		if isPinnedTweak(&tweak) {
+			if !isPoolPinned(platformAdmin, platformAdminStatus, tweak.Pools[0]) {
+				continue
+			}
+			pinnedPools[tweak.Pools[0]] = struct{}{}
+		}
+		tweaks = append(tweaks, tweak)
+	}
+
+	current := yas.Spec.Workload.WorkloadTemplate.DeploymentTemplate
+	if current != nil && !equality.Semantic.DeepEqual(current.Spec.Template, desired.Template) {
+		raw, err := json.Marshal(current.Spec.Template)
+		if err != nil {
+			return err
+		}
+		for _, poolName := range yas.Spec.Pools {
+			if _, ok := pinnedPools[poolName]; ok || !isPoolPinned(platformAdmin, platformAdminStatus, poolName) {
+				continue
+			}
+			tweaks = append(tweaks, appsv1beta1.WorkloadTweak{
+				Pools: []string{poolName},
+				Tweaks: appsv1beta1.Tweaks{
+					Patches: []appsv1beta1.Patch{
+						{
+							Path:      pinnedTemplatePath,
+							Operation: appsv1beta1.REPLACE,
+							Value:     apiextensionsv1.JSON{Raw: raw},
+						},
+					},
+				},
+			})
+		}
+	}
+	yas.Spec.Workload.WorkloadTweaks = tweaks
+	return nil
+}
+
+// reconcilePoolVersions records the nodepools whose components are upgraded and starts upgrading
+// the next nodepools according to the upgrade strategy.
+func (r *ReconcilePlatformAdmin) reconcilePoolVersions(ctx context.Context, platformAdmin *iotv1beta1.PlatformAdmin, platformAdminStatus *iotv1beta1.PlatformAdminStatus, platformAdminFramework *PlatformAdminFramework) error {
+	version := platformAdmin.Spec.Version
+	deadline := progressDeadline(platformAdmin)
+	now := metav1.Now()
+	upgraded, upgrading := 0, 0
+	var stalled []string
+	for i := range platformAdminStatus.PoolVersions {
+		pv := &platformAdminStatus.PoolVersions[i]
+		if pv.TargetVersion != version {
+			continue
+		}
+		if pv.CurrentVersion != version {
+			ok, err := r.isNodePoolUpgraded(ctx, platformAdmin, platformAdminFramework, pv.NodePool)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				upgrading++
+				if pv.UpgradeStartTime == nil {
+					pv.UpgradeStartTime = &now
+				} else if now.Sub(pv.UpgradeStartTime.Time) > deadline {
+					stalled = append(stalled, pv.NodePool)
+				}
+				continue
+			}
+			klog.V(4).Info(Format("NodePool %s of PlatformAdmin %s/%s is upgraded to %s", pv.NodePool, platformAdmin.Namespace, platformAdmin.Name, version))
+			pv.CurrentVersion = version
+		}
+		pv.UpgradeStartTime = nil
+		upgraded++
+	}
+
+	// The stalled nodepools count as upgrading, so the next nodepools are not upgraded until they are fixed or rolled back
+	if len(stalled) != 0 {
+		message := fmt.Sprintf("nodepools %s are not upgraded to %s within %s", strings.Join(stalled, ","), version, deadline)
+		if cond := util.GetPlatformAdminCondition(*platformAdminStatus, iotv1beta1.VersionUpgradedCondition); cond == nil || cond.Reason != iotv1beta1.VersionUpgradeStalledReason {
+			klog.Error(Format("PlatformAdmin %s/%s upgrade stalled, %s", platformAdmin.Namespace, platformAdmin.Name, message))
+			r.recorder.Event(platformAdmin, corev1.EventTypeWarning, iotv1beta1.VersionUpgradeStalledReason, message)
+		}
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1beta1.VersionUpgradedCondition, corev1.ConditionFalse, iotv1beta1.VersionUpgradeStalledReason, message))
+		return nil
+	}
+
+	if platformAdminStatus.Version == version {
+		if upgrading == 0 {
+			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1beta1.VersionUpgradedCondition, corev1.ConditionTrue, "", ""))
+		}
+		return nil
+	}
+	if upgraded == len(platformAdminStatus.PoolVersions) {
+		klog.Info(Format("PlatformAdmin %s/%s is upgraded from %s to %s", platformAdmin.Namespace, platformAdmin.Name, platformAdminStatus.Version, version))
+		platformAdminStatus.Version = version
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1beta1.VersionUpgradedCondition, corev1.ConditionTrue, "", ""))
+		return nil
+	}
+
+	// Start upgrading the next nodepools in the order of the spec
+	maxUnavailable, paused := 1, false
+	if strategy := platformAdmin.Spec.UpgradeStrategy; strategy != nil {
+		if strategy.MaxUnavailable > 0 {
+			maxUnavailable = int(strategy.MaxUnavailable)
+		}
+		paused = strategy.Paused
+	}
+	for i := range platformAdminStatus.PoolVersions {
+		pv := &platformAdminStatus.PoolVersions[i]
+		if paused || upgrading >= maxUnavailable {
+			break
+		}
+		if pv.TargetVersion != version {
+			klog.V(4).Info(Format("Start upgrading NodePool %s of PlatformAdmin %s/%s to %s", pv.NodePool, platformAdmin.Namespace, platformAdmin.Name, version))
+			pv.TargetVersion = version
+			upgrading++
+		}
+	}
+
+	reason := iotv1beta1.VersionUpgradingReason
+	if paused {
+		reason = iotv1beta1.VersionUpgradePausedReason
+	}
+	message := fmt.Sprintf("upgrading from %s to %s, %d/%d nodepools upgraded", platformAdminStatus.Version, version, upgraded, len(platformAdminStatus.PoolVersions))
+	util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1beta1.VersionUpgradedCondition, corev1.ConditionFalse, reason, message))
+	return nil
+}
+
+// progressDeadline returns the maximum time for a nodepool to be upgraded
+func progressDeadline(platformAdmin *iotv1beta1.PlatformAdmin) time.Duration {
+	if strategy := platformAdmin.Spec.UpgradeStrategy; strategy != nil && strategy.ProgressDeadlineSeconds > 0 {
+		return time.Duration(strategy.ProgressDeadlineSeconds) * time.Second
+	}
+	return defaultProgressDeadline
+}
+
+// isUpgradeStalled returns whether the nodepools are not upgraded in time, the upgrade waits for the nodepools
+// to be fixed or the version to be rolled back then
+func isUpgradeStalled(platformAdminStatus *iotv1beta1.PlatformAdminStatus) bool {
+	cond := util.GetPlatformAdminCondition(*platformAdminStatus, iotv1beta1.VersionUpgradedCondition)
+	return cond != nil && cond.Reason == iotv1beta1.VersionUpgradeStalledReason
+}
+
+// isNodePoolUpgraded returns whether the workloads of all the components in the nodepool run the desired pod template and are available
+func (r *ReconcilePlatformAdmin) isNodePoolUpgraded(ctx context.Context, platformAdmin *iotv1beta1.PlatformAdmin, platformAdminFramework *PlatformAdminFramework, poolName string) (bool, error) {
+	for _, component := range platformAdminFramework.Components {
+		if component.Deployment == nil {
+			continue
+		}
+		deployments := &appsv1.DeploymentList{}
+		if err := r.List(ctx, deployments, client.InNamespace(platformAdmin.Namespace), client.MatchingLabels{
+			apps.PoolNameLabelKey:        poolName,
+			apps.YurtAppSetOwnerLabelKey: platformAdmin.Name + "-" + component.Name,
+		}); err != nil {
+			return false, err
+		}
+		if len(deployments.Items) == 0 {
+			return false, nil
+		}
+		for i := range deployments.Items {
+			if !isDeploymentUpgraded(&deployments.Items[i], component.Deployment) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func isDeploymentUpgraded(deployment *appsv1.Deployment, desired *appsv1.DeploymentSpec) bool {
+	images := make(map[string]string, len(deployment.Spec.Template.Spec.Containers))
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		images[c.Name] = c.Image
+	}
+	for _, c := range desired.Template.Spec.Containers {
+		if images[c.Name] != c.Image {
+			return false
+		}
+	}
+
+	replicas := ptr.Deref(deployment.Spec.Replicas, 1)
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas
+}
+
+// isUpgrading returns whether the nodepools of PlatformAdmin have not all run its version
+func isUpgrading(platformAdmin *iotv1beta1.PlatformAdmin, platformAdminStatus *iotv1beta1.PlatformAdminStatus) bool {
+	if platformAdminStatus.Version != platformAdmin.Spec.Version {
+		return true
+	}
+	for _, pv := range platformAdminStatus.PoolVersions {
+		if pv.CurrentVersion != platformAdmin.Spec.Version {
+			return true
+		}
+	}
+	return false
+}
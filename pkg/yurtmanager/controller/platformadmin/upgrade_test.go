@@ -0,0 +1,360 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps"
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
+	iotv1beta1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/platformadmin/utils"
+)
+
+// markPoolReady simulates the yurtappset controller rolling the template out to the nodepool
+func markPoolReady(t *testing.T, c client.Client, poolName string) {
+	yasList := &v1beta1.YurtAppSetList{}
+	require.NoError(t, c.List(context.TODO(), yasList))
+	for _, yas := range yasList.Items {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      yas.Name + "-" + poolName,
+				Namespace: "default",
+				Labels:    map[string]string{apps.PoolNameLabelKey: poolName, apps.YurtAppSetOwnerLabelKey: yas.Name},
+			},
+		}
+		err := c.Get(context.TODO(), client.ObjectKeyFromObject(deployment), deployment)
+		if apierrors.IsNotFound(err) {
+			require.NoError(t, c.Create(context.TODO(), deployment))
+		} else {
+			require.NoError(t, err)
+		}
+		deployment.Spec = yas.Spec.Workload.WorkloadTemplate.DeploymentTemplate.Spec
+		deployment.Spec.Replicas = ptr.To[int32](1)
+		require.NoError(t, c.Update(context.TODO(), deployment))
+		deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: deployment.Generation, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+		require.NoError(t, c.Status().Update(context.TODO(), deployment))
+	}
+}
+
+func TestUpgradePlatformAdmin(t *testing.T) {
+	platformAdmin := &iotv1beta1.PlatformAdmin{
+		TypeMeta:   metav1.TypeMeta{Kind: "PlatformAdmin", APIVersion: iotv1beta1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "edgex", Namespace: "default"},
+		Spec: iotv1beta1.PlatformAdminSpec{
+			Version:   "levski",
+			Platform:  iotv1beta1.PlatformAdminPlatformEdgeX,
+			NodePools: []string{"pool1", "pool2"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(fakeScheme).WithObjects(platformAdmin).
+		WithStatusSubresource(&iotv1beta1.PlatformAdmin{}).Build()
+	r := &ReconcilePlatformAdmin{
+		Client:         c,
+		scheme:         fakeScheme,
+		recorder:       &fakeEventRecorder{},
+		yamlSerializer: kjson.NewSerializerWithOptions(kjson.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, kjson.SerializerOptions{Yaml: true, Pretty: true}),
+		Configuration:  *config.NewPlatformAdminControllerConfiguration(),
+	}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(platformAdmin)}
+
+	reconcilePlatformAdmin := func() *iotv1beta1.PlatformAdmin {
+		_, err := r.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		got := &iotv1beta1.PlatformAdmin{}
+		require.NoError(t, c.Get(context.TODO(), request.NamespacedName, got))
+		return got
+	}
+	getYas := func(component string) *v1beta1.YurtAppSet {
+		yas := &v1beta1.YurtAppSet{}
+		require.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "edgex-" + component}, yas))
+		return yas
+	}
+	pinnedPools := func(component string) []string {
+		var pools []string
+		for _, tweak := range getYas(component).Spec.Workload.WorkloadTweaks {
+			if isPinnedTweak(&tweak) {
+				pools = append(pools, tweak.Pools...)
+			}
+		}
+		return pools
+	}
+	// the nodepools run the version once their components are available
+	got := reconcilePlatformAdmin()
+	assert.Equal(t, "levski", got.Status.Version)
+	markPoolReady(t, c, "pool1")
+	markPoolReady(t, c, "pool2")
+	got = reconcilePlatformAdmin()
+	assert.Equal(t, []iotv1beta1.PlatformAdminPoolVersion{
+		{NodePool: "pool1", CurrentVersion: "levski", TargetVersion: "levski"},
+		{NodePool: "pool2", CurrentVersion: "levski", TargetVersion: "levski"},
+	}, got.Status.PoolVersions)
+	assert.Equal(t, corev1.ConditionTrue, util.GetPlatformAdminCondition(got.Status, iotv1beta1.VersionUpgradedCondition).Status)
+
+	// the framework is migrated and the nodepools are upgraded one by one
+	got.Spec.Version = "minnesota"
+	require.NoError(t, c.Update(context.TODO(), got))
+	got = reconcilePlatformAdmin()
+	assert.Equal(t, "levski", got.Status.Version)
+	assert.Equal(t, "minnesota", got.Status.PoolVersions[0].TargetVersion)
+	assert.Equal(t, "levski", got.Status.PoolVersions[1].TargetVersion)
+	cond := util.GetPlatformAdminCondition(got.Status, iotv1beta1.VersionUpgradedCondition)
+	assert.Equal(t, iotv1beta1.VersionUpgradingReason, cond.Reason)
+	assert.Contains(t, cond.Message, "0/2")
+	framework, err := r.readFramework(context.TODO(), got)
+	require.NoError(t, err)
+	assert.Equal(t, "minnesota", framework.Version)
+	assert.Equal(t, "openyurt/core-command:3.0.0", getYas("edgex-core-command").Spec.Workload.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image)
+
+	got = reconcilePlatformAdmin()
+	assert.Equal(t, []string{"pool2"}, pinnedPools("edgex-core-command"))
+	markPoolReady(t, c, "pool1")
+	got = reconcilePlatformAdmin()
+	assert.Equal(t, "minnesota", got.Status.PoolVersions[0].CurrentVersion)
+	assert.Equal(t, "minnesota", got.Status.PoolVersions[1].TargetVersion)
+
+	got = reconcilePlatformAdmin()
+	assert.Empty(t, pinnedPools("edgex-core-command"))
+	markPoolReady(t, c, "pool2")
+	got = reconcilePlatformAdmin()
+	assert.Equal(t, "minnesota", got.Status.Version)
+	assert.Equal(t, corev1.ConditionTrue, util.GetPlatformAdminCondition(got.Status, iotv1beta1.VersionUpgradedCondition).Status)
+
+	// the incompatible version is not rolled out
+	got.Spec.Version = "hanoi"
+	require.NoError(t, c.Update(context.TODO(), got))
+	got = reconcilePlatformAdmin()
+	cond = util.GetPlatformAdminCondition(got.Status, iotv1beta1.VersionUpgradedCondition)
+	assert.Equal(t, iotv1beta1.VersionIncompatibleReason, cond.Reason)
+	assert.Equal(t, "openyurt/core-command:3.0.0", getYas("edgex-core-command").Spec.Workload.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestReconcilePoolVersionsWithStrategy(t *testing.T) {
+	tests := map[string]struct {
+		strategy *iotv1beta1.PlatformAdminUpgradeStrategy
+		targets  []string
+		reason   string
+	}{
+		"default strategy": {
+			targets: []string{"minnesota", "levski", "levski"},
+			reason:  iotv1beta1.VersionUpgradingReason,
+		},
+		"max unavailable": {
+			strategy: &iotv1beta1.PlatformAdminUpgradeStrategy{MaxUnavailable: 2},
+			targets:  []string{"minnesota", "minnesota", "levski"},
+			reason:   iotv1beta1.VersionUpgradingReason,
+		},
+		"paused": {
+			strategy: &iotv1beta1.PlatformAdminUpgradeStrategy{Paused: true},
+			targets:  []string{"levski", "levski", "levski"},
+			reason:   iotv1beta1.VersionUpgradePausedReason,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			platformAdmin := &iotv1beta1.PlatformAdmin{
+				ObjectMeta: metav1.ObjectMeta{Name: "edgex", Namespace: "default"},
+				Spec: iotv1beta1.PlatformAdminSpec{
+					Version:         "minnesota",
+					NodePools:       []string{"pool1", "pool2", "pool3"},
+					UpgradeStrategy: tt.strategy,
+				},
+			}
+			status := &iotv1beta1.PlatformAdminStatus{Version: "levski"}
+			for _, poolName := range platformAdmin.Spec.NodePools {
+				status.PoolVersions = append(status.PoolVersions, iotv1beta1.PlatformAdminPoolVersion{NodePool: poolName, CurrentVersion: "levski", TargetVersion: "levski"})
+			}
+			r := &ReconcilePlatformAdmin{Client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()}
+			require.NoError(t, r.reconcilePoolVersions(context.TODO(), platformAdmin, status, &PlatformAdminFramework{}))
+
+			var targets []string
+			for _, pv := range status.PoolVersions {
+				targets = append(targets, pv.TargetVersion)
+			}
+			assert.Equal(t, tt.targets, targets)
+			assert.Equal(t, tt.reason, util.GetPlatformAdminCondition(*status, iotv1beta1.VersionUpgradedCondition).Reason)
+		})
+	}
+}
+
+func TestRollbackPlatformAdmin(t *testing.T) {
+	platformAdmin := &iotv1beta1.PlatformAdmin{
+		TypeMeta:   metav1.TypeMeta{Kind: "PlatformAdmin", APIVersion: iotv1beta1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "edgex", Namespace: "default"},
+		Spec: iotv1beta1.PlatformAdminSpec{
+			Version:   "levski",
+			Platform:  iotv1beta1.PlatformAdminPlatformEdgeX,
+			NodePools: []string{"pool1", "pool2"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(fakeScheme).WithObjects(platformAdmin).
+		WithStatusSubresource(&iotv1beta1.PlatformAdmin{}).Build()
+	r := &ReconcilePlatformAdmin{
+		Client:         c,
+		scheme:         fakeScheme,
+		recorder:       &fakeEventRecorder{},
+		yamlSerializer: kjson.NewSerializerWithOptions(kjson.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, kjson.SerializerOptions{Yaml: true, Pretty: true}),
+		Configuration:  *config.NewPlatformAdminControllerConfiguration(),
+	}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(platformAdmin)}
+	reconcilePlatformAdmin := func() *iotv1beta1.PlatformAdmin {
+		_, err := r.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		got := &iotv1beta1.PlatformAdmin{}
+		require.NoError(t, c.Get(context.TODO(), request.NamespacedName, got))
+		return got
+	}
+
+	reconcilePlatformAdmin()
+	markPoolReady(t, c, "pool1")
+	markPoolReady(t, c, "pool2")
+	got := reconcilePlatformAdmin()
+	got.Spec.Version = "minnesota"
+	require.NoError(t, c.Update(context.TODO(), got))
+	reconcilePlatformAdmin()
+	markPoolReady(t, c, "pool1")
+	got = reconcilePlatformAdmin()
+	assert.Equal(t, "minnesota", got.Status.PoolVersions[0].CurrentVersion)
+	assert.Equal(t, "levski", got.Status.Version)
+
+	// rolling back to the version of the nodepools is applied to all the nodepools at once
+	got.Spec.Version = "levski"
+	require.NoError(t, c.Update(context.TODO(), got))
+	got = reconcilePlatformAdmin()
+	for _, pv := range got.Status.PoolVersions {
+		assert.Equal(t, "levski", pv.TargetVersion)
+	}
+	framework, err := r.readFramework(context.TODO(), got)
+	require.NoError(t, err)
+	assert.Equal(t, "levski", framework.Version)
+
+	markPoolReady(t, c, "pool1")
+	markPoolReady(t, c, "pool2")
+	got = reconcilePlatformAdmin()
+	assert.Equal(t, "levski", got.Status.PoolVersions[0].CurrentVersion)
+	assert.Equal(t, corev1.ConditionTrue, util.GetPlatformAdminCondition(got.Status, iotv1beta1.VersionUpgradedCondition).Status)
+}
+
+func TestReconcilePoolVersionsStalled(t *testing.T) {
+	platformAdmin := &iotv1beta1.PlatformAdmin{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgex", Namespace: "default"},
+		Spec: iotv1beta1.PlatformAdminSpec{
+			Version:         "minnesota",
+			NodePools:       []string{"pool1", "pool2"},
+			UpgradeStrategy: &iotv1beta1.PlatformAdminUpgradeStrategy{ProgressDeadlineSeconds: 60},
+		},
+	}
+	startTime := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	status := &iotv1beta1.PlatformAdminStatus{
+		Version: "levski",
+		PoolVersions: []iotv1beta1.PlatformAdminPoolVersion{
+			{NodePool: "pool1", CurrentVersion: "levski", TargetVersion: "minnesota", UpgradeStartTime: &startTime},
+			{NodePool: "pool2", CurrentVersion: "levski", TargetVersion: "levski"},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &ReconcilePlatformAdmin{Client: fake.NewClientBuilder().WithScheme(fakeScheme).Build(), recorder: recorder}
+	framework := &PlatformAdminFramework{Components: []*config.Component{{Name: "edgex-core-data", Deployment: &appsv1.DeploymentSpec{}}}}
+
+	// the nodepool which is not upgraded in time is reported, and the next nodepools are not upgraded
+	require.NoError(t, r.reconcilePoolVersions(context.TODO(), platformAdmin, status, framework))
+	assert.Equal(t, "levski", status.PoolVersions[1].TargetVersion)
+	cond := util.GetPlatformAdminCondition(*status, iotv1beta1.VersionUpgradedCondition)
+	assert.Equal(t, iotv1beta1.VersionUpgradeStalledReason, cond.Reason)
+	assert.Contains(t, cond.Message, "pool1")
+	assert.True(t, isUpgradeStalled(status))
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, iotv1beta1.VersionUpgradeStalledReason)
+
+	// the event is emitted once
+	require.NoError(t, r.reconcilePoolVersions(context.TODO(), platformAdmin, status, framework))
+	assert.Empty(t, recorder.Events)
+}
+
+func TestUpgradePlatformAdminWithConfigChanges(t *testing.T) {
+	platformAdmin := &iotv1beta1.PlatformAdmin{
+		TypeMeta:   metav1.TypeMeta{Kind: "PlatformAdmin", APIVersion: iotv1beta1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "edgex", Namespace: "default"},
+		Spec: iotv1beta1.PlatformAdminSpec{
+			Version:   "minnesota",
+			Platform:  iotv1beta1.PlatformAdminPlatformEdgeX,
+			Security:  true,
+			NodePools: []string{"pool1", "pool2"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(fakeScheme).WithObjects(platformAdmin).
+		WithStatusSubresource(&iotv1beta1.PlatformAdmin{}).Build()
+	r := &ReconcilePlatformAdmin{
+		Client:         c,
+		scheme:         fakeScheme,
+		recorder:       &fakeEventRecorder{},
+		yamlSerializer: kjson.NewSerializerWithOptions(kjson.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, kjson.SerializerOptions{Yaml: true, Pretty: true}),
+		Configuration:  *config.NewPlatformAdminControllerConfiguration(),
+	}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(platformAdmin)}
+	reconcilePlatformAdmin := func() *iotv1beta1.PlatformAdmin {
+		_, err := r.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		got := &iotv1beta1.PlatformAdmin{}
+		require.NoError(t, c.Get(context.TODO(), request.NamespacedName, got))
+		return got
+	}
+	got := reconcilePlatformAdmin()
+	markPoolReady(t, c, "pool1")
+	markPoolReady(t, c, "pool2")
+	got = reconcilePlatformAdmin()
+	assert.Equal(t, corev1.ConditionTrue, util.GetPlatformAdminCondition(got.Status, iotv1beta1.VersionUpgradedCondition).Status)
+
+	// the configuration of napa differs from minnesota, so it is not upgraded in stages
+	got.Spec.Version = "napa"
+	require.NoError(t, c.Update(context.TODO(), got))
+	got = reconcilePlatformAdmin()
+	cond := util.GetPlatformAdminCondition(got.Status, iotv1beta1.VersionUpgradedCondition)
+	assert.Equal(t, iotv1beta1.VersionIncompatibleReason, cond.Reason)
+	assert.Contains(t, cond.Message, "maxUnavailable")
+	framework, err := r.readFramework(context.TODO(), got)
+	require.NoError(t, err)
+	assert.Equal(t, "minnesota", framework.Version)
+
+	// all the nodepools are upgraded at once
+	got.Spec.UpgradeStrategy = &iotv1beta1.PlatformAdminUpgradeStrategy{MaxUnavailable: 2}
+	require.NoError(t, c.Update(context.TODO(), got))
+	got = reconcilePlatformAdmin()
+	framework, err = r.readFramework(context.TODO(), got)
+	require.NoError(t, err)
+	assert.Equal(t, "napa", framework.Version)
+	assert.Equal(t, "napa", got.Status.PoolVersions[0].TargetVersion)
+	assert.Equal(t, "napa", got.Status.PoolVersions[1].TargetVersion)
+}
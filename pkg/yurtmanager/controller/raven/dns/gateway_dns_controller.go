@@ -34,6 +34,8 @@ import (
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -42,6 +44,9 @@ import (
 	yurtClient "github.com/openyurtio/openyurt/cmd/yurt-manager/app/client"
 	appconfig "github.com/openyurtio/openyurt/cmd/yurt-manager/app/config"
 	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
+	appsv1beta2 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta2"
+	networkv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/network/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
 )
 
@@ -84,20 +89,32 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 	}
 
 	// Watch for changes to service
-	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Service{}, &EnqueueRequestForServiceEvent{}, predicate.NewPredicateFuncs(
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Service{}, &EnqueueRequestForServiceEvent{}, servicePredicate()))
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to raven-cfg, node pools and pool services, which are the sources of service records
+	enqueueDNSConfigmap := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: util.WorkingNamespace, Name: util.RavenProxyNodesConfig}}}
+	})
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.ConfigMap{}, enqueueDNSConfigmap, predicate.NewPredicateFuncs(
 		func(obj client.Object) bool {
-			svc, ok := obj.(*corev1.Service)
-			if !ok {
-				return false
-			}
-			if svc.Spec.Type != corev1.ServiceTypeClusterIP {
-				return false
-			}
-			return svc.Namespace == util.WorkingNamespace && svc.Name == util.GatewayProxyInternalService
+			return obj.GetNamespace() == util.WorkingNamespace && obj.GetName() == util.RavenGlobalConfig
 		})))
 	if err != nil {
 		return err
 	}
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &appsv1beta2.NodePool{}, enqueueDNSConfigmap, predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool { return false },
+	}))
+	if err != nil {
+		return err
+	}
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &networkv1alpha1.PoolService{}, enqueueDNSConfigmap))
+	if err != nil {
+		return err
+	}
 	//Watch for changes to nodes
 	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Node{}, &EnqueueRequestForNodeEvent{}))
 	if err != nil {
@@ -111,13 +128,14 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update;delete
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get
+// +kubebuilder:rbac:groups=network.openyurt.io,resources=poolservices,verbs=get
 
 func (r *ReconcileDns) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
 	klog.V(4).Info(Format("Reconcile DNS configMap for gateway %s", req.Name))
 	defer func() {
 		klog.V(4).Info(Format("finished DNS configMap for gateway %s", req.Name))
 	}()
-	var proxyAddress = ""
+	var proxyAddresses []string
 	//1. ensure configmap to record dns
 	cm, err := r.getProxyDNS(ctx, client.ObjectKey{Namespace: util.WorkingNamespace, Name: util.RavenProxyNodesConfig})
 	if err != nil {
@@ -138,10 +156,9 @@ func (r *ReconcileDns) Reconcile(ctx context.Context, req reconcile.Request) (re
 			klog.Infoln(Format("the proxy feature lacks service %s/%s", util.WorkingNamespace, util.GatewayProxyInternalService))
 		}
 		if svc != nil {
-			if svc.Spec.ClusterIP == "" {
+			proxyAddresses = getClusterIPs(svc)
+			if len(proxyAddresses) == 0 {
 				klog.Infof("the service %s/%s cluster IP is empty", util.WorkingNamespace, util.GatewayProxyInternalService)
-			} else {
-				proxyAddress = svc.Spec.ClusterIP
 			}
 		}
 	}
@@ -153,7 +170,31 @@ func (r *ReconcileDns) Reconcile(ctx context.Context, req reconcile.Request) (re
 		klog.Error(Format("could not list node, error %s", err.Error()))
 		return reconcile.Result{Requeue: true, RequeueAfter: 2 * time.Second}, err
 	}
-	cm.Data[util.ProxyNodesKey] = buildDNSRecords(&nodeList, enableProxy, proxyAddress)
+	records := buildDNSRecords(&nodeList, enableProxy, proxyAddresses)
+
+	//4. update service records
+	recordCfg := getRecordConfig(ctx, r.Client)
+	svcRecords, srvRecords, err := r.buildServiceDNSRecords(ctx, proxyAddresses, recordCfg)
+	if err != nil {
+		klog.Error(Format("could not build service dns records, error %s", err.Error()))
+		return reconcile.Result{Requeue: true, RequeueAfter: 2 * time.Second}, err
+	}
+	if len(svcRecords) != 0 {
+		if records != "" {
+			svcRecords = append([]string{records}, svcRecords...)
+		}
+		records = strings.Join(svcRecords, "\n")
+	}
+	cm.Data[util.ProxyNodesKey] = records
+	// the zone is kept even if it has no SRV records, so that the file plugin of raven proxy dns always loads it,
+	// and the file plugin is only imported by raven proxy dns when SRV records are exported
+	if recordCfg.exportSRV {
+		cm.Data[util.SRVRecordsKey] = buildSRVZone(recordCfg.domain, srvRecords)
+		cm.Data[util.SRVZoneKey] = buildSRVZoneImport(recordCfg.domain)
+	} else {
+		delete(cm.Data, util.SRVRecordsKey)
+		delete(cm.Data, util.SRVZoneKey)
+	}
 	err = r.updateDNS(cm)
 	if err != nil {
 		klog.Error(Format("could not update configmap %s/%s, error %s",
@@ -163,6 +204,67 @@ func (r *ReconcileDns) Reconcile(ctx context.Context, req reconcile.Request) (re
 	return reconcile.Result{}, nil
 }
 
+// servicePredicate filters the services which are the sources of dns records. An update is accepted if the service
+// is a source before or after it, so that the records are removed once the service is not exported anymore.
+func servicePredicate() predicate.Funcs {
+	isSource := func(obj client.Object) bool {
+		svc, ok := obj.(*corev1.Service)
+		if !ok {
+			return false
+		}
+		if isExportedService(svc) {
+			return true
+		}
+		return svc.Spec.Type == corev1.ServiceTypeClusterIP &&
+			svc.Namespace == util.WorkingNamespace && svc.Name == util.GatewayProxyInternalService
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isSource(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isSource(e.ObjectOld) || isSource(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isSource(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return isSource(e.Object)
+		},
+	}
+}
+
+// buildServiceDNSRecords lists the sources of service records and returns the records of exported services.
+func (r *ReconcileDns) buildServiceDNSRecords(ctx context.Context, proxyIPs []string, cfg recordConfig) ([]string, []string, error) {
+	var svcList corev1.ServiceList
+	if err := r.Client.List(ctx, &svcList, client.MatchingLabels{raven.LabelDNSExport: "true"}); err != nil {
+		return nil, nil, fmt.Errorf("could not list exported services, %s", err.Error())
+	}
+	exported := make([]corev1.Service, 0, len(svcList.Items))
+	for i := range svcList.Items {
+		if isExportedService(&svcList.Items[i]) {
+			exported = append(exported, svcList.Items[i])
+		}
+	}
+	if len(exported) == 0 {
+		return nil, nil, nil
+	}
+	var poolList appsv1beta2.NodePoolList
+	if err := r.Client.List(ctx, &poolList); err != nil {
+		return nil, nil, fmt.Errorf("could not list nodepools, %s", err.Error())
+	}
+	var poolSvcList networkv1alpha1.PoolServiceList
+	if err := r.Client.List(ctx, &poolSvcList); err != nil {
+		return nil, nil, fmt.Errorf("could not list poolservices, %s", err.Error())
+	}
+	pools := make([]string, 0, len(poolList.Items))
+	for _, np := range poolList.Items {
+		pools = append(pools, np.Name)
+	}
+	hosts, srv := buildServiceRecords(exported, pools, poolSvcList.Items, proxyIPs, cfg)
+	return hosts, srv, nil
+}
+
 func (r ReconcileDns) getProxyDNS(ctx context.Context, objKey client.ObjectKey) (*corev1.ConfigMap, error) {
 	var cm corev1.ConfigMap
 	waitErr := wait.PollUntilContextTimeout(ctx, 5*time.Second, time.Minute, true, func(ctx context.Context) (done bool, err error) {
@@ -221,48 +323,58 @@ func (r *ReconcileDns) updateDNS(cm *corev1.ConfigMap) error {
 	return nil
 }
 
-func buildDNSRecords(nodeList *corev1.NodeList, needProxy bool, proxyIp string) string {
+// buildDNSRecords records node name <-> ip address in hosts format for all nodes. A node has a record
+// for each IP family, so both A and AAAA records are resolved for dual-stack nodes.
+func buildDNSRecords(nodeList *corev1.NodeList, needProxy bool, proxyIPs []string) string {
 	// record node name <-> ip address
-	if needProxy && proxyIp == "" {
+	if needProxy && len(proxyIPs) == 0 {
 		klog.Infoln(Format("internal proxy address is empty for dns record, redirect node internal address"))
 		needProxy = false
 	}
 	var err error
 	dns := make([]string, 0, len(nodeList.Items))
 	for _, node := range nodeList.Items {
-		ip := proxyIp
+		ips := proxyIPs
 		if !needProxy {
-			ip, err = getHostIP(&node)
+			ips, err = getHostIPs(&node)
 			if err != nil {
 				klog.Error(Format("could not parse node address for %s, %s", node.Name, err.Error()))
 				continue
 			}
 		}
-		dns = append(dns, fmt.Sprintf("%s\t%s", ip, node.Name))
+		for _, ip := range ips {
+			dns = append(dns, fmt.Sprintf("%s\t%s", ip, node.Name))
+		}
 	}
 	sort.Strings(dns)
 	return strings.Join(dns, "\n")
 }
 
-func getHostIP(node *corev1.Node) (string, error) {
-	// get InternalIPs first and then ExternalIPs
-	var externalIP net.IP
-	for _, addr := range node.Status.Addresses {
-		switch addr.Type {
-		case corev1.NodeInternalIP:
-			ip := net.ParseIP(addr.Address)
-			if ip != nil {
-				return ip.String(), nil
-			}
-		case corev1.NodeExternalIP:
-			ip := net.ParseIP(addr.Address)
-			if ip != nil {
-				externalIP = ip
-			}
+// getHostIPs returns the InternalIPs of node for each IP family, and ExternalIPs if there is no InternalIP.
+func getHostIPs(node *corev1.Node) ([]string, error) {
+	ips := util.GetNodeInternalIPs(*node)
+	if len(ips) == 0 {
+		ips = util.GetNodeExternalIPs(*node)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("host IP unknown; known addresses: %v", node.Status.Addresses)
+	}
+	for i := range ips {
+		ips[i] = net.ParseIP(ips[i]).String()
+	}
+	return ips, nil
+}
+
+// getClusterIPs returns the cluster IPs of service for each IP family.
+func getClusterIPs(svc *corev1.Service) []string {
+	ips := make([]string, 0, len(svc.Spec.ClusterIPs))
+	for _, ip := range svc.Spec.ClusterIPs {
+		if net.ParseIP(ip) != nil {
+			ips = append(ips, ip)
 		}
 	}
-	if externalIP == nil {
-		return "", fmt.Errorf("host IP unknown; known addresses: %v", node.Status.Addresses)
+	if len(ips) == 0 && net.ParseIP(svc.Spec.ClusterIP) != nil {
+		ips = append(ips, svc.Spec.ClusterIP)
 	}
-	return externalIP.String(), nil
+	return ips
 }
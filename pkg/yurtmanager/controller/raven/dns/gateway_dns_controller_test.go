@@ -18,6 +18,7 @@ package dns
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,6 +29,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/openyurtio/openyurt/pkg/apis/raven"
@@ -205,3 +207,90 @@ func TestReconcileDns_getService(t *testing.T) {
 		assert.Equal(t, ProxyIP, svc.Spec.ClusterIP, "expected correct clusterIP")
 	})
 }
+
+func TestBuildDNSRecords(t *testing.T) {
+	nodeList := &v1.NodeList{
+		Items: []v1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "ipv4-node"},
+				Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "192.168.0.1"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "ipv6-node"},
+				Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "fd00::1"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "dual-stack-node"},
+				Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "192.168.0.3"},
+					{Type: v1.NodeInternalIP, Address: "192.168.0.4"},
+					{Type: v1.NodeInternalIP, Address: "fd00::3"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "external-node"},
+				Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+					{Type: v1.NodeExternalIP, Address: "10.0.0.5"},
+				}},
+			},
+		},
+	}
+
+	t.Run("node addresses", func(t *testing.T) {
+		expected := []string{
+			"10.0.0.5\texternal-node",
+			"192.168.0.1\tipv4-node",
+			"192.168.0.3\tdual-stack-node",
+			"fd00::1\tipv6-node",
+			"fd00::3\tdual-stack-node",
+		}
+		assert.Equal(t, strings.Join(expected, "\n"), buildDNSRecords(nodeList, false, nil))
+	})
+
+	t.Run("dual-stack proxy addresses", func(t *testing.T) {
+		records := strings.Split(buildDNSRecords(nodeList, true, []string{"10.96.0.10", "fd00:96::10"}), "\n")
+		assert.Len(t, records, 8)
+		assert.Contains(t, records, "10.96.0.10\tipv6-node")
+		assert.Contains(t, records, "fd00:96::10\tipv4-node")
+	})
+
+	t.Run("proxy address is empty", func(t *testing.T) {
+		records := strings.Split(buildDNSRecords(nodeList, true, nil), "\n")
+		assert.Len(t, records, 5)
+	})
+}
+
+func TestGetClusterIPs(t *testing.T) {
+	svc := &v1.Service{Spec: v1.ServiceSpec{ClusterIP: "10.96.0.10", ClusterIPs: []string{"10.96.0.10", "fd00:96::10"}}}
+	assert.Equal(t, []string{"10.96.0.10", "fd00:96::10"}, getClusterIPs(svc))
+
+	svc = &v1.Service{Spec: v1.ServiceSpec{ClusterIP: "10.96.0.10"}}
+	assert.Equal(t, []string{"10.96.0.10"}, getClusterIPs(svc))
+
+	svc = &v1.Service{Spec: v1.ServiceSpec{ClusterIP: v1.ClusterIPNone, ClusterIPs: []string{v1.ClusterIPNone}}}
+	assert.Empty(t, getClusterIPs(svc))
+}
+
+func TestServicePredicate(t *testing.T) {
+	exported := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{raven.LabelDNSExport: "true"}},
+		Spec:       v1.ServiceSpec{Type: v1.ServiceTypeClusterIP, ClusterIP: "10.96.0.20"},
+	}
+	unexported := exported.DeepCopy()
+	unexported.Labels = nil
+	nodePort := exported.DeepCopy()
+	nodePort.Spec.Type = v1.ServiceTypeNodePort
+
+	p := servicePredicate()
+	assert.True(t, p.Create(event.CreateEvent{Object: exported}))
+	assert.False(t, p.Create(event.CreateEvent{Object: unexported}))
+	assert.False(t, p.Create(event.CreateEvent{Object: nodePort}))
+	// the records are removed once the service is not exported anymore
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: exported, ObjectNew: unexported}))
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: exported, ObjectNew: nodePort}))
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: unexported, ObjectNew: nodePort}))
+}
@@ -18,6 +18,7 @@ package dns
 
 import (
 	"context"
+	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/util/workqueue"
@@ -56,7 +57,8 @@ func (h *EnqueueRequestForServiceEvent) Update(ctx context.Context, e event.Upda
 		klog.Error(Format("could not assert runtime Object to v1.Service"))
 		return
 	}
-	if newSvc.Spec.ClusterIP != oldSvc.Spec.ClusterIP {
+	if newSvc.Spec.ClusterIP != oldSvc.Spec.ClusterIP || isExportedService(newSvc) != isExportedService(oldSvc) ||
+		(isExportedService(newSvc) && !reflect.DeepEqual(newSvc.Spec.Ports, oldSvc.Spec.Ports)) {
 		klog.V(4).Info(Format("enqueue configmap %s/%s due to service update event", util.WorkingNamespace, util.RavenProxyNodesConfig))
 		util.AddDNSConfigmapToWorkQueue(q)
 	}
@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
 )
 
@@ -91,6 +92,22 @@ func TestEnqueueRequestForServiceEvent(t *testing.T) {
 		t.Errorf("failed to update service, expected %d, but get %d", 1, queue.Len())
 	}
 	clearQueue(queue)
+
+	exportedSvc := svc.DeepCopy()
+	exportedSvc.Labels = map[string]string{raven.LabelDNSExport: "true"}
+	h.Update(context.Background(), event.UpdateEvent{ObjectOld: svc, ObjectNew: exportedSvc}, queue)
+	if !assert.Equal(t, 1, queue.Len()) {
+		t.Errorf("failed to update service, expected %d, but get %d", 1, queue.Len())
+	}
+	clearQueue(queue)
+
+	newExportedSvc := exportedSvc.DeepCopy()
+	newExportedSvc.Spec.Ports = []corev1.ServicePort{{Name: "http", Port: 80}}
+	h.Update(context.Background(), event.UpdateEvent{ObjectOld: exportedSvc, ObjectNew: newExportedSvc}, queue)
+	if !assert.Equal(t, 1, queue.Len()) {
+		t.Errorf("failed to update service, expected %d, but get %d", 1, queue.Len())
+	}
+	clearQueue(queue)
 }
 
 func TestEnqueueRequestForNodeEvent(t *testing.T) {
@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/pkg/apis/network"
+	networkv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/network/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
+)
+
+const (
+	defaultDNSDomain      = "local"
+	defaultRecordTemplate = "{{.Name}}.{{.Namespace}}.{{.Pool}}.{{.Domain}}"
+	srvRecordTTL          = 30
+	// srvZoneDir is where the edge-tunnel-nodes configmap is mounted in raven proxy dns
+	srvZoneDir = "/etc/edge"
+)
+
+// recordConfig is the dns record configuration read from raven-cfg.
+type recordConfig struct {
+	// domain is the suffix of the service records, local by default.
+	domain string
+	// templates render the hostnames of a service in a node pool.
+	templates []*template.Template
+	// exportSRV indicates whether SRV records are exported for the named ports of services.
+	exportSRV bool
+}
+
+// recordName is the data to render the record templates.
+type recordName struct {
+	Name      string
+	Namespace string
+	Pool      string
+	Domain    string
+}
+
+// getRecordConfig reads the dns record configuration from raven-cfg, the default configuration
+// is returned if raven-cfg is not found.
+func getRecordConfig(ctx context.Context, c client.Client) recordConfig {
+	var cm corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Namespace: util.WorkingNamespace, Name: util.RavenGlobalConfig}, &cm); err != nil {
+		return parseRecordConfig(nil)
+	}
+	return parseRecordConfig(cm.Data)
+}
+
+func parseRecordConfig(data map[string]string) recordConfig {
+	cfg := recordConfig{domain: defaultDNSDomain}
+	if domain := strings.Trim(strings.TrimSpace(data[util.RavenDNSDomain]), "."); domain != "" {
+		cfg.domain = domain
+	}
+	if val, ok := data[util.RavenDNSExportSRV]; ok && strings.ToLower(val) == "true" {
+		cfg.exportSRV = true
+	}
+
+	texts := []string{defaultRecordTemplate}
+	if val := strings.TrimSpace(data[util.RavenDNSRecordTemplates]); val != "" {
+		texts = strings.Split(val, "\n")
+	}
+	for i, text := range texts {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		tmpl, err := template.New(fmt.Sprintf("record-%d", i)).Option("missingkey=error").Parse(text)
+		if err != nil {
+			klog.Error(Format("could not parse dns record template %q, error %s", text, err.Error()))
+			continue
+		}
+		cfg.templates = append(cfg.templates, tmpl)
+	}
+	return cfg
+}
+
+// buildServiceRecords records hostname <-> ip address in hosts format for the exported services in each node pool,
+// the hostnames are rendered by the record templates. The address of a service in a node pool is the load balancer
+// address of its PoolService, or the gateway proxy address if the proxy is enabled, or its cluster IPs otherwise.
+// SRV records are returned for the named ports of services if exportSRV is set, the records out of the
+// domain are skipped since they are served by the zone of the domain.
+func buildServiceRecords(svcs []corev1.Service, pools []string, poolSvcs []networkv1alpha1.PoolService,
+	proxyIPs []string, cfg recordConfig) (hosts []string, srv []string) {
+	poolAddresses := make(map[string][]string, len(poolSvcs))
+	for i := range poolSvcs {
+		ps := &poolSvcs[i]
+		svcName, poolName := ps.Labels[network.LabelServiceName], ps.Labels[network.LabelNodePoolName]
+		if svcName == "" || poolName == "" {
+			continue
+		}
+		if ips := getIngressIPs(ps); len(ips) != 0 {
+			poolAddresses[poolServiceKey(ps.Namespace, svcName, poolName)] = ips
+		}
+	}
+
+	for i := range svcs {
+		svc := &svcs[i]
+		defaultIPs := proxyIPs
+		if len(defaultIPs) == 0 {
+			defaultIPs = getClusterIPs(svc)
+		}
+		for _, pool := range pools {
+			ips, ok := poolAddresses[poolServiceKey(svc.Namespace, svc.Name, pool)]
+			if !ok {
+				ips = defaultIPs
+			}
+			if len(ips) == 0 {
+				continue
+			}
+			for _, name := range renderRecordNames(cfg, recordName{Name: svc.Name, Namespace: svc.Namespace, Pool: pool, Domain: cfg.domain}) {
+				for _, ip := range ips {
+					hosts = append(hosts, fmt.Sprintf("%s\t%s", ip, name))
+				}
+				if cfg.exportSRV {
+					if !isInZone(name, cfg.domain) {
+						klog.V(4).Info(Format("skip SRV records of %q out of zone %s", name, cfg.domain))
+						continue
+					}
+					srv = append(srv, buildSRVRecords(svc, name)...)
+				}
+			}
+		}
+	}
+	sort.Strings(hosts)
+	sort.Strings(srv)
+	return hosts, srv
+}
+
+// renderRecordNames renders the hostnames of a service in a node pool, invalid hostnames are skipped.
+func renderRecordNames(cfg recordConfig, data recordName) []string {
+	names := make([]string, 0, len(cfg.templates))
+	for _, tmpl := range cfg.templates {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			klog.Error(Format("could not render dns record template %s, error %s", tmpl.Name(), err.Error()))
+			continue
+		}
+		name := strings.ToLower(strings.Trim(strings.TrimSpace(buf.String()), "."))
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+			klog.Error(Format("invalid dns record %q for service %s/%s, %s", name, data.Namespace, data.Name, strings.Join(errs, ", ")))
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// buildSRVRecords returns the SRV records in zone file format for the named ports of service, e.g.
+// _http._tcp.svc.ns.pool.local. 30 IN SRV 0 100 80 svc.ns.pool.local.
+func buildSRVRecords(svc *corev1.Service, name string) []string {
+	records := make([]string, 0, len(svc.Spec.Ports))
+	for _, port := range svc.Spec.Ports {
+		if port.Name == "" {
+			continue
+		}
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		records = append(records, fmt.Sprintf("_%s._%s.%s.\t%d\tIN\tSRV\t0 100 %d %s.",
+			port.Name, strings.ToLower(string(protocol)), name, srvRecordTTL, port.Port, name))
+	}
+	return records
+}
+
+// buildSRVZone returns the zone file of the SRV records which is served by the file plugin of raven proxy dns,
+// the serial of SOA changes with the records, so that the zone is reloaded once the records change.
+func buildSRVZone(domain string, records []string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.Join(records, "\n")))
+	zone := []string{
+		fmt.Sprintf("$ORIGIN %s.", domain),
+		fmt.Sprintf("@\t%d\tIN\tSOA\tns.dns.%s. hostmaster.%s. %d 7200 1800 86400 %d", srvRecordTTL, domain, domain, hash.Sum32(), srvRecordTTL),
+	}
+	return strings.Join(append(zone, records...), "\n") + "\n"
+}
+
+// buildSRVZoneImport returns the file plugin which serves the zone of SRV records, it is imported into the
+// Corefile of raven proxy dns. The queries in the domain which have no SRV records fall through to the next
+// plugins, so that they are still forwarded.
+func buildSRVZoneImport(domain string) string {
+	return fmt.Sprintf("file %s/%s %s {\n    reload 10s\n    fallthrough\n}\n", srvZoneDir, util.SRVRecordsKey, domain)
+}
+
+func isInZone(name, domain string) bool {
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
+
+// getIngressIPs returns the load balancer ip addresses of PoolService.
+func getIngressIPs(ps *networkv1alpha1.PoolService) []string {
+	ips := make([]string, 0, len(ps.Status.LoadBalancer.Ingress))
+	for _, ingress := range ps.Status.LoadBalancer.Ingress {
+		if net.ParseIP(ingress.IP) != nil {
+			ips = append(ips, ingress.IP)
+		}
+	}
+	return ips
+}
+
+func poolServiceKey(namespace, svcName, poolName string) string {
+	return strings.Join([]string{namespace, svcName, poolName}, "/")
+}
+
+// isExportedService checks whether the service is published by the raven proxy dns,
+// only the ClusterIP services labeled to be exported are published.
+func isExportedService(svc *corev1.Service) bool {
+	return svc.Labels[raven.LabelDNSExport] == "true" && svc.Spec.Type == corev1.ServiceTypeClusterIP
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1beta2 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta2"
+	"github.com/openyurtio/openyurt/pkg/apis/network"
+	networkv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/network/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
+)
+
+func mockExportedService() corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			Labels:    map[string]string{raven.LabelDNSExport: "true"},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:       corev1.ServiceTypeClusterIP,
+			ClusterIP:  "10.96.0.20",
+			ClusterIPs: []string{"10.96.0.20"},
+			Ports: []corev1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Protocol: corev1.ProtocolUDP, Port: 53},
+			},
+		},
+	}
+}
+
+func mockPoolService() networkv1alpha1.PoolService {
+	return networkv1alpha1.PoolService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-hangzhou",
+			Namespace: "default",
+			Labels: map[string]string{
+				network.LabelServiceName:  "web",
+				network.LabelNodePoolName: "hangzhou",
+			},
+		},
+		Status: networkv1alpha1.PoolServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "47.0.0.1"}}},
+		},
+	}
+}
+
+func TestParseRecordConfig(t *testing.T) {
+	cfg := parseRecordConfig(nil)
+	assert.Equal(t, defaultDNSDomain, cfg.domain)
+	assert.False(t, cfg.exportSRV)
+	assert.Len(t, cfg.templates, 1)
+
+	cfg = parseRecordConfig(map[string]string{
+		util.RavenDNSDomain:          ".edge.",
+		util.RavenDNSExportSRV:       "True",
+		util.RavenDNSRecordTemplates: "{{.Name}}.{{.Pool}}.{{.Domain}}\n\n{{.Name}.invalid\n{{.Name}}-{{.Namespace}}.{{.Domain}}",
+	})
+	assert.Equal(t, "edge", cfg.domain)
+	assert.True(t, cfg.exportSRV)
+	assert.Len(t, cfg.templates, 2)
+}
+
+func TestBuildServiceRecords(t *testing.T) {
+	svcs := []corev1.Service{mockExportedService()}
+	pools := []string{"hangzhou", "beijing"}
+	poolSvcs := []networkv1alpha1.PoolService{mockPoolService()}
+
+	testcases := map[string]struct {
+		proxyIPs    []string
+		data        map[string]string
+		expectHosts []string
+		expectSRV   []string
+	}{
+		"pool service and cluster ip": {
+			expectHosts: []string{
+				"10.96.0.20\tweb.default.beijing.local",
+				"47.0.0.1\tweb.default.hangzhou.local",
+			},
+		},
+		"pool service and proxy ip": {
+			proxyIPs: []string{"172.168.0.1"},
+			expectHosts: []string{
+				"172.168.0.1\tweb.default.beijing.local",
+				"47.0.0.1\tweb.default.hangzhou.local",
+			},
+		},
+		"custom templates and srv records": {
+			data: map[string]string{
+				util.RavenDNSDomain:          "edge",
+				util.RavenDNSExportSRV:       "true",
+				util.RavenDNSRecordTemplates: "{{.Name}}.{{.Pool}}.{{.Domain}}\n{{.Name}}_{{.Pool}}",
+			},
+			expectHosts: []string{
+				"10.96.0.20\tweb.beijing.edge",
+				"47.0.0.1\tweb.hangzhou.edge",
+			},
+			expectSRV: []string{
+				"_http._tcp.web.beijing.edge.\t30\tIN\tSRV\t0 100 80 web.beijing.edge.",
+				"_http._tcp.web.hangzhou.edge.\t30\tIN\tSRV\t0 100 80 web.hangzhou.edge.",
+			},
+		}, "srv records out of zone": {
+			data: map[string]string{
+				util.RavenDNSExportSRV:       "true",
+				util.RavenDNSRecordTemplates: "{{.Name}}.{{.Pool}}.{{.Domain}}\n{{.Name}}.{{.Pool}}.example.com",
+			},
+			expectHosts: []string{
+				"10.96.0.20\tweb.beijing.example.com",
+				"10.96.0.20\tweb.beijing.local",
+				"47.0.0.1\tweb.hangzhou.example.com",
+				"47.0.0.1\tweb.hangzhou.local",
+			},
+			expectSRV: []string{
+				"_http._tcp.web.beijing.local.\t30\tIN\tSRV\t0 100 80 web.beijing.local.",
+				"_http._tcp.web.hangzhou.local.\t30\tIN\tSRV\t0 100 80 web.hangzhou.local.",
+			},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			hosts, srv := buildServiceRecords(svcs, pools, poolSvcs, tc.proxyIPs, parseRecordConfig(tc.data))
+			assert.Equal(t, tc.expectHosts, hosts)
+			assert.Equal(t, tc.expectSRV, srv)
+		})
+	}
+}
+
+func TestReconcileDns_ServiceRecords(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, appsv1beta2.AddToScheme(scheme))
+	assert.NoError(t, networkv1alpha1.AddToScheme(scheme))
+
+	svc := mockExportedService()
+	ps := mockPoolService()
+	objs := []client.Object{
+		&svc,
+		&ps,
+		&appsv1beta2.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: util.RavenGlobalConfig, Namespace: util.WorkingNamespace},
+			Data:       map[string]string{util.RavenDNSExportSRV: "true"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: util.RavenProxyNodesConfig, Namespace: util.WorkingNamespace},
+			Data:       map[string]string{util.ProxyNodesKey: ""},
+		},
+	}
+	r := &ReconcileDns{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		recorder: record.NewFakeRecorder(100),
+	}
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Namespace: util.WorkingNamespace, Name: util.RavenProxyNodesConfig}})
+	assert.NoError(t, err)
+
+	var cm corev1.ConfigMap
+	assert.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: util.WorkingNamespace, Name: util.RavenProxyNodesConfig}, &cm))
+	assert.Equal(t, "47.0.0.1\tweb.default.hangzhou.local", cm.Data[util.ProxyNodesKey])
+	assert.Equal(t, buildSRVZone("local", []string{"_http._tcp.web.default.hangzhou.local.\t30\tIN\tSRV\t0 100 80 web.default.hangzhou.local."}), cm.Data[util.SRVRecordsKey])
+	assert.Equal(t, "file /etc/edge/srv-records local {\n    reload 10s\n    fallthrough\n}\n", cm.Data[util.SRVZoneKey])
+
+	// the zone is not served once SRV records are not exported
+	var cfg corev1.ConfigMap
+	assert.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: util.WorkingNamespace, Name: util.RavenGlobalConfig}, &cfg))
+	cfg.Data[util.RavenDNSExportSRV] = "false"
+	assert.NoError(t, r.Client.Update(context.Background(), &cfg))
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Namespace: util.WorkingNamespace, Name: util.RavenProxyNodesConfig}})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: util.WorkingNamespace, Name: util.RavenProxyNodesConfig}, &cm))
+	assert.NotContains(t, cm.Data, util.SRVRecordsKey)
+	assert.NotContains(t, cm.Data, util.SRVZoneKey)
+}
+
+func TestBuildSRVZone(t *testing.T) {
+	records := []string{"_http._tcp.web.hangzhou.edge.\t30\tIN\tSRV\t0 100 80 web.hangzhou.edge."}
+	zone := strings.Split(buildSRVZone("edge", records), "\n")
+	assert.Equal(t, "$ORIGIN edge.", zone[0])
+	assert.True(t, strings.HasPrefix(zone[1], "@\t30\tIN\tSOA\tns.dns.edge. hostmaster.edge. "))
+	assert.Equal(t, records[0], zone[2])
+
+	// the serial changes with the records, so that the zone is reloaded
+	assert.Equal(t, buildSRVZone("edge", records), buildSRVZone("edge", records))
+	assert.NotEqual(t, strings.Split(buildSRVZone("edge", nil), "\n")[1], zone[1])
+}
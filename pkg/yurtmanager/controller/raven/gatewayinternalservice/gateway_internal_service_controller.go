@@ -46,6 +46,7 @@ import (
 	appconfig "github.com/openyurtio/openyurt/cmd/yurt-manager/app/config"
 	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
 	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurthub/filter/servicetopology"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
 )
 
@@ -131,7 +132,13 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 // and what is in the Gateway.Spec
 func (r *ReconcileService) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
 
-	gwList, err := r.listExposedGateway(ctx)
+	var gwList []*ravenv1beta1.Gateway
+	var err error
+	if req.Name == util.GatewayProxyEdgeService {
+		gwList, err = r.listEdgeProxyGateway(ctx)
+	} else {
+		gwList, err = r.listExposedGateway(ctx)
+	}
 	if err != nil {
 		return reconcile.Result{Requeue: true}, err
 	}
@@ -163,6 +170,8 @@ func (r *ReconcileService) listExposedGateway(ctx context.Context) ([]*ravenv1be
 			exposedGateways = append(exposedGateways, gw.DeepCopy())
 		case ravenv1beta1.ExposeTypeLoadBalancer:
 			exposedGateways = append(exposedGateways, gw.DeepCopy())
+		case ravenv1beta1.ExposeTypeNodePort, ravenv1beta1.ExposeTypeHostNetwork:
+			exposedGateways = append(exposedGateways, gw.DeepCopy())
 		default:
 			continue
 		}
@@ -170,6 +179,22 @@ func (r *ReconcileService) listExposedGateway(ctx context.Context) ([]*ravenv1be
 	return exposedGateways, nil
 }
 
+// listEdgeProxyGateway returns the gateways not exposed which accept edge-originated proxy requests,
+// their active proxy endpoints are the endpoints of the edge proxy service.
+func (r *ReconcileService) listEdgeProxyGateway(ctx context.Context) ([]*ravenv1beta1.Gateway, error) {
+	var gatewayList ravenv1beta1.GatewayList
+	if err := r.List(ctx, &gatewayList); err != nil {
+		return nil, fmt.Errorf("unable to list gateways: %s", err)
+	}
+	edgeGateways := make([]*ravenv1beta1.Gateway, 0)
+	for _, gw := range gatewayList.Items {
+		if gw.Spec.ExposeType == "" && gw.Spec.ProxyConfig.EdgeProxy {
+			edgeGateways = append(edgeGateways, gw.DeepCopy())
+		}
+	}
+	return edgeGateways, nil
+}
+
 func (r *ReconcileService) reconcileService(ctx context.Context, req ctrl.Request, gatewayList []*ravenv1beta1.Gateway, enableProxy bool) error {
 	if len(gatewayList) == 0 || !enableProxy {
 		if err := r.cleanService(ctx, req); err != nil {
@@ -196,7 +221,7 @@ func (r *ReconcileService) cleanService(ctx context.Context, req ctrl.Request) e
 }
 
 func generateService(req ctrl.Request) corev1.Service {
-	return corev1.Service{
+	svc := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      req.Name,
 			Namespace: req.Namespace,
@@ -205,9 +230,24 @@ func generateService(req ctrl.Request) corev1.Service {
 			},
 		},
 		Spec: corev1.ServiceSpec{
-			Type: corev1.ServiceTypeClusterIP,
+			Type:           corev1.ServiceTypeClusterIP,
+			IPFamilyPolicy: util.PreferDualStack(),
 		},
 	}
+	ensureServiceTopology(&svc)
+	return svc
+}
+
+// ensureServiceTopology limits the traffic of edge proxy service in the node pool, so that the components on
+// edge nodes reach the active proxy endpoints of their own gateway.
+func ensureServiceTopology(svc *corev1.Service) {
+	if svc.Name != util.GatewayProxyEdgeService {
+		return
+	}
+	if svc.Annotations == nil {
+		svc.Annotations = make(map[string]string)
+	}
+	svc.Annotations[servicetopology.AnnotationServiceTopologyKey] = servicetopology.AnnotationServiceTopologyValueNodePool
 }
 
 func (r *ReconcileService) updateService(ctx context.Context, req ctrl.Request, gatewayList []*ravenv1beta1.Gateway) error {
@@ -229,6 +269,8 @@ func (r *ReconcileService) updateService(ctx context.Context, req ctrl.Request,
 		return r.Create(ctx, &svc)
 	}
 	svc.Spec.Ports = servicePorts
+	svc.Spec.IPFamilyPolicy = util.PreferDualStack()
+	ensureServiceTopology(&svc)
 	return r.Update(ctx, &svc)
 }
 
@@ -259,9 +301,18 @@ func (r *ReconcileService) getTargetPort() (insecurePort, securePort int32) {
 
 func acquiredSpecPorts(gatewayList []*ravenv1beta1.Gateway, insecurePort, securePort int32) []corev1.ServicePort {
 	specPorts := make([]corev1.ServicePort, 0)
+	seen := make(map[string]struct{})
 	for _, gw := range gatewayList {
-		specPorts = append(specPorts, generateServicePorts(gw.Spec.ProxyConfig.ProxyHTTPPort, HTTPPorts, insecurePort)...)
-		specPorts = append(specPorts, generateServicePorts(gw.Spec.ProxyConfig.ProxyHTTPSPort, HTTPSPorts, securePort)...)
+		ports := generateServicePorts(gw.Spec.ProxyConfig.ProxyHTTPPort, HTTPPorts, insecurePort)
+		ports = append(ports, generateServicePorts(gw.Spec.ProxyConfig.ProxyHTTPSPort, HTTPSPorts, securePort)...)
+		// gateways may declare the same ports, which are merged into one service port
+		for _, port := range ports {
+			if _, ok := seen[port.Name]; ok {
+				continue
+			}
+			seen[port.Name] = struct{}{}
+			specPorts = append(specPorts, port)
+		}
 	}
 	return specPorts
 }
@@ -378,10 +429,13 @@ func (r *ReconcileService) ensureSpecEndpoints(ctx context.Context, gateways []*
 			if err != nil {
 				continue
 			}
-			specAddresses = append(specAddresses, corev1.EndpointAddress{
-				IP:       util.GetNodeInternalIP(node),
-				NodeName: func(n corev1.Node) *string { return &n.Name }(node),
-			})
+			// one address for each IP family of dual-stack node
+			for _, ip := range util.GetNodeInternalIPs(node) {
+				specAddresses = append(specAddresses, corev1.EndpointAddress{
+					IP:       ip,
+					NodeName: func(n corev1.Node) *string { return &n.Name }(node),
+				})
+			}
 		}
 	}
 	return specAddresses
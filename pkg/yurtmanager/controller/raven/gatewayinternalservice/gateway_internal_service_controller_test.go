@@ -34,6 +34,7 @@ import (
 	"github.com/openyurtio/openyurt/pkg/apis"
 	"github.com/openyurtio/openyurt/pkg/apis/raven"
 	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurthub/filter/servicetopology"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
 )
 
@@ -47,6 +48,8 @@ const (
 	Node3Address = "192.168.0.3"
 	Node4Address = "192.168.0.4"
 	MockGateway  = "gw-mock"
+
+	MockEdgeGateway = "gw-edge-mock"
 )
 
 func MockReconcile() *ReconcileService {
@@ -185,6 +188,32 @@ func MockReconcile() *ReconcileService {
 					},
 				},
 			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: MockEdgeGateway,
+				},
+				Spec: ravenv1beta1.GatewaySpec{
+					ProxyConfig: ravenv1beta1.ProxyConfiguration{
+						Replicas:       1,
+						ProxyHTTPSPort: "10250",
+						EdgeProxy:      true,
+					},
+					Endpoints: []ravenv1beta1.Endpoint{
+						{
+							NodeName: Node3Name,
+							Type:     ravenv1beta1.Proxy,
+						},
+					},
+				},
+				Status: ravenv1beta1.GatewayStatus{
+					ActiveEndpoints: []*ravenv1beta1.Endpoint{
+						{
+							NodeName: Node3Name,
+							Type:     ravenv1beta1.Proxy,
+						},
+					},
+				},
+			},
 		},
 	}
 	objs := []runtime.Object{nodeList, gateways, configmaps}
@@ -212,6 +241,35 @@ func TestReconcileService_Reconcile(t *testing.T) {
 	}
 }
 
+func TestReconcileService_ReconcileEdgeService(t *testing.T) {
+	r := MockReconcile()
+	key := types.NamespacedName{Name: util.GatewayProxyEdgeService, Namespace: util.WorkingNamespace}
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	assert.NoError(t, err)
+
+	var svc corev1.Service
+	assert.NoError(t, r.Client.Get(context.Background(), key, &svc))
+	assert.Equal(t, servicetopology.AnnotationServiceTopologyValueNodePool, svc.Annotations[servicetopology.AnnotationServiceTopologyKey])
+	if assert.Len(t, svc.Spec.Ports, 1) {
+		assert.Equal(t, int32(10250), svc.Spec.Ports[0].Port)
+	}
+
+	var eps corev1.Endpoints
+	assert.NoError(t, r.Client.Get(context.Background(), key, &eps))
+	if assert.Len(t, eps.Subsets, 1) && assert.Len(t, eps.Subsets[0].Addresses, 1) {
+		assert.Equal(t, Node3Address, eps.Subsets[0].Addresses[0].IP)
+	}
+}
+
+func TestAcquiredSpecPorts(t *testing.T) {
+	gws := []*ravenv1beta1.Gateway{
+		{Spec: ravenv1beta1.GatewaySpec{ProxyConfig: ravenv1beta1.ProxyConfiguration{ProxyHTTPPort: "10255", ProxyHTTPSPort: "10250"}}},
+		{Spec: ravenv1beta1.GatewaySpec{ProxyConfig: ravenv1beta1.ProxyConfiguration{ProxyHTTPSPort: "10250,9445"}}},
+	}
+	ports := acquiredSpecPorts(gws, 10264, 10263)
+	assert.Len(t, ports, 3)
+}
+
 func TestReconcileService_cleanService(t *testing.T) {
 	r := MockReconcile()
 	service := &corev1.Service{
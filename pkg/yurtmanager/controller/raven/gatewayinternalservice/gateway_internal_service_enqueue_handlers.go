@@ -38,6 +38,10 @@ func (h *EnqueueRequestForGatewayEvent) Create(ctx context.Context, e event.Crea
 		klog.Error(Format("could not assert runtime Object %s/%s to v1beta1.Gateway", e.Object.GetNamespace(), e.Object.GetName()))
 		return
 	}
+	if gw.Spec.ProxyConfig.EdgeProxy {
+		klog.V(4).Info(Format("enqueue service %s/%s due to gateway %s create event", util.WorkingNamespace, util.GatewayProxyEdgeService, gw.GetName()))
+		util.AddGatewayProxyEdgeService(q)
+	}
 	if gw.Spec.ExposeType == "" {
 		return
 	}
@@ -56,6 +60,10 @@ func (h *EnqueueRequestForGatewayEvent) Update(ctx context.Context, e event.Upda
 		klog.Error(Format("could not assert runtime Object %s/%s to v1beta1.Gateway", e.ObjectOld.GetNamespace(), e.ObjectOld.GetName()))
 		return
 	}
+	if oldGw.Spec.ProxyConfig.EdgeProxy || newGw.Spec.ProxyConfig.EdgeProxy {
+		klog.V(4).Info(Format("enqueue service %s/%s due to gateway %s update event", util.WorkingNamespace, util.GatewayProxyEdgeService, newGw.GetName()))
+		util.AddGatewayProxyEdgeService(q)
+	}
 	if oldGw.Spec.ExposeType == "" && newGw.Spec.ExposeType == "" {
 		return
 	}
@@ -69,6 +77,10 @@ func (h *EnqueueRequestForGatewayEvent) Delete(ctx context.Context, e event.Dele
 		klog.Error(Format("could not assert runtime Object %s/%s to v1beta1.Gateway", e.Object.GetNamespace(), e.Object.GetName()))
 		return
 	}
+	if gw.Spec.ProxyConfig.EdgeProxy {
+		klog.V(4).Info(Format("enqueue service %s/%s due to gateway %s delete event", util.WorkingNamespace, util.GatewayProxyEdgeService, gw.GetName()))
+		util.AddGatewayProxyEdgeService(q)
+	}
 	if gw.Spec.ExposeType == "" {
 		return
 	}
@@ -95,6 +107,7 @@ func (h *EnqueueRequestForConfigEvent) Create(ctx context.Context, e event.Creat
 		klog.V(4).Info(Format("enqueue service %s/%s due to config %s/%s create event",
 			util.WorkingNamespace, util.GatewayProxyInternalService, util.WorkingNamespace, util.RavenAgentConfig))
 		util.AddGatewayProxyInternalService(q)
+		util.AddGatewayProxyEdgeService(q)
 		return
 	}
 	_, _, err = net.SplitHostPort(cm.Data[util.ProxyServerSecurePortKey])
@@ -102,6 +115,7 @@ func (h *EnqueueRequestForConfigEvent) Create(ctx context.Context, e event.Creat
 		klog.V(4).Info(Format("enqueue service %s/%s due to config %s/%s create event",
 			util.WorkingNamespace, util.GatewayProxyInternalService, util.WorkingNamespace, util.RavenAgentConfig))
 		util.AddGatewayProxyInternalService(q)
+		util.AddGatewayProxyEdgeService(q)
 		return
 	}
 }
@@ -124,6 +138,7 @@ func (h *EnqueueRequestForConfigEvent) Update(ctx context.Context, e event.Updat
 			klog.V(4).Info(Format("enqueue service %s/%s due to config %s/%s update event",
 				util.WorkingNamespace, util.GatewayProxyInternalService, util.WorkingNamespace, util.RavenAgentConfig))
 			util.AddGatewayProxyInternalService(q)
+			util.AddGatewayProxyEdgeService(q)
 			return
 		}
 	}
@@ -134,6 +149,7 @@ func (h *EnqueueRequestForConfigEvent) Update(ctx context.Context, e event.Updat
 			klog.V(4).Info(Format("enqueue service %s/%s due to config %s/%s update event",
 				util.WorkingNamespace, util.GatewayProxyInternalService, util.WorkingNamespace, util.RavenAgentConfig))
 			util.AddGatewayProxyInternalService(q)
+			util.AddGatewayProxyEdgeService(q)
 			return
 		}
 	}
@@ -115,6 +115,22 @@ func TestEnqueueRequestForGatewayEvent(t *testing.T) {
 			},
 			expectedLen: 1,
 		},
+		{
+			name:        "should get work queue len is 1 when Create edge Gateway with EdgeProxy",
+			expectedLen: 1,
+			eventHandler: func() {
+				gw := &ravenv1beta1.Gateway{Spec: ravenv1beta1.GatewaySpec{ProxyConfig: ravenv1beta1.ProxyConfiguration{EdgeProxy: true}}}
+				h.Create(ctx, event.CreateEvent{Object: gw}, queue)
+			},
+		},
+		{
+			name:        "should get work queue len is 1 when Update edge Gateway to disable EdgeProxy",
+			expectedLen: 1,
+			eventHandler: func() {
+				oldGw := &ravenv1beta1.Gateway{Spec: ravenv1beta1.GatewaySpec{ProxyConfig: ravenv1beta1.ProxyConfiguration{EdgeProxy: true}}}
+				h.Update(ctx, event.UpdateEvent{ObjectOld: oldGw, ObjectNew: &ravenv1beta1.Gateway{}}, queue)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,8 +160,8 @@ func TestEnqueueRequestForConfigEvent(t *testing.T) {
 			},
 		},
 		{
-			name:        "should get work queue len is 1 when Create ConfigMap with invalid ProxyServerInsecurePortKey",
-			expectedLen: 1,
+			name:        "should get work queue len is 2 when Create ConfigMap with invalid ProxyServerInsecurePortKey",
+			expectedLen: 2,
 			eventHandler: func() {
 				configMap := mockConfigMap()
 				configMap.Data[util.ProxyServerInsecurePortKey] = "127.0.0.1"
@@ -153,8 +169,8 @@ func TestEnqueueRequestForConfigEvent(t *testing.T) {
 			},
 		},
 		{
-			name:        "should get work queue len is 1 when Create ConfigMap with valid ProxyServerInsecurePortKey",
-			expectedLen: 1,
+			name:        "should get work queue len is 2 when Create ConfigMap with valid ProxyServerInsecurePortKey",
+			expectedLen: 2,
 			eventHandler: func() {
 				h.Create(ctx, event.CreateEvent{Object: mockConfigMap()}, queue)
 			},
@@ -174,24 +190,24 @@ func TestEnqueueRequestForConfigEvent(t *testing.T) {
 			},
 		},
 		{
-			name: "should get work queue len is 1 when update ConfigMap with new InsecurePortKey",
+			name: "should get work queue len is 2 when update ConfigMap with new InsecurePortKey",
 			eventHandler: func() {
 				oldConfigMap := mockConfigMap()
 				newConfigMap := oldConfigMap.DeepCopy()
 				newConfigMap.Data[util.ProxyServerInsecurePortKey] = "127.0.0.1:90"
 				h.Update(ctx, event.UpdateEvent{ObjectOld: oldConfigMap, ObjectNew: newConfigMap}, queue)
 			},
-			expectedLen: 1,
+			expectedLen: 2,
 		},
 		{
-			name: "should get work queue len is 1 when Update ConfigMap with new SecurePortKey",
+			name: "should get work queue len is 2 when Update ConfigMap with new SecurePortKey",
 			eventHandler: func() {
 				oldConfigMap := mockConfigMap()
 				newConfigMap := oldConfigMap.DeepCopy()
 				newConfigMap.Data[util.ProxyServerSecurePortKey] = "127.0.0.2:90"
 				h.Update(ctx, event.UpdateEvent{ObjectOld: oldConfigMap, ObjectNew: newConfigMap}, queue)
 			},
-			expectedLen: 1,
+			expectedLen: 2,
 		},
 	}
 
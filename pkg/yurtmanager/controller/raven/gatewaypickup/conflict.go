@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewaypickup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
+)
+
+// resolveConflicts lists the nodes selected by the gateway and the other gateways, then resolves the managed nodes
+// of the gateway and sets its Conflicted condition. The gateway label of the nodes won by the gateway is corrected,
+// so that the label always agrees with the gateway status.
+func (r *ReconcileGateway) resolveConflicts(ctx context.Context, gw *ravenv1beta1.Gateway, nodeList *corev1.NodeList) error {
+	if gw.Spec.NodeSelector == nil {
+		resolveNodeConflicts(gw, nil, nil, nodeList)
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(gw.Spec.NodeSelector)
+	if err != nil {
+		return fmt.Errorf("could not parse node selector of gateway %s, %w", gw.Name, err)
+	}
+	var selected corev1.NodeList
+	if err := r.List(ctx, &selected, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return fmt.Errorf("could not list nodes selected by gateway %s, %w", gw.Name, err)
+	}
+	var gwList ravenv1beta1.GatewayList
+	if err := r.List(ctx, &gwList); err != nil {
+		return fmt.Errorf("could not list gateways, %w", err)
+	}
+	for _, node := range resolveNodeConflicts(gw, gwList.Items, selected.Items, nodeList) {
+		patch := client.MergeFrom(node.DeepCopy())
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+		node.Labels[raven.LabelCurrentGateway] = gw.Name
+		if err := r.Patch(ctx, &node, patch); err != nil {
+			return fmt.Errorf("could not label node %s with gateway %s, %w", node.Name, gw.Name, err)
+		}
+		klog.V(2).Info(Format("node %s is labeled with gateway %s as it wins the conflict", node.Name, gw.Name))
+	}
+	return nil
+}
+
+// resolveNodeConflicts resolves the nodes selected by the gateway which are also selected by other gateways, each of them
+// is managed by the preferred gateway, no matter which gateway it is labeled with. The Conflicted condition of the gateway
+// is true if any node it selects is selected by other gateways. The nodes won by the gateway but not labeled with it
+// are returned, the managed copies of them in nodeList are labeled with the gateway.
+func resolveNodeConflicts(gw *ravenv1beta1.Gateway, gateways []ravenv1beta1.Gateway, selected []corev1.Node, nodeList *corev1.NodeList) []corev1.Node {
+	conflictedNodes := sets.New[string]()
+	conflictedGateways := sets.New[string]()
+	lost := sets.New[string]()
+	var won, relabeled []corev1.Node
+	for i := range selected {
+		node := &selected[i]
+		candidates := []*ravenv1beta1.Gateway{gw}
+		for j := range gateways {
+			if gateways[j].Name != gw.Name && util.GatewaySelectsNode(&gateways[j], node) {
+				candidates = append(candidates, &gateways[j])
+				conflictedGateways.Insert(gateways[j].Name)
+			}
+		}
+		if len(candidates) == 1 {
+			continue
+		}
+		conflictedNodes.Insert(node.Name)
+		if util.PreferredGateway(candidates).Name == gw.Name {
+			won = append(won, *node)
+			if node.Labels[raven.LabelCurrentGateway] != gw.Name {
+				relabeled = append(relabeled, *node)
+			}
+		} else {
+			lost.Insert(node.Name)
+		}
+	}
+
+	managed := make([]corev1.Node, 0, len(nodeList.Items)+len(won))
+	for _, node := range nodeList.Items {
+		if !lost.Has(node.Name) {
+			managed = append(managed, node)
+		}
+	}
+	for _, node := range won {
+		found := false
+		for i := range managed {
+			if managed[i].Name == node.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			node = *node.DeepCopy()
+			if node.Labels == nil {
+				node.Labels = make(map[string]string)
+			}
+			node.Labels[raven.LabelCurrentGateway] = gw.Name
+			managed = append(managed, node)
+		}
+	}
+	nodeList.Items = managed
+
+	condition := metav1.Condition{
+		Type:               ravenv1beta1.GatewayConflicted,
+		Status:             metav1.ConditionFalse,
+		Reason:             ravenv1beta1.ReasonNoConflict,
+		ObservedGeneration: gw.Generation,
+	}
+	if conflictedNodes.Len() != 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ravenv1beta1.ReasonNodeSelectorOverlapped
+		condition.Message = fmt.Sprintf("nodes [%s] are also selected by gateways [%s], nodes [%s] are managed by other gateways",
+			strings.Join(sets.List(conflictedNodes), ","), strings.Join(sets.List(conflictedGateways), ","), strings.Join(sets.List(lost), ","))
+	}
+	meta.SetStatusCondition(&gw.Status.Conditions, condition)
+	return relabeled
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewaypickup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+func newSelectorGateway(name string, created time.Time, matchLabels map[string]string) ravenv1beta1.Gateway {
+	return ravenv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+		Spec:       ravenv1beta1.GatewaySpec{NodeSelector: &metav1.LabelSelector{MatchLabels: matchLabels}},
+	}
+}
+
+func TestResolveNodeConflicts(t *testing.T) {
+	now := time.Now()
+	node1 := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"zone": "a", raven.LabelCurrentGateway: "gw-b"}}}
+	node2 := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2", Labels: map[string]string{"zone": "b", raven.LabelCurrentGateway: "gw-b"}}}
+	gwA := newSelectorGateway("gw-a", now.Add(-time.Hour), map[string]string{"zone": "a"})
+	gwB := newSelectorGateway("gw-b", now, map[string]string{})
+	gwC := newSelectorGateway("gw-c", now.Add(-time.Hour), map[string]string{"zone": "b"})
+	gateways := []ravenv1beta1.Gateway{gwA, gwB, gwC}
+
+	testcases := map[string]struct {
+		gw           ravenv1beta1.Gateway
+		selected     []corev1.Node
+		labeled      []corev1.Node
+		expectNodes  []string
+		expectLabel  []string
+		expectStatus metav1.ConditionStatus
+		expectReason string
+	}{
+		"newer gateway loses conflicted nodes": {
+			gw:           gwB,
+			selected:     []corev1.Node{node1, node2},
+			labeled:      []corev1.Node{node1, node2},
+			expectNodes:  []string{},
+			expectStatus: metav1.ConditionTrue,
+			expectReason: ravenv1beta1.ReasonNodeSelectorOverlapped,
+		},
+		"older gateway manages conflicted nodes labeled with other gateway": {
+			gw:           gwA,
+			selected:     []corev1.Node{node1},
+			labeled:      nil,
+			expectNodes:  []string{"node-1"},
+			expectLabel:  []string{"node-1"},
+			expectStatus: metav1.ConditionTrue,
+			expectReason: ravenv1beta1.ReasonNodeSelectorOverlapped,
+		},
+		"name breaks the tie of gateways created at the same time": {
+			gw:           newSelectorGateway("gw-d", now.Add(-time.Hour), map[string]string{"zone": "b"}),
+			selected:     []corev1.Node{node2},
+			labeled:      nil,
+			expectNodes:  []string{},
+			expectStatus: metav1.ConditionTrue,
+			expectReason: ravenv1beta1.ReasonNodeSelectorOverlapped,
+		},
+		"gateway without node selector is not conflicted": {
+			gw:           ravenv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw-e"}},
+			labeled:      []corev1.Node{node1},
+			expectNodes:  []string{"node-1"},
+			expectStatus: metav1.ConditionFalse,
+			expectReason: ravenv1beta1.ReasonNoConflict,
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			gw := tc.gw.DeepCopy()
+			nodeList := corev1.NodeList{Items: tc.labeled}
+			relabeled := resolveNodeConflicts(gw, append(gateways, *gw), tc.selected, &nodeList)
+			nodes := []string{}
+			for _, node := range nodeList.Items {
+				nodes = append(nodes, node.Name)
+			}
+			assert.Equal(t, tc.expectNodes, nodes)
+			var labels []string
+			for _, node := range relabeled {
+				labels = append(labels, node.Name)
+				for _, managed := range nodeList.Items {
+					if managed.Name == node.Name {
+						assert.Equal(t, gw.Name, managed.Labels[raven.LabelCurrentGateway])
+					}
+				}
+			}
+			assert.Equal(t, tc.expectLabel, labels)
+			condition := meta.FindStatusCondition(gw.Status.Conditions, ravenv1beta1.GatewayConflicted)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, tc.expectStatus, condition.Status)
+				assert.Equal(t, tc.expectReason, condition.Reason)
+			}
+		})
+	}
+}
+
+func TestResolveConflictsLabelsWonNodes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = ravenv1beta1.AddToScheme(scheme)
+
+	now := time.Now()
+	gwA := newSelectorGateway("gw-a", now.Add(-time.Hour), map[string]string{"zone": "a"})
+	gwB := newSelectorGateway("gw-b", now, map[string]string{"zone": "a"})
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"zone": "a", raven.LabelCurrentGateway: "gw-b"}}}
+	r := &ReconcileGateway{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&gwA, &gwB, node).Build(),
+	}
+
+	// the newer gateway gives the node up, but leaves its label to the winner
+	nodeList := corev1.NodeList{Items: []corev1.Node{*node}}
+	require.NoError(t, r.resolveConflicts(context.Background(), gwB.DeepCopy(), &nodeList))
+	assert.Empty(t, nodeList.Items)
+	var got corev1.Node
+	require.NoError(t, r.Get(context.Background(), types.NamespacedName{Name: node.Name}, &got))
+	assert.Equal(t, "gw-b", got.Labels[raven.LabelCurrentGateway])
+
+	// the older gateway wins the node and corrects its label
+	nodeList = corev1.NodeList{}
+	require.NoError(t, r.resolveConflicts(context.Background(), gwA.DeepCopy(), &nodeList))
+	if assert.Len(t, nodeList.Items, 1) {
+		assert.Equal(t, "node-1", nodeList.Items[0].Name)
+	}
+	require.NoError(t, r.Get(context.Background(), types.NamespacedName{Name: node.Name}, &got))
+	assert.Equal(t, "gw-a", got.Labels[raven.LabelCurrentGateway])
+}
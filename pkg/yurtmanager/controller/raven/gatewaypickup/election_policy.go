@@ -0,0 +1,226 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewaypickup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	nodeutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/node"
+)
+
+var (
+	defaultPreferredNATTypes = []string{
+		ravenv1beta1.NATTypeFullCone,
+		ravenv1beta1.NATTypeRestrictedCone,
+		ravenv1beta1.NATTypePortRestrictedCone,
+		ravenv1beta1.NATTypeSymmetric,
+	}
+	defaultAvoidNodeConditions = []corev1.NodeConditionType{
+		corev1.NodeMemoryPressure,
+		corev1.NodeDiskPressure,
+		corev1.NodePIDPressure,
+		corev1.NodeNetworkUnavailable,
+	}
+)
+
+// candidate is an endpoint which takes part in the election, together with its ranking keys.
+type candidate struct {
+	ep        *ravenv1beta1.Endpoint
+	active    bool
+	ready     bool
+	unhealthy int
+	natRank   int
+}
+
+// outranks checks whether candidate c ranks higher than candidate o. Ready nodes rank first, then nodes
+// with fewer avoided conditions, then preferred NAT types, then larger bandwidth, and at last the active endpoint.
+func (c *candidate) outranks(o *candidate) bool {
+	if c.ready != o.ready {
+		return c.ready
+	}
+	if c.unhealthy != o.unhealthy {
+		return c.unhealthy < o.unhealthy
+	}
+	if c.natRank != o.natRank {
+		return c.natRank < o.natRank
+	}
+	if c.ep.Bandwidth != o.ep.Bandwidth {
+		return c.ep.Bandwidth > o.ep.Bandwidth
+	}
+	return c.active && !o.active
+}
+
+func sortCandidates(candidates []*candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].outranks(candidates[j]) })
+}
+
+// electRankedEndpoints elects active endpoints of endpointType by the election policy of gateway.
+// It returns the elected endpoints and the duration after which the election should be done again,
+// because a dwell time of node expires. Zero duration means no more election is needed.
+func electRankedEndpoints(gw *ravenv1beta1.Gateway, endpointType string, nodes map[string]*corev1.Node, now time.Time) ([]*ravenv1beta1.Endpoint, time.Duration) {
+	policy := gw.Spec.ElectionPolicy
+	replicas := endpointReplicas(gw, endpointType)
+	dwell := time.Duration(policy.MinDwellSeconds) * time.Second
+	natTypes := policy.PreferredNATTypes
+	if len(natTypes) == 0 {
+		natTypes = defaultPreferredNATTypes
+	}
+	avoidConditions := policy.AvoidNodeConditions
+	if len(avoidConditions) == 0 {
+		avoidConditions = defaultAvoidNodeConditions
+	}
+
+	activeNodes := sets.New[string]()
+	for _, aep := range gw.Status.ActiveEndpoints {
+		if aep.Type == endpointType {
+			activeNodes.Insert(aep.NodeName)
+		}
+	}
+
+	var requeueAfter time.Duration
+	requeue := func(d time.Duration) {
+		if d > 0 && (requeueAfter == 0 || d < requeueAfter) {
+			requeueAfter = d
+		}
+	}
+
+	kept := make([]*candidate, 0)
+	settled := make([]*candidate, 0)
+	unsettled := make([]*candidate, 0)
+	seen := sets.New[string]()
+	for i := range gw.Spec.Endpoints {
+		ep := &gw.Spec.Endpoints[i]
+		if ep.Type != endpointType || seen.Has(ep.NodeName) {
+			continue
+		}
+		node, ok := nodes[ep.NodeName]
+		if !ok {
+			continue
+		}
+		seen.Insert(ep.NodeName)
+
+		if healthy, _ := isEndpointHealthy(node, ep.Type); !healthy {
+			// the health probe failed, fail over to a standby endpoint immediately
+			continue
+		}
+
+		ready, since := nodeReadySince(node)
+		elapsed := now.Sub(since)
+		c := &candidate{
+			ep:        ep,
+			active:    activeNodes.Has(ep.NodeName),
+			ready:     ready,
+			unhealthy: countNodeConditions(node, avoidConditions),
+			natRank:   natTypeRank(discoveredEndpoint(gw, ep, node), natTypes),
+		}
+		switch {
+		case c.active && ready:
+			kept = append(kept, c)
+		case c.active && elapsed < dwell:
+			// keep the active endpoint for a while, the node may become Ready soon
+			klog.V(4).Info(Format("keep active endpoint on NotReady node %s for gateway %s, dwell %s", ep.NodeName, gw.GetName(), dwell-elapsed))
+			kept = append(kept, c)
+			requeue(dwell - elapsed)
+		case !ready:
+			continue
+		case !policy.Preemption || elapsed >= dwell:
+			settled = append(settled, c)
+		default:
+			// the node has not been Ready long enough to preempt an active endpoint
+			unsettled = append(unsettled, c)
+			requeue(dwell - elapsed)
+		}
+	}
+
+	var candidates []*candidate
+	if policy.Preemption {
+		candidates = append(kept, settled...)
+		sortCandidates(candidates)
+		sortCandidates(unsettled)
+		candidates = append(candidates, unsettled...)
+	} else {
+		sortCandidates(kept)
+		sortCandidates(settled)
+		candidates = append(kept, settled...)
+	}
+
+	eps := make([]*ravenv1beta1.Endpoint, 0, replicas)
+	for _, c := range candidates {
+		if len(eps) == replicas {
+			break
+		}
+		eps = append(eps, c.ep.DeepCopy())
+	}
+	aepInfo, _ := getActiveEndpointsInfo(eps)
+	klog.V(4).Info(Format("elect %d active endpoints %s by election policy for gateway %s",
+		len(eps), fmt.Sprintf("[%s]", strings.Join(aepInfo[ActiveEndpointsName], ",")), gw.GetName()))
+	return eps, requeueAfter
+}
+
+func endpointReplicas(gw *ravenv1beta1.Gateway, endpointType string) int {
+	switch endpointType {
+	case ravenv1beta1.Proxy:
+		return gw.Spec.ProxyConfig.Replicas
+	case ravenv1beta1.Tunnel:
+		return gw.Spec.TunnelConfig.Replicas
+	default:
+		return 1
+	}
+}
+
+// nodeReadySince returns whether the node is ready and the last time the ready condition changed.
+func nodeReadySince(node *corev1.Node) (bool, time.Time) {
+	_, nc := nodeutil.GetNodeCondition(&node.Status, corev1.NodeReady)
+	if nc == nil {
+		return false, time.Time{}
+	}
+	return nc.Status == corev1.ConditionTrue, nc.LastTransitionTime.Time
+}
+
+// countNodeConditions returns the number of conditions whose status is True on the node.
+func countNodeConditions(node *corev1.Node, conditionTypes []corev1.NodeConditionType) int {
+	count := 0
+	for _, conditionType := range conditionTypes {
+		_, nc := nodeutil.GetNodeCondition(&node.Status, conditionType)
+		if nc != nil && nc.Status == corev1.ConditionTrue {
+			count++
+		}
+	}
+	return count
+}
+
+// natTypeRank returns 0 for endpoint not under NAT, otherwise the position of its NAT type
+// in preferred NAT types starting from 1. Unknown NAT type ranks last.
+func natTypeRank(ep *ravenv1beta1.Endpoint, natTypes []string) int {
+	if !ep.UnderNAT {
+		return 0
+	}
+	for i, natType := range natTypes {
+		if natType == ep.NATType {
+			return i + 1
+		}
+	}
+	return len(natTypes) + 1
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewaypickup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+var electionNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newElectionNode(name string, ready bool, since time.Duration, conditions ...corev1.NodeConditionType) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{
+					Type:               corev1.NodeReady,
+					Status:             status,
+					LastTransitionTime: metav1.NewTime(electionNow.Add(-since)),
+				},
+			},
+		},
+	}
+	for _, conditionType := range conditions {
+		node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{Type: conditionType, Status: corev1.ConditionTrue})
+	}
+	return node
+}
+
+func TestElectRankedEndpoints(t *testing.T) {
+	testcases := map[string]struct {
+		policy        ravenv1beta1.ElectionPolicy
+		endpoints     []ravenv1beta1.Endpoint
+		active        []string
+		nodes         []*corev1.Node
+		expectNodes   []string
+		expectRequeue time.Duration
+	}{
+		"prefer endpoint not under NAT": {
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, UnderNAT: true, NATType: ravenv1beta1.NATTypeFullCone},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel},
+			},
+			nodes:       []*corev1.Node{newElectionNode("node-1", true, time.Hour), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{"node-2"},
+		},
+		"prefer full cone NAT to symmetric NAT": {
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, UnderNAT: true, NATType: ravenv1beta1.NATTypeSymmetric},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, UnderNAT: true, NATType: ravenv1beta1.NATTypeFullCone},
+			},
+			nodes:       []*corev1.Node{newElectionNode("node-1", true, time.Hour), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{"node-2"},
+		},
+		"custom preferred NAT types": {
+			policy: ravenv1beta1.ElectionPolicy{PreferredNATTypes: []string{ravenv1beta1.NATTypeSymmetric}},
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, UnderNAT: true, NATType: ravenv1beta1.NATTypeFullCone},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, UnderNAT: true, NATType: ravenv1beta1.NATTypeSymmetric},
+			},
+			nodes:       []*corev1.Node{newElectionNode("node-1", true, time.Hour), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{"node-2"},
+		},
+		"prefer larger bandwidth": {
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Bandwidth: 100},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, Bandwidth: 1000},
+			},
+			nodes:       []*corev1.Node{newElectionNode("node-1", true, time.Hour), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{"node-2"},
+		},
+		"avoid node under pressure": {
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Bandwidth: 1000},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, Bandwidth: 100},
+			},
+			nodes: []*corev1.Node{
+				newElectionNode("node-1", true, time.Hour, corev1.NodeMemoryPressure),
+				newElectionNode("node-2", true, time.Hour),
+			},
+			expectNodes: []string{"node-2"},
+		},
+		"active endpoint sticks without preemption": {
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Bandwidth: 100},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, Bandwidth: 1000},
+			},
+			active:      []string{"node-1"},
+			nodes:       []*corev1.Node{newElectionNode("node-1", true, time.Hour), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{"node-1"},
+		},
+		"active endpoint is preempted by better endpoint": {
+			policy: ravenv1beta1.ElectionPolicy{Preemption: true},
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Bandwidth: 100},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, Bandwidth: 1000},
+			},
+			active:      []string{"node-1"},
+			nodes:       []*corev1.Node{newElectionNode("node-1", true, time.Hour), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{"node-2"},
+		},
+		"active endpoint wins the tie with preemption": {
+			policy: ravenv1beta1.ElectionPolicy{Preemption: true},
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel},
+			},
+			active:      []string{"node-2"},
+			nodes:       []*corev1.Node{newElectionNode("node-1", true, time.Hour), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{"node-2"},
+		},
+		"recently ready endpoint can not preempt within dwell time": {
+			policy: ravenv1beta1.ElectionPolicy{Preemption: true, MinDwellSeconds: 60},
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Bandwidth: 100},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, Bandwidth: 1000},
+			},
+			active:        []string{"node-1"},
+			nodes:         []*corev1.Node{newElectionNode("node-1", true, time.Hour), newElectionNode("node-2", true, 20*time.Second)},
+			expectNodes:   []string{"node-1"},
+			expectRequeue: 40 * time.Second,
+		},
+		"active endpoint is kept within dwell time after NotReady": {
+			policy: ravenv1beta1.ElectionPolicy{MinDwellSeconds: 60},
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel},
+			},
+			active:        []string{"node-1"},
+			nodes:         []*corev1.Node{newElectionNode("node-1", false, 10*time.Second), newElectionNode("node-2", true, time.Hour)},
+			expectNodes:   []string{"node-1"},
+			expectRequeue: 50 * time.Second,
+		},
+		"active endpoint is switched after dwell time": {
+			policy: ravenv1beta1.ElectionPolicy{MinDwellSeconds: 60},
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel},
+			},
+			active:      []string{"node-1"},
+			nodes:       []*corev1.Node{newElectionNode("node-1", false, 2*time.Minute), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{"node-2"},
+		},
+		"no ready node": {
+			endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel},
+				{NodeName: "node-2", Type: ravenv1beta1.Proxy},
+			},
+			nodes:       []*corev1.Node{newElectionNode("node-1", false, time.Hour), newElectionNode("node-2", true, time.Hour)},
+			expectNodes: []string{},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			policy := tc.policy
+			gw := &ravenv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "gateway-1"},
+				Spec: ravenv1beta1.GatewaySpec{
+					TunnelConfig:   ravenv1beta1.TunnelConfiguration{Replicas: 1},
+					Endpoints:      tc.endpoints,
+					ElectionPolicy: &policy,
+				},
+			}
+			for _, name := range tc.active {
+				gw.Status.ActiveEndpoints = append(gw.Status.ActiveEndpoints, &ravenv1beta1.Endpoint{NodeName: name, Type: ravenv1beta1.Tunnel})
+			}
+			nodes := make(map[string]*corev1.Node)
+			for _, node := range tc.nodes {
+				nodes[node.Name] = node
+			}
+
+			eps, requeueAfter := electRankedEndpoints(gw, ravenv1beta1.Tunnel, nodes, electionNow)
+			names := make([]string, 0, len(eps))
+			for _, ep := range eps {
+				names = append(names, ep.NodeName)
+			}
+			assert.Equal(t, tc.expectNodes, names)
+			assert.Equal(t, tc.expectRequeue, requeueAfter)
+		})
+	}
+}
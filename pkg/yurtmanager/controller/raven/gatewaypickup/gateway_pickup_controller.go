@@ -29,6 +29,8 @@ import (
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -112,8 +114,27 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 		return err
 	}
 
+	// Watch for changes to node selector of Gateway, the other gateways selecting the same nodes should be resolved again
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &ravenv1beta1.Gateway{}, &EnqueueGatewayForGateway{client: yurtClient.GetClientByControllerNameOrDie(mgr, names.GatewayPickupController)}))
+	if err != nil {
+		return err
+	}
+
 	// Watch for changes to Nodes
-	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Node{}, &EnqueueGatewayForNode{}))
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Node{}, &EnqueueGatewayForNode{client: mgr.GetClient()}))
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to public services, the node port of NodePort service is the public port of active endpoint
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Service{}, handler.EnqueueRequestsFromMapFunc(
+		func(ctx context.Context, obj client.Object) []reconcile.Request {
+			gwName := obj.GetLabels()[raven.LabelCurrentGateway]
+			if obj.GetNamespace() != util.WorkingNamespace || gwName == "" {
+				return nil
+			}
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: gwName}}}
+		})))
 	if err != nil {
 		return err
 	}
@@ -141,8 +162,9 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 //+kubebuilder:rbac:groups=raven.openyurt.io,resources=gateways,verbs=get;create;delete;update
 //+kubebuilder:rbac:groups=raven.openyurt.io,resources=gateways/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=raven.openyurt.io,resources=gateways/finalizers,verbs=update
-//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;patch
 //+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get
 //+kubebuilder:rbac:groups=crd.projectcalico.org,resources=blockaffinities,verbs=get
 
 // Reconcile reads that state of the cluster for a Gateway object and makes changes based on the state read
@@ -152,6 +174,9 @@ func (r *ReconcileGateway) Reconcile(ctx context.Context, req reconcile.Request)
 	var gw ravenv1beta1.Gateway
 	if err := r.Get(ctx, req.NamespacedName, &gw); err != nil {
 		klog.Error(Format("unable get gateway %s, error %s", req.String(), err.Error()))
+		if apierrs.IsNotFound(err) {
+			recordTrafficMetrics(req.Name, nil)
+		}
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
 
@@ -166,12 +191,20 @@ func (r *ReconcileGateway) Reconcile(ctx context.Context, req reconcile.Request)
 		klog.Error(Format("unable to list node error %s", err.Error()))
 		return reconcile.Result{}, err
 	}
+	if err = r.resolveConflicts(ctx, &gw, &nodeList); err != nil {
+		klog.Error(Format("unable to resolve node conflicts of gateway %s, error %s", gw.GetName(), err.Error()))
+		return reconcile.Result{}, err
+	}
 
 	// 1. try to elect an active endpoint if possible
-	activeEp := r.electActiveEndpoint(nodeList, &gw)
+	activeEp, requeueAfter := r.electActiveEndpoint(nodeList, &gw)
+	r.recordFailoverEvent(&gw, nodeList, gw.Status.ActiveEndpoints, activeEp)
 	r.recordEndpointEvent(&gw, gw.Status.ActiveEndpoints, activeEp)
 	gw.Status.ActiveEndpoints = activeEp
 	r.configEndpoints(ctx, &gw)
+	r.exposeEndpoints(ctx, &gw, nodeList)
+	discoverEndpoints(&gw, nodeList)
+	gw.Status.Traffic = collectTraffic(&gw, nodeList)
 	// 2. get nodeInfo list of nodes managed by the Gateway
 	var nodes []ravenv1beta1.NodeInfo
 	for _, v := range nodeList.Items {
@@ -181,9 +214,10 @@ func (r *ReconcileGateway) Reconcile(ctx context.Context, req reconcile.Request)
 			return reconcile.Result{}, err
 		}
 		nodes = append(nodes, ravenv1beta1.NodeInfo{
-			NodeName:  v.Name,
-			PrivateIP: util.GetNodeInternalIP(v),
-			Subnets:   podCIDRs,
+			NodeName:   v.Name,
+			PrivateIP:  util.GetNodeInternalIP(v),
+			PrivateIPs: util.GetNodeInternalIPs(v),
+			Subnets:    podCIDRs,
 		})
 	}
 	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeName < nodes[j].NodeName })
@@ -198,7 +232,8 @@ func (r *ReconcileGateway) Reconcile(ctx context.Context, req reconcile.Request)
 		klog.Error(Format("unable to update %s gateway.status, error %s", gw.GetName(), err.Error()))
 		return reconcile.Result{Requeue: true, RequeueAfter: 5 * time.Second}, err
 	}
-	return reconcile.Result{}, nil
+	recordTrafficMetrics(gw.Name, gw.Status.Traffic)
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *ReconcileGateway) recordEndpointEvent(sourceObj *ravenv1beta1.Gateway, previous, current []*ravenv1beta1.Endpoint) {
@@ -227,13 +262,66 @@ func (r *ReconcileGateway) recordEndpointEvent(sourceObj *ravenv1beta1.Gateway,
 	}
 }
 
+// recordFailoverEvent records an event for each previous active endpoint which is replaced
+// because its health probe failed.
+func (r *ReconcileGateway) recordFailoverEvent(sourceObj *ravenv1beta1.Gateway, nodeList corev1.NodeList, previous, current []*ravenv1beta1.Endpoint) {
+	for _, prev := range previous {
+		var node *corev1.Node
+		for i := range nodeList.Items {
+			if nodeList.Items[i].Name == prev.NodeName {
+				node = &nodeList.Items[i]
+				break
+			}
+		}
+		if node == nil {
+			continue
+		}
+		healthy, message := isEndpointHealthy(node, prev.Type)
+		if healthy {
+			continue
+		}
+		standby := ""
+		replaced := true
+		for _, cur := range current {
+			if cur.Type != prev.Type {
+				continue
+			}
+			if cur.NodeName == prev.NodeName {
+				replaced = false
+				break
+			}
+			if standby == "" {
+				standby = cur.NodeName
+			}
+		}
+		if !replaced {
+			r.recorder.Event(sourceObj.DeepCopy(), corev1.EventTypeWarning,
+				ravenv1beta1.EventActiveEndpointUnhealthy,
+				fmt.Sprintf("The active endpoint hosted by node %s failed the %s health probe: %s, no healthy standby endpoint, keep it active", prev.NodeName, prev.Type, message))
+			klog.V(2).InfoS(Format("active endpoint unhealthy without standby"), "nodeName", prev.NodeName, "type", prev.Type)
+			continue
+		}
+		if standby == "" {
+			// the endpoint is lost for other reasons, e.g. the node is NotReady, which is recorded by recordEndpointEvent
+			continue
+		}
+		r.recorder.Event(sourceObj.DeepCopy(), corev1.EventTypeWarning,
+			ravenv1beta1.EventActiveEndpointFailover,
+			fmt.Sprintf("The active endpoint hosted by node %s failed the %s health probe: %s, failover to node %s", prev.NodeName, prev.Type, message, standby))
+		klog.V(2).InfoS(Format("active endpoint failover"), "nodeName", prev.NodeName, "type", prev.Type, "standby", standby)
+	}
+}
+
 // electActiveEndpoint tries to elect an active Endpoint.
 // If the current active endpoint remains valid, then we don't change it.
-// Otherwise, try to elect a new one.
-func (r *ReconcileGateway) electActiveEndpoint(nodeList corev1.NodeList, gw *ravenv1beta1.Gateway) []*ravenv1beta1.Endpoint {
+// Otherwise, try to elect a new one. If the gateway has an election policy, the endpoints are ranked
+// by the policy, and the returned duration tells when the election should be done again.
+func (r *ReconcileGateway) electActiveEndpoint(nodeList corev1.NodeList, gw *ravenv1beta1.Gateway) ([]*ravenv1beta1.Endpoint, time.Duration) {
 	// get all ready nodes referenced by endpoints
+	nodes := make(map[string]*corev1.Node)
 	readyNodes := make(map[string]*corev1.Node)
 	for _, v := range nodeList.Items {
+		nodes[v.Name] = &v
 		if isNodeReady(v) {
 			readyNodes[v.Name] = &v
 		}
@@ -242,33 +330,45 @@ func (r *ReconcileGateway) electActiveEndpoint(nodeList corev1.NodeList, gw *rav
 	// init a endpoints slice
 	enableProxy, enableTunnel := util.CheckServer(context.TODO(), r.Client)
 	eps := make([]*ravenv1beta1.Endpoint, 0)
+	var requeueAfter time.Duration
+	elect := func(endpointType string) {
+		var elected []*ravenv1beta1.Endpoint
+		if gw.Spec.ElectionPolicy == nil {
+			elected = electEndpoints(gw, endpointType, readyNodes)
+		} else {
+			var after time.Duration
+			elected, after = electRankedEndpoints(gw, endpointType, nodes, time.Now())
+			if after > 0 && (requeueAfter == 0 || after < requeueAfter) {
+				requeueAfter = after
+			}
+		}
+		if len(elected) == 0 {
+			// fail over only when a healthy standby exists, otherwise keep the unhealthy active endpoints
+			elected = unhealthyActiveEndpoints(gw, endpointType, readyNodes)
+		}
+		eps = append(eps, elected...)
+	}
 	if enableProxy {
-		eps = append(eps, electEndpoints(gw, ravenv1beta1.Proxy, readyNodes)...)
+		elect(ravenv1beta1.Proxy)
 	}
 	if enableTunnel {
-		eps = append(eps, electEndpoints(gw, ravenv1beta1.Tunnel, readyNodes)...)
+		elect(ravenv1beta1.Tunnel)
 	}
 	sort.Slice(eps, func(i, j int) bool { return eps[i].NodeName < eps[j].NodeName })
-	return eps
+	return eps, requeueAfter
 }
 
 func electEndpoints(gw *ravenv1beta1.Gateway, endpointType string, readyNodes map[string]*corev1.Node) []*ravenv1beta1.Endpoint {
 	eps := make([]*ravenv1beta1.Endpoint, 0)
-	var replicas int
-	switch endpointType {
-	case ravenv1beta1.Proxy:
-		replicas = gw.Spec.ProxyConfig.Replicas
-	case ravenv1beta1.Tunnel:
-		replicas = gw.Spec.TunnelConfig.Replicas
-	default:
-		replicas = 1
-	}
+	replicas := endpointReplicas(gw, endpointType)
 
 	checkCandidates := func(ep *ravenv1beta1.Endpoint) bool {
-		if _, ok := readyNodes[ep.NodeName]; ok && ep.Type == endpointType {
-			return true
+		node, ok := readyNodes[ep.NodeName]
+		if !ok || ep.Type != endpointType {
+			return false
 		}
-		return false
+		healthy, _ := isEndpointHealthy(node, ep.Type)
+		return healthy
 	}
 
 	// the current active endpoint is still competent.
@@ -310,6 +410,29 @@ func electEndpoints(gw *ravenv1beta1.Gateway, endpointType string, readyNodes ma
 	return eps
 }
 
+// unhealthyActiveEndpoints returns the current active endpoints of endpointType hosted by ready nodes
+// whose health probe failed, they are kept active when no healthy standby endpoint exists.
+func unhealthyActiveEndpoints(gw *ravenv1beta1.Gateway, endpointType string, readyNodes map[string]*corev1.Node) []*ravenv1beta1.Endpoint {
+	eps := make([]*ravenv1beta1.Endpoint, 0)
+	for _, aep := range gw.Status.ActiveEndpoints {
+		node, ok := readyNodes[aep.NodeName]
+		if !ok || aep.Type != endpointType {
+			continue
+		}
+		if healthy, _ := isEndpointHealthy(node, aep.Type); healthy {
+			continue
+		}
+		for _, ep := range gw.Spec.Endpoints {
+			if ep.NodeName == aep.NodeName && ep.Type == aep.Type {
+				klog.V(2).Info(Format("keep unhealthy active endpoint on node %s for gateway %s, no healthy standby endpoint", ep.NodeName, gw.GetName()))
+				eps = append(eps, ep.DeepCopy())
+				break
+			}
+		}
+	}
+	return eps
+}
+
 // isNodeReady checks if the `node` is `corev1.NodeReady`
 func isNodeReady(node corev1.Node) bool {
 	_, nc := nodeutil.GetNodeCondition(&node.Status, corev1.NodeReady)
@@ -317,6 +440,25 @@ func isNodeReady(node corev1.Node) bool {
 	return nc != nil && nc.Status == corev1.ConditionTrue
 }
 
+// isEndpointHealthy checks the health probe of the endpoint reported by raven agent in the conditions of node,
+// and returns the message of the condition if the probe failed. An endpoint without probe result is healthy.
+func isEndpointHealthy(node *corev1.Node, endpointType string) (bool, string) {
+	var conditionType corev1.NodeConditionType
+	switch endpointType {
+	case ravenv1beta1.Tunnel:
+		conditionType = ravenv1beta1.NodeTunnelHealthy
+	case ravenv1beta1.Proxy:
+		conditionType = ravenv1beta1.NodeProxyHealthy
+	default:
+		return true, ""
+	}
+	_, nc := nodeutil.GetNodeCondition(&node.Status, conditionType)
+	if nc == nil || nc.Status != corev1.ConditionFalse {
+		return true, ""
+	}
+	return false, nc.Message
+}
+
 // getPodCIDRs returns the pod IP ranges assigned to the node.
 func (r *ReconcileGateway) getPodCIDRs(ctx context.Context, node corev1.Node) ([]string, error) {
 	podCIDRs := make([]string, 0)
@@ -337,6 +479,10 @@ func (r *ReconcileGateway) getPodCIDRs(ctx context.Context, node corev1.Node) ([
 			return podCIDRs, nil
 		}
 	}
+	// a dual-stack node has a pod CIDR for each IP family
+	if len(node.Spec.PodCIDRs) != 0 {
+		return append(podCIDRs, node.Spec.PodCIDRs...), nil
+	}
 	return append(podCIDRs, node.Spec.PodCIDR), nil
 }
 
@@ -365,6 +511,7 @@ func (r *ReconcileGateway) configEndpoints(ctx context.Context, gw *ravenv1beta1
 		switch val.Type {
 		case ravenv1beta1.Proxy:
 			gw.Status.ActiveEndpoints[idx].Config[util.RavenEnableProxy] = strconv.FormatBool(enableProxy)
+			configEdgeProxy(gw, gw.Status.ActiveEndpoints[idx])
 		case ravenv1beta1.Tunnel:
 			gw.Status.ActiveEndpoints[idx].Config[util.RavenEnableTunnel] = strconv.FormatBool(enableTunnel)
 		default:
@@ -372,6 +519,79 @@ func (r *ReconcileGateway) configEndpoints(ctx context.Context, gw *ravenv1beta1
 	}
 }
 
+// configEdgeProxy records the edge proxy configuration for the raven agent of active proxy endpoint, the agent
+// routes the edge-originated proxy requests of the nodes via the cloud gateway, and only accepts the ones from
+// the allowed source gateways.
+func configEdgeProxy(gw *ravenv1beta1.Gateway, aep *ravenv1beta1.Endpoint) {
+	if gw.Spec.ExposeType == "" && gw.Spec.ProxyConfig.EdgeProxy {
+		aep.Config[util.RavenEnableEdgeProxy] = "true"
+	}
+	if len(gw.Spec.ProxyConfig.AllowedSourceGateways) != 0 {
+		sources := sets.List(sets.New[string](gw.Spec.ProxyConfig.AllowedSourceGateways...))
+		aep.Config[util.EdgeProxyAllowedSourcesKey] = strings.Join(sources, ",")
+	}
+}
+
+// exposeEndpoints populates the public address of active endpoints for the gateway exposed by NodePort or
+// HostNetwork, the active endpoints are reached by the external address of node and the node port or host port.
+// The public address set in the endpoints of gateway spec is kept.
+func (r *ReconcileGateway) exposeEndpoints(ctx context.Context, gw *ravenv1beta1.Gateway, nodeList corev1.NodeList) {
+	if gw.Spec.ExposeType != ravenv1beta1.ExposeTypeNodePort && gw.Spec.ExposeType != ravenv1beta1.ExposeTypeHostNetwork {
+		return
+	}
+	for _, aep := range gw.Status.ActiveEndpoints {
+		if aep.PublicIP == "" {
+			for _, node := range nodeList.Items {
+				if node.Name != aep.NodeName {
+					continue
+				}
+				ips := util.GetNodeExternalIPs(node)
+				if len(ips) == 0 {
+					ips = util.GetNodeInternalIPs(node)
+				}
+				if len(ips) != 0 {
+					aep.PublicIP = ips[0]
+					aep.PublicIPs = ips
+				}
+				break
+			}
+		}
+
+		switch gw.Spec.ExposeType {
+		case ravenv1beta1.ExposeTypeHostNetwork:
+			if aep.PublicPort == 0 {
+				aep.PublicPort = aep.Port
+			}
+		case ravenv1beta1.ExposeTypeNodePort:
+			if port := r.getNodePort(ctx, gw.GetName(), aep.Type); port != 0 {
+				aep.PublicPort = port
+			}
+		}
+	}
+}
+
+// getNodePort returns the node port allocated to the public service of gateway for the endpoint type.
+func (r *ReconcileGateway) getNodePort(ctx context.Context, gwName, endpointType string) int {
+	var svcList corev1.ServiceList
+	err := r.List(ctx, &svcList, &client.ListOptions{
+		Namespace: util.WorkingNamespace,
+		LabelSelector: labels.Set{
+			raven.LabelCurrentGateway:     gwName,
+			raven.LabelCurrentGatewayType: endpointType,
+		}.AsSelector(),
+	})
+	if err != nil {
+		klog.Error(Format("could not list service for gateway %s, error %s", gwName, err.Error()))
+		return 0
+	}
+	for _, svc := range svcList.Items {
+		if svc.Spec.Type == corev1.ServiceTypeNodePort && len(svc.Spec.Ports) != 0 {
+			return int(svc.Spec.Ports[0].NodePort)
+		}
+	}
+	return 0
+}
+
 func (r *ReconcileGateway) addExtraAllowedSubnet(gw *ravenv1beta1.Gateway) {
 	if gw.Annotations == nil || gw.Annotations[util.ExtraAllowedSourceCIDRs] == "" {
 		return
@@ -24,8 +24,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
 	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/gatewaypickup/config"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
@@ -423,13 +425,172 @@ func TestReconcileGateway_electActiveEndpoint(t *testing.T) {
 	for _, v := range tt {
 		t.Run(v.name, func(t *testing.T) {
 			a := assert.New(t)
-			eps := mockReconciler.electActiveEndpoint(v.nodeList, v.gw)
+			eps, _ := mockReconciler.electActiveEndpoint(v.nodeList, v.gw)
 			a.Equal(len(v.expectedEps), len(eps))
 		})
 	}
 
 }
 
+func TestReconcileGateway_failover(t *testing.T) {
+	obj := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.RavenGlobalConfig,
+			Namespace: util.WorkingNamespace,
+		},
+		Data: map[string]string{
+			util.RavenEnableTunnel: "true",
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	mockReconciler := &ReconcileGateway{
+		Configuration: config.GatewayPickupControllerConfiguration{},
+		Client:        fake.NewClientBuilder().WithObjects(obj).Build(),
+		recorder:      recorder,
+	}
+
+	unhealthyStatus := nodeReadyStatus.DeepCopy()
+	unhealthyStatus.Conditions = append(unhealthyStatus.Conditions, corev1.NodeCondition{
+		Type:    ravenv1beta1.NodeTunnelHealthy,
+		Status:  corev1.ConditionFalse,
+		Message: "tunnel is down",
+	})
+	nodeList := corev1.NodeList{
+		Items: []corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Status: *unhealthyStatus},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}, Status: nodeReadyStatus},
+		},
+	}
+	policies := map[string]*ravenv1beta1.ElectionPolicy{
+		"without election policy": nil,
+		"with election policy":    {MinDwellSeconds: 60},
+	}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			gw := &ravenv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "gateway-1"},
+				Spec: ravenv1beta1.GatewaySpec{
+					TunnelConfig: ravenv1beta1.TunnelConfiguration{Replicas: 1},
+					Endpoints: []ravenv1beta1.Endpoint{
+						{NodeName: "node-1", Type: ravenv1beta1.Tunnel},
+						{NodeName: "node-2", Type: ravenv1beta1.Tunnel},
+					},
+					ElectionPolicy: policy,
+				},
+				Status: ravenv1beta1.GatewayStatus{
+					ActiveEndpoints: []*ravenv1beta1.Endpoint{{NodeName: "node-1", Type: ravenv1beta1.Tunnel}},
+				},
+			}
+
+			eps, _ := mockReconciler.electActiveEndpoint(nodeList, gw)
+			if assert.Len(t, eps, 1) {
+				assert.Equal(t, "node-2", eps[0].NodeName)
+			}
+			mockReconciler.recordFailoverEvent(gw, nodeList, gw.Status.ActiveEndpoints, eps)
+			select {
+			case e := <-recorder.Events:
+				assert.Contains(t, e, ravenv1beta1.EventActiveEndpointFailover)
+				assert.Contains(t, e, "tunnel is down, failover to node node-2")
+			default:
+				t.Errorf("expect failover event, but got nothing")
+			}
+		})
+	}
+}
+
+func TestReconcileGateway_exposeEndpoints(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.FormatName(util.GatewayTunnelServiceNamePrefix + "-gateway-1"),
+			Namespace: util.WorkingNamespace,
+			Labels: map[string]string{
+				raven.LabelCurrentGateway:     "gateway-1",
+				raven.LabelCurrentGatewayType: ravenv1beta1.Tunnel,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{{Port: 4500, NodePort: 30500}},
+		},
+	}
+	mockReconciler := &ReconcileGateway{
+		Configuration: config.GatewayPickupControllerConfiguration{},
+		Client:        fake.NewClientBuilder().WithObjects(svc).Build(),
+	}
+	nodeList := corev1.NodeList{
+		Items: []corev1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+					{Type: corev1.NodeInternalIP, Address: "192.168.0.1"},
+					{Type: corev1.NodeExternalIP, Address: "1.1.1.1"},
+					{Type: corev1.NodeExternalIP, Address: "2001:db8::1"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+				Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+					{Type: corev1.NodeInternalIP, Address: "192.168.0.2"},
+				}},
+			},
+		},
+	}
+
+	var tt = []struct {
+		name         string
+		exposeType   string
+		aep          ravenv1beta1.Endpoint
+		expectedIP   string
+		expectedIPs  []string
+		expectedPort int
+	}{
+		{
+			name:         "node port",
+			exposeType:   ravenv1beta1.ExposeTypeNodePort,
+			aep:          ravenv1beta1.Endpoint{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Port: 4500},
+			expectedIP:   "1.1.1.1",
+			expectedIPs:  []string{"1.1.1.1", "2001:db8::1"},
+			expectedPort: 30500,
+		},
+		{
+			name:         "host network without external ip",
+			exposeType:   ravenv1beta1.ExposeTypeHostNetwork,
+			aep:          ravenv1beta1.Endpoint{NodeName: "node-2", Type: ravenv1beta1.Proxy, Port: 10262},
+			expectedIP:   "192.168.0.2",
+			expectedIPs:  []string{"192.168.0.2"},
+			expectedPort: 10262,
+		},
+		{
+			name:         "public ip in spec is kept",
+			exposeType:   ravenv1beta1.ExposeTypeHostNetwork,
+			aep:          ravenv1beta1.Endpoint{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Port: 4500, PublicIP: "3.3.3.3", PublicPort: 14500},
+			expectedIP:   "3.3.3.3",
+			expectedPort: 14500,
+		},
+		{
+			name:         "load balancer is not changed",
+			exposeType:   ravenv1beta1.ExposeTypeLoadBalancer,
+			aep:          ravenv1beta1.Endpoint{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Port: 4500},
+			expectedIP:   "",
+			expectedPort: 0,
+		},
+	}
+	for _, v := range tt {
+		t.Run(v.name, func(t *testing.T) {
+			aep := v.aep.DeepCopy()
+			gw := &ravenv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "gateway-1"},
+				Spec:       ravenv1beta1.GatewaySpec{ExposeType: v.exposeType},
+				Status:     ravenv1beta1.GatewayStatus{ActiveEndpoints: []*ravenv1beta1.Endpoint{aep}},
+			}
+			mockReconciler.exposeEndpoints(context.Background(), gw, nodeList)
+			assert.Equal(t, v.expectedIP, aep.PublicIP)
+			assert.Equal(t, v.expectedIPs, aep.PublicIPs)
+			assert.Equal(t, v.expectedPort, aep.PublicPort)
+		})
+	}
+}
+
 func TestReconcileGateway_getPodCIDRs(t *testing.T) {
 	mockReconciler := &ReconcileGateway{
 		Configuration: config.GatewayPickupControllerConfiguration{},
@@ -448,6 +609,16 @@ func TestReconcileGateway_getPodCIDRs(t *testing.T) {
 			},
 			expectPodCIDR: []string{"10.0.0.1/24"},
 		},
+		{
+			name: "dual-stack node has pod CIDRs",
+			node: corev1.Node{
+				Spec: corev1.NodeSpec{
+					PodCIDR:  "10.0.0.1/24",
+					PodCIDRs: []string{"10.0.0.1/24", "fd00:10::/64"},
+				},
+			},
+			expectPodCIDR: []string{"10.0.0.1/24", "fd00:10::/64"},
+		},
 		{
 			name: "node hasn't pod CIDR",
 			node: corev1.Node{
@@ -542,3 +713,104 @@ func TestReconcileGateway_addExtraAllowedSubnet(t *testing.T) {
 		t.Errorf("failed add extra allowed subnet, expect %v, but get %v", expect.Status.Nodes, gw.Status.Nodes)
 	}
 }
+
+func TestConfigEdgeProxy(t *testing.T) {
+	testcases := map[string]struct {
+		exposeType string
+		proxy      ravenv1beta1.ProxyConfiguration
+		expect     map[string]string
+	}{
+		"edge gateway enables edge proxy": {
+			proxy: ravenv1beta1.ProxyConfiguration{EdgeProxy: true, AllowedSourceGateways: []string{"gw-b", "gw-a", "gw-b"}},
+			expect: map[string]string{
+				util.RavenEnableEdgeProxy:       "true",
+				util.EdgeProxyAllowedSourcesKey: "gw-a,gw-b",
+			},
+		},
+		"exposed gateway does not enable edge proxy": {
+			exposeType: ravenv1beta1.ExposeTypePublicIP,
+			proxy:      ravenv1beta1.ProxyConfiguration{EdgeProxy: true},
+			expect:     map[string]string{},
+		},
+		"edge proxy is not configured": {
+			expect: map[string]string{},
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			gw := &ravenv1beta1.Gateway{Spec: ravenv1beta1.GatewaySpec{ExposeType: tc.exposeType, ProxyConfig: tc.proxy}}
+			aep := &ravenv1beta1.Endpoint{NodeName: "node-1", Type: ravenv1beta1.Proxy, Config: map[string]string{}}
+			configEdgeProxy(gw, aep)
+			if !reflect.DeepEqual(tc.expect, aep.Config) {
+				t.Errorf("failed config edge proxy, expect %v, but get %v", tc.expect, aep.Config)
+			}
+		})
+	}
+}
+
+func TestReconcileGateway_failoverWithoutHealthyStandby(t *testing.T) {
+	obj := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.RavenGlobalConfig,
+			Namespace: util.WorkingNamespace,
+		},
+		Data: map[string]string{
+			util.RavenEnableTunnel: "true",
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	mockReconciler := &ReconcileGateway{
+		Configuration: config.GatewayPickupControllerConfiguration{},
+		Client:        fake.NewClientBuilder().WithObjects(obj).Build(),
+		recorder:      recorder,
+	}
+
+	unhealthyStatus := nodeReadyStatus.DeepCopy()
+	unhealthyStatus.Conditions = append(unhealthyStatus.Conditions, corev1.NodeCondition{
+		Type:    ravenv1beta1.NodeTunnelHealthy,
+		Status:  corev1.ConditionFalse,
+		Message: "tunnel is down",
+	})
+	nodeList := corev1.NodeList{
+		Items: []corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Status: *unhealthyStatus},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}, Status: *unhealthyStatus},
+		},
+	}
+	policies := map[string]*ravenv1beta1.ElectionPolicy{
+		"without election policy": nil,
+		"with election policy":    {MinDwellSeconds: 60},
+	}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			gw := &ravenv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "gateway-1"},
+				Spec: ravenv1beta1.GatewaySpec{
+					TunnelConfig: ravenv1beta1.TunnelConfiguration{Replicas: 1},
+					Endpoints: []ravenv1beta1.Endpoint{
+						{NodeName: "node-1", Type: ravenv1beta1.Tunnel},
+						{NodeName: "node-2", Type: ravenv1beta1.Tunnel},
+					},
+					ElectionPolicy: policy,
+				},
+				Status: ravenv1beta1.GatewayStatus{
+					ActiveEndpoints: []*ravenv1beta1.Endpoint{{NodeName: "node-1", Type: ravenv1beta1.Tunnel}},
+				},
+			}
+
+			eps, _ := mockReconciler.electActiveEndpoint(nodeList, gw)
+			if assert.Len(t, eps, 1) {
+				assert.Equal(t, "node-1", eps[0].NodeName)
+			}
+			mockReconciler.recordFailoverEvent(gw, nodeList, gw.Status.ActiveEndpoints, eps)
+			select {
+			case e := <-recorder.Events:
+				assert.Contains(t, e, ravenv1beta1.EventActiveEndpointUnhealthy)
+				assert.Contains(t, e, "tunnel is down, no healthy standby endpoint, keep it active")
+			default:
+				t.Errorf("expect unhealthy event, but got nothing")
+			}
+			assert.Empty(t, recorder.Events)
+		})
+	}
+}
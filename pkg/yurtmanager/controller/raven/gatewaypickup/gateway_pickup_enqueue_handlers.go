@@ -18,8 +18,10 @@ package gatewaypickup
 
 import (
 	"context"
+	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -31,7 +33,9 @@ import (
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
 )
 
-type EnqueueGatewayForNode struct{}
+type EnqueueGatewayForNode struct {
+	client client.Client
+}
 
 // Create implements EventHandler
 func (e *EnqueueGatewayForNode) Create(ctx context.Context, evt event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
@@ -69,15 +73,56 @@ func (e *EnqueueGatewayForNode) Update(ctx context.Context, evt event.UpdateEven
 	oldGwName := oldNode.Labels[raven.LabelCurrentGateway]
 	newGwName := newNode.Labels[raven.LabelCurrentGateway]
 
-	// check if NodeReady condition changed
+	// check if NodeReady condition, health probe or NAT discovery of endpoints changed
 	statusChanged := func(oldObj, newObj *corev1.Node) bool {
-		return isNodeReady(*oldObj) != isNodeReady(*newObj)
+		if isNodeReady(*oldObj) != isNodeReady(*newObj) {
+			return true
+		}
+		if oldObj.Annotations[raven.AnnotationNATDiscovery] != newObj.Annotations[raven.AnnotationNATDiscovery] {
+			return true
+		}
+		for _, endpointType := range []string{ravenv1beta1.Tunnel, ravenv1beta1.Proxy} {
+			oldHealthy, _ := isEndpointHealthy(oldObj, endpointType)
+			newHealthy, _ := isEndpointHealthy(newObj, endpointType)
+			if oldHealthy != newHealthy {
+				return true
+			}
+		}
+		return false
 	}
 
 	if oldGwName != newGwName || statusChanged(oldNode, newNode) {
 		util.AddGatewayToWorkQueue(oldGwName, q)
 		util.AddGatewayToWorkQueue(newGwName, q)
+	} else if newGwName != "" && oldNode.Annotations[raven.AnnotationTrafficStats] != newNode.Annotations[raven.AnnotationTrafficStats] {
+		// traffic statistics change on every report, the reports within a period are synced together
+		q.AddAfter(reconcile.Request{NamespacedName: types.NamespacedName{Name: newGwName}}, trafficSyncPeriod)
+	}
+
+	// the node may be selected by other gateways before or after its labels changed, they have to resolve
+	// the conflicts on the node again
+	if !reflect.DeepEqual(oldNode.Labels, newNode.Labels) {
+		if err := e.enqueueSelectingGateways(ctx, q, oldNode, newNode); err != nil {
+			klog.Error(Format("could not enqueue gateways selecting node(%s), error %s", newNode.GetName(), err.Error()))
+		}
+	}
+}
+
+// enqueueSelectingGateways enqueues every gateway whose node selector matches any of the nodes.
+func (e *EnqueueGatewayForNode) enqueueSelectingGateways(ctx context.Context, q workqueue.TypedRateLimitingInterface[reconcile.Request], nodes ...*corev1.Node) error {
+	var gwList ravenv1beta1.GatewayList
+	if err := e.client.List(ctx, &gwList); err != nil {
+		return err
+	}
+	for i := range gwList.Items {
+		for _, node := range nodes {
+			if util.GatewaySelectsNode(&gwList.Items[i], node) {
+				util.AddGatewayToWorkQueue(gwList.Items[i].Name, q)
+				break
+			}
+		}
 	}
+	return nil
 }
 
 // Delete implements EventHandler
@@ -178,3 +223,76 @@ func (e *EnqueueGatewayForRavenConfig) enqueueGateways(q workqueue.TypedRateLimi
 	}
 	return nil
 }
+
+// EnqueueGatewayForGateway enqueues the other gateways selecting nodes when the node selector of a gateway changes,
+// so that their Conflicted condition and managed nodes are resolved again.
+type EnqueueGatewayForGateway struct {
+	client client.Client
+}
+
+func (e *EnqueueGatewayForGateway) Create(ctx context.Context, evt event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	gw, ok := evt.Object.(*ravenv1beta1.Gateway)
+	if !ok {
+		klog.Error(Format("could not assert runtime Object to v1beta1.Gateway"))
+		return
+	}
+	if gw.Spec.NodeSelector == nil {
+		return
+	}
+	klog.V(4).Info(Format("Will enqueue gateways with node selector as gateway(%s) has been created", gw.GetName()))
+	if err := e.enqueueGateways(gw.GetName(), q); err != nil {
+		klog.Error(Format("could not enqueue gateways with node selector, error %s", err.Error()))
+	}
+}
+
+func (e *EnqueueGatewayForGateway) Update(ctx context.Context, evt event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	oldGw, ok := evt.ObjectOld.(*ravenv1beta1.Gateway)
+	if !ok {
+		klog.Error(Format("could not assert runtime Object to v1beta1.Gateway"))
+		return
+	}
+	newGw, ok := evt.ObjectNew.(*ravenv1beta1.Gateway)
+	if !ok {
+		klog.Error(Format("could not assert runtime Object to v1beta1.Gateway"))
+		return
+	}
+	if reflect.DeepEqual(oldGw.Spec.NodeSelector, newGw.Spec.NodeSelector) {
+		return
+	}
+	klog.V(4).Info(Format("Will enqueue gateways with node selector as node selector of gateway(%s) has been updated", newGw.GetName()))
+	if err := e.enqueueGateways(newGw.GetName(), q); err != nil {
+		klog.Error(Format("could not enqueue gateways with node selector, error %s", err.Error()))
+	}
+}
+
+func (e *EnqueueGatewayForGateway) Delete(ctx context.Context, evt event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	gw, ok := evt.Object.(*ravenv1beta1.Gateway)
+	if !ok {
+		klog.Error(Format("could not assert runtime Object to v1beta1.Gateway"))
+		return
+	}
+	if gw.Spec.NodeSelector == nil {
+		return
+	}
+	klog.V(4).Info(Format("Will enqueue gateways with node selector as gateway(%s) has been deleted", gw.GetName()))
+	if err := e.enqueueGateways(gw.GetName(), q); err != nil {
+		klog.Error(Format("could not enqueue gateways with node selector, error %s", err.Error()))
+	}
+}
+
+func (e *EnqueueGatewayForGateway) Generic(ctx context.Context, evt event.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+}
+
+func (e *EnqueueGatewayForGateway) enqueueGateways(exclude string, q workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+	var gwList ravenv1beta1.GatewayList
+	err := e.client.List(context.TODO(), &gwList)
+	if err != nil {
+		return err
+	}
+	for _, gw := range gwList.Items {
+		if gw.Name != exclude && gw.Spec.NodeSelector != nil {
+			util.AddGatewayToWorkQueue(gw.Name, q)
+		}
+	}
+	return nil
+}
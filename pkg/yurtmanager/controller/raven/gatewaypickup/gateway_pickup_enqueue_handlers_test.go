@@ -38,7 +38,17 @@ import (
 
 // TestEnqueueGatewayForNode tests the method of EnqueueGatewayForNode.
 func TestEnqueueGatewayForNode(t *testing.T) {
-	h := &EnqueueGatewayForNode{}
+	scheme := runtime.NewScheme()
+	_ = ravenv1beta1.AddToScheme(scheme)
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	selecting := &ravenv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw-selecting"},
+		Spec:       ravenv1beta1.GatewaySpec{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}}},
+	}
+	h := &EnqueueGatewayForNode{
+		client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(append(mockObjs(), selecting)...).Build(),
+	}
 	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
 
 	ctx := context.Background()
@@ -114,6 +124,27 @@ func TestEnqueueGatewayForNode(t *testing.T) {
 				h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, queue)
 			},
 		},
+		{
+			name:        "should get work queue len is 1 when Update Node labels selected by other gateway",
+			expectedLen: 1,
+			eventHandler: func() {
+				oldNode := mockNode()
+				newNode := oldNode.DeepCopy()
+				newNode.ObjectMeta.Labels["zone"] = "a"
+				h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, queue)
+			},
+		},
+		{
+			name:        "should get work queue len is 1 when Update Node labels no longer selected by other gateway",
+			expectedLen: 1,
+			eventHandler: func() {
+				oldNode := mockNode()
+				oldNode.ObjectMeta.Labels["zone"] = "a"
+				newNode := oldNode.DeepCopy()
+				delete(newNode.ObjectMeta.Labels, "zone")
+				h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, queue)
+			},
+		},
 		{
 			name:        "should get work queue len is 1 Update Node with status change",
 			expectedLen: 1,
@@ -124,6 +155,52 @@ func TestEnqueueGatewayForNode(t *testing.T) {
 				h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, queue)
 			},
 		},
+		{
+			name:        "should get work queue len is 1 Update Node with tunnel health probe failed",
+			expectedLen: 1,
+			eventHandler: func() {
+				oldNode := mockNode()
+				newNode := oldNode.DeepCopy()
+				newNode.Status.Conditions = append(newNode.Status.Conditions, corev1.NodeCondition{
+					Type:   ravenv1beta1.NodeTunnelHealthy,
+					Status: corev1.ConditionFalse,
+				})
+				h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, queue)
+			},
+		},
+		{
+			name:        "should get work queue len is 0 Update Node with healthy probe reported",
+			expectedLen: 0,
+			eventHandler: func() {
+				oldNode := mockNode()
+				newNode := oldNode.DeepCopy()
+				newNode.Status.Conditions = append(newNode.Status.Conditions, corev1.NodeCondition{
+					Type:   ravenv1beta1.NodeProxyHealthy,
+					Status: corev1.ConditionTrue,
+				})
+				h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, queue)
+			},
+		},
+		{
+			name:        "should get work queue len is 0 Update Node with traffic statistics reported within the sync period",
+			expectedLen: 0,
+			eventHandler: func() {
+				oldNode := mockNode()
+				newNode := oldNode.DeepCopy()
+				newNode.Annotations = map[string]string{raven.AnnotationTrafficStats: `[{"type":"tunnel","bytesIn":1024}]`}
+				h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, queue)
+			},
+		},
+		{
+			name:        "should get work queue len is 1 Update Node with NAT discovery reported",
+			expectedLen: 1,
+			eventHandler: func() {
+				oldNode := mockNode()
+				newNode := oldNode.DeepCopy()
+				newNode.Annotations = map[string]string{raven.AnnotationNATDiscovery: `{"underNAT":true,"natType":"Symmetric"}`}
+				h.Update(ctx, event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode}, queue)
+			},
+		},
 	}
 
 	for _, tc := range tests {
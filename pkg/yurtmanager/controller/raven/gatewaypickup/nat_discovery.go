@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewaypickup
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/util/natdiscovery"
+)
+
+// getNATDiscovery returns the NAT mapping reported on the node by raven agent, nil if it is not reported.
+func getNATDiscovery(node *corev1.Node) *natdiscovery.Result {
+	val, ok := node.Annotations[raven.AnnotationNATDiscovery]
+	if !ok {
+		return nil
+	}
+	var result natdiscovery.Result
+	if err := json.Unmarshal([]byte(val), &result); err != nil {
+		klog.Error(Format("could not parse nat discovery of node %s, error %s", node.Name, err.Error()))
+		return nil
+	}
+	return &result
+}
+
+// applyNATDiscovery fills the NAT mapping of endpoint by the discovery result,
+// the NAT type, public IP and public port declared in the endpoint are kept.
+func applyNATDiscovery(ep *ravenv1beta1.Endpoint, result *natdiscovery.Result) {
+	if !ep.UnderNAT && ep.NATType == "" {
+		ep.UnderNAT = result.UnderNAT
+		ep.NATType = result.NATType
+	}
+	if ep.PublicIP == "" && result.PublicIP != "" {
+		ep.PublicIP = result.PublicIP
+		ep.PublicIPs = []string{result.PublicIP}
+	}
+	if ep.PublicPort == 0 {
+		ep.PublicPort = result.PublicPort
+	}
+}
+
+// discoveredEndpoint returns a copy of endpoint with the NAT mapping discovered on node. The endpoint itself is
+// returned if the gateway is exposed, whose endpoints are not under NAT, if it is not a tunnel endpoint, since
+// the mapping is discovered from the tunnel socket, or nothing is discovered.
+func discoveredEndpoint(gw *ravenv1beta1.Gateway, ep *ravenv1beta1.Endpoint, node *corev1.Node) *ravenv1beta1.Endpoint {
+	if gw.Spec.ExposeType != "" || ep.Type != ravenv1beta1.Tunnel {
+		return ep
+	}
+	result := getNATDiscovery(node)
+	if result == nil {
+		return ep
+	}
+	discovered := ep.DeepCopy()
+	applyNATDiscovery(discovered, result)
+	return discovered
+}
+
+// discoverEndpoints populates the NAT mapping of active tunnel endpoints of the gateway not exposed,
+// so that raven agents could attempt hole punching between the gateways under NAT.
+func discoverEndpoints(gw *ravenv1beta1.Gateway, nodeList corev1.NodeList) {
+	if gw.Spec.ExposeType != "" {
+		return
+	}
+	for _, aep := range gw.Status.ActiveEndpoints {
+		if aep.Type != ravenv1beta1.Tunnel {
+			continue
+		}
+		for i := range nodeList.Items {
+			if nodeList.Items[i].Name != aep.NodeName {
+				continue
+			}
+			if result := getNATDiscovery(&nodeList.Items[i]); result != nil {
+				applyNATDiscovery(aep, result)
+			}
+			break
+		}
+	}
+}
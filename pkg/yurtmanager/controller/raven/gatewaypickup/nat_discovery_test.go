@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewaypickup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+func TestDiscoverEndpoints(t *testing.T) {
+	nodeList := corev1.NodeList{
+		Items: []corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Annotations: map[string]string{
+				raven.AnnotationNATDiscovery: `{"underNAT":true,"natType":"Symmetric","publicIP":"47.0.0.1","publicPort":14500}`,
+			}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-2", Annotations: map[string]string{
+				raven.AnnotationNATDiscovery: `invalid`,
+			}}},
+		},
+	}
+	testcases := map[string]struct {
+		exposeType string
+		active     []*ravenv1beta1.Endpoint
+		expect     []*ravenv1beta1.Endpoint
+	}{
+		"fill NAT mapping": {
+			active: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Port: 4500},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, Port: 4500},
+			},
+			expect: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Port: 4500, UnderNAT: true, NATType: ravenv1beta1.NATTypeSymmetric,
+					PublicIP: "47.0.0.1", PublicIPs: []string{"47.0.0.1"}, PublicPort: 14500},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel, Port: 4500},
+			},
+		},
+		"proxy endpoint on the same node is not filled": {
+			active: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Proxy, Port: 10262},
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Port: 4500},
+			},
+			expect: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Proxy, Port: 10262},
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, Port: 4500, UnderNAT: true, NATType: ravenv1beta1.NATTypeSymmetric,
+					PublicIP: "47.0.0.1", PublicIPs: []string{"47.0.0.1"}, PublicPort: 14500},
+			},
+		},
+		"declared NAT mapping is kept": {
+			active: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, UnderNAT: true, NATType: ravenv1beta1.NATTypeFullCone, PublicIP: "47.0.0.2"},
+			},
+			expect: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel, UnderNAT: true, NATType: ravenv1beta1.NATTypeFullCone, PublicIP: "47.0.0.2", PublicPort: 14500},
+			},
+		},
+		"exposed gateway is not under NAT": {
+			exposeType: ravenv1beta1.ExposeTypeLoadBalancer,
+			active:     []*ravenv1beta1.Endpoint{{NodeName: "node-1", Type: ravenv1beta1.Tunnel}},
+			expect:     []*ravenv1beta1.Endpoint{{NodeName: "node-1", Type: ravenv1beta1.Tunnel}},
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			gw := &ravenv1beta1.Gateway{
+				Spec:   ravenv1beta1.GatewaySpec{ExposeType: tc.exposeType},
+				Status: ravenv1beta1.GatewayStatus{ActiveEndpoints: tc.active},
+			}
+			discoverEndpoints(gw, nodeList)
+			assert.Equal(t, tc.expect, gw.Status.ActiveEndpoints)
+		})
+	}
+}
+
+func TestElectRankedEndpointsByNATDiscovery(t *testing.T) {
+	node1 := newElectionNode("node-1", true, time.Hour)
+	node1.Annotations = map[string]string{raven.AnnotationNATDiscovery: `{"underNAT":true,"natType":"Symmetric"}`}
+	node2 := newElectionNode("node-2", true, time.Hour)
+	node2.Annotations = map[string]string{raven.AnnotationNATDiscovery: `{"underNAT":true,"natType":"RestrictedCone"}`}
+	gw := &ravenv1beta1.Gateway{
+		Spec: ravenv1beta1.GatewaySpec{
+			TunnelConfig:   ravenv1beta1.TunnelConfiguration{Replicas: 1},
+			ElectionPolicy: &ravenv1beta1.ElectionPolicy{},
+			Endpoints: []ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel},
+				{NodeName: "node-2", Type: ravenv1beta1.Tunnel},
+			},
+		},
+	}
+	eps, _ := electRankedEndpoints(gw, ravenv1beta1.Tunnel, map[string]*corev1.Node{"node-1": node1, "node-2": node2}, electionNow)
+	if assert.Len(t, eps, 1) {
+		assert.Equal(t, "node-2", eps[0].NodeName)
+	}
+
+	// the NAT mapping of the tunnel socket does not rank the proxy endpoints
+	gw.Spec.ProxyConfig.Replicas = 1
+	gw.Spec.Endpoints = []ravenv1beta1.Endpoint{
+		{NodeName: "node-1", Type: ravenv1beta1.Proxy},
+		{NodeName: "node-2", Type: ravenv1beta1.Proxy},
+	}
+	eps, _ = electRankedEndpoints(gw, ravenv1beta1.Proxy, map[string]*corev1.Node{"node-1": node1, "node-2": node2}, electionNow)
+	if assert.Len(t, eps, 1) {
+		assert.Equal(t, "node-1", eps[0].NodeName)
+	}
+}
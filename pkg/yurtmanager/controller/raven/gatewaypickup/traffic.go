@@ -0,0 +1,192 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewaypickup
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+const gatewaySubsystem = "raven_gateway"
+
+// trafficSyncPeriod is the period within which the traffic statistics reported by raven agents are synced
+// to the gateway status at most once, since they change on every report.
+const trafficSyncPeriod = time.Minute
+
+var (
+	gatewayBytesIn = prometheus.NewDesc(
+		prometheus.BuildFQName("", gatewaySubsystem, "receive_bytes_total"),
+		"Number of bytes received by the active endpoints of gateway, reported by raven agents.",
+		[]string{"gateway", "type"}, nil,
+	)
+	gatewayBytesOut = prometheus.NewDesc(
+		prometheus.BuildFQName("", gatewaySubsystem, "transmit_bytes_total"),
+		"Number of bytes sent by the active endpoints of gateway, reported by raven agents.",
+		[]string{"gateway", "type"}, nil,
+	)
+	gatewayActiveConnections = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: gatewaySubsystem,
+			Name:      "active_connections",
+			Help:      "Number of active connections of the active endpoints of gateway, reported by raven agents.",
+		},
+		[]string{"gateway", "type"},
+	)
+	gatewayLastHandshake = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: gatewaySubsystem,
+			Name:      "last_handshake_timestamp_seconds",
+			Help:      "Unix timestamp of the last tunnel handshake of the active endpoints of gateway.",
+		},
+		[]string{"gateway", "type"},
+	)
+	gatewayBytes = &trafficBytesCollector{bytes: make(map[trafficKey]trafficBytes)}
+)
+
+func init() {
+	metrics.Registry.MustRegister(gatewayBytes, gatewayActiveConnections, gatewayLastHandshake)
+}
+
+type trafficKey struct {
+	gateway      string
+	endpointType string
+}
+
+type trafficBytes struct {
+	in  float64
+	out float64
+}
+
+// trafficBytesCollector exports the cumulative byte counts reported by raven agents as counters. They are
+// const metrics because the controller only sees the reported totals instead of the increments.
+type trafficBytesCollector struct {
+	sync.Mutex
+	bytes map[trafficKey]trafficBytes
+}
+
+// Describe implements prometheus.Collector
+func (c *trafficBytesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- gatewayBytesIn
+	ch <- gatewayBytesOut
+}
+
+// Collect implements prometheus.Collector
+func (c *trafficBytesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.Lock()
+	defer c.Unlock()
+	for key, bytes := range c.bytes {
+		ch <- prometheus.MustNewConstMetric(gatewayBytesIn, prometheus.CounterValue, bytes.in, key.gateway, key.endpointType)
+		ch <- prometheus.MustNewConstMetric(gatewayBytesOut, prometheus.CounterValue, bytes.out, key.gateway, key.endpointType)
+	}
+}
+
+func (c *trafficBytesCollector) set(gwName, endpointType string, in, out float64) {
+	c.Lock()
+	defer c.Unlock()
+	c.bytes[trafficKey{gateway: gwName, endpointType: endpointType}] = trafficBytes{in: in, out: out}
+}
+
+func (c *trafficBytesCollector) delete(gwName, endpointType string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.bytes, trafficKey{gateway: gwName, endpointType: endpointType})
+}
+
+// collectTraffic aggregates the traffic statistics reported on the nodes of active endpoints.
+// It returns nil if no active endpoint reports the statistics.
+func collectTraffic(gw *ravenv1beta1.Gateway, nodeList corev1.NodeList) *ravenv1beta1.GatewayTraffic {
+	nodes := make(map[string]*corev1.Node, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes[nodeList.Items[i].Name] = &nodeList.Items[i]
+	}
+
+	var traffic *ravenv1beta1.GatewayTraffic
+	for _, aep := range gw.Status.ActiveEndpoints {
+		node, ok := nodes[aep.NodeName]
+		if !ok {
+			continue
+		}
+		stats, ok := node.Annotations[raven.AnnotationTrafficStats]
+		if !ok {
+			continue
+		}
+		var eps []ravenv1beta1.EndpointTraffic
+		if err := json.Unmarshal([]byte(stats), &eps); err != nil {
+			klog.Error(Format("could not parse traffic statistics of node %s, error %s", node.Name, err.Error()))
+			continue
+		}
+		for _, ep := range eps {
+			if ep.Type != aep.Type {
+				continue
+			}
+			if traffic == nil {
+				traffic = &ravenv1beta1.GatewayTraffic{}
+			}
+			ep.NodeName = aep.NodeName
+			traffic.BytesIn += ep.BytesIn
+			traffic.BytesOut += ep.BytesOut
+			traffic.ActiveConnections += ep.ActiveConnections
+			traffic.Endpoints = append(traffic.Endpoints, ep)
+			break
+		}
+	}
+	return traffic
+}
+
+// recordTrafficMetrics exports the traffic statistics of gateway by endpoint type, nil traffic removes the metrics.
+func recordTrafficMetrics(gwName string, traffic *ravenv1beta1.GatewayTraffic) {
+	for _, endpointType := range []string{ravenv1beta1.Proxy, ravenv1beta1.Tunnel} {
+		var bytesIn, bytesOut, connections, handshake float64
+		var found bool
+		if traffic != nil {
+			for _, ep := range traffic.Endpoints {
+				if ep.Type != endpointType {
+					continue
+				}
+				found = true
+				bytesIn += float64(ep.BytesIn)
+				bytesOut += float64(ep.BytesOut)
+				connections += float64(ep.ActiveConnections)
+				if ep.LastHandshakeTime != nil && float64(ep.LastHandshakeTime.Unix()) > handshake {
+					handshake = float64(ep.LastHandshakeTime.Unix())
+				}
+			}
+		}
+		if !found {
+			gatewayBytes.delete(gwName, endpointType)
+			gatewayActiveConnections.DeleteLabelValues(gwName, endpointType)
+			gatewayLastHandshake.DeleteLabelValues(gwName, endpointType)
+			continue
+		}
+		gatewayBytes.set(gwName, endpointType, bytesIn, bytesOut)
+		gatewayActiveConnections.WithLabelValues(gwName, endpointType).Set(connections)
+		if handshake > 0 {
+			gatewayLastHandshake.WithLabelValues(gwName, endpointType).Set(handshake)
+		} else {
+			gatewayLastHandshake.DeleteLabelValues(gwName, endpointType)
+		}
+	}
+}
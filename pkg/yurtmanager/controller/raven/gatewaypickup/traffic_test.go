@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewaypickup
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+func TestCollectTraffic(t *testing.T) {
+	handshake := metav1.NewTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	nodeList := corev1.NodeList{
+		Items: []corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Annotations: map[string]string{
+				raven.AnnotationTrafficStats: `[{"type":"tunnel","bytesIn":100,"bytesOut":200,"activeConnections":2,"lastHandshakeTime":"2024-01-01T12:00:00Z"},{"type":"proxy","bytesIn":1}]`,
+			}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-2", Annotations: map[string]string{
+				raven.AnnotationTrafficStats: `[{"type":"proxy","bytesIn":10,"bytesOut":20,"activeConnections":5}]`,
+			}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-3", Annotations: map[string]string{
+				raven.AnnotationTrafficStats: `invalid`,
+			}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-4"}},
+		},
+	}
+	testcases := map[string]struct {
+		active []*ravenv1beta1.Endpoint
+		expect *ravenv1beta1.GatewayTraffic
+	}{
+		"aggregate active endpoints": {
+			active: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-1", Type: ravenv1beta1.Tunnel},
+				{NodeName: "node-2", Type: ravenv1beta1.Proxy},
+			},
+			expect: &ravenv1beta1.GatewayTraffic{
+				BytesIn:           110,
+				BytesOut:          220,
+				ActiveConnections: 7,
+				Endpoints: []ravenv1beta1.EndpointTraffic{
+					{NodeName: "node-1", Type: ravenv1beta1.Tunnel, BytesIn: 100, BytesOut: 200, ActiveConnections: 2, LastHandshakeTime: &handshake},
+					{NodeName: "node-2", Type: ravenv1beta1.Proxy, BytesIn: 10, BytesOut: 20, ActiveConnections: 5},
+				},
+			},
+		},
+		"statistics of other endpoint type are ignored": {
+			active: []*ravenv1beta1.Endpoint{{NodeName: "node-2", Type: ravenv1beta1.Tunnel}},
+		},
+		"invalid or missing statistics": {
+			active: []*ravenv1beta1.Endpoint{
+				{NodeName: "node-3", Type: ravenv1beta1.Tunnel},
+				{NodeName: "node-4", Type: ravenv1beta1.Tunnel},
+				{NodeName: "node-5", Type: ravenv1beta1.Tunnel},
+			},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			gw := &ravenv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "gateway-1"},
+				Status:     ravenv1beta1.GatewayStatus{ActiveEndpoints: tc.active},
+			}
+			traffic := collectTraffic(gw, nodeList)
+			if tc.expect == nil {
+				assert.Nil(t, traffic)
+				return
+			}
+			if assert.NotNil(t, traffic) {
+				assert.Equal(t, tc.expect.BytesIn, traffic.BytesIn)
+				assert.Equal(t, tc.expect.BytesOut, traffic.BytesOut)
+				assert.Equal(t, tc.expect.ActiveConnections, traffic.ActiveConnections)
+				assert.Len(t, traffic.Endpoints, len(tc.expect.Endpoints))
+				for i := range tc.expect.Endpoints {
+					expect, got := tc.expect.Endpoints[i], traffic.Endpoints[i]
+					assert.Equal(t, expect.NodeName, got.NodeName)
+					assert.Equal(t, expect.BytesIn, got.BytesIn)
+					assert.Equal(t, expect.ActiveConnections, got.ActiveConnections)
+					assert.True(t, expect.LastHandshakeTime.Equal(got.LastHandshakeTime))
+				}
+			}
+		})
+	}
+}
+
+func TestRecordTrafficMetrics(t *testing.T) {
+	handshake := metav1.NewTime(time.Unix(1700000000, 0))
+	recordTrafficMetrics("gw-metrics", &ravenv1beta1.GatewayTraffic{
+		Endpoints: []ravenv1beta1.EndpointTraffic{
+			{NodeName: "node-1", Type: ravenv1beta1.Tunnel, BytesIn: 100, BytesOut: 200, ActiveConnections: 2, LastHandshakeTime: &handshake},
+			{NodeName: "node-2", Type: ravenv1beta1.Proxy, BytesIn: 10, BytesOut: 20, ActiveConnections: 5},
+		},
+	})
+	expected := `
+# HELP raven_gateway_receive_bytes_total Number of bytes received by the active endpoints of gateway, reported by raven agents.
+# TYPE raven_gateway_receive_bytes_total counter
+raven_gateway_receive_bytes_total{gateway="gw-metrics",type="proxy"} 10
+raven_gateway_receive_bytes_total{gateway="gw-metrics",type="tunnel"} 100
+# HELP raven_gateway_transmit_bytes_total Number of bytes sent by the active endpoints of gateway, reported by raven agents.
+# TYPE raven_gateway_transmit_bytes_total counter
+raven_gateway_transmit_bytes_total{gateway="gw-metrics",type="proxy"} 20
+raven_gateway_transmit_bytes_total{gateway="gw-metrics",type="tunnel"} 200
+`
+	assert.NoError(t, testutil.CollectAndCompare(gatewayBytes, strings.NewReader(expected)))
+	assert.Equal(t, float64(5), testutil.ToFloat64(gatewayActiveConnections.WithLabelValues("gw-metrics", ravenv1beta1.Proxy)))
+	assert.Equal(t, float64(1700000000), testutil.ToFloat64(gatewayLastHandshake.WithLabelValues("gw-metrics", ravenv1beta1.Tunnel)))
+
+	recordTrafficMetrics("gw-metrics", nil)
+	assert.Equal(t, 0, testutil.CollectAndCount(gatewayBytes))
+	assert.Equal(t, 0, testutil.CollectAndCount(gatewayLastHandshake))
+}
@@ -44,11 +44,17 @@ import (
 	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
 	"github.com/openyurtio/openyurt/pkg/apis/raven"
 	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/util/natdiscovery"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
 )
 
 const (
 	ServiceDeleteFailed = "DeleteServiceFail"
+
+	// the names of ports of the tunnel service which exposes the nat discovery reflector besides the tunnel
+	tunnelPortName                = "tunnel"
+	natDiscoveryPortName          = "nat-discovery"
+	natDiscoveryAlternatePortName = "nat-discovery-alt"
 )
 
 func Format(format string, args ...interface{}) string {
@@ -275,7 +281,12 @@ func (r *ReconcileService) manageService(ctx context.Context, gateway *ravenv1be
 	proxyPort, tunnelPort := r.getTargetPort()
 	specSvcList := acquiredSpecService(gateway, gatewayType, proxyPort, tunnelPort)
 	addSvc, updateSvc, deleteSvc := classifyService(curSvcList, specSvcList)
-	recordServiceNames(specSvcList.Items, record)
+	// record names of the services to be created or updated, the name of reused service is kept
+	services := make([]corev1.Service, 0, len(addSvc)+len(updateSvc))
+	for _, svc := range append(addSvc, updateSvc...) {
+		services = append(services, *svc)
+	}
+	recordServiceNames(services, record)
 	for i := 0; i < len(addSvc); i++ {
 		if err := r.Create(ctx, addSvc[i]); err != nil {
 			if apierrs.IsAlreadyExists(err) {
@@ -364,7 +375,7 @@ func (r *ReconcileService) listService(ctx context.Context, gatewayName, gateway
 	}
 	newList := make([]corev1.Service, 0)
 	for _, val := range svcList.Items {
-		if val.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		if val.Spec.Type == corev1.ServiceTypeLoadBalancer || val.Spec.Type == corev1.ServiceTypeNodePort {
 			newList = append(newList, val)
 		}
 	}
@@ -392,7 +403,7 @@ func (r *ReconcileService) acquiredSpecEndpoints(ctx context.Context, gateway *r
 		if aep.Type != gatewayType {
 			continue
 		}
-		address, err := r.getEndpointsAddress(ctx, aep.NodeName)
+		addresses, err := r.getEndpointsAddresses(ctx, aep.NodeName)
 		if err != nil {
 			continue
 		}
@@ -414,7 +425,7 @@ func (r *ReconcileService) acquiredSpecEndpoints(ctx context.Context, gateway *r
 				},
 				Subsets: []corev1.EndpointSubset{
 					{
-						Addresses: []corev1.EndpointAddress{*address},
+						Addresses: addresses,
 						Ports: []corev1.EndpointPort{
 							{
 								Port:     proxyPort,
@@ -441,13 +452,8 @@ func (r *ReconcileService) acquiredSpecEndpoints(ctx context.Context, gateway *r
 				},
 				Subsets: []corev1.EndpointSubset{
 					{
-						Addresses: []corev1.EndpointAddress{*address},
-						Ports: []corev1.EndpointPort{
-							{
-								Port:     tunnelPort,
-								Protocol: corev1.ProtocolUDP,
-							},
-						},
+						Addresses: addresses,
+						Ports:     tunnelEndpointPorts(gateway.Spec.ExposeType, tunnelPort),
 					},
 				},
 			})
@@ -456,14 +462,22 @@ func (r *ReconcileService) acquiredSpecEndpoints(ctx context.Context, gateway *r
 	return &corev1.EndpointsList{Items: endpoints}
 }
 
-func (r *ReconcileService) getEndpointsAddress(ctx context.Context, name string) (*corev1.EndpointAddress, error) {
+// getEndpointsAddresses returns the addresses of node hosting active endpoint, one for each IP family.
+func (r *ReconcileService) getEndpointsAddresses(ctx context.Context, name string) ([]corev1.EndpointAddress, error) {
 	var node corev1.Node
 	err := r.Get(ctx, types.NamespacedName{Name: name}, &node)
 	if err != nil {
 		klog.Error(Format("could not get node %s for get active endpoints address, error %s", name, err.Error()))
 		return nil, err
 	}
-	return &corev1.EndpointAddress{NodeName: func(n corev1.Node) *string { return &n.Name }(node), IP: util.GetNodeInternalIP(node)}, nil
+	addresses := make([]corev1.EndpointAddress, 0, 2)
+	for _, ip := range util.GetNodeInternalIPs(node) {
+		addresses = append(addresses, corev1.EndpointAddress{NodeName: func(n corev1.Node) *string { return &n.Name }(node), IP: ip})
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("node %s has no internal ip", name)
+	}
+	return addresses, nil
 }
 
 func acquiredSpecService(gateway *ravenv1beta1.Gateway, gatewayType string, proxyPort, tunnelPort int32) *corev1.ServiceList {
@@ -471,9 +485,10 @@ func acquiredSpecService(gateway *ravenv1beta1.Gateway, gatewayType string, prox
 	if gateway == nil {
 		return &corev1.ServiceList{Items: services}
 	}
-	if gateway.Spec.ExposeType != ravenv1beta1.ExposeTypeLoadBalancer {
+	if !util.NeedPublicService(gateway.Spec.ExposeType) {
 		return &corev1.ServiceList{Items: services}
 	}
+	serviceType := corev1.ServiceType(gateway.Spec.ExposeType)
 	for _, aep := range gateway.Status.ActiveEndpoints {
 		if aep.Type != gatewayType {
 			continue
@@ -495,8 +510,9 @@ func acquiredSpecService(gateway *ravenv1beta1.Gateway, gatewayType string, prox
 					Annotations: map[string]string{"svc.openyurt.io/discard": "true"},
 				},
 				Spec: corev1.ServiceSpec{
-					Type:                  corev1.ServiceTypeLoadBalancer,
+					Type:                  serviceType,
 					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+					IPFamilyPolicy:        util.PreferDualStack(),
 					Ports: []corev1.ServicePort{
 						{
 							Protocol: corev1.ProtocolTCP,
@@ -505,6 +521,7 @@ func acquiredSpecService(gateway *ravenv1beta1.Gateway, gatewayType string, prox
 								Type:   intstr.Int,
 								IntVal: proxyPort,
 							},
+							NodePort: nodePort(serviceType, aep),
 						},
 					},
 				},
@@ -522,18 +539,10 @@ func acquiredSpecService(gateway *ravenv1beta1.Gateway, gatewayType string, prox
 					Annotations: map[string]string{"svc.openyurt.io/discard": "true"},
 				},
 				Spec: corev1.ServiceSpec{
-					Type:                  corev1.ServiceTypeLoadBalancer,
+					Type:                  serviceType,
 					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
-					Ports: []corev1.ServicePort{
-						{
-							Protocol: corev1.ProtocolUDP,
-							Port:     int32(aep.Port),
-							TargetPort: intstr.IntOrString{
-								Type:   intstr.Int,
-								IntVal: tunnelPort,
-							},
-						},
-					},
+					IPFamilyPolicy:        util.PreferDualStack(),
+					Ports:                 tunnelServicePorts(serviceType, aep, tunnelPort),
 				},
 			})
 		}
@@ -541,6 +550,64 @@ func acquiredSpecService(gateway *ravenv1beta1.Gateway, gatewayType string, prox
 	return &corev1.ServiceList{Items: services}
 }
 
+// tunnelServicePorts returns the ports of the tunnel service. The load balancer exposes the nat discovery reflector
+// run by the active endpoint as well, so that the endpoints of gateways under NAT could query it at the public IP.
+// The reflector of other expose types is reached at the public IP of node directly. The response from the alternate
+// port to a request of the primary port is dropped by the load balancer, so the NAT filtering is not checked by
+// querying the reflector behind it.
+func tunnelServicePorts(serviceType corev1.ServiceType, aep *ravenv1beta1.Endpoint, tunnelPort int32) []corev1.ServicePort {
+	ports := []corev1.ServicePort{
+		{
+			Protocol: corev1.ProtocolUDP,
+			Port:     int32(aep.Port),
+			TargetPort: intstr.IntOrString{
+				Type:   intstr.Int,
+				IntVal: tunnelPort,
+			},
+			NodePort: nodePort(serviceType, aep),
+		},
+	}
+	if serviceType != corev1.ServiceTypeLoadBalancer {
+		return ports
+	}
+	ports[0].Name = tunnelPortName
+	return append(ports,
+		corev1.ServicePort{
+			Name:       natDiscoveryPortName,
+			Protocol:   corev1.ProtocolUDP,
+			Port:       natdiscovery.DefaultPort,
+			TargetPort: intstr.FromInt32(natdiscovery.DefaultPort),
+		},
+		corev1.ServicePort{
+			Name:       natDiscoveryAlternatePortName,
+			Protocol:   corev1.ProtocolUDP,
+			Port:       natdiscovery.DefaultAlternatePort,
+			TargetPort: intstr.FromInt32(natdiscovery.DefaultAlternatePort),
+		},
+	)
+}
+
+// tunnelEndpointPorts returns the ports of the endpoints of tunnel service, which match the ports of service by name.
+func tunnelEndpointPorts(exposeType string, tunnelPort int32) []corev1.EndpointPort {
+	if exposeType != ravenv1beta1.ExposeTypeLoadBalancer {
+		return []corev1.EndpointPort{{Port: tunnelPort, Protocol: corev1.ProtocolUDP}}
+	}
+	return []corev1.EndpointPort{
+		{Name: tunnelPortName, Port: tunnelPort, Protocol: corev1.ProtocolUDP},
+		{Name: natDiscoveryPortName, Port: natdiscovery.DefaultPort, Protocol: corev1.ProtocolUDP},
+		{Name: natDiscoveryAlternatePortName, Port: natdiscovery.DefaultAlternatePort, Protocol: corev1.ProtocolUDP},
+	}
+}
+
+// nodePort returns the node port of NodePort service for the active endpoint. The public port of active endpoint
+// is the node port allocated at the first time, so the node port is kept for the gateway.
+func nodePort(serviceType corev1.ServiceType, aep *ravenv1beta1.Endpoint) int32 {
+	if serviceType != corev1.ServiceTypeNodePort || aep.PublicPort < 1 || aep.PublicPort > 65535 {
+		return 0
+	}
+	return int32(aep.PublicPort)
+}
+
 func classifyService(current, spec *corev1.ServiceList) (added, updated, deleted []*corev1.Service) {
 	added = make([]*corev1.Service, 0)
 	updated = make([]*corev1.Service, 0)
@@ -581,6 +648,20 @@ func classifyService(current, spec *corev1.ServiceList) (added, updated, deleted
 		deleted = append(deleted, current.Items[val].DeepCopy())
 		delete(r, key)
 	}
+	return reuseDeletedService(added, updated, deleted)
+}
+
+// reuseDeletedService turns the pairs of added and deleted services into updated services, it happens when
+// the active endpoint is changed, so the service and its allocated node port and load balancer are kept for
+// the gateway. All services are of the same gateway and type.
+func reuseDeletedService(added, updated, deleted []*corev1.Service) ([]*corev1.Service, []*corev1.Service, []*corev1.Service) {
+	for len(added) != 0 && len(deleted) != 0 {
+		updatedService := deleted[0].DeepCopy()
+		updatedService.Labels = added[0].Labels
+		updatedService.Spec = *added[0].Spec.DeepCopy()
+		updated = append(updated, updatedService)
+		added, deleted = added[1:], deleted[1:]
+	}
 	return added, updated, deleted
 }
 
@@ -623,7 +704,36 @@ func classifyEndpoints(current, spec *corev1.EndpointsList) (added, updated, del
 		deleted = append(deleted, current.Items[val].DeepCopy())
 		delete(r, key)
 	}
-	return added, updated, deleted
+	return reuseDeletedEndpoints(added, updated, deleted)
+}
+
+// reuseDeletedEndpoints turns the pair of added and deleted endpoints with the same name into updated endpoints,
+// it happens when the active endpoint is changed and the service is reused.
+func reuseDeletedEndpoints(added, updated, deleted []*corev1.Endpoints) ([]*corev1.Endpoints, []*corev1.Endpoints, []*corev1.Endpoints) {
+	deletedByName := make(map[string]*corev1.Endpoints)
+	for _, eps := range deleted {
+		deletedByName[eps.GetName()] = eps
+	}
+	newAdded := make([]*corev1.Endpoints, 0, len(added))
+	for _, eps := range added {
+		old, ok := deletedByName[eps.GetName()]
+		if !ok {
+			newAdded = append(newAdded, eps)
+			continue
+		}
+		delete(deletedByName, eps.GetName())
+		updatedEndpoints := old.DeepCopy()
+		updatedEndpoints.Labels = eps.Labels
+		updatedEndpoints.Subsets = eps.DeepCopy().Subsets
+		updated = append(updated, updatedEndpoints)
+	}
+	newDeleted := make([]*corev1.Endpoints, 0, len(deletedByName))
+	for _, eps := range deleted {
+		if _, ok := deletedByName[eps.GetName()]; ok {
+			newDeleted = append(newDeleted, eps)
+		}
+	}
+	return newAdded, updated, newDeleted
 }
 
 func formatKey(endpointName, endpointType string) string {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -32,6 +33,7 @@ import (
 	"github.com/openyurtio/openyurt/pkg/apis"
 	"github.com/openyurtio/openyurt/pkg/apis/raven"
 	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/util/natdiscovery"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
 )
 
@@ -213,3 +215,118 @@ func TestReconcileService_Reconcile(t *testing.T) {
 		t.Errorf("failed to reconcile service %s", MockGateway)
 	}
 }
+
+func TestAcquiredSpecService(t *testing.T) {
+	gw := &ravenv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: MockGateway},
+		Spec:       ravenv1beta1.GatewaySpec{ExposeType: ravenv1beta1.ExposeTypeNodePort},
+		Status: ravenv1beta1.GatewayStatus{
+			ActiveEndpoints: []*ravenv1beta1.Endpoint{
+				{NodeName: Node1Name, Type: ravenv1beta1.Tunnel, Port: ravenv1beta1.DefaultTunnelServerExposedPort, PublicPort: 30500},
+				{NodeName: Node1Name, Type: ravenv1beta1.Proxy, Port: ravenv1beta1.DefaultProxyServerExposedPort},
+			},
+		},
+	}
+
+	t.Run("node port is kept for tunnel", func(t *testing.T) {
+		svcList := acquiredSpecService(gw, ravenv1beta1.Tunnel, ravenv1beta1.DefaultProxyServerSecurePort, ravenv1beta1.DefaultTunnelServerExposedPort)
+		if assert.Len(t, svcList.Items, 1) {
+			assert.Equal(t, corev1.ServiceTypeNodePort, svcList.Items[0].Spec.Type)
+			assert.Equal(t, int32(30500), svcList.Items[0].Spec.Ports[0].NodePort)
+		}
+	})
+
+	t.Run("node port is allocated for proxy", func(t *testing.T) {
+		svcList := acquiredSpecService(gw, ravenv1beta1.Proxy, ravenv1beta1.DefaultProxyServerSecurePort, ravenv1beta1.DefaultTunnelServerExposedPort)
+		if assert.Len(t, svcList.Items, 1) {
+			assert.Equal(t, corev1.ServiceTypeNodePort, svcList.Items[0].Spec.Type)
+			assert.Equal(t, int32(0), svcList.Items[0].Spec.Ports[0].NodePort)
+		}
+	})
+
+	t.Run("load balancer exposes the nat discovery reflector", func(t *testing.T) {
+		lbGw := gw.DeepCopy()
+		lbGw.Spec.ExposeType = ravenv1beta1.ExposeTypeLoadBalancer
+		svcList := acquiredSpecService(lbGw, ravenv1beta1.Tunnel, ravenv1beta1.DefaultProxyServerSecurePort, ravenv1beta1.DefaultTunnelServerExposedPort)
+		if assert.Len(t, svcList.Items, 1) {
+			ports := svcList.Items[0].Spec.Ports
+			if assert.Len(t, ports, 3) {
+				assert.Equal(t, tunnelPortName, ports[0].Name)
+				assert.Equal(t, int32(natdiscovery.DefaultPort), ports[1].Port)
+				assert.Equal(t, int32(natdiscovery.DefaultAlternatePort), ports[2].Port)
+			}
+			epsPorts := tunnelEndpointPorts(lbGw.Spec.ExposeType, ravenv1beta1.DefaultTunnelServerExposedPort)
+			if assert.Len(t, epsPorts, len(ports)) {
+				for i := range ports {
+					assert.Equal(t, ports[i].Name, epsPorts[i].Name)
+					assert.Equal(t, ports[i].TargetPort.IntVal, epsPorts[i].Port)
+				}
+			}
+		}
+	})
+
+	t.Run("no service for host network", func(t *testing.T) {
+		hostGw := gw.DeepCopy()
+		hostGw.Spec.ExposeType = ravenv1beta1.ExposeTypeHostNetwork
+		svcList := acquiredSpecService(hostGw, ravenv1beta1.Tunnel, ravenv1beta1.DefaultProxyServerSecurePort, ravenv1beta1.DefaultTunnelServerExposedPort)
+		assert.Empty(t, svcList.Items)
+	})
+}
+
+func TestClassifyService(t *testing.T) {
+	newService := func(nodeName string) corev1.Service {
+		return corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      util.FormatName("x-raven-tunnel-svc-gw-mock"),
+				Namespace: util.WorkingNamespace,
+				Labels: map[string]string{
+					raven.LabelCurrentGateway:         MockGateway,
+					raven.LabelCurrentGatewayType:     ravenv1beta1.Tunnel,
+					util.LabelCurrentGatewayEndpoints: nodeName,
+				},
+			},
+			Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort},
+		}
+	}
+	current := &corev1.ServiceList{Items: []corev1.Service{newService(Node1Name)}}
+	current.Items[0].ResourceVersion = "1"
+	spec := &corev1.ServiceList{Items: []corev1.Service{newService(Node2Name)}}
+
+	// the active endpoint is changed from node-1 to node-2, the service is updated rather than recreated
+	added, updated, deleted := classifyService(current, spec)
+	assert.Empty(t, added)
+	assert.Empty(t, deleted)
+	if assert.Len(t, updated, 1) {
+		assert.Equal(t, Node2Name, updated[0].Labels[util.LabelCurrentGatewayEndpoints])
+		assert.Equal(t, current.Items[0].Name, updated[0].Name)
+		assert.Equal(t, "1", updated[0].ResourceVersion)
+	}
+}
+
+func TestClassifyEndpoints(t *testing.T) {
+	newEndpoints := func(nodeName string) corev1.Endpoints {
+		return corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "x-raven-tunnel-svc-gw-mock-00000001",
+				Namespace: util.WorkingNamespace,
+				Labels: map[string]string{
+					raven.LabelCurrentGateway:         MockGateway,
+					raven.LabelCurrentGatewayType:     ravenv1beta1.Tunnel,
+					util.LabelCurrentGatewayEndpoints: nodeName,
+				},
+			},
+			Subsets: []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: nodeName}}}},
+		}
+	}
+	current := &corev1.EndpointsList{Items: []corev1.Endpoints{newEndpoints(Node1Name)}}
+	spec := &corev1.EndpointsList{Items: []corev1.Endpoints{newEndpoints(Node2Name)}}
+
+	// the endpoints of reused service are updated rather than recreated
+	added, updated, deleted := classifyEndpoints(current, spec)
+	assert.Empty(t, added)
+	assert.Empty(t, deleted)
+	if assert.Len(t, updated, 1) {
+		assert.Equal(t, Node2Name, updated[0].Labels[util.LabelCurrentGatewayEndpoints])
+		assert.Equal(t, Node2Name, updated[0].Subsets[0].Addresses[0].IP)
+	}
+}
@@ -39,7 +39,7 @@ func (h *EnqueueRequestForGatewayEvent) Create(ctx context.Context, e event.Crea
 		klog.Error(Format("could not assert runtime Object %s/%s to v1beta1.Gateway,", e.Object.GetNamespace(), e.Object.GetName()))
 		return
 	}
-	if gw.Spec.ExposeType != ravenv1beta1.ExposeTypeLoadBalancer {
+	if !util.NeedPublicService(gw.Spec.ExposeType) {
 		return
 	}
 	klog.V(4).Info(Format("enqueue gateway %s as create event", gw.GetName()))
@@ -69,7 +69,7 @@ func (h *EnqueueRequestForGatewayEvent) Delete(ctx context.Context, e event.Dele
 		klog.Error(Format("could not assert runtime Object %s/%s to v1beta1.Gateway,", e.Object.GetNamespace(), e.Object.GetName()))
 		return
 	}
-	if gw.Spec.ExposeType != ravenv1beta1.ExposeTypeLoadBalancer {
+	if !util.NeedPublicService(gw.Spec.ExposeType) {
 		return
 	}
 	klog.V(4).Info(Format("enqueue gateway %s as delete event", gw.GetName()))
@@ -80,7 +80,7 @@ func (h *EnqueueRequestForGatewayEvent) Generic(ctx context.Context, e event.Gen
 }
 
 func needUpdate(newObj, oldObj *ravenv1beta1.Gateway) bool {
-	if newObj.Spec.ExposeType == ravenv1beta1.ExposeTypeLoadBalancer || oldObj.Spec.ExposeType == ravenv1beta1.ExposeTypeLoadBalancer {
+	if util.NeedPublicService(newObj.Spec.ExposeType) || util.NeedPublicService(oldObj.Spec.ExposeType) {
 		if newObj.Spec.ExposeType != oldObj.Spec.ExposeType {
 			return true
 		}
@@ -152,7 +152,7 @@ func addExposedGateway(client client.Client, q workqueue.TypedRateLimitingInterf
 		return
 	}
 	for _, gw := range gwList.Items {
-		if gw.Spec.ExposeType == ravenv1beta1.ExposeTypeLoadBalancer {
+		if util.NeedPublicService(gw.Spec.ExposeType) {
 			klog.V(4).Info(Format("enqueue gateway %s", gw.GetName()))
 			util.AddGatewayToWorkQueue(gw.GetName(), q)
 		}
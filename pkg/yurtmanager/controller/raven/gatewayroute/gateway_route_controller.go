@@ -0,0 +1,223 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewayroute
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	yurtClient "github.com/openyurtio/openyurt/cmd/yurt-manager/app/client"
+	appconfig "github.com/openyurtio/openyurt/cmd/yurt-manager/app/config"
+	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
+)
+
+func Format(format string, args ...interface{}) string {
+	s := fmt.Sprintf(format, args...)
+	return fmt.Sprintf("%s: %s", names.GatewayRouteController, s)
+}
+
+// Add creates a new GatewayRoute Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(ctx context.Context, c *appconfig.CompletedConfig, mgr manager.Manager) error {
+	return add(mgr, c, newReconciler(mgr))
+}
+
+var _ reconcile.Reconciler = &ReconcileGatewayRoute{}
+
+// ReconcileGatewayRoute computes the effective route table of all GatewayRoutes into the raven agent config
+type ReconcileGatewayRoute struct {
+	client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileGatewayRoute{
+		Client:   yurtClient.GetClientByControllerNameOrDie(mgr, names.GatewayRouteController),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor(names.GatewayRouteController),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(names.GatewayRouteController, mgr, controller.Options{
+		Reconciler: r, MaxConcurrentReconciles: int(cfg.ComponentConfig.GatewayRouteController.ConcurrentGatewayRouteWorkers),
+	})
+	if err != nil {
+		return err
+	}
+
+	// the route table is computed from all routes and gateways, so all events are mapped to the raven agent config
+	enqueueRouteTable := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: util.WorkingNamespace, Name: util.RavenAgentConfig}}}
+	})
+
+	// Watch for changes to GatewayRoute
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &ravenv1beta1.GatewayRoute{}, enqueueRouteTable, predicate.GenerationChangedPredicate{}))
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to Gateway, routes select gateways by labels
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &ravenv1beta1.Gateway{}, enqueueRouteTable, predicate.LabelChangedPredicate{}))
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to raven agent config
+	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.ConfigMap{}, enqueueRouteTable, predicate.NewPredicateFuncs(
+		func(object client.Object) bool {
+			return object.GetNamespace() == util.WorkingNamespace && object.GetName() == util.RavenAgentConfig
+		},
+	)))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=raven.openyurt.io,resources=gatewayroutes,verbs=get
+// +kubebuilder:rbac:groups=raven.openyurt.io,resources=gatewayroutes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=raven.openyurt.io,resources=gateways,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;update
+
+// Reconcile computes the links of all GatewayRoutes, records them in the status of GatewayRoutes
+// and writes the effective route table into the raven agent config.
+func (r *ReconcileGatewayRoute) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	klog.V(4).Info(Format("started reconciling route table %s", req.String()))
+	defer func() {
+		klog.V(4).Info(Format("finished reconciling route table %s", req.String()))
+	}()
+
+	var gwList ravenv1beta1.GatewayList
+	if err := r.List(ctx, &gwList); err != nil {
+		klog.Error(Format("could not list gateways, error %s", err.Error()))
+		return reconcile.Result{}, err
+	}
+	var routeList ravenv1beta1.GatewayRouteList
+	if err := r.List(ctx, &routeList); err != nil {
+		klog.Error(Format("could not list gateway routes, error %s", err.Error()))
+		return reconcile.Result{}, err
+	}
+
+	table := make(routeTable)
+	for i := range routeList.Items {
+		route := &routeList.Items[i]
+		if route.DeletionTimestamp != nil {
+			continue
+		}
+		members, links, missing, err := buildRouteLinks(route, gwList.Items)
+		if err != nil {
+			klog.Error(Format("could not build links of gateway route %s, error %s", route.GetName(), err.Error()))
+			r.recorder.Event(route, corev1.EventTypeWarning, ravenv1beta1.EventGatewayRouteInvalid, err.Error())
+		}
+		for _, link := range links {
+			table.addLink(link.Source, link.Destination)
+		}
+		if err := r.updateRouteStatus(ctx, route, members, links, missing); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if err := r.updateRouteTable(ctx, req.NamespacedName, table); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *ReconcileGatewayRoute) updateRouteStatus(ctx context.Context, route *ravenv1beta1.GatewayRoute, members []string, links []ravenv1beta1.GatewayPair, missing []string) error {
+	status := ravenv1beta1.GatewayRouteStatus{
+		ObservedGeneration: route.Generation,
+		Links:              int32(len(links)),
+	}
+	if len(members) != 0 {
+		status.Gateways = members
+	}
+	if len(missing) != 0 {
+		status.MissingGateways = missing
+	}
+	if reflect.DeepEqual(route.Status, status) {
+		return nil
+	}
+	route.Status = status
+	if err := r.Status().Update(ctx, route); err != nil {
+		klog.Error(Format("could not update status of gateway route %s, error %s", route.GetName(), err.Error()))
+		return err
+	}
+	return nil
+}
+
+// updateRouteTable writes the route table into the raven agent config, the key is removed if there is no link,
+// so that raven agents fall back to route the site-to-site traffic through the cloud.
+func (r *ReconcileGatewayRoute) updateRouteTable(ctx context.Context, key types.NamespacedName, table routeTable) error {
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, key, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.Info(Format("configmap %s is not found, skip writing route table", key.String()))
+			return nil
+		}
+		klog.Error(Format("could not get configmap %s, error %s", key.String(), err.Error()))
+		return err
+	}
+
+	routes, err := table.encode()
+	if err != nil {
+		klog.Error(Format("could not encode route table, error %s", err.Error()))
+		return err
+	}
+	old, ok := cm.Data[util.GatewayRoutesKey]
+	switch {
+	case len(table) == 0 && !ok:
+		return nil
+	case len(table) == 0:
+		delete(cm.Data, util.GatewayRoutesKey)
+	case ok && old == routes:
+		return nil
+	default:
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[util.GatewayRoutesKey] = routes
+	}
+
+	if err := r.Update(ctx, &cm); err != nil {
+		klog.Error(Format("could not update configmap %s, error %s", key.String(), err.Error()))
+		return err
+	}
+	return nil
+}
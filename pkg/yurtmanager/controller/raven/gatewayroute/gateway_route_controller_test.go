@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewayroute
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openyurtio/openyurt/pkg/apis"
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
+)
+
+func mockReconcile(t *testing.T, objs ...client.Object) *ReconcileGatewayRoute {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, apis.AddToScheme(scheme))
+	return &ReconcileGatewayRoute{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(&ravenv1beta1.GatewayRoute{}).Build(),
+		scheme:   scheme,
+		recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func TestReconcileGatewayRoute(t *testing.T) {
+	key := types.NamespacedName{Namespace: util.WorkingNamespace, Name: util.RavenAgentConfig}
+	gwA := mockGateway("gw-a", nil)
+	gwB := mockGateway("gw-b", nil)
+	gwC := mockGateway("gw-c", nil)
+	testcases := map[string]struct {
+		routes        []*ravenv1beta1.GatewayRoute
+		data          map[string]string
+		expectRoutes  string
+		expectHasKey  bool
+		expectStatus  map[string]ravenv1beta1.GatewayRouteStatus
+		expectWarning bool
+	}{
+		"union of routes": {
+			routes: []*ravenv1beta1.GatewayRoute{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "route-hub", Generation: 1},
+					Spec:       ravenv1beta1.GatewayRouteSpec{Mode: ravenv1beta1.RouteModeHubAndSpoke, Hubs: []string{"gw-a"}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "route-pairs", Generation: 2},
+					Spec: ravenv1beta1.GatewayRouteSpec{Mode: ravenv1beta1.RouteModePairs, Pairs: []ravenv1beta1.GatewayPair{
+						{Source: "gw-b", Destination: "gw-c"},
+						{Source: "gw-b", Destination: "gw-d"},
+					}},
+				},
+			},
+			data:         map[string]string{"other": "value"},
+			expectRoutes: `{"gw-a":["gw-b","gw-c"],"gw-b":["gw-a","gw-c"],"gw-c":["gw-a","gw-b"]}`,
+			expectHasKey: true,
+			expectStatus: map[string]ravenv1beta1.GatewayRouteStatus{
+				"route-hub":   {ObservedGeneration: 1, Gateways: []string{"gw-a", "gw-b", "gw-c"}, Links: 2},
+				"route-pairs": {ObservedGeneration: 2, Gateways: []string{"gw-b", "gw-c"}, Links: 1, MissingGateways: []string{"gw-d"}},
+			},
+		},
+		"route table is removed without routes": {
+			data:         map[string]string{util.GatewayRoutesKey: `{"gw-a":["gw-b"],"gw-b":["gw-a"]}`},
+			expectHasKey: false,
+		},
+		"invalid route": {
+			routes: []*ravenv1beta1.GatewayRoute{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "route-invalid", Generation: 1},
+					Spec:       ravenv1beta1.GatewayRouteSpec{Mode: "Ring"},
+				},
+			},
+			expectHasKey: false,
+			expectStatus: map[string]ravenv1beta1.GatewayRouteStatus{
+				"route-invalid": {ObservedGeneration: 1},
+			},
+			expectWarning: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			objs := []client.Object{
+				gwA.DeepCopy(), gwB.DeepCopy(), gwC.DeepCopy(),
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}, Data: tc.data},
+			}
+			for _, route := range tc.routes {
+				objs = append(objs, route)
+			}
+			r := mockReconcile(t, objs...)
+
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+			assert.NoError(t, err)
+
+			var cm corev1.ConfigMap
+			assert.NoError(t, r.Get(context.Background(), key, &cm))
+			routes, ok := cm.Data[util.GatewayRoutesKey]
+			assert.Equal(t, tc.expectHasKey, ok)
+			assert.Equal(t, tc.expectRoutes, routes)
+			for name, status := range tc.expectStatus {
+				var route ravenv1beta1.GatewayRoute
+				assert.NoError(t, r.Get(context.Background(), types.NamespacedName{Name: name}, &route))
+				assert.Equal(t, status, route.Status)
+			}
+			assert.Equal(t, tc.expectWarning, len(r.recorder.(*record.FakeRecorder).Events) != 0)
+		})
+	}
+}
+
+func TestReconcileGatewayRoute_withoutConfigMap(t *testing.T) {
+	gw := mockGateway("gw-a", nil)
+	r := mockReconcile(t, &gw)
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: util.WorkingNamespace, Name: util.RavenAgentConfig}})
+	assert.NoError(t, err)
+}
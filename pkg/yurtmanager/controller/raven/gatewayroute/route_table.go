@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewayroute
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+// routeTable maps the name of a gateway to the names of peer gateways which it may talk to directly.
+type routeTable map[string]sets.Set[string]
+
+// addLink adds a bidirectional link between gateway a and b.
+func (t routeTable) addLink(a, b string) {
+	if a == b {
+		return
+	}
+	if t[a] == nil {
+		t[a] = sets.New[string]()
+	}
+	if t[b] == nil {
+		t[b] = sets.New[string]()
+	}
+	t[a].Insert(b)
+	t[b].Insert(a)
+}
+
+// encode returns the route table in json, peers of each gateway are sorted so that the result is stable.
+func (t routeTable) encode() (string, error) {
+	routes := make(map[string][]string, len(t))
+	for gw, peers := range t {
+		routes[gw] = sets.List(peers)
+	}
+	data, err := json.Marshal(routes)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// buildRouteLinks computes the links declared by the route among the existing gateways. It returns the
+// gateways which take part in the route, the links between them, and the referenced gateways not found.
+func buildRouteLinks(route *ravenv1beta1.GatewayRoute, gateways []ravenv1beta1.Gateway) ([]string, []ravenv1beta1.GatewayPair, []string, error) {
+	existing := sets.New[string]()
+	for i := range gateways {
+		existing.Insert(gateways[i].GetName())
+	}
+	members := sets.New[string]()
+	missing := sets.New[string]()
+	links := make(map[ravenv1beta1.GatewayPair]struct{})
+	addLink := func(a, b string) {
+		if a == b {
+			return
+		}
+		if a > b {
+			a, b = b, a
+		}
+		members.Insert(a, b)
+		links[ravenv1beta1.GatewayPair{Source: a, Destination: b}] = struct{}{}
+	}
+
+	switch route.Spec.Mode {
+	case ravenv1beta1.RouteModeFullMesh:
+		selected, err := selectGateways(route.Spec.GatewaySelector, gateways)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for i := range selected {
+			for j := i + 1; j < len(selected); j++ {
+				addLink(selected[i], selected[j])
+			}
+		}
+	case ravenv1beta1.RouteModeHubAndSpoke:
+		selected, err := selectGateways(route.Spec.GatewaySelector, gateways)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		hubs := make([]string, 0, len(route.Spec.Hubs))
+		for _, hub := range route.Spec.Hubs {
+			if !existing.Has(hub) {
+				missing.Insert(hub)
+				continue
+			}
+			hubs = append(hubs, hub)
+		}
+		for i, hub := range hubs {
+			// hubs talk to each other, so that spokes of different hubs are reachable through hubs
+			for _, peer := range hubs[i+1:] {
+				addLink(hub, peer)
+			}
+			for _, spoke := range selected {
+				addLink(hub, spoke)
+			}
+		}
+	case ravenv1beta1.RouteModePairs:
+		for _, pair := range route.Spec.Pairs {
+			found := true
+			for _, name := range []string{pair.Source, pair.Destination} {
+				if !existing.Has(name) {
+					missing.Insert(name)
+					found = false
+				}
+			}
+			if found {
+				addLink(pair.Source, pair.Destination)
+			}
+		}
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown route mode %q", route.Spec.Mode)
+	}
+
+	pairs := make([]ravenv1beta1.GatewayPair, 0, len(links))
+	for pair := range links {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Source != pairs[j].Source {
+			return pairs[i].Source < pairs[j].Source
+		}
+		return pairs[i].Destination < pairs[j].Destination
+	})
+	return sets.List(members), pairs, sets.List(missing), nil
+}
+
+// selectGateways returns the sorted names of gateways matching the selector, nil selector matches all gateways.
+func selectGateways(selector *metav1.LabelSelector, gateways []ravenv1beta1.Gateway) ([]string, error) {
+	s := labels.Everything()
+	if selector != nil {
+		var err error
+		s, err = metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gateway selector, %s", err.Error())
+		}
+	}
+	names := make([]string, 0, len(gateways))
+	for i := range gateways {
+		if s.Matches(labels.Set(gateways[i].GetLabels())) {
+			names = append(names, gateways[i].GetName())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
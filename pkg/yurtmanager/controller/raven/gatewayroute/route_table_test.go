@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewayroute
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+func mockGateway(name string, labels map[string]string) ravenv1beta1.Gateway {
+	return ravenv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestBuildRouteLinks(t *testing.T) {
+	gateways := []ravenv1beta1.Gateway{
+		mockGateway("gw-cloud", map[string]string{"site": "cloud"}),
+		mockGateway("gw-a", map[string]string{"site": "edge"}),
+		mockGateway("gw-b", map[string]string{"site": "edge"}),
+		mockGateway("gw-c", map[string]string{"site": "edge"}),
+	}
+	testcases := map[string]struct {
+		spec          ravenv1beta1.GatewayRouteSpec
+		expectMembers []string
+		expectLinks   []ravenv1beta1.GatewayPair
+		expectMissing []string
+		expectErr     bool
+	}{
+		"full mesh of selected gateways": {
+			spec: ravenv1beta1.GatewayRouteSpec{
+				Mode:            ravenv1beta1.RouteModeFullMesh,
+				GatewaySelector: &metav1.LabelSelector{MatchLabels: map[string]string{"site": "edge"}},
+			},
+			expectMembers: []string{"gw-a", "gw-b", "gw-c"},
+			expectLinks: []ravenv1beta1.GatewayPair{
+				{Source: "gw-a", Destination: "gw-b"},
+				{Source: "gw-a", Destination: "gw-c"},
+				{Source: "gw-b", Destination: "gw-c"},
+			},
+			expectMissing: []string{},
+		},
+		"full mesh selects all gateways without selector": {
+			spec:          ravenv1beta1.GatewayRouteSpec{Mode: ravenv1beta1.RouteModeFullMesh},
+			expectMembers: []string{"gw-a", "gw-b", "gw-c", "gw-cloud"},
+			expectLinks: []ravenv1beta1.GatewayPair{
+				{Source: "gw-a", Destination: "gw-b"},
+				{Source: "gw-a", Destination: "gw-c"},
+				{Source: "gw-a", Destination: "gw-cloud"},
+				{Source: "gw-b", Destination: "gw-c"},
+				{Source: "gw-b", Destination: "gw-cloud"},
+				{Source: "gw-c", Destination: "gw-cloud"},
+			},
+			expectMissing: []string{},
+		},
+		"hub and spoke": {
+			spec: ravenv1beta1.GatewayRouteSpec{
+				Mode:            ravenv1beta1.RouteModeHubAndSpoke,
+				GatewaySelector: &metav1.LabelSelector{MatchLabels: map[string]string{"site": "edge"}},
+				Hubs:            []string{"gw-a", "gw-hub"},
+			},
+			expectMembers: []string{"gw-a", "gw-b", "gw-c"},
+			expectLinks: []ravenv1beta1.GatewayPair{
+				{Source: "gw-a", Destination: "gw-b"},
+				{Source: "gw-a", Destination: "gw-c"},
+			},
+			expectMissing: []string{"gw-hub"},
+		},
+		"hubs talk to each other": {
+			spec: ravenv1beta1.GatewayRouteSpec{
+				Mode:            ravenv1beta1.RouteModeHubAndSpoke,
+				GatewaySelector: &metav1.LabelSelector{MatchLabels: map[string]string{"site": "none"}},
+				Hubs:            []string{"gw-cloud", "gw-a"},
+			},
+			expectMembers: []string{"gw-a", "gw-cloud"},
+			expectLinks:   []ravenv1beta1.GatewayPair{{Source: "gw-a", Destination: "gw-cloud"}},
+			expectMissing: []string{},
+		},
+		"explicit pairs": {
+			spec: ravenv1beta1.GatewayRouteSpec{
+				Mode: ravenv1beta1.RouteModePairs,
+				Pairs: []ravenv1beta1.GatewayPair{
+					{Source: "gw-c", Destination: "gw-a"},
+					{Source: "gw-a", Destination: "gw-c"},
+					{Source: "gw-b", Destination: "gw-x"},
+				},
+			},
+			expectMembers: []string{"gw-a", "gw-c"},
+			expectLinks:   []ravenv1beta1.GatewayPair{{Source: "gw-a", Destination: "gw-c"}},
+			expectMissing: []string{"gw-x"},
+		},
+		"unknown mode": {
+			spec:      ravenv1beta1.GatewayRouteSpec{Mode: "Ring"},
+			expectErr: true,
+		},
+		"invalid selector": {
+			spec: ravenv1beta1.GatewayRouteSpec{
+				Mode: ravenv1beta1.RouteModeFullMesh,
+				GatewaySelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "site", Operator: "Unknown"},
+				}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			route := &ravenv1beta1.GatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route-1"}, Spec: tc.spec}
+			members, links, missing, err := buildRouteLinks(route, gateways)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectMembers, members)
+			assert.Equal(t, tc.expectLinks, links)
+			assert.Equal(t, tc.expectMissing, missing)
+		})
+	}
+}
+
+func TestRouteTableEncode(t *testing.T) {
+	table := make(routeTable)
+	table.addLink("gw-b", "gw-a")
+	table.addLink("gw-c", "gw-a")
+	table.addLink("gw-a", "gw-a")
+
+	routes, err := table.encode()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"gw-a":["gw-b","gw-c"],"gw-b":["gw-a"],"gw-c":["gw-a"]}`, routes)
+}
@@ -22,9 +22,11 @@ const (
 	RavenAgentConfig               = "raven-agent-config"
 	LabelCurrentGatewayEndpoints   = "raven.openyurt.io/endpoints-name"
 	GatewayProxyInternalService    = "x-raven-proxy-internal-svc"
+	GatewayProxyEdgeService        = "x-raven-proxy-edge-svc"
 	GatewayProxyServiceNamePrefix  = "x-raven-proxy-svc"
 	GatewayTunnelServiceNamePrefix = "x-raven-tunnel-svc"
 	ExtraAllowedSourceCIDRs        = "raven.openyurt.io/extra-allowed-source-cidrs"
+	GatewayRoutesKey               = "gateway-routes"
 
 	RavenProxyNodesConfig      = "edge-tunnel-nodes"
 	ProxyNodesKey              = "tunnel-nodes"
@@ -34,4 +36,12 @@ const (
 	VPNServerExposedPortKey    = "tunnel-bind-addr"
 	RavenEnableProxy           = "enable-l7-proxy"
 	RavenEnableTunnel          = "enable-l3-tunnel"
+	RavenEnableEdgeProxy       = "enable-edge-proxy"
+	EdgeProxyAllowedSourcesKey = "edge-proxy-allowed-sources"
+
+	SRVRecordsKey           = "srv-records"
+	SRVZoneKey              = "srv-zone.import"
+	RavenDNSDomain          = "dns-domain"
+	RavenDNSRecordTemplates = "dns-record-templates"
+	RavenDNSExportSRV       = "dns-export-srv"
 )
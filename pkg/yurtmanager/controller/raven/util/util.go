@@ -28,11 +28,15 @@ import (
 
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ravenv1beta1 "github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
 )
 
 // GetNodeInternalIP returns internal ip of the given `node`.
@@ -47,6 +51,91 @@ func GetNodeInternalIP(node corev1.Node) string {
 	return ip
 }
 
+// GetNodeInternalIPs returns internal ips of the given `node`, at most one for each IP family.
+// The first one is the same as GetNodeInternalIP returns.
+func GetNodeInternalIPs(node corev1.Node) []string {
+	return getNodeIPsByFamily(node, corev1.NodeInternalIP)
+}
+
+// GetNodeExternalIPs returns external ips of the given `node`, at most one for each IP family.
+func GetNodeExternalIPs(node corev1.Node) []string {
+	return getNodeIPsByFamily(node, corev1.NodeExternalIP)
+}
+
+func getNodeIPsByFamily(node corev1.Node, addrType corev1.NodeAddressType) []string {
+	ips := make([]string, 0, 2)
+	var hasIPv4, hasIPv6 bool
+	for _, addr := range node.Status.Addresses {
+		if addr.Type != addrType {
+			continue
+		}
+		ip := net.ParseIP(addr.Address)
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil {
+			if hasIPv4 {
+				continue
+			}
+			hasIPv4 = true
+		} else {
+			if hasIPv6 {
+				continue
+			}
+			hasIPv6 = true
+		}
+		ips = append(ips, addr.Address)
+	}
+	return ips
+}
+
+// NeedPublicService checks whether the gateway of exposeType is exposed by public services.
+func NeedPublicService(exposeType string) bool {
+	return exposeType == ravenv1beta1.ExposeTypeLoadBalancer || exposeType == ravenv1beta1.ExposeTypeNodePort
+}
+
+// PreferDualStack returns the IP family policy of raven services, so that the services are
+// dual-stack in a dual-stack cluster and single-stack otherwise.
+func PreferDualStack() *corev1.IPFamilyPolicy {
+	policy := corev1.IPFamilyPolicyPreferDualStack
+	return &policy
+}
+
+// GatewaySelectsNode checks whether the node selector of gateway matches the node,
+// a gateway without node selector selects no node.
+func GatewaySelectsNode(gw *ravenv1beta1.Gateway, node *corev1.Node) bool {
+	if gw.Spec.NodeSelector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(gw.Spec.NodeSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(node.Labels))
+}
+
+// PreferredGateway breaks the tie among the gateways selecting the same node, the oldest gateway is preferred
+// and the one with the smallest name is preferred if they are created at the same time.
+func PreferredGateway(gws []*ravenv1beta1.Gateway) *ravenv1beta1.Gateway {
+	var preferred *ravenv1beta1.Gateway
+	for _, gw := range gws {
+		if preferred == nil {
+			preferred = gw
+			continue
+		}
+		if gw.CreationTimestamp.Equal(&preferred.CreationTimestamp) {
+			if gw.Name < preferred.Name {
+				preferred = gw
+			}
+			continue
+		}
+		if gw.CreationTimestamp.Before(&preferred.CreationTimestamp) {
+			preferred = gw
+		}
+	}
+	return preferred
+}
+
 // AddGatewayToWorkQueue adds the Gateway the reconciler's workqueue
 func AddGatewayToWorkQueue(gwName string,
 	q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
@@ -86,6 +175,12 @@ func AddGatewayProxyInternalService(q workqueue.TypedRateLimitingInterface[recon
 	})
 }
 
+func AddGatewayProxyEdgeService(q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	q.Add(reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: WorkingNamespace, Name: GatewayProxyEdgeService},
+	})
+}
+
 func HashObject(o interface{}) string {
 	data, _ := json.Marshal(o)
 	var a interface{}
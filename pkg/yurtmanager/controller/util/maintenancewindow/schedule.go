@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancewindow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds the search for the next activation of a schedule,
+// so that schedules which never fire (e.g. "0 0 30 2 *") don't loop forever.
+const maxSearchYears = 5
+
+type bounds struct {
+	min, max int
+}
+
+var (
+	minuteBounds = bounds{0, 59}
+	hourBounds   = bounds{0, 23}
+	domBounds    = bounds{1, 31}
+	monthBounds  = bounds{1, 12}
+	dowBounds    = bounds{0, 7} // 7 is accepted as an alias of Sunday
+)
+
+// Schedule is a parsed cron expression with five fields:
+// minute, hour, day of month, month and day of week.
+// Each field supports `*`, single values, ranges (`1-5`), lists (`1,3,5`) and steps (`*/15`, `0-30/10`).
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domRestricted and dowRestricted record whether the day fields are not `*`.
+	// As in standard cron, when both are restricted a day matches if either field matches.
+	domRestricted, dowRestricted bool
+}
+
+// ParseSchedule parses a cron expression with five fields.
+func ParseSchedule(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected exactly 5 fields in schedule %q, found %d", spec, len(fields))
+	}
+
+	var err error
+	s := &Schedule{}
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, fmt.Errorf("invalid minute field in schedule %q, %v", spec, err)
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, fmt.Errorf("invalid hour field in schedule %q, %v", spec, err)
+	}
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, fmt.Errorf("invalid day of month field in schedule %q, %v", spec, err)
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, fmt.Errorf("invalid month field in schedule %q, %v", spec, err)
+	}
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, fmt.Errorf("invalid day of week field in schedule %q, %v", spec, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		// fold Sunday(7) into Sunday(0)
+		s.dow = (s.dow | 1) &^ (1 << 7)
+	}
+	s.domRestricted = !strings.HasPrefix(fields[2], "*")
+	s.dowRestricted = !strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		r, err := parseRange(expr, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= r
+	}
+	return bits, nil
+}
+
+func parseRange(expr string, b bounds) (uint64, error) {
+	var (
+		start, end int
+		step       = 1
+		err        error
+	)
+
+	rangeAndStep := strings.SplitN(expr, "/", 2)
+	lowAndHigh := strings.SplitN(rangeAndStep[0], "-", 2)
+
+	if lowAndHigh[0] == "*" {
+		if len(lowAndHigh) > 1 {
+			return 0, fmt.Errorf("invalid range %q", expr)
+		}
+		start, end = b.min, b.max
+	} else {
+		if start, err = parseInt(lowAndHigh[0]); err != nil {
+			return 0, err
+		}
+		end = start
+		if len(lowAndHigh) > 1 {
+			if end, err = parseInt(lowAndHigh[1]); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if len(rangeAndStep) > 1 {
+		if step, err = parseInt(rangeAndStep[1]); err != nil {
+			return 0, err
+		}
+		if step == 0 {
+			return 0, fmt.Errorf("step of %q should be positive", expr)
+		}
+		// "N/step" means from N to the upper bound
+		if len(lowAndHigh) == 1 && lowAndHigh[0] != "*" {
+			end = b.max
+		}
+	}
+
+	if start < b.min || end > b.max || start > end {
+		return 0, fmt.Errorf("%q is out of range [%d, %d]", expr, b.min, b.max)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << uint(i)
+	}
+	return bits, nil
+}
+
+func parseInt(s string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse %q as a number", s)
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("%q should not be negative", s)
+	}
+	return i, nil
+}
+
+// Next returns the first time the schedule fires strictly after t, evaluated in t's location.
+// It returns the zero time if the schedule doesn't fire within the next few years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + maxSearchYears
+
+	for t.Year() <= limit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
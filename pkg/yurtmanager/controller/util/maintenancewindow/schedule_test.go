@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancewindow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	testcases := map[string]struct {
+		spec      string
+		expectErr bool
+	}{
+		"every minute": {
+			spec: "* * * * *",
+		},
+		"lists, ranges and steps": {
+			spec: "0,30 1-5/2 */10 1-12 1-5",
+		},
+		"sunday as 7": {
+			spec: "0 2 * * 7",
+		},
+		"too few fields": {
+			spec:      "0 2 * *",
+			expectErr: true,
+		},
+		"hour out of range": {
+			spec:      "0 24 * * *",
+			expectErr: true,
+		},
+		"day of month out of range": {
+			spec:      "0 0 0 * *",
+			expectErr: true,
+		},
+		"zero step": {
+			spec:      "*/0 * * * *",
+			expectErr: true,
+		},
+		"reversed range": {
+			spec:      "0 5-1 * * *",
+			expectErr: true,
+		},
+		"not a number": {
+			spec:      "a * * * *",
+			expectErr: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			_, err := ParseSchedule(tc.spec)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expect error %v, but got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	testcases := map[string]struct {
+		spec   string
+		now    time.Time
+		expect time.Time
+	}{
+		"next minute": {
+			spec:   "* * * * *",
+			now:    time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC),
+			expect: time.Date(2024, 1, 1, 10, 1, 0, 0, time.UTC),
+		},
+		"strictly after now": {
+			spec:   "0 2 * * *",
+			now:    time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC),
+			expect: time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC),
+		},
+		"same day": {
+			spec:   "30 2 * * *",
+			now:    time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			expect: time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC),
+		},
+		"next month": {
+			spec:   "0 0 1 * *",
+			now:    time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			expect: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"weekday": {
+			// 2024-01-06 is Saturday
+			spec:   "0 3 * * 1-5",
+			now:    time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+			expect: time.Date(2024, 1, 8, 3, 0, 0, 0, time.UTC),
+		},
+		"day of month or day of week": {
+			// 2024-01-07 is Sunday
+			spec:   "0 0 10 * 0",
+			now:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			expect: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+		},
+		"leap day": {
+			spec:   "0 0 29 2 *",
+			now:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			expect: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		"never": {
+			spec:   "0 0 30 2 *",
+			now:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			expect: time.Time{},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			s, err := ParseSchedule(tc.spec)
+			if err != nil {
+				t.Fatalf("could not parse schedule %q, %v", tc.spec, err)
+			}
+			if got := s.Next(tc.now); !got.Equal(tc.expect) {
+				t.Errorf("expect next time %v, but got %v", tc.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancewindow
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+// window is the parsed form of appsv1alpha1.MaintenanceWindow
+type window struct {
+	schedule  *Schedule
+	duration  time.Duration
+	location  *time.Location
+	nodePools sets.Set[string]
+}
+
+// appliesTo checks whether the window covers nodes in the given node pool
+func (w *window) appliesTo(nodePool string) bool {
+	return w.nodePools.Len() == 0 || w.nodePools.Has(nodePool)
+}
+
+// currentStart returns the start time of the window which is open at now, or the zero time if the window is closed.
+func (w *window) currentStart(now time.Time) time.Time {
+	local := now.In(w.location)
+	start := w.schedule.Next(local.Add(-w.duration))
+	if start.IsZero() || start.After(local) {
+		return time.Time{}
+	}
+	return start
+}
+
+// nextTransition returns the next time after now at which the window opens or closes
+func (w *window) nextTransition(now time.Time) time.Time {
+	if start := w.currentStart(now); !start.IsZero() {
+		return start.Add(w.duration)
+	}
+	return w.schedule.Next(now.In(w.location))
+}
+
+// Checker decides whether an upgrade is allowed on a node at a given time,
+// according to the paused flag and the maintenance windows of a workload.
+type Checker struct {
+	paused  bool
+	windows []*window
+}
+
+// NewChecker parses the given maintenance windows and returns a Checker.
+func NewChecker(paused bool, windows []appsv1alpha1.MaintenanceWindow) (*Checker, error) {
+	c := &Checker{
+		paused:  paused,
+		windows: make([]*window, 0, len(windows)),
+	}
+
+	for i := range windows {
+		w, err := parseWindow(&windows[i])
+		if err != nil {
+			return nil, err
+		}
+		c.windows = append(c.windows, w)
+	}
+	return c, nil
+}
+
+// Validate checks whether the given maintenance window can be parsed.
+func Validate(mw *appsv1alpha1.MaintenanceWindow) error {
+	_, err := parseWindow(mw)
+	return err
+}
+
+func parseWindow(mw *appsv1alpha1.MaintenanceWindow) (*window, error) {
+	schedule, err := ParseSchedule(mw.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	if mw.Duration.Duration < time.Minute {
+		return nil, fmt.Errorf("duration %v of maintenance window %q should be at least 1m", mw.Duration.Duration, mw.Schedule)
+	}
+
+	location := time.UTC
+	if mw.TimeZone != "" {
+		if location, err = time.LoadLocation(mw.TimeZone); err != nil {
+			return nil, fmt.Errorf("could not load time zone %q of maintenance window %q, %v", mw.TimeZone, mw.Schedule, err)
+		}
+	}
+
+	return &window{
+		schedule:  schedule,
+		duration:  mw.Duration.Duration,
+		location:  location,
+		nodePools: sets.New[string](mw.NodePools...),
+	}, nil
+}
+
+// Paused returns whether the upgrade is paused.
+func (c *Checker) Paused() bool {
+	return c.paused
+}
+
+// Restricted returns whether the upgrade is paused or limited by maintenance windows.
+func (c *Checker) Restricted() bool {
+	return c.paused || len(c.windows) != 0
+}
+
+// Allowed returns whether a node which belongs to the given node pool can be upgraded at now.
+// Upgrades are never allowed when paused, and always allowed when no window is configured.
+// Otherwise a node can be upgraded only if one of the windows that apply to it is open,
+// so a node which is not covered by any window is never upgraded.
+func (c *Checker) Allowed(nodePool string, now time.Time) bool {
+	if c.paused {
+		return false
+	}
+	if len(c.windows) == 0 {
+		return true
+	}
+
+	for _, w := range c.windows {
+		if w.appliesTo(nodePool) && !w.currentStart(now).IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// RequeueAfter returns how long to wait until the next time any maintenance window opens or closes,
+// so that the caller can re-evaluate which nodes can be upgraded. It returns zero if there is
+// nothing to wait for, that is the upgrade is paused or no window is configured.
+func (c *Checker) RequeueAfter(now time.Time) time.Duration {
+	if c.paused {
+		return 0
+	}
+
+	var next time.Time
+	for _, w := range c.windows {
+		t := w.nextTransition(now)
+		if t.IsZero() {
+			continue
+		}
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+
+	if next.IsZero() {
+		return 0
+	}
+	return next.Sub(now)
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancewindow
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+func TestCheckerAllowed(t *testing.T) {
+	nightly := appsv1alpha1.MaintenanceWindow{
+		Schedule: "0 22 * * *",
+		Duration: metav1.Duration{Duration: 4 * time.Hour},
+	}
+	poolWindow := appsv1alpha1.MaintenanceWindow{
+		Schedule:  "0 12 * * *",
+		Duration:  metav1.Duration{Duration: time.Hour},
+		NodePools: []string{"hangzhou"},
+	}
+
+	testcases := map[string]struct {
+		paused   bool
+		windows  []appsv1alpha1.MaintenanceWindow
+		nodePool string
+		now      time.Time
+		expect   bool
+	}{
+		"no restriction": {
+			now:    time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			expect: true,
+		},
+		"paused": {
+			paused: true,
+			now:    time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			expect: false,
+		},
+		"paused in window": {
+			paused:  true,
+			windows: []appsv1alpha1.MaintenanceWindow{nightly},
+			now:     time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC),
+			expect:  false,
+		},
+		"in window": {
+			windows: []appsv1alpha1.MaintenanceWindow{nightly},
+			now:     time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC),
+			expect:  true,
+		},
+		"in window across midnight": {
+			windows: []appsv1alpha1.MaintenanceWindow{nightly},
+			now:     time.Date(2024, 1, 2, 1, 59, 0, 0, time.UTC),
+			expect:  true,
+		},
+		"window closed": {
+			windows: []appsv1alpha1.MaintenanceWindow{nightly},
+			now:     time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC),
+			expect:  false,
+		},
+		"node pool window is open": {
+			windows:  []appsv1alpha1.MaintenanceWindow{nightly, poolWindow},
+			nodePool: "hangzhou",
+			now:      time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+			expect:   true,
+		},
+		"window of other node pool is open": {
+			windows:  []appsv1alpha1.MaintenanceWindow{nightly, poolWindow},
+			nodePool: "beijing",
+			now:      time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+			expect:   false,
+		},
+		"node not covered by any window": {
+			windows:  []appsv1alpha1.MaintenanceWindow{poolWindow},
+			nodePool: "beijing",
+			now:      time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+			expect:   false,
+		},
+		"node without node pool not covered by any window": {
+			windows: []appsv1alpha1.MaintenanceWindow{poolWindow},
+			now:     time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			expect:  false,
+		},
+		"time zone": {
+			windows: []appsv1alpha1.MaintenanceWindow{{
+				Schedule: "0 2 * * *",
+				Duration: metav1.Duration{Duration: time.Hour},
+				TimeZone: "Asia/Shanghai",
+			}},
+			now:    time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC),
+			expect: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			c, err := NewChecker(tc.paused, tc.windows)
+			if err != nil {
+				t.Fatalf("could not create checker, %v", err)
+			}
+			if got := c.Allowed(tc.nodePool, tc.now); got != tc.expect {
+				t.Errorf("expect allowed %v, but got %v", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestCheckerRequeueAfter(t *testing.T) {
+	windows := []appsv1alpha1.MaintenanceWindow{
+		{
+			Schedule: "0 22 * * *",
+			Duration: metav1.Duration{Duration: 4 * time.Hour},
+		},
+		{
+			Schedule: "0 12 * * *",
+			Duration: metav1.Duration{Duration: time.Hour},
+		},
+	}
+
+	testcases := map[string]struct {
+		paused  bool
+		windows []appsv1alpha1.MaintenanceWindow
+		now     time.Time
+		expect  time.Duration
+	}{
+		"no window": {
+			now:    time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			expect: 0,
+		},
+		"paused": {
+			paused:  true,
+			windows: windows,
+			now:     time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			expect:  0,
+		},
+		"wait for window to open": {
+			windows: windows,
+			now:     time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			expect:  2 * time.Hour,
+		},
+		"wait for window to close": {
+			windows: windows,
+			now:     time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			expect:  time.Hour,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			c, err := NewChecker(tc.paused, tc.windows)
+			if err != nil {
+				t.Fatalf("could not create checker, %v", err)
+			}
+			if got := c.RequeueAfter(tc.now); got != tc.expect {
+				t.Errorf("expect requeue after %v, but got %v", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testcases := map[string]struct {
+		window    appsv1alpha1.MaintenanceWindow
+		expectErr bool
+	}{
+		"valid window": {
+			window: appsv1alpha1.MaintenanceWindow{
+				Schedule: "0 2 * * *",
+				Duration: metav1.Duration{Duration: time.Hour},
+				TimeZone: "Asia/Shanghai",
+			},
+		},
+		"invalid schedule": {
+			window: appsv1alpha1.MaintenanceWindow{
+				Schedule: "0 2 * *",
+				Duration: metav1.Duration{Duration: time.Hour},
+			},
+			expectErr: true,
+		},
+		"duration too short": {
+			window: appsv1alpha1.MaintenanceWindow{
+				Schedule: "0 2 * * *",
+				Duration: metav1.Duration{Duration: time.Second},
+			},
+			expectErr: true,
+		},
+		"unknown time zone": {
+			window: appsv1alpha1.MaintenanceWindow{
+				Schedule: "0 2 * * *",
+				Duration: metav1.Duration{Duration: time.Hour},
+				TimeZone: "Mars/Olympus",
+			},
+			expectErr: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			err := Validate(&tc.window)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expect error %v, but got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
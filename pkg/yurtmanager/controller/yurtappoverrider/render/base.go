@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps"
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+// RenderFromBase renders the workload which is the subject of overrider from its base spec, which is recorded in
+// the annotation of workload when it is rendered. The json patches are not idempotent, e.g. `add` to the end of
+// a list, so they are never applied to the rendered spec again, and the entries which are removed are reverted.
+// The spec is taken as the new base once it is changed by others, e.g. the owner of the workload.
+// A nil overrider restores the base spec and removes the annotations.
+func RenderFromBase(obj *unstructured.Unstructured, poolName string, overrider *v1alpha1.YurtAppOverrider) (*unstructured.Unstructured, error) {
+	spec, ok, err := unstructured.NestedFieldNoCopy(obj.Object, "spec")
+	if err != nil || !ok {
+		return nil, fmt.Errorf("could not get spec of %s %s, %v", obj.GetKind(), obj.GetName(), err)
+	}
+	hash, err := HashSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := obj.DeepCopy()
+	annotations := rendered.GetAnnotations()
+	base, ok := annotations[apps.AnnotationOverrideBase]
+	if !ok || annotations[apps.AnnotationOverrideHash] != hash {
+		baseBytes, err := json.Marshal(spec)
+		if err != nil {
+			return nil, err
+		}
+		base = string(baseBytes)
+	} else {
+		var baseSpec map[string]interface{}
+		if err := utiljson.Unmarshal([]byte(base), &baseSpec); err != nil {
+			return nil, err
+		}
+		rendered.Object["spec"] = baseSpec
+	}
+
+	if overrider == nil {
+		delete(annotations, apps.AnnotationOverrideBase)
+		delete(annotations, apps.AnnotationOverrideHash)
+		rendered.SetAnnotations(annotations)
+		return rendered, nil
+	}
+
+	if err := Render(rendered, poolName, overrider); err != nil {
+		return nil, err
+	}
+	hash, err = HashSpec(rendered.Object["spec"])
+	if err != nil {
+		return nil, err
+	}
+	annotations = rendered.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[apps.AnnotationOverrideBase] = base
+	annotations[apps.AnnotationOverrideHash] = hash
+	rendered.SetAnnotations(annotations)
+	return rendered, nil
+}
+
+// HashSpec returns the hash of the spec which is recorded in AnnotationOverrideHash
+func HashSpec(spec interface{}) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
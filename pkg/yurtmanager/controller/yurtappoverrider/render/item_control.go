@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+// replaceItems replaces the replicas and images of workload, the workload can be any kind
+// which has `spec.replicas` and pod template in `spec.template`.
+func replaceItems(obj runtime.Object, items []v1alpha1.Item) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		switch {
+		case item.Replicas != nil:
+			if err := unstructured.SetNestedField(content, int64(*item.Replicas), "spec", "replicas"); err != nil {
+				return err
+			}
+		case item.Image != nil:
+			for _, field := range []string{"containers", "initContainers"} {
+				if err := replaceImage(content, field, item.Image); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(content, obj)
+}
+
+func replaceImage(content map[string]interface{}, field string, image *v1alpha1.ImageItem) error {
+	fields := []string{"spec", "template", "spec", field}
+	containers, found, err := unstructured.NestedSlice(content, fields...)
+	if err != nil || !found {
+		return err
+	}
+	for i := range containers {
+		container, ok := containers[i].(map[string]interface{})
+		if !ok || container["name"] != image.ContainerName {
+			continue
+		}
+		container["image"] = image.ImageClaim
+	}
+	return unstructured.SetNestedSlice(content, containers, fields...)
+}
@@ -14,13 +14,12 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package v1alpha1
+package render
 
 import (
 	"encoding/json"
 
 	jsonpatch "github.com/evanphx/json-patch"
-	appsv1 "k8s.io/api/apps/v1"
 
 	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
 )
@@ -54,7 +53,7 @@ func (pc *PatchControl) jsonMergePatch() error {
 	if err != nil {
 		return err
 	}
-	patchedData, err := json.Marshal(pc.patchObject.(*appsv1.Deployment))
+	patchedData, err := json.Marshal(pc.patchObject)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"context"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	// NodePoolPlaceholder in the value of patches is replaced with the name of nodepool
+	NodePoolPlaceholder = "{{nodepool}}"
+	// AllPools matches all nodepools in the pools of entry
+	AllPools = "*"
+)
+
+// SubjectMatches checks whether the subject refers to the object of the given apiVersion, kind and name.
+// Only the group of apiVersion is compared, because an object is served in all versions of its group.
+func SubjectMatches(subject v1alpha1.Subject, apiVersion, kind, name string) bool {
+	if subject.Kind != kind || subject.Name != name {
+		return false
+	}
+	sgv, err := schema.ParseGroupVersion(subject.APIVersion)
+	if err != nil {
+		return false
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return false
+	}
+	return sgv.Group == gv.Group
+}
+
+// GetOverrider returns the YurtAppOverrider whose subject is the object of the given apiVersion, kind and name,
+// nil is returned if there is no such YurtAppOverrider.
+func GetOverrider(ctx context.Context, c client.Client, namespace, apiVersion, kind, name string) (*v1alpha1.YurtAppOverrider, error) {
+	var allOverriderList v1alpha1.YurtAppOverriderList
+	if err := c.List(ctx, &allOverriderList, client.InNamespace(namespace)); err != nil {
+		klog.Infof("error in listing YurtAppOverrider: %v", err)
+		return nil, err
+	}
+	for i := range allOverriderList.Items {
+		// YurtAppOverrider and its subject are one-to-one relationship
+		if SubjectMatches(allOverriderList.Items[i].Subject, apiVersion, kind, name) {
+			return &allOverriderList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// EntryMatchesPool checks whether the entry should be applied to the workload of nodepool.
+// A nodepool is excluded from the entry by `-<nodepool>` in the pools.
+func EntryMatchesPool(entry *v1alpha1.Entry, poolName string) bool {
+	matched := false
+	for _, pool := range entry.Pools {
+		if strings.HasPrefix(pool, "-") {
+			if pool[1:] == poolName {
+				return false
+			}
+			continue
+		}
+		if pool == poolName || pool == AllPools {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// Render applies the entries of overrider which match the nodepool to the workload. The workload can be
+// any kind which has `spec.replicas` and pod template in `spec.template`, e.g. Deployment, StatefulSet,
+// or an unstructured object of custom workload.
+func Render(obj runtime.Object, poolName string, overrider *v1alpha1.YurtAppOverrider) error {
+	for i := range overrider.Entries {
+		entry := &overrider.Entries[i]
+		if !EntryMatchesPool(entry, poolName) {
+			continue
+		}
+
+		// Replace items
+		if err := replaceItems(obj, entry.Items); err != nil {
+			return err
+		}
+
+		// json patch
+		patches := make([]v1alpha1.Patch, len(entry.Patches))
+		for j, patch := range entry.Patches {
+			patches[j] = patch
+			if strings.Contains(string(patch.Value.Raw), NodePoolPlaceholder) {
+				newPatchString := strings.ReplaceAll(string(patch.Value.Raw), NodePoolPlaceholder, poolName)
+				patches[j].Value = apiextensionsv1.JSON{Raw: []byte(newPatchString)}
+			}
+		}
+		if len(patches) == 0 {
+			continue
+		}
+		pc := PatchControl{
+			patches:     patches,
+			patchObject: obj,
+		}
+		if err := pc.jsonMergePatch(); err != nil {
+			klog.Infof("could not update patches for %T: %v", obj, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+func TestSubjectMatches(t *testing.T) {
+	subject := v1alpha1.Subject{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps.openyurt.io/v1alpha1", Kind: "YurtAppSet"},
+		Name:     "demo",
+	}
+	assert.True(t, SubjectMatches(subject, "apps.openyurt.io/v1alpha1", "YurtAppSet", "demo"))
+	assert.True(t, SubjectMatches(subject, "apps.openyurt.io/v1beta1", "YurtAppSet", "demo"))
+	assert.False(t, SubjectMatches(subject, "apps/v1", "YurtAppSet", "demo"))
+	assert.False(t, SubjectMatches(subject, "apps.openyurt.io/v1beta1", "YurtAppDaemon", "demo"))
+	assert.False(t, SubjectMatches(subject, "apps.openyurt.io/v1beta1", "YurtAppSet", "foo"))
+}
+
+func TestEntryMatchesPool(t *testing.T) {
+	testcases := map[string]struct {
+		pools  []string
+		expect bool
+	}{
+		"listed":               {pools: []string{"hangzhou"}, expect: true},
+		"not listed":           {pools: []string{"beijing"}, expect: false},
+		"all pools":            {pools: []string{"*"}, expect: true},
+		"excluded":             {pools: []string{"*", "-hangzhou"}, expect: false},
+		"excluded before all":  {pools: []string{"-hangzhou", "*"}, expect: false},
+		"other pools excluded": {pools: []string{"*", "-beijing"}, expect: true},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, tc.expect, EntryMatchesPool(&v1alpha1.Entry{Pools: tc.pools}, "hangzhou"))
+		})
+	}
+}
+
+var renderOverrider = &v1alpha1.YurtAppOverrider{
+	Entries: []v1alpha1.Entry{
+		{
+			Pools: []string{"hangzhou"},
+			Items: []v1alpha1.Item{
+				{Image: &v1alpha1.ImageItem{ContainerName: "nginx", ImageClaim: "nginx:1.18"}},
+				{Replicas: &itemReplicas},
+			},
+		},
+		{
+			Pools: []string{"*"},
+			Patches: []v1alpha1.Patch{
+				{
+					Operation: v1alpha1.ADD,
+					Path:      "/spec/template/metadata/labels/pool",
+					Value:     apiextensionsv1.JSON{Raw: []byte(`"{{nodepool}}"`)},
+				},
+			},
+		},
+		{
+			Pools: []string{"beijing"},
+			Items: []v1alpha1.Item{
+				{Image: &v1alpha1.ImageItem{ContainerName: "nginx", ImageClaim: "nginx:beijing"}},
+			},
+		},
+	},
+}
+
+func TestRenderStatefulSet(t *testing.T) {
+	var replicas int32 = 1
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}}},
+			},
+		},
+	}
+
+	if err := Render(sts, "hangzhou", renderOverrider); err != nil {
+		t.Fatalf("could not render statefulset, %v", err)
+	}
+	assert.Equal(t, "nginx:1.18", sts.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, itemReplicas, *sts.Spec.Replicas)
+	assert.Equal(t, "hangzhou", sts.Spec.Template.Labels["pool"])
+	assert.Equal(t, "demo", sts.Spec.Template.Labels["app"])
+	// the placeholder in overrider is not changed
+	assert.Equal(t, `"{{nodepool}}"`, string(renderOverrider.Entries[1].Patches[0].Value.Raw))
+}
+
+func TestRenderUnstructured(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.kruise.io/v1alpha1",
+		"kind":       "CloneSet",
+		"metadata":   map[string]interface{}{"name": "demo", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": "demo"},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "nginx", "image": "nginx"},
+					},
+				},
+			},
+		},
+	}}
+
+	if err := Render(obj, "hangzhou", renderOverrider); err != nil {
+		t.Fatalf("could not render unstructured workload, %v", err)
+	}
+	replicas, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	assert.Equal(t, int64(itemReplicas), replicas)
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, "nginx:1.18", containers[0].(map[string]interface{})["image"])
+	pool, _, _ := unstructured.NestedString(obj.Object, "spec", "template", "metadata", "labels", "pool")
+	assert.Equal(t, "hangzhou", pool)
+	assert.Equal(t, "CloneSet", obj.GetKind())
+}
@@ -20,25 +20,34 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	yurtClient "github.com/openyurtio/openyurt/cmd/yurt-manager/app/client"
 	appconfig "github.com/openyurtio/openyurt/cmd/yurt-manager/app/config"
 	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
+	"github.com/openyurtio/openyurt/pkg/apis/apps"
 	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappoverrider/config"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappoverrider/render"
 )
 
 var (
@@ -73,6 +82,12 @@ type ReconcileYurtAppOverrider struct {
 	Configuration     config.YurtAppOverriderControllerConfiguration
 	CacheOverriderMap map[string]*appsv1alpha1.YurtAppOverrider
 	recorder          record.EventRecorder
+
+	// the workloads of other kinds are watched once they are the subject of a YurtAppOverrider
+	controller   controller.Controller
+	cache        cache.Cache
+	watchedLock  sync.Mutex
+	watchedKinds sets.Set[schema.GroupVersionKind]
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -82,6 +97,8 @@ func newReconciler(c *appconfig.CompletedConfig, mgr manager.Manager) reconcile.
 		Configuration:     c.ComponentConfig.YurtAppOverriderController,
 		CacheOverriderMap: make(map[string]*appsv1alpha1.YurtAppOverrider),
 		recorder:          mgr.GetEventRecorderFor(names.YurtAppOverriderController),
+		cache:             mgr.GetCache(),
+		watchedKinds:      sets.New[schema.GroupVersionKind](),
 	}
 }
 
@@ -94,6 +111,9 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 	if err != nil {
 		return err
 	}
+	if reconciler, ok := r.(*ReconcileYurtAppOverrider); ok {
+		reconciler.controller = c
+	}
 
 	// Watch for changes to YurtAppOverrider
 	err = c.Watch(source.Kind[client.Object](mgr.GetCache(), &appsv1alpha1.YurtAppOverrider{}, &handler.EnqueueRequestForObject{}))
@@ -107,7 +127,11 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappoverriders,verbs=get
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappsets,verbs=get
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappdaemons,verbs=get
-// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=update
+
+// Workloads of other kinds which are the subject of YurtAppOverrider are got and updated with the client
+// of this controller and watched by yurt-manager, so get, list, watch and update of them should be granted
+// by cluster admin, e.g. with yurtAppOverrider.workloadResources of the yurt-manager chart.
 
 // Reconcile reads that state of the cluster for a YurtAppOverrider object and makes changes based on the state read
 // and what is in the YurtAppOverrider.Spec
@@ -123,6 +147,11 @@ func (r *ReconcileYurtAppOverrider) Reconcile(_ context.Context, request reconci
 	err := r.Get(context.TODO(), request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			if cacheOverrider, ok := r.CacheOverriderMap[request.Namespace+"/"+request.Name]; ok {
+				if err := r.restoreWorkload(cacheOverrider); err != nil {
+					return reconcile.Result{}, err
+				}
+			}
 			delete(r.CacheOverriderMap, request.Namespace+"/"+request.Name)
 			return reconcile.Result{}, nil
 		}
@@ -139,10 +168,6 @@ func (r *ReconcileYurtAppOverrider) Reconcile(_ context.Context, request reconci
 		if err := r.Get(context.TODO(), client.ObjectKey{Namespace: instance.Namespace, Name: instance.Subject.Name}, appSet); err != nil {
 			return reconcile.Result{}, err
 		}
-		if appSet.Spec.WorkloadTemplate.StatefulSetTemplate != nil {
-			r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("unable to override statefulset workload of %s", appSet.Name), "It is not supported to overrider statefulset now")
-			return reconcile.Result{}, nil
-		}
 	case "YurtAppDaemon":
 		appDaemon := &appsv1alpha1.YurtAppDaemon{}
 		if err := r.Get(context.TODO(), client.ObjectKey{Namespace: instance.Namespace, Name: instance.Subject.Name}, appDaemon); err != nil {
@@ -152,35 +177,210 @@ func (r *ReconcileYurtAppOverrider) Reconcile(_ context.Context, request reconci
 			r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("unable to override statefulset workload of %s", appDaemon.Name), "It is not supported to overrider statefulset now")
 			return reconcile.Result{}, nil
 		}
-	default:
-		return reconcile.Result{}, fmt.Errorf("unsupported kind: %s", instance.Subject.Kind)
 	}
 
-	if cacheOverrider, ok := r.CacheOverriderMap[instance.Namespace+"/"+instance.Name]; ok {
+	// the workloads of other kinds are rendered from their base spec whenever they change, rendering them
+	// again is a no-op if nothing changed, so the cache only saves the touches of the workloads rendered by
+	// the webhook.
+	gvk := schema.FromAPIVersionAndKind(instance.Subject.APIVersion, instance.Subject.Kind)
+	customKind := !isRenderedByWebhook(gvk)
+	if cacheOverrider, ok := r.CacheOverriderMap[instance.Namespace+"/"+instance.Name]; ok && !customKind {
 		if reflect.DeepEqual(cacheOverrider.Entries, instance.Entries) {
 			return reconcile.Result{}, nil
 		}
 	}
+
+	switch instance.Subject.Kind {
+	case "YurtAppSet", "YurtAppDaemon":
+		if err := r.touchRenderedWorkloads(instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	default:
+		if customKind {
+			if err := r.watchWorkloadKind(gvk); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if err := r.overrideWorkload(instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
 	r.CacheOverriderMap[instance.Namespace+"/"+instance.Name] = instance.DeepCopy()
 
+	return reconcile.Result{}, nil
+}
+
+// touchRenderedWorkloads updates the workloads rendered by the subject, so that the overrider is applied
+// by the render webhook of Deployment and StatefulSet.
+func (r *ReconcileYurtAppOverrider) touchRenderedWorkloads(instance *appsv1alpha1.YurtAppOverrider) error {
 	deployments := v1.DeploymentList{}
-	if err := r.List(context.TODO(), &deployments); err != nil {
-		return reconcile.Result{}, err
+	if err := r.List(context.TODO(), &deployments, client.InNamespace(instance.Namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		if isRenderedBy(&deployments.Items[i], instance) {
+			if err := r.touch(&deployments.Items[i]); err != nil {
+				return err
+			}
+		}
 	}
 
-	for _, deployment := range deployments.Items {
-		if len(deployment.OwnerReferences) != 0 {
-			if deployment.OwnerReferences[0].Kind == instance.Subject.Kind && deployment.OwnerReferences[0].Name == instance.Subject.Name {
-				if deployment.Annotations == nil {
-					deployment.Annotations = make(map[string]string)
-				}
-				deployment.Annotations["LastOverrideTime"] = time.Now().String()
-				if err := r.Client.Update(context.TODO(), &deployment); err != nil {
-					return reconcile.Result{}, err
-				}
+	if instance.Subject.Kind != "YurtAppSet" {
+		return nil
+	}
+	statefulSets := v1.StatefulSetList{}
+	if err := r.List(context.TODO(), &statefulSets, client.InNamespace(instance.Namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		if isRenderedBy(&statefulSets.Items[i], instance) {
+			if err := r.touch(&statefulSets.Items[i]); err != nil {
+				return err
 			}
 		}
 	}
+	return nil
+}
+
+// overrideWorkload applies the overrider to the workload which is the subject itself. Deployments and
+// StatefulSets are rendered by the webhook when they are updated, and other kinds of workloads with
+// pod template are rendered here. The nodepool of workload is taken from its pool-name label.
+func (r *ReconcileYurtAppOverrider) overrideWorkload(instance *appsv1alpha1.YurtAppOverrider) error {
+	gv, err := schema.ParseGroupVersion(instance.Subject.APIVersion)
+	if err != nil {
+		return err
+	}
+	key := client.ObjectKey{Namespace: instance.Namespace, Name: instance.Subject.Name}
 
-	return reconcile.Result{}, nil
+	var workload client.Object
+	switch gv.WithKind(instance.Subject.Kind) {
+	case v1.SchemeGroupVersion.WithKind("Deployment"):
+		workload = &v1.Deployment{}
+	case v1.SchemeGroupVersion.WithKind("StatefulSet"):
+		workload = &v1.StatefulSet{}
+	default:
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gv.WithKind(instance.Subject.Kind))
+		if err := r.Get(context.TODO(), key, obj); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		rendered, err := render.RenderFromBase(obj, obj.GetLabels()[apps.PoolNameLabelKey], instance)
+		if err != nil {
+			r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, "OverrideFailed", err.Error())
+			return nil
+		}
+		if equality.Semantic.DeepEqual(obj, rendered) {
+			return nil
+		}
+		return r.Update(context.TODO(), rendered)
+	}
+
+	if err := r.Get(context.TODO(), key, workload); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return r.touch(workload)
+}
+
+// restoreWorkload restores the base spec of the workload which is the subject of the deleted overrider.
+// Deployments and StatefulSets are restored by the render webhook once they are touched.
+func (r *ReconcileYurtAppOverrider) restoreWorkload(instance *appsv1alpha1.YurtAppOverrider) error {
+	gvk := schema.FromAPIVersionAndKind(instance.Subject.APIVersion, instance.Subject.Kind)
+	key := client.ObjectKey{Namespace: instance.Namespace, Name: instance.Subject.Name}
+	var workload client.Object
+	switch gvk {
+	case v1.SchemeGroupVersion.WithKind("Deployment"):
+		workload = &v1.Deployment{}
+	case v1.SchemeGroupVersion.WithKind("StatefulSet"):
+		workload = &v1.StatefulSet{}
+	default:
+		if isRenderedByWebhook(gvk) {
+			return nil
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := r.Get(context.TODO(), key, obj); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if _, ok := obj.GetAnnotations()[apps.AnnotationOverrideBase]; !ok {
+			return nil
+		}
+		restored, err := render.RenderFromBase(obj, obj.GetLabels()[apps.PoolNameLabelKey], nil)
+		if err != nil {
+			return err
+		}
+		return r.Update(context.TODO(), restored)
+	}
+
+	if err := r.Get(context.TODO(), key, workload); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if _, ok := workload.GetAnnotations()[apps.AnnotationOverrideBase]; !ok {
+		return nil
+	}
+	return r.touch(workload)
+}
+
+// watchWorkloadKind watches the workloads of the kind, so that the overriders are applied again when the
+// spec of their subject is changed, e.g. the pod template is reverted by the owner of the workload.
+func (r *ReconcileYurtAppOverrider) watchWorkloadKind(gvk schema.GroupVersionKind) error {
+	if r.controller == nil {
+		return nil
+	}
+	r.watchedLock.Lock()
+	defer r.watchedLock.Unlock()
+	if r.watchedKinds.Has(gvk) {
+		return nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := r.controller.Watch(source.Kind[client.Object](r.cache, obj,
+		handler.EnqueueRequestsFromMapFunc(r.overridersOfWorkload), predicate.GenerationChangedPredicate{})); err != nil {
+		return err
+	}
+	klog.Info(Format("watch the workloads of %s which are the subject of YurtAppOverrider", gvk.String()))
+	r.watchedKinds.Insert(gvk)
+	return nil
+}
+
+// overridersOfWorkload maps the workload to the YurtAppOverriders whose subject it is
+func (r *ReconcileYurtAppOverrider) overridersOfWorkload(ctx context.Context, workload client.Object) []reconcile.Request {
+	overriders := &appsv1alpha1.YurtAppOverriderList{}
+	if err := r.List(ctx, overriders, client.InNamespace(workload.GetNamespace())); err != nil {
+		klog.Error(Format("could not list YurtAppOverriders of %s, %v", client.ObjectKeyFromObject(workload), err))
+		return nil
+	}
+
+	gvk := workload.GetObjectKind().GroupVersionKind()
+	var requests []reconcile.Request
+	for _, overrider := range overriders.Items {
+		if overrider.Subject.Name == workload.GetName() && overrider.Subject.Kind == gvk.Kind && overrider.Subject.APIVersion == gvk.GroupVersion().String() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&overrider)})
+		}
+	}
+	return requests
+}
+
+func (r *ReconcileYurtAppOverrider) touch(workload client.Object) error {
+	annotations := workload.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations["LastOverrideTime"] = time.Now().String()
+	workload.SetAnnotations(annotations)
+	return r.Client.Update(context.TODO(), workload)
+}
+
+// isRenderedByWebhook checks whether the subject is rendered by the render webhooks when it is updated
+func isRenderedByWebhook(gvk schema.GroupVersionKind) bool {
+	switch gvk.Kind {
+	case "YurtAppSet", "YurtAppDaemon":
+		return true
+	}
+	return gvk == v1.SchemeGroupVersion.WithKind("Deployment") || gvk == v1.SchemeGroupVersion.WithKind("StatefulSet")
+}
+
+func isRenderedBy(workload client.Object, instance *appsv1alpha1.YurtAppOverrider) bool {
+	owners := workload.GetOwnerReferences()
+	return len(owners) != 0 && owners[0].Kind == instance.Subject.Kind && owners[0].Name == instance.Subject.Name
 }
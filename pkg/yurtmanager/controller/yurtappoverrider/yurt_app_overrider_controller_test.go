@@ -19,10 +19,14 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -136,3 +140,252 @@ func TestReconcile(t *testing.T) {
 		t.Logf("fail to call Reconcile: %v", err)
 	}
 }
+
+func TestReconcileWorkloads(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add yurt custom resource")
+	}
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add kubernetes clint-go custom resource")
+	}
+
+	yurtAppSet := &v1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "yurtappset", Namespace: "default"},
+	}
+	appSetStatefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "yurtappset-hangzhou",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps.openyurt.io/v1beta1",
+				Kind:       "YurtAppSet",
+				Name:       "yurtappset",
+			}},
+		},
+	}
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+	}
+
+	testcases := map[string]struct {
+		subject v1alpha1.Subject
+		touched string
+	}{
+		"statefulset rendered by YurtAppSet": {
+			subject: v1alpha1.Subject{
+				TypeMeta: metav1.TypeMeta{Kind: "YurtAppSet", APIVersion: "apps.openyurt.io/v1alpha1"},
+				Name:     "yurtappset",
+			},
+			touched: "yurtappset-hangzhou",
+		},
+		"statefulset as subject": {
+			subject: v1alpha1.Subject{
+				TypeMeta: metav1.TypeMeta{Kind: "StatefulSet", APIVersion: "apps/v1"},
+				Name:     "demo",
+			},
+			touched: "demo",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			instance := overrider.DeepCopy()
+			instance.Subject = tc.subject
+			c := fakeclient.NewClientBuilder().WithScheme(scheme).
+				WithObjects(yurtAppSet, appSetStatefulSet, statefulSet, instance).Build()
+			reconciler := ReconcileYurtAppOverrider{
+				Client:            c,
+				CacheOverriderMap: make(map[string]*v1alpha1.YurtAppOverrider),
+			}
+			if _, err := reconciler.Reconcile(context.Background(), reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: "default", Name: "demo"},
+			}); err != nil {
+				t.Fatalf("fail to call Reconcile: %v", err)
+			}
+
+			for _, name := range []string{"yurtappset-hangzhou", "demo"} {
+				sts := &appsv1.StatefulSet{}
+				if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, sts); err != nil {
+					t.Fatalf("could not get statefulset %s, %v", name, err)
+				}
+				_, ok := sts.Annotations["LastOverrideTime"]
+				assert.Equal(t, name == tc.touched, ok, "statefulset %s", name)
+			}
+		})
+	}
+}
+
+func TestReconcileCustomWorkload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add yurt custom resource")
+	}
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add kubernetes clint-go custom resource")
+	}
+
+	gvk := schema.GroupVersionKind{Group: "apps.kruise.io", Version: "v1alpha1", Kind: "CloneSet"}
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind("CloneSetList"), &unstructured.UnstructuredList{})
+
+	cloneSet := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "demo",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"apps.openyurt.io/pool-name": "hangzhou"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx"}},
+				},
+			},
+		},
+	}}
+	cloneSet.SetGroupVersionKind(gvk)
+
+	instance := overrider.DeepCopy()
+	instance.Subject = v1alpha1.Subject{
+		TypeMeta: metav1.TypeMeta{Kind: gvk.Kind, APIVersion: gvk.GroupVersion().String()},
+		Name:     "demo",
+	}
+	instance.Entries = []v1alpha1.Entry{{
+		Pools: []string{"hangzhou"},
+		Items: []v1alpha1.Item{{Image: &v1alpha1.ImageItem{ContainerName: "nginx", ImageClaim: "nginx:1.18"}}},
+	}}
+
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(cloneSet, instance).Build()
+	reconciler := ReconcileYurtAppOverrider{
+		Client:            c,
+		CacheOverriderMap: make(map[string]*v1alpha1.YurtAppOverrider),
+	}
+	if _, err := reconciler.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "demo"},
+	}); err != nil {
+		t.Fatalf("fail to call Reconcile: %v", err)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(gvk)
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "demo"}, got); err != nil {
+		t.Fatalf("could not get CloneSet, %v", err)
+	}
+	containers, _, _ := unstructured.NestedSlice(got.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, "nginx:1.18", containers[0].(map[string]interface{})["image"])
+
+	// the workload is rendered again once its pod template is reverted
+	containers[0].(map[string]interface{})["image"] = "nginx"
+	if err := unstructured.SetNestedSlice(got.Object, containers, "spec", "template", "spec", "containers"); err != nil {
+		t.Fatalf("could not set containers, %v", err)
+	}
+	if err := c.Update(context.Background(), got); err != nil {
+		t.Fatalf("could not update CloneSet, %v", err)
+	}
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "demo"}}},
+		reconciler.overridersOfWorkload(context.Background(), got))
+	if _, err := reconciler.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "demo"},
+	}); err != nil {
+		t.Fatalf("fail to call Reconcile: %v", err)
+	}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "demo"}, got); err != nil {
+		t.Fatalf("could not get CloneSet, %v", err)
+	}
+	containers, _, _ = unstructured.NestedSlice(got.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, "nginx:1.18", containers[0].(map[string]interface{})["image"])
+
+	other := got.DeepCopy()
+	other.SetName("other")
+	assert.Empty(t, reconciler.overridersOfWorkload(context.Background(), other))
+}
+
+func TestReconcileCustomWorkloadIdempotent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add yurt custom resource")
+	}
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add kubernetes clint-go custom resource")
+	}
+
+	gvk := schema.GroupVersionKind{Group: "apps.kruise.io", Version: "v1alpha1", Kind: "CloneSet"}
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind("CloneSetList"), &unstructured.UnstructuredList{})
+
+	cloneSet := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "demo",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"apps.openyurt.io/pool-name": "hangzhou"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx"}},
+				},
+			},
+		},
+	}}
+	cloneSet.SetGroupVersionKind(gvk)
+
+	instance := overrider.DeepCopy()
+	instance.Subject = v1alpha1.Subject{
+		TypeMeta: metav1.TypeMeta{Kind: gvk.Kind, APIVersion: gvk.GroupVersion().String()},
+		Name:     "demo",
+	}
+	instance.Entries = []v1alpha1.Entry{{
+		Pools: []string{"hangzhou"},
+		Patches: []v1alpha1.Patch{{
+			Operation: v1alpha1.ADD,
+			Path:      "/spec/template/spec/containers/-",
+			Value:     apiextensionsv1.JSON{Raw: []byte(`{"name":"sidecar","image":"busybox"}`)},
+		}},
+	}}
+
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(cloneSet, instance).Build()
+	reconciler := ReconcileYurtAppOverrider{
+		Client:            c,
+		CacheOverriderMap: make(map[string]*v1alpha1.YurtAppOverrider),
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "demo"}}
+	key := types.NamespacedName{Namespace: "default", Name: "demo"}
+	getCloneSet := func() *unstructured.Unstructured {
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(gvk)
+		if err := c.Get(context.Background(), key, got); err != nil {
+			t.Fatalf("could not get CloneSet, %v", err)
+		}
+		return got
+	}
+
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("fail to call Reconcile: %v", err)
+	}
+	rendered := getCloneSet()
+	containers, _, _ := unstructured.NestedSlice(rendered.Object, "spec", "template", "spec", "containers")
+	assert.Len(t, containers, 2)
+
+	// the workload is not changed when it is rendered again
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("fail to call Reconcile: %v", err)
+	}
+	assert.Equal(t, rendered, getCloneSet())
+
+	// the removed entry is reverted
+	current := &v1alpha1.YurtAppOverrider{}
+	if err := c.Get(context.Background(), key, current); err != nil {
+		t.Fatalf("could not get YurtAppOverrider, %v", err)
+	}
+	current.Entries = nil
+	if err := c.Update(context.Background(), current); err != nil {
+		t.Fatalf("could not update YurtAppOverrider, %v", err)
+	}
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("fail to call Reconcile: %v", err)
+	}
+	containers, _, _ = unstructured.NestedSlice(getCloneSet().Object, "spec", "template", "spec", "containers")
+	assert.Len(t, containers, 1)
+}
@@ -20,14 +20,20 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/klog/v2"
 	"k8s.io/kubectl/pkg/util/podutils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
+	"github.com/openyurtio/openyurt/pkg/projectinfo"
 	podutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/pod"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtstaticset/util"
 )
@@ -64,6 +70,14 @@ type UpgradeInfo struct {
 
 	// Indicate whether the node is ready. It's used in AdvancedRollingUpdate mode.
 	NodeReady bool
+
+	// The node pool which the node belongs to. It's used to match maintenance windows.
+	NodePool string
+
+	// The result of upgrading to the latest static pod on the node, which is reported by
+	// the worker pod in AdvancedRollingUpdate mode or by YurtHub in OTA mode.
+	// It's nil if no upgrade to the latest static pod has finished on the node.
+	UpgradeResult *upgradeutil.UpgradeResult
 }
 
 // New constructs the upgrade information for nodes which have the target static pod
@@ -124,12 +138,24 @@ func initStaticPodInfo(instance *appsv1alpha1.YurtStaticSet, c client.Client, no
 		infos[nodeName].StaticPodReady = true
 	}
 
-	// Sets the ready status for every node which has the target static pod
-	ready, err := util.NodeReadyByName(c, nodeName)
-	if err != nil {
+	// Sets the ready status and node pool for every node which has the target static pod
+	node := &corev1.Node{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
 		return err
 	}
-	infos[nodeName].NodeReady = ready
+	infos[nodeName].NodeReady = util.NodeReady(node)
+	infos[nodeName].NodePool = node.Labels[projectinfo.GetNodePoolLabel()]
+
+	// Sets the result of OTA upgrade which is reported by YurtHub, and the result reported by worker pod takes precedence
+	results, err := upgradeutil.GetUpgradeResults(node.Annotations)
+	if err != nil {
+		klog.Warningf("could not get static pod upgrade results of node %s, %v", nodeName, err)
+		return nil
+	}
+	if result, ok := results[upgradeutil.UpgradeResultKey(instance.Namespace, instance.Name)]; ok && result.Hash == hash &&
+		infos[nodeName].UpgradeResult == nil {
+		infos[nodeName].UpgradeResult = result
+	}
 	return nil
 }
 
@@ -142,8 +168,11 @@ func initWorkerPodInfo(nodeName, hash string, pod *corev1.Pod, infos map[string]
 	infos[nodeName].WorkerPodStatusPhase = pod.Status.Phase
 	switch pod.Status.Phase {
 	case corev1.PodFailed:
-		// The worker pod is failed, then some irreparable failure has occurred. Just stop reconcile and update status
-		return fmt.Errorf("fail to init worker pod info, cause worker pod %s failed", pod.Name)
+		// The worker pod is failed, then some irreparable failure has occurred. The result is recorded
+		// and the upgrade stops until the worker pod is removed or the YurtStaticSet is updated.
+		if pod.Annotations[StaticPodHashAnnotation] == hash {
+			infos[nodeName].UpgradeResult = workerPodResult(pod, hash)
+		}
 	case corev1.PodSucceeded:
 		// The worker pod is succeeded, then this node must be up-to-date. Just delete this worker pod
 		infos[nodeName].WorkerPodDeleteNeeded = true
@@ -159,6 +188,30 @@ func initWorkerPodInfo(nodeName, hash string, pod *corev1.Pod, infos map[string]
 	return nil
 }
 
+// workerPodResult gets the upgrade result from termination message of the failed worker pod
+func workerPodResult(pod *corev1.Pod, hash string) *upgradeutil.UpgradeResult {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Terminated == nil || len(cs.State.Terminated.Message) == 0 {
+			continue
+		}
+		result, err := upgradeutil.ParseUpgradeResult(cs.State.Terminated.Message)
+		if err != nil {
+			klog.Warningf("could not get upgrade result of worker pod %s, %v", pod.Name, err)
+			continue
+		}
+		return result
+	}
+
+	// The worker pod exits without reporting result
+	return &upgradeutil.UpgradeResult{
+		Hash:    hash,
+		Result:  upgradeutil.UpgradeFailed,
+		Reason:  "WorkerPodFailed",
+		Message: fmt.Sprintf("worker pod %s failed", pod.Name),
+		Time:    pod.CreationTimestamp,
+	}
+}
+
 // match check if the given YurtStaticSet's template matches the pod.
 func match(instance *appsv1alpha1.YurtStaticSet, pod *corev1.Pod) bool {
 
@@ -255,6 +308,88 @@ func ListOutUpgradeNeededNodesAndUpgradedNodes(infos map[string]*UpgradeInfo) ([
 	return upgradeNeededNodes, upgradeNodes
 }
 
+// FailedWorkerPodExists checks whether there is a failed worker pod of the latest static pod
+func FailedWorkerPodExists(infos map[string]*UpgradeInfo) bool {
+	for _, info := range infos {
+		if info.WorkerPod != nil && info.WorkerPodStatusPhase == corev1.PodFailed && !info.WorkerPodDeleteNeeded {
+			return true
+		}
+	}
+	return false
+}
+
+// ListNodeUpgradeStatuses lists the upgrade status of nodes which are running the static pod, sorted by node name.
+// The transition time in previous statuses is kept if the phase of node is not changed.
+func ListNodeUpgradeStatuses(infos map[string]*UpgradeInfo, previous []appsv1alpha1.NodeUpgradeStatus,
+	now metav1.Time) []appsv1alpha1.NodeUpgradeStatus {
+	previousStatuses := make(map[string]*appsv1alpha1.NodeUpgradeStatus, len(previous))
+	for i := range previous {
+		previousStatuses[previous[i].NodeName] = &previous[i]
+	}
+
+	var statuses []appsv1alpha1.NodeUpgradeStatus
+	for node, info := range infos {
+		if info.StaticPod == nil {
+			continue
+		}
+
+		status := nodeUpgradeStatus(node, info)
+		if p, ok := previousStatuses[node]; ok && p.Phase == status.Phase && !p.LastTransitionTime.IsZero() {
+			status.LastTransitionTime = p.LastTransitionTime
+		} else if info.UpgradeResult != nil && !info.UpgradeResult.Time.IsZero() {
+			status.LastTransitionTime = info.UpgradeResult.Time
+		} else {
+			status.LastTransitionTime = now
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].NodeName < statuses[j].NodeName
+	})
+	return statuses
+}
+
+// nodeUpgradeStatus figures out the upgrade phase of the node from the upgrade info
+func nodeUpgradeStatus(nodeName string, info *UpgradeInfo) appsv1alpha1.NodeUpgradeStatus {
+	status := appsv1alpha1.NodeUpgradeStatus{NodeName: nodeName}
+	result := info.UpgradeResult
+
+	switch {
+	case !info.UpgradeNeeded:
+		status.Phase = appsv1alpha1.NodeUpgradeSucceeded
+	case result != nil && result.Finished() && !result.Succeeded():
+		status.Phase = appsv1alpha1.NodeUpgradeFailed
+		status.RolledBack = result.Result == upgradeutil.UpgradeRolledBack
+		status.Reason = result.Reason
+		status.Message = result.Message
+	case result != nil:
+		// The upgrade is reported by YurtHub, or it's succeeded but the mirror pod is not updated yet
+		status.Phase = appsv1alpha1.NodeUpgradeInProgress
+	case info.WorkerPodRunning && !info.WorkerPodDeleteNeeded:
+		status.Phase = appsv1alpha1.NodeUpgradeInProgress
+		status.Reason = "WorkerPodRunning"
+		status.Message = fmt.Sprintf("worker pod %s is upgrading the static pod", info.WorkerPod.Name)
+	case !info.NodeReady:
+		status.Phase = appsv1alpha1.NodeUpgradePending
+		status.Reason = "NodeNotReady"
+	default:
+		status.Phase = appsv1alpha1.NodeUpgradePending
+	}
+	return status
+}
+
+// FailedNodeNames returns the names of nodes in failed phase
+func FailedNodeNames(statuses []appsv1alpha1.NodeUpgradeStatus) []string {
+	var names []string
+	for _, s := range statuses {
+		if s.Phase == appsv1alpha1.NodeUpgradeFailed {
+			names = append(names, s.NodeName)
+		}
+	}
+	return names
+}
+
 // CalculateOperateInfoFromUpgradeInfoMap calculate the number of ready static pods, upgraded nodes,
 // the delete pods and whether all worker is finished.
 func CalculateOperateInfoFromUpgradeInfoMap(infos map[string]*UpgradeInfo) (int32, int32, bool, []*corev1.Pod) {
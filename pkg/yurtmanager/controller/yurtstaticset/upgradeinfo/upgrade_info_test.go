@@ -17,8 +17,10 @@ limitations under the License.
 package upgradeinfo
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
 	podutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/pod"
 )
 
@@ -137,6 +140,97 @@ func Test_ConstructStaticPodsUpgradeInfoList(t *testing.T) {
 	})
 }
 
+func TestListNodeUpgradeStatuses(t *testing.T) {
+	const hash = "789c7f9f47"
+	instance := newStaticPod()
+
+	// node1 failed in AdvancedRollingUpdate mode and reports the result by termination message
+	failedWorker := newPod(fakeStaticPodName, "node1", metav1.NamespaceDefault, false)
+	failedWorker.Annotations[StaticPodHashAnnotation] = hash
+	failedWorker.Status.Phase = corev1.PodFailed
+	failedWorker.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			Message: `{"hash":"789c7f9f47","result":"RolledBack","reason":"NotReady","message":"timeout"}`,
+		}},
+	}}
+
+	// node2 failed in OTA mode and reports the result by node annotation
+	node2 := newNode("node2")
+	annotations, err := upgradeutil.SetUpgradeResult(nil, upgradeutil.UpgradeResultKey(instance.Namespace, instance.Name),
+		&upgradeutil.UpgradeResult{Hash: hash, Result: upgradeutil.UpgradeFailed, Reason: upgradeutil.ReasonRollbackFailed})
+	if err != nil {
+		t.Fatalf("could not set upgrade result, %v", err)
+	}
+	node2.Annotations = annotations
+
+	// node3 failed to upgrade to an old hash, and is waiting for the latest one
+	node3 := newNode("node3")
+	node3.Annotations, _ = upgradeutil.SetUpgradeResult(nil, upgradeutil.UpgradeResultKey(instance.Namespace, instance.Name),
+		&upgradeutil.UpgradeResult{Hash: "old", Result: upgradeutil.UpgradeRolledBack})
+
+	// node4 is being upgraded in OTA mode
+	node4 := newNode("node4")
+	node4.Annotations, _ = upgradeutil.SetUpgradeResult(nil, upgradeutil.UpgradeResultKey(instance.Namespace, instance.Name),
+		&upgradeutil.UpgradeResult{Hash: hash, Result: upgradeutil.UpgradeInProgress})
+
+	// node5 is being upgraded in AdvancedRollingUpdate mode
+	runningWorker := newPod(fakeStaticPodName, "node5", metav1.NamespaceDefault, false)
+	runningWorker.Annotations[StaticPodHashAnnotation] = hash
+
+	// all static pods except node6 are running the old version
+	staticPods := newPods([]string{"node1", "node2", "node3", "node4", "node5", "node6"}, metav1.NamespaceDefault, true)
+	for _, pod := range staticPods[:5] {
+		pod.GetAnnotations()[StaticPodHashAnnotation] = "old"
+	}
+	staticPods[5].GetAnnotations()[StaticPodHashAnnotation] = hash
+	objects := append(staticPods, failedWorker, runningWorker, newNode("node1"), node2, node3, node4, newNode("node5"), newNode("node6"))
+	c := fake.NewClientBuilder().WithObjects(objects...).Build()
+
+	infos, err := New(c, instance, UpgradeWorkerPodPrefix, hash)
+	if err != nil {
+		t.Fatalf("could not construct upgrade info, %v", err)
+	}
+	if !FailedWorkerPodExists(infos) {
+		t.Errorf("expect failed worker pod exists")
+	}
+
+	now := metav1.Now()
+	earlier := metav1.NewTime(now.Add(-time.Hour))
+	previous := []appsv1alpha1.NodeUpgradeStatus{
+		{NodeName: "node3", Phase: appsv1alpha1.NodeUpgradeFailed, LastTransitionTime: earlier},
+		{NodeName: "node6", Phase: appsv1alpha1.NodeUpgradeSucceeded, LastTransitionTime: earlier},
+	}
+	statuses := ListNodeUpgradeStatuses(infos, previous, now)
+
+	expect := []struct {
+		phase      appsv1alpha1.NodeUpgradePhase
+		rolledBack bool
+		reason     string
+		time       metav1.Time
+	}{
+		{phase: appsv1alpha1.NodeUpgradeFailed, rolledBack: true, reason: upgradeutil.ReasonNotReady, time: now},
+		{phase: appsv1alpha1.NodeUpgradeFailed, reason: upgradeutil.ReasonRollbackFailed, time: now},
+		{phase: appsv1alpha1.NodeUpgradePending, reason: "NodeNotReady", time: now},
+		{phase: appsv1alpha1.NodeUpgradeInProgress, time: now},
+		{phase: appsv1alpha1.NodeUpgradeInProgress, reason: "WorkerPodRunning", time: now},
+		{phase: appsv1alpha1.NodeUpgradeSucceeded, time: earlier},
+	}
+	if len(statuses) != len(expect) {
+		t.Fatalf("expect %d node statuses, but got %v", len(expect), statuses)
+	}
+	for i, e := range expect {
+		s := statuses[i]
+		if s.NodeName != fmt.Sprintf("node%d", i+1) || s.Phase != e.phase || s.RolledBack != e.rolledBack ||
+			s.Reason != e.reason || !s.LastTransitionTime.Equal(&e.time) {
+			t.Errorf("unexpected status of node%d, %v", i+1, s)
+		}
+	}
+
+	if names := FailedNodeNames(statuses); !reflect.DeepEqual(names, []string{"node1", "node2"}) {
+		t.Errorf("expect failed nodes [node1 node2], but got %v", names)
+	}
+}
+
 func TestNodes(t *testing.T) {
 	spi := map[string]*UpgradeInfo{
 		"node1": {
@@ -27,7 +27,6 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/cli-runtime/pkg/printers"
@@ -109,16 +108,11 @@ func GenStaticPodManifest(tmplSpec *corev1.PodTemplateSpec, hash string) (string
 	return buf.String(), nil
 }
 
-// NodeReadyByName check if the given node is ready
-func NodeReadyByName(c client.Client, nodeName string) (bool, error) {
-	node := &corev1.Node{}
-	if err := c.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
-		return false, err
-	}
-
+// NodeReady check if the given node is ready
+func NodeReady(node *corev1.Node) bool {
 	_, nc := nodeutil.GetNodeCondition(&node.Status, corev1.NodeReady)
 
-	return nc != nil && nc.Status == corev1.ConditionTrue, nil
+	return nc != nil && nc.Status == corev1.ConditionTrue
 }
 
 // SetPodUpgradeCondition set pod condition `PodNeedUpgrade` to the specified value
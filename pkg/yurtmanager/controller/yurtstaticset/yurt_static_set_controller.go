@@ -19,14 +19,19 @@ package yurtstaticset
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,6 +48,9 @@ import (
 	appconfig "github.com/openyurtio/openyurt/cmd/yurt-manager/app/config"
 	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
 	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	upgrade "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade"
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/maintenancewindow"
 	nodeutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/node"
 	podutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/pod"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtstaticset/config"
@@ -68,7 +76,7 @@ const (
 	UpgradeWorkerPodPrefix     = "yss-upgrade-worker-"
 	UpgradeWorkerContainerName = "upgrade-worker"
 
-	ArgTmpl = "/usr/local/bin/node-servant static-pod-upgrade --name=%s --namespace=%s --manifest=%s --hash=%s --mode=%s"
+	ArgTmpl = "/usr/local/bin/node-servant static-pod-upgrade --name=%s --namespace=%s --manifest=%s --hash=%s --mode=%s --timeout=%s"
 )
 
 // upgradeWorker is the pod template used for static pod upgrade
@@ -205,13 +213,26 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 		return err
 	}
 
-	// 3. Watch for changes to upgrade worker pods which are created by yurt-static-set-controller
+	// 3. Watch for changes of upgrade results which are reported to node by YurtHub in OTA mode,
+	// and reconcile the YurtStaticSet instances whose results are changed
+	if err := c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Node{},
+		handler.Funcs{
+			UpdateFunc: func(ctx context.Context, evt event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				for _, req := range changedUpgradeResults(evt) {
+					q.Add(req)
+				}
+			},
+		})); err != nil {
+		return err
+	}
+
+	// 4. Watch for changes to upgrade worker pods which are created by yurt-static-set-controller
 	if err := c.Watch(source.Kind[client.Object](mgr.GetCache(), &corev1.Pod{},
 		handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &appsv1alpha1.YurtStaticSet{}, handler.OnlyControllerOwner()))); err != nil {
 		return err
 	}
 
-	// 4. Watch for changes of static pods
+	// 5. Watch for changes of static pods
 	reconcileYurtStaticSetForStaticPod := func(obj client.Object) []reconcile.Request {
 		var reqs []reconcile.Request
 		pod, ok := obj.(*corev1.Pod)
@@ -241,6 +262,43 @@ func add(mgr manager.Manager, cfg *appconfig.CompletedConfig, r reconcile.Reconc
 	return nil
 }
 
+// changedUpgradeResults returns the YurtStaticSet instances whose upgrade results in the annotation of node are changed
+func changedUpgradeResults(evt event.UpdateEvent) []reconcile.Request {
+	oldNode, ok := evt.ObjectOld.(*corev1.Node)
+	if !ok {
+		return nil
+	}
+	newNode, ok := evt.ObjectNew.(*corev1.Node)
+	if !ok {
+		return nil
+	}
+	if oldNode.Annotations[upgradeutil.UpgradeResultsAnnotation] == newNode.Annotations[upgradeutil.UpgradeResultsAnnotation] {
+		return nil
+	}
+
+	oldResults, err := upgradeutil.GetUpgradeResults(oldNode.Annotations)
+	if err != nil {
+		oldResults = nil
+	}
+	newResults, err := upgradeutil.GetUpgradeResults(newNode.Annotations)
+	if err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for key, result := range newResults {
+		if old, ok := oldResults[key]; ok && reflect.DeepEqual(old, result) {
+			continue
+		}
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}})
+	}
+	return reqs
+}
+
 // nodeTurnReady filter events: old node is not-ready or unknown, new node is ready
 func nodeTurnReady(evt event.UpdateEvent) bool {
 	if _, ok := evt.ObjectOld.(*corev1.Node); !ok {
@@ -281,6 +339,9 @@ func (r *ReconcileYurtStaticSet) Reconcile(_ context.Context, request reconcile.
 	if err := r.Get(context.TODO(), request.NamespacedName, instance); err != nil {
 		// if the yurtStaticSet does not exist, delete the specified configmap if exist.
 		if kerr.IsNotFound(err) {
+			if err := r.removeUpgradeResults(request.Name, request.Namespace); err != nil {
+				return reconcile.Result{}, err
+			}
 			return reconcile.Result{}, r.deleteConfigMap(request.Name, request.Namespace)
 		}
 		klog.Errorf("could not get YurtStaticSet %v, %v", request.NamespacedName, err)
@@ -289,7 +350,10 @@ func (r *ReconcileYurtStaticSet) Reconcile(_ context.Context, request reconcile.
 
 	if instance.DeletionTimestamp != nil {
 		// handle the deletion event
-		// delete the configMap which is created by yurtStaticSet
+		// delete the configMap which is created by yurtStaticSet and the upgrade results on nodes
+		if err := r.removeUpgradeResults(request.Name, request.Namespace); err != nil {
+			return reconcile.Result{}, err
+		}
 		return reconcile.Result{}, r.deleteConfigMap(request.Name, request.Namespace)
 	}
 
@@ -334,18 +398,13 @@ func (r *ReconcileYurtStaticSet) Reconcile(_ context.Context, request reconcile.
 	// The later upgrade operation is conducted based on upgradeInfos
 	upgradeInfos, err := upgradeinfo.New(r.Client, instance, UpgradeWorkerPodPrefix, latestHash)
 	if err != nil {
-		// The worker pod is failed, then some irreparable failure has occurred. Just stop reconcile and update status
-		if strings.Contains(err.Error(), "could not init worker pod") {
-			r.recorder.Eventf(instance, corev1.EventTypeWarning, "YurtStaticSet Upgrade Failed", err.Error())
-			klog.Error(err.Error())
-			return reconcile.Result{}, err
-		}
-
 		klog.Error(Format("could not get static pod and worker pod upgrade info for nodes of YurtStaticSet %v, %v",
 			request.NamespacedName, err))
 		return ctrl.Result{}, err
 	}
 	totalNumber = int32(len(upgradeInfos))
+	instance.Status.NodeStatuses = upgradeinfo.ListNodeUpgradeStatuses(upgradeInfos, instance.Status.NodeStatuses, metav1.Now())
+	instance.Status.FailedNumber = int32(len(upgradeinfo.FailedNodeNames(instance.Status.NodeStatuses)))
 	// There are no nodes running target static pods in the cluster
 	if totalNumber == 0 {
 		klog.Info(Format("No static pods need to be upgraded of YurtStaticSet %v", request.NamespacedName))
@@ -368,29 +427,46 @@ func (r *ReconcileYurtStaticSet) Reconcile(_ context.Context, request reconcile.
 		return r.updateYurtStaticSetStatus(instance, totalNumber, readyNumber, upgradedNumber)
 	}
 
+	// The paused flag and maintenance windows decide on which nodes the upgrade can be conducted now
+	checker, err := maintenancewindow.NewChecker(instance.Spec.UpgradeStrategy.Paused, instance.Spec.UpgradeStrategy.MaintenanceWindows)
+	if err != nil {
+		r.recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidMaintenanceWindow", err.Error())
+		klog.Error(Format("could not parse maintenance windows of YurtStaticSet %v, %v", request.NamespacedName, err))
+		return r.updateYurtStaticSetStatus(instance, totalNumber, readyNumber, upgradedNumber)
+	}
+	now := time.Now()
+
 	switch strings.ToLower(string(instance.Spec.UpgradeStrategy.Type)) {
 	// AdvancedRollingUpdate Upgrade is to automate the upgrade process for the target static pods on ready nodes
 	// It supports rolling update and the max-unavailable number can be specified by users
 	case strings.ToLower(string(appsv1alpha1.AdvancedRollingUpdateUpgradeStrategyType)):
+		// The worker pod is failed, then some irreparable failure has occurred. Just stop upgrade and update status
+		if upgradeinfo.FailedWorkerPodExists(upgradeInfos) {
+			r.recorder.Eventf(instance, corev1.EventTypeWarning, "YurtStaticSet Upgrade Failed",
+				"static pod upgrade failed on nodes %v", upgradeinfo.FailedNodeNames(instance.Status.NodeStatuses))
+			klog.Error(Format("Stop AdvancedRollingUpdate upgrade of YurtStaticSet %v because of failed worker pods", request.NamespacedName))
+			return r.updateYurtStaticSetStatus(instance, totalNumber, readyNumber, upgradedNumber)
+		}
+
 		if !allSucceeded {
 			klog.V(5).Info(Format("Wait last round AdvancedRollingUpdate upgrade to finish of YurtStaticSet %v", request.NamespacedName))
 			return r.updateYurtStaticSetStatus(instance, totalNumber, readyNumber, upgradedNumber)
 		}
 
-		if err := r.advancedRollingUpdate(instance, upgradeInfos, latestHash); err != nil {
+		if err := r.advancedRollingUpdate(instance, upgradeInfos, latestHash, checker, now); err != nil {
 			klog.Error(Format("could not AdvancedRollingUpdate upgrade of YurtStaticSet %v, %v", request.NamespacedName, err))
 			return ctrl.Result{}, err
 		}
-		return r.updateYurtStaticSetStatus(instance, totalNumber, readyNumber, upgradedNumber)
+		return r.updateYurtStaticSetStatusAndRequeue(instance, totalNumber, readyNumber, upgradedNumber, checker.RequeueAfter(now))
 
 	// OTA Upgrade can help users control the timing of static pods upgrade
 	// It will set PodNeedUpgrade condition and work with YurtHub component
 	case strings.ToLower(string(appsv1alpha1.OTAUpgradeStrategyType)):
-		if err := r.otaUpgrade(upgradeInfos); err != nil {
+		if err := r.otaUpgrade(upgradeInfos, checker, now); err != nil {
 			klog.Error(Format("could not OTA upgrade of YurtStaticSet %v, %v", request.NamespacedName, err))
 			return ctrl.Result{}, err
 		}
-		return r.updateYurtStaticSetStatus(instance, totalNumber, readyNumber, upgradedNumber)
+		return r.updateYurtStaticSetStatusAndRequeue(instance, totalNumber, readyNumber, upgradedNumber, checker.RequeueAfter(now))
 	}
 
 	return ctrl.Result{}, nil
@@ -408,7 +484,8 @@ func (r *ReconcileYurtStaticSet) syncConfigMap(instance *appsv1alpha1.YurtStatic
 					Name:      cmName,
 					Namespace: instance.Namespace,
 					Annotations: map[string]string{
-						StaticPodHashAnnotation: hash,
+						StaticPodHashAnnotation:            hash,
+						upgradeutil.ReadyTimeoutAnnotation: readyTimeout(instance),
 					},
 				},
 
@@ -424,9 +501,16 @@ func (r *ReconcileYurtStaticSet) syncConfigMap(instance *appsv1alpha1.YurtStatic
 		return err
 	}
 
-	// if the hash value in the annotation of the cm does not match the latest hash, then update the data in the cm
-	if cm.Annotations[StaticPodHashAnnotation] != hash {
+	// if the hash value or ready timeout in the annotation of the cm does not match the latest one, then update the cm
+	if cm.Annotations[StaticPodHashAnnotation] != hash || cm.Annotations[upgradeutil.ReadyTimeoutAnnotation] != readyTimeout(instance) {
+		if cm.Annotations == nil {
+			cm.Annotations = make(map[string]string)
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
 		cm.Annotations[StaticPodHashAnnotation] = hash
+		cm.Annotations[upgradeutil.ReadyTimeoutAnnotation] = readyTimeout(instance)
 		cm.Data[instance.Spec.StaticPodManifest] = data
 
 		if err := r.Update(context.TODO(), cm, &client.UpdateOptions{}); err != nil {
@@ -438,9 +522,16 @@ func (r *ReconcileYurtStaticSet) syncConfigMap(instance *appsv1alpha1.YurtStatic
 }
 
 // advancedRollingUpdate automatically rolling upgrade the target static pods in cluster
-func (r *ReconcileYurtStaticSet) advancedRollingUpdate(instance *appsv1alpha1.YurtStaticSet, infos map[string]*upgradeinfo.UpgradeInfo, hash string) error {
-	// readyUpgradeWaitingNodes represents nodes that need to create worker pods
-	readyUpgradeWaitingNodes := upgradeinfo.ReadyUpgradeWaitingNodes(infos)
+func (r *ReconcileYurtStaticSet) advancedRollingUpdate(instance *appsv1alpha1.YurtStaticSet, infos map[string]*upgradeinfo.UpgradeInfo,
+	hash string, checker *maintenancewindow.Checker, now time.Time) error {
+	// readyUpgradeWaitingNodes represents nodes that need to create worker pods,
+	// nodes out of maintenance windows are skipped in current round
+	var readyUpgradeWaitingNodes []string
+	for _, n := range upgradeinfo.ReadyUpgradeWaitingNodes(infos) {
+		if checker.Allowed(infos[n].NodePool, now) {
+			readyUpgradeWaitingNodes = append(readyUpgradeWaitingNodes, n)
+		}
+	}
 
 	waitingNumber := len(readyUpgradeWaitingNodes)
 	if waitingNumber == 0 {
@@ -466,12 +557,16 @@ func (r *ReconcileYurtStaticSet) advancedRollingUpdate(instance *appsv1alpha1.Yu
 }
 
 // otaUpgrade adds condition PodNeedUpgrade to the target static pods
-func (r *ReconcileYurtStaticSet) otaUpgrade(infos map[string]*upgradeinfo.UpgradeInfo) error {
+func (r *ReconcileYurtStaticSet) otaUpgrade(infos map[string]*upgradeinfo.UpgradeInfo, checker *maintenancewindow.Checker, now time.Time) error {
 	upgradeNeededNodes, upgradedNodes := upgradeinfo.ListOutUpgradeNeededNodesAndUpgradedNodes(infos)
 
-	// Set condition for upgrade needed static pods
+	// Set condition for upgrade needed static pods, static pods out of maintenance windows are not upgradable
 	for _, n := range upgradeNeededNodes {
-		if err := util.SetPodUpgradeCondition(r.Client, corev1.ConditionTrue, infos[n].StaticPod); err != nil {
+		status := corev1.ConditionTrue
+		if !checker.Allowed(infos[n].NodePool, now) {
+			status = corev1.ConditionFalse
+		}
+		if err := util.SetPodUpgradeCondition(r.Client, status, infos[n].StaticPod); err != nil {
 			return err
 		}
 	}
@@ -516,7 +611,7 @@ func createUpgradeWorker(c client.Client, instance *appsv1alpha1.YurtStaticSet,
 			},
 		})
 		pod.Spec.Containers[0].Args = []string{fmt.Sprintf(ArgTmpl, util.Hyphen(instance.Name, node), instance.Namespace,
-			instance.Spec.StaticPodManifest, hash, mode)}
+			instance.Spec.StaticPodManifest, hash, mode, readyTimeout(instance))}
 		pod.Spec.Containers[0].Image = img
 		if err := controllerutil.SetControllerReference(instance, pod, c.Scheme()); err != nil {
 			return err
@@ -531,6 +626,14 @@ func createUpgradeWorker(c client.Client, instance *appsv1alpha1.YurtStaticSet,
 	return nil
 }
 
+// readyTimeout returns how long to wait for the upgraded static pod to be ready before rolling back
+func readyTimeout(instance *appsv1alpha1.YurtStaticSet) string {
+	if instance.Spec.UpgradeStrategy.ReadyTimeout != nil {
+		return instance.Spec.UpgradeStrategy.ReadyTimeout.Duration.String()
+	}
+	return upgrade.DefaultStaticPodRunningCheckTimeout.String()
+}
+
 // updateYurtStaticSetStatus set the status of instance to the given values
 func (r *ReconcileYurtStaticSet) updateYurtStaticSetStatus(instance *appsv1alpha1.YurtStaticSet, totalNum, readyNum, upgradedNum int32) (reconcile.Result, error) {
 	instance.Status.TotalNumber = totalNum
@@ -544,6 +647,17 @@ func (r *ReconcileYurtStaticSet) updateYurtStaticSetStatus(instance *appsv1alpha
 	return reconcile.Result{}, nil
 }
 
+// updateYurtStaticSetStatusAndRequeue set the status of instance to the given values, and requeue the instance
+// after the given duration if it's positive
+func (r *ReconcileYurtStaticSet) updateYurtStaticSetStatusAndRequeue(instance *appsv1alpha1.YurtStaticSet, totalNum, readyNum, upgradedNum int32,
+	requeueAfter time.Duration) (reconcile.Result, error) {
+	if result, err := r.updateYurtStaticSetStatus(instance, totalNum, readyNum, upgradedNum); err != nil {
+		return result, err
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
 // deleteConfigMap delete the configMap if YurtStaticSet is deleting
 func (r *ReconcileYurtStaticSet) deleteConfigMap(name, namespace string) error {
 	cmName := util.WithConfigMapPrefix(name)
@@ -560,3 +674,39 @@ func (r *ReconcileYurtStaticSet) deleteConfigMap(name, namespace string) error {
 	klog.Info(Format("Delete ConfigMap %s from YurtStaticSet %s", configMap.Name, name))
 	return nil
 }
+
+// removeUpgradeResults removes the OTA upgrade results of the deleted YurtStaticSet from the annotation of nodes
+func (r *ReconcileYurtStaticSet) removeUpgradeResults(name, namespace string) error {
+	nodes := &corev1.NodeList{}
+	if err := r.List(context.TODO(), nodes); err != nil {
+		return err
+	}
+
+	key := upgradeutil.UpgradeResultKey(namespace, name)
+	for i := range nodes.Items {
+		results, err := upgradeutil.GetUpgradeResults(nodes.Items[i].Annotations)
+		if err != nil {
+			continue
+		}
+		if _, ok := results[key]; !ok {
+			continue
+		}
+
+		nodeName := nodes.Items[i].Name
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			node := &corev1.Node{}
+			if err := r.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
+				return err
+			}
+			if removed, err := upgradeutil.RemoveUpgradeResult(node.Annotations, key); err != nil || !removed {
+				return err
+			}
+			return r.Update(context.TODO(), node)
+		})
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		klog.V(4).Info(Format("Remove upgrade result of YurtStaticSet %s/%s from node %s", namespace, name, nodeName))
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ package yurtstaticset
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -32,6 +33,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	upgradeutil "github.com/openyurtio/openyurt/pkg/node-servant/static-pod-upgrade/util"
+	podutil "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/pod"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtstaticset/util"
 )
 
@@ -128,6 +131,60 @@ func TestReconcile(t *testing.T) {
 	}
 }
 
+func TestReconcilePaused(t *testing.T) {
+	var strategy = []appsv1alpha1.YurtStaticSetUpgradeStrategy{
+		{Type: appsv1alpha1.OTAUpgradeStrategyType, Paused: true},
+		{Type: appsv1alpha1.AdvancedRollingUpdateUpgradeStrategyType, MaxUnavailable: &DefaultMaxUnavailable, Paused: true},
+	}
+	instance := &appsv1alpha1.YurtStaticSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TestStaticPodName,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: appsv1alpha1.YurtStaticSetSpec{
+			StaticPodManifest: "nginx",
+			Template:          corev1.PodTemplateSpec{},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := appsv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("Fail to add yurt custom resource")
+	}
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal("Fail to add kubernetes clint-go custom resource")
+	}
+
+	for _, s := range strategy {
+		instance.Spec.UpgradeStrategy = s
+		c := fakeclient.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(instance).WithStatusSubresource(instance).
+			WithObjects(prepareStaticPods()...).WithObjects(prepareNodes()...).Build()
+
+		var req = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: metav1.NamespaceDefault, Name: TestStaticPodName}}
+		rsp := ReconcileYurtStaticSet{
+			Client: c,
+			scheme: scheme,
+		}
+
+		if _, err := rsp.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("failed to control static-pod controller, %v", err)
+		}
+
+		podList := &corev1.PodList{}
+		if err := c.List(context.TODO(), podList); err != nil {
+			t.Fatalf("could not list pods, %v", err)
+		}
+		for _, pod := range podList.Items {
+			if strings.HasPrefix(pod.Name, UpgradeWorkerPodPrefix) {
+				t.Errorf("upgrade worker %s should not be created when upgrade is paused", pod.Name)
+			}
+			if _, cond := podutil.GetPodCondition(&pod.Status, util.PodNeedUpgrade); cond != nil && cond.Status == corev1.ConditionTrue {
+				t.Errorf("static pod %s should not be upgradable when upgrade is paused", pod.Name)
+			}
+		}
+	}
+}
+
 func Test_nodeTurnReady(t *testing.T) {
 	evt := event.UpdateEvent{
 		ObjectNew: &corev1.Node{
@@ -218,3 +275,58 @@ func TestReconcileYurtStaticSetDeleteConfigMap(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileYurtStaticSetRemoveUpgradeResults(t *testing.T) {
+	results := map[string]*upgradeutil.UpgradeResult{
+		upgradeutil.UpgradeResultKey(metav1.NamespaceDefault, TestStaticPodName): {Hash: "old", Result: upgradeutil.UpgradeSucceeded},
+		upgradeutil.UpgradeResultKey(metav1.NamespaceDefault, "coredns"):         {Hash: "new", Result: upgradeutil.UpgradeInProgress},
+	}
+	var annotations map[string]string
+	var err error
+	for key, result := range results {
+		if annotations, err = upgradeutil.SetUpgradeResult(annotations, key, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: annotations}}
+	onlyResult, err := upgradeutil.SetUpgradeResult(nil, upgradeutil.UpgradeResultKey(metav1.NamespaceDefault, TestStaticPodName), results[upgradeutil.UpgradeResultKey(metav1.NamespaceDefault, TestStaticPodName)])
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2", Annotations: onlyResult}}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appsv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(node, otherNode).Build()
+	r := &ReconcileYurtStaticSet{Client: c, scheme: scheme}
+
+	// the results of the deleted YurtStaticSet are removed from the nodes
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: metav1.NamespaceDefault, Name: TestStaticPodName}}
+	if _, err := r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("failed to reconcile the deleted YurtStaticSet, %v", err)
+	}
+
+	updated := &corev1.Node{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "node1"}, updated); err != nil {
+		t.Fatal(err)
+	}
+	got, err := upgradeutil.GetUpgradeResults(updated.Annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[upgradeutil.UpgradeResultKey(metav1.NamespaceDefault, "coredns")] == nil {
+		t.Errorf("expect only the result of other YurtStaticSet is kept, got %v", got)
+	}
+
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "node2"}, updated); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := updated.Annotations[upgradeutil.UpgradeResultsAnnotation]; ok {
+		t.Errorf("expect the empty results annotation is removed, got %v", updated.Annotations)
+	}
+}
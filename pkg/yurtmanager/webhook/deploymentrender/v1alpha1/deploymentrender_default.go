@@ -19,17 +19,19 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	v1 "k8s.io/api/apps/v1"
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
+	k8sappsv1 "k8s.io/kubernetes/pkg/apis/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/openyurtio/openyurt/pkg/apis/apps"
 	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappdaemon/workloadcontroller"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappoverrider/render"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappset/adapter"
 )
 
@@ -52,16 +54,14 @@ func (webhook *DeploymentRenderHandler) Default(ctx context.Context, obj runtime
 	if !ok {
 		return apierrors.NewBadRequest(fmt.Sprintf("expected a Deployment but got a %T", obj))
 	}
-	if deployment.OwnerReferences == nil {
-		return nil
-	}
-	if !contain(deployment.OwnerReferences[0].Kind, resources) {
-		return nil
+	if len(deployment.OwnerReferences) == 0 || !contain(deployment.OwnerReferences[0].Kind, resources) {
+		// the deployment may be the subject of YurtAppOverrider itself
+		return renderWorkload(ctx, webhook.Client, deployment, v1.SchemeGroupVersion.String(), "Deployment")
 	}
 
 	// Get nodepool of deployment
 	np := &v1alpha1.NodePool{}
-	npName := deployment.Labels["apps.openyurt.io/pool-name"]
+	npName := deployment.Labels[apps.PoolNameLabelKey]
 	if err := webhook.Client.Get(ctx, client.ObjectKey{
 		Name: npName,
 	}, np); err != nil {
@@ -124,54 +124,66 @@ func (webhook *DeploymentRenderHandler) Default(ctx context.Context, obj runtime
 	}
 
 	// Get YurtAppOverrider resource of app(1 to 1)
-	var allOverriderList v1alpha1.YurtAppOverriderList
-	//listOptions := client.MatchingFields{"spec.subject.kind": app.Kind, "spec.subject.name": app.Name, "spec.subject.APIVersion": app.APIVersion}
-	if err := webhook.Client.List(ctx, &allOverriderList, client.InNamespace(deployment.Namespace)); err != nil {
-		klog.Infof("error in listing YurtAppOverrider: %v", err)
+	overrider, err := render.GetOverrider(ctx, webhook.Client, deployment.Namespace, app.APIVersion, app.Kind, app.Name)
+	if err != nil || overrider == nil {
 		return err
 	}
-	var overriders = make([]v1alpha1.YurtAppOverrider, 0)
-	for _, overrider := range allOverriderList.Items {
-		if overrider.Subject.Kind == app.Kind && overrider.Subject.Name == app.Name && overrider.Subject.APIVersion == app.APIVersion {
-			overriders = append(overriders, overrider)
-		}
+	if err := render.Render(deployment, npName, overrider); err != nil {
+		klog.Infof("could not update patches for deployment: %v", err)
+		return err
 	}
+	return nil
+}
 
-	klog.Infof("list YurtAppOverrider, total: %d", len(overriders))
-	if len(overriders) == 0 {
+// renderWorkload applies the YurtAppOverrider whose subject is the workload itself. The workload is
+// not rendered by YurtAppSet or YurtAppDaemon, so the nodepool is taken from its pool-name label.
+// The workload is rendered from its base spec, so rendering it again on every update is idempotent.
+func renderWorkload(ctx context.Context, c client.Client, obj client.Object, apiVersion, kind string) error {
+	overrider, err := render.GetOverrider(ctx, c, obj.GetNamespace(), apiVersion, kind, obj.GetName())
+	if err != nil {
+		return err
+	}
+	if _, ok := obj.GetAnnotations()[apps.AnnotationOverrideBase]; overrider == nil && !ok {
+		return nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	// the base spec is restored once the overrider is deleted
+	rendered, err := render.RenderFromBase(u, obj.GetLabels()[apps.PoolNameLabelKey], overrider)
+	if err != nil {
+		klog.Infof("could not render %s %s/%s: %v", kind, obj.GetNamespace(), obj.GetName(), err)
+		return err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rendered.Object, obj); err != nil {
+		return err
+	}
+	if overrider == nil {
 		return nil
 	}
-	render := overriders[0]
 
-	for _, entry := range render.Entries {
-		for _, pool := range entry.Pools {
-			if pool[0] == '-' && pool[1:] == npName {
-				continue
-			}
-			if pool == npName || pool == "*" {
-				// Replace items
-				replaceItems(deployment, entry.Items)
-				// json patch
-				for i, patch := range entry.Patches {
-					if strings.Contains(string(patch.Value.Raw), "{{nodepool}}") {
-						newPatchString := strings.ReplaceAll(string(patch.Value.Raw), "{{nodepool}}", npName)
-						entry.Patches[i].Value = apiextensionsv1.JSON{Raw: []byte(newPatchString)}
-					}
-				}
-				// Implement injection
-				dataStruct := v1.Deployment{}
-				pc := PatchControl{
-					patches:     entry.Patches,
-					patchObject: deployment,
-					dataStruct:  dataStruct,
-				}
-				if err := pc.jsonMergePatch(); err != nil {
-					klog.Infof("could not update patches for deployment: %v", err)
-					return err
-				}
-				break
-			}
-		}
+	// the apiserver sets the defaults of the fields added by the overrider, so the hash is taken from the
+	// defaulted spec, otherwise the rendered spec is taken as a new base when the workload is updated again
+	switch workload := obj.(type) {
+	case *v1.Deployment:
+		k8sappsv1.SetObjectDefaults_Deployment(workload)
+	case *v1.StatefulSet:
+		k8sappsv1.SetObjectDefaults_StatefulSet(workload)
+	}
+	content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	hash, err := render.HashSpec(content["spec"])
+	if err != nil {
+		return err
 	}
+	annotations := obj.GetAnnotations()
+	annotations[apps.AnnotationOverrideHash] = hash
+	obj.SetAnnotations(annotations)
 	return nil
 }
@@ -373,3 +373,65 @@ func TestDeploymentRenderHandler_Default(t *testing.T) {
 		})
 	}
 }
+
+func TestDeploymentRenderHandler_DefaultSubjectIdempotent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("could not add v1alpha1 to scheme, %v", err)
+	}
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("could not add client go to scheme, %v", err)
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo",
+			Namespace: "default",
+			Labels:    map[string]string{"apps.openyurt.io/pool-name": "nodepool-test"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replica,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}}},
+			},
+		},
+	}
+	overrider := &v1alpha1.YurtAppOverrider{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Subject: v1alpha1.Subject{
+			TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			Name:     "demo",
+		},
+		Entries: []v1alpha1.Entry{{
+			Pools: []string{"*"},
+			Patches: []v1alpha1.Patch{{
+				Operation: v1alpha1.ADD,
+				Path:      "/spec/template/spec/containers/-",
+				Value:     apiextensionsv1.JSON{Raw: []byte(`{"name":"sidecar","image":"busybox"}`)},
+			}},
+		}},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(overrider).Build()
+	webhook := &DeploymentRenderHandler{Client: c, Scheme: scheme}
+
+	// the overrider is applied once, no matter how many times the deployment is updated
+	for i := 0; i < 2; i++ {
+		if err := webhook.Default(context.TODO(), deployment); err != nil {
+			t.Fatal(err)
+		}
+		if len(deployment.Spec.Template.Spec.Containers) != 2 {
+			t.Fatalf("expect 2 containers after %d renders, but got %d", i+1, len(deployment.Spec.Template.Spec.Containers))
+		}
+	}
+
+	// the base spec is restored once the entry is removed
+	overrider.Entries = nil
+	if err := c.Update(context.TODO(), overrider); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhook.Default(context.TODO(), deployment); err != nil {
+		t.Fatal(err)
+	}
+	if len(deployment.Spec.Template.Spec.Containers) != 1 {
+		t.Fatalf("expect the sidecar to be removed, but got %d containers", len(deployment.Spec.Template.Spec.Containers))
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps"
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappoverrider/render"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappset/workloadmanager"
+)
+
+// Default satisfies the defaulting webhook interface.
+func (webhook *StatefulSetRenderHandler) Default(ctx context.Context, obj runtime.Object) error {
+	statefulset, ok := obj.(*v1.StatefulSet)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a StatefulSet but got a %T", obj))
+	}
+
+	owner := yurtAppSetOwner(statefulset)
+	if owner == nil {
+		// the statefulset may be the subject of YurtAppOverrider itself
+		return renderWorkload(ctx, webhook.Client, statefulset, v1.SchemeGroupVersion.String(), "StatefulSet")
+	}
+
+	// Get YurtAppOverrider resource of YurtAppSet(1 to 1)
+	overrider, err := render.GetOverrider(ctx, webhook.Client, statefulset.Namespace, owner.APIVersion, owner.Kind, owner.Name)
+	if err != nil || overrider == nil {
+		return err
+	}
+
+	// restore statefulset from YurtAppSet, so that the overrider is always applied to the rendered template
+	yas := &v1beta1.YurtAppSet{}
+	if err := webhook.Client.Get(ctx, client.ObjectKey{Namespace: statefulset.Namespace, Name: owner.Name}, yas); err != nil {
+		return err
+	}
+	npName := statefulset.Labels[apps.PoolNameLabelKey]
+	revision := statefulset.Labels[apps.ControllerRevisionHashLabelKey]
+	if len(revision) == 0 {
+		revision = yas.Status.CurrentRevision
+	}
+	manager := workloadmanager.StatefulSetManager{
+		Client: webhook.Client,
+		Scheme: webhook.Scheme,
+	}
+	if err := manager.ApplyTemplate(yas, npName, revision, statefulset); err != nil {
+		return err
+	}
+
+	if err := render.Render(statefulset, npName, overrider); err != nil {
+		klog.Infof("could not update patches for statefulset: %v", err)
+		return err
+	}
+	return nil
+}
+
+// yurtAppSetOwner returns the YurtAppSet owner reference of statefulset. StatefulSets are only
+// rendered by YurtAppSet v1beta1, the owner reference is matched by group so that all versions are covered.
+func yurtAppSetOwner(statefulset *v1.StatefulSet) *metav1.OwnerReference {
+	for i, ref := range statefulset.OwnerReferences {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			continue
+		}
+		if ref.Kind == "YurtAppSet" && gv.Group == v1beta1.GroupVersion.Group {
+			return &statefulset.OwnerReferences[i]
+		}
+	}
+	return nil
+}
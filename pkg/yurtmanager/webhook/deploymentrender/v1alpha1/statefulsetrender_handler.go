@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	yurtClient "github.com/openyurtio/openyurt/cmd/yurt-manager/app/client"
+	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/util"
+)
+
+// SetupWebhookWithManager sets up StatefulSet webhooks. 	mutate path, validatepath, error
+func (webhook *StatefulSetRenderHandler) SetupWebhookWithManager(mgr ctrl.Manager) (string, string, error) {
+	// init
+	webhook.Client = yurtClient.GetClientByControllerNameOrDie(mgr, names.YurtAppOverriderController)
+	webhook.Scheme = mgr.GetScheme()
+
+	return util.RegisterWebhook(mgr, &v1.StatefulSet{}, webhook)
+}
+
+// +kubebuilder:webhook:path=/mutate-apps-v1-statefulset,mutating=true,failurePolicy=ignore,groups=apps,resources=statefulsets,verbs=create;update,versions=v1,name=mutate.apps.v1.statefulset,sideEffects=None,admissionReviewVersions=v1
+
+// StatefulSetRenderHandler implements a defaulting webhook which applies YurtAppOverrider to StatefulSet.
+type StatefulSetRenderHandler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+var _ webhook.CustomDefaulter = &StatefulSetRenderHandler{}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/apis/apps/v1beta2"
+)
+
+func newStatefulSetRenderScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme, v1alpha1.AddToScheme, v1beta1.AddToScheme, v1beta2.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			t.Fatalf("could not add to scheme, %v", err)
+		}
+	}
+	return scheme
+}
+
+func TestStatefulSetRenderHandler_Default(t *testing.T) {
+	var replicas int32 = 1
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "test"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}}},
+	}
+	yas := &v1beta1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "yurtappset-patch", Namespace: "default"},
+		Spec: v1beta1.YurtAppSetSpec{
+			Workload: v1beta1.Workload{
+				WorkloadTemplate: v1beta1.WorkloadTemplate{
+					StatefulSetTemplate: &v1beta1.StatefulSetTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "test"}},
+						Spec: appsv1.StatefulSetSpec{
+							Replicas: &replicas,
+							Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+							Template: template,
+						},
+					},
+				},
+			},
+		},
+	}
+	np := &v1beta2.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "nodepool-test"}}
+
+	testcases := map[string]struct {
+		statefulset *appsv1.StatefulSet
+		subject     v1alpha1.Subject
+		expectImage string
+	}{
+		"rendered by YurtAppSet": {
+			statefulset: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "yurtappset-patch-nodepool-test",
+					Namespace: "default",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "apps.openyurt.io/v1beta1",
+						Kind:       "YurtAppSet",
+						Name:       "yurtappset-patch",
+					}},
+					Labels: map[string]string{"apps.openyurt.io/pool-name": "nodepool-test"},
+				},
+				Spec: appsv1.StatefulSetSpec{Replicas: &replicas, Template: *template.DeepCopy()},
+			},
+			subject: v1alpha1.Subject{
+				TypeMeta: metav1.TypeMeta{Kind: "YurtAppSet", APIVersion: "apps.openyurt.io/v1alpha1"},
+				Name:     "yurtappset-patch",
+			},
+			expectImage: "nginx:1.18",
+		},
+		"statefulset as subject": {
+			statefulset: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "demo",
+					Namespace: "default",
+					Labels:    map[string]string{"apps.openyurt.io/pool-name": "nodepool-test"},
+				},
+				Spec: appsv1.StatefulSetSpec{Replicas: &replicas, Template: *template.DeepCopy()},
+			},
+			subject: v1alpha1.Subject{
+				TypeMeta: metav1.TypeMeta{Kind: "StatefulSet", APIVersion: "apps/v1"},
+				Name:     "demo",
+			},
+			expectImage: "nginx:1.18",
+		},
+		"no overrider": {
+			statefulset: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas, Template: *template.DeepCopy()},
+			},
+			subject: v1alpha1.Subject{
+				TypeMeta: metav1.TypeMeta{Kind: "StatefulSet", APIVersion: "apps/v1"},
+				Name:     "demo",
+			},
+			expectImage: "nginx",
+		},
+	}
+
+	scheme := newStatefulSetRenderScheme(t)
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			overrider := &v1alpha1.YurtAppOverrider{
+				ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+				Subject:    tc.subject,
+				Entries: []v1alpha1.Entry{{
+					Pools: []string{"*"},
+					Items: []v1alpha1.Item{{Image: &v1alpha1.ImageItem{ContainerName: "nginx", ImageClaim: "nginx:1.18"}}},
+				}},
+			}
+			webhook := &StatefulSetRenderHandler{
+				Client: fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(yas, np, overrider).Build(),
+				Scheme: scheme,
+			}
+			if err := webhook.Default(context.TODO(), tc.statefulset); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expectImage, tc.statefulset.Spec.Template.Spec.Containers[0].Image)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtiotdock/controllers/util"
+)
+
+const (
+	// EdgeXObjectName is the label of device which overrides the name of the device on the edge platform,
+	// it's the same label which yurt-iot-dock uses.
+	EdgeXObjectName = "yurt-iot-dock/edgex-object.name"
+)
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *DeviceHandler) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	device, ok := obj.(*v1alpha1.Device)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a Device but got a %T", obj))
+	}
+
+	if allErrs := webhook.validateEdgeDeviceClaim(ctx, device); len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("Device").GroupKind(), device.Name, allErrs)
+	}
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *DeviceHandler) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	newDevice, ok := newObj.(*v1alpha1.Device)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a Device but got a %T", newObj))
+	}
+	oldDevice, ok := oldObj.(*v1alpha1.Device)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a Device but got a %T", oldObj))
+	}
+
+	// the claim is only checked when it's changed, so that the existing devices can still be updated
+	if newDevice.Spec.NodePool == oldDevice.Spec.NodePool &&
+		util.GetEdgeDeviceName(newDevice, EdgeXObjectName) == util.GetEdgeDeviceName(oldDevice, EdgeXObjectName) {
+		return nil, nil
+	}
+	if allErrs := webhook.validateEdgeDeviceClaim(ctx, newDevice); len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("Device").GroupKind(), newDevice.Name, allErrs)
+	}
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *DeviceHandler) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateEdgeDeviceClaim checks that the device on the edge platform is not claimed by another device of the same
+// nodepool, e.g. in another namespace. Each nodepool runs its own edge platform, so the devices of different
+// nodepools with the same name are different devices.
+func (webhook *DeviceHandler) validateEdgeDeviceClaim(ctx context.Context, device *v1alpha1.Device) field.ErrorList {
+	if webhook.Client == nil || len(device.Spec.NodePool) == 0 {
+		return nil
+	}
+
+	var devices v1alpha1.DeviceList
+	if err := webhook.Client.List(ctx, &devices); err != nil {
+		return field.ErrorList{field.InternalError(field.NewPath("spec", "nodePool"), err)}
+	}
+
+	edgeDeviceName := util.GetEdgeDeviceName(device, EdgeXObjectName)
+	namePath := field.NewPath("metadata", "name")
+	if _, ok := device.Labels[EdgeXObjectName]; ok {
+		namePath = field.NewPath("metadata", "labels").Key(EdgeXObjectName)
+	}
+	for i := range devices.Items {
+		claim := &devices.Items[i]
+		if client.ObjectKeyFromObject(claim) == client.ObjectKeyFromObject(device) ||
+			claim.Spec.NodePool != device.Spec.NodePool || !claim.DeletionTimestamp.IsZero() ||
+			util.GetEdgeDeviceName(claim, EdgeXObjectName) != edgeDeviceName {
+			continue
+		}
+		klog.V(4).Infof("device %s on edge platform of nodepool %s is already claimed by %s", edgeDeviceName, claim.Spec.NodePool, klog.KObj(claim))
+		return field.ErrorList{field.Invalid(namePath, edgeDeviceName,
+			fmt.Sprintf("the device on edge platform of nodepool %s is already claimed by %s", claim.Spec.NodePool, klog.KObj(claim)))}
+	}
+	return nil
+}
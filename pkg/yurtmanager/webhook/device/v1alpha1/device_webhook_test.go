@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+func newDevice(name, namespace, nodePool string, labels map[string]string) *v1alpha1.Device {
+	return &v1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec:       v1alpha1.DeviceSpec{NodePool: nodePool, Profile: "thermometer"},
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	// sensor of hangzhou is synchronized into namespace iot, and camera claims the edge device sensor-2
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newDevice("hangzhou-sensor", "iot", "hangzhou", map[string]string{EdgeXObjectName: "sensor"}),
+		newDevice("camera", metav1.NamespaceDefault, "hangzhou", map[string]string{EdgeXObjectName: "sensor-2"}),
+	).Build()
+
+	testcases := map[string]struct {
+		obj         runtime.Object
+		errHappened bool
+	}{
+		"it is not a device": {
+			obj:         &corev1.Pod{},
+			errHappened: true,
+		},
+		"the edge device is not claimed": {
+			obj: newDevice("sensor-3", metav1.NamespaceDefault, "hangzhou", nil),
+		},
+		"the edge device with the same name is synchronized from another nodepool": {
+			obj: newDevice("beijing-sensor", "iot", "beijing", map[string]string{EdgeXObjectName: "sensor"}),
+		},
+		"the edge device is claimed by the label in another namespace of the same nodepool": {
+			obj:         newDevice("hangzhou-sensor", metav1.NamespaceDefault, "hangzhou", map[string]string{EdgeXObjectName: "sensor"}),
+			errHappened: true,
+		},
+		"the edge device is claimed by the name in the same nodepool": {
+			obj:         newDevice("sensor-2", metav1.NamespaceDefault, "hangzhou", nil),
+			errHappened: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			h := DeviceHandler{Client: c}
+			_, err := h.ValidateCreate(context.TODO(), tc.obj)
+			assert.Equal(t, tc.errHappened, err != nil)
+		})
+	}
+}
+
+func TestValidateCreateDevicesOfNodePools(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	h := DeviceHandler{Client: c}
+
+	// the default virtual devices exist on the edge platform of every nodepool, and they are synchronized
+	// as <nodepool>-<name> with the name label of edge platform
+	for _, pool := range []string{"hangzhou", "beijing"} {
+		device := newDevice(pool+"-random-integer-device", metav1.NamespaceDefault, pool,
+			map[string]string{EdgeXObjectName: "Random-Integer-Device"})
+		_, err := h.ValidateCreate(context.TODO(), device)
+		assert.NoError(t, err, "nodepool %s", pool)
+		assert.NoError(t, c.Create(context.TODO(), device))
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	oldDevice := newDevice("sensor", "iot", "beijing", map[string]string{EdgeXObjectName: "sensor-1"})
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newDevice("sensor", metav1.NamespaceDefault, "hangzhou", nil),
+		newDevice("thermometer", metav1.NamespaceDefault, "beijing", nil),
+		oldDevice,
+	).Build()
+
+	newObj := func(nodePool, edgeName string) *v1alpha1.Device {
+		return newDevice("sensor", "iot", nodePool, map[string]string{EdgeXObjectName: edgeName})
+	}
+	described := newObj("beijing", "sensor-1")
+	described.Spec.Description = "thermometer of the warehouse"
+	testcases := map[string]struct {
+		newObj      runtime.Object
+		errHappened bool
+	}{
+		"it is not a device": {
+			newObj:      &corev1.Pod{},
+			errHappened: true,
+		},
+		"the claim is not changed": {
+			newObj: described,
+		},
+		"the edge device is changed to one claimed in the same nodepool": {
+			newObj:      newObj("beijing", "thermometer"),
+			errHappened: true,
+		},
+		"the edge device is changed to one claimed in another nodepool": {
+			newObj: newObj("beijing", "sensor"),
+		},
+		"the nodepool is changed": {
+			newObj: newObj("shanghai", "sensor-1"),
+		},
+		"the nodepool is changed to the one which claims the edge device": {
+			newObj:      newObj("hangzhou", "sensor"),
+			errHappened: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			h := DeviceHandler{Client: c}
+			_, err := h.ValidateUpdate(context.TODO(), oldDevice, tc.newObj)
+			assert.Equal(t, tc.errHappened, err != nil)
+		})
+	}
+}
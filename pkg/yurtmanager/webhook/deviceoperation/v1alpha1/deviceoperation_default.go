@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+// Default satisfies the defaulting webhook interface.
+func (webhook *DeviceOperationHandler) Default(ctx context.Context, obj runtime.Object) error {
+	op, ok := obj.(*v1alpha1.DeviceOperation)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a DeviceOperation but got a %T", obj))
+	}
+
+	v1alpha1.SetDefaultsDeviceOperation(op)
+
+	// the requester is always the user who creates the operation, it can not be specified by the user
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	if req.Operation == admissionv1.Create {
+		op.Spec.Requester = req.UserInfo.Username
+	}
+	return nil
+}
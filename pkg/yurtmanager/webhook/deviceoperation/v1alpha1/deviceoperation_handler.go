@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	yurtClient "github.com/openyurtio/openyurt/cmd/yurt-manager/app/client"
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/util"
+)
+
+const (
+	WebhookName = "deviceoperation"
+)
+
+// SetupWebhookWithManager sets up Cluster webhooks. mutate path, validate path, error
+func (webhook *DeviceOperationHandler) SetupWebhookWithManager(mgr ctrl.Manager) (string, string, error) {
+	// init
+	webhook.Client = yurtClient.GetClientByControllerNameOrDie(mgr, "")
+
+	return util.RegisterWebhook(mgr, &v1alpha1.DeviceOperation{}, webhook)
+}
+
+// +kubebuilder:webhook:path=/validate-iot-openyurt-io-v1alpha1-deviceoperation,mutating=false,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1,groups=iot.openyurt.io,resources=deviceoperations,verbs=create;update,versions=v1alpha1,name=validate.iot.v1alpha1.deviceoperation.openyurt.io
+// +kubebuilder:webhook:path=/mutate-iot-openyurt-io-v1alpha1-deviceoperation,mutating=true,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1,groups=iot.openyurt.io,resources=deviceoperations,verbs=create,versions=v1alpha1,name=mutate.iot.v1alpha1.deviceoperation.openyurt.io
+
+// +kubebuilder:rbac:groups=iot.openyurt.io,resources=devices;deviceprofiles,verbs=get;list;watch
+
+// DeviceOperationHandler records the requester of DeviceOperation, validates the operation against the device
+// and keeps its spec immutable for auditing.
+type DeviceOperationHandler struct {
+	Client client.Client
+}
+
+var _ webhook.CustomDefaulter = &DeviceOperationHandler{}
+var _ webhook.CustomValidator = &DeviceOperationHandler{}
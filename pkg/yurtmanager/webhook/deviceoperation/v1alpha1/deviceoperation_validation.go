@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+const (
+	// the maximum length of the value set by an operation
+	maxOperationValueLength = 4096
+)
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *DeviceOperationHandler) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	op, ok := obj.(*v1alpha1.DeviceOperation)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a DeviceOperation but got a %T", obj))
+	}
+
+	if allErrs := webhook.validateDeviceOperationSpec(ctx, op); len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("DeviceOperation").GroupKind(), op.Name, allErrs)
+	}
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *DeviceOperationHandler) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	newOp, ok := newObj.(*v1alpha1.DeviceOperation)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a DeviceOperation but got a %T", newObj))
+	}
+	oldOp, ok := oldObj.(*v1alpha1.DeviceOperation)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a DeviceOperation but got a %T", oldObj))
+	}
+
+	// the operation is a record of the command, so its spec can not be changed after it is created
+	if !apiequality.Semantic.DeepEqual(newOp.Spec, oldOp.Spec) {
+		return nil, apierrors.NewInvalid(
+			v1alpha1.GroupVersion.WithKind("DeviceOperation").GroupKind(),
+			newOp.Name,
+			field.ErrorList{field.Forbidden(field.NewPath("spec"), "the spec of DeviceOperation is immutable")},
+		)
+	}
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *DeviceOperationHandler) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateDeviceOperationSpec validates the operation against the device it refers to and the profile of the device
+func (webhook *DeviceOperationHandler) validateDeviceOperationSpec(ctx context.Context, op *v1alpha1.DeviceOperation) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	if len(op.Spec.DeviceName) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("deviceName"), "the device of operation must be specified"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(op.Spec.DeviceName) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("deviceName"), op.Spec.DeviceName, msg))
+		}
+	}
+	if len(op.Spec.PropertyName) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("propertyName"), "the property of operation must be specified"))
+	} else if strings.ContainsAny(op.Spec.PropertyName, "/ \t\n") {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("propertyName"), op.Spec.PropertyName, "the property name must not contain '/' or whitespaces"))
+	}
+	if len(op.Spec.Value) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("value"), "the value of operation must be specified"))
+	} else if len(op.Spec.Value) > maxOperationValueLength {
+		allErrs = append(allErrs, field.TooLong(specPath.Child("value"), op.Spec.Value, maxOperationValueLength))
+	}
+	if op.Spec.MaxRetries != nil && *op.Spec.MaxRetries < 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("maxRetries"), *op.Spec.MaxRetries, "must be greater than or equal to 0"))
+	}
+	if op.Spec.TTLSecondsAfterFinished != nil && *op.Spec.TTLSecondsAfterFinished < 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("ttlSecondsAfterFinished"), *op.Spec.TTLSecondsAfterFinished, "must be greater than or equal to 0"))
+	}
+	if len(allErrs) != 0 || webhook.Client == nil {
+		return allErrs
+	}
+
+	// the device must exist, and the property must be a writable resource or command of its profile
+	var device v1alpha1.Device
+	if err := webhook.Client.Get(ctx, client.ObjectKey{Namespace: op.Namespace, Name: op.Spec.DeviceName}, &device); err != nil {
+		if apierrors.IsNotFound(err) {
+			return append(allErrs, field.NotFound(specPath.Child("deviceName"), op.Spec.DeviceName))
+		}
+		return append(allErrs, field.InternalError(specPath.Child("deviceName"), err))
+	}
+	if !device.DeletionTimestamp.IsZero() {
+		return append(allErrs, field.Invalid(specPath.Child("deviceName"), op.Spec.DeviceName, "the device is being deleted"))
+	}
+
+	profile, err := webhook.getDeviceProfile(ctx, &device)
+	if err != nil {
+		return append(allErrs, field.InternalError(specPath.Child("propertyName"), err))
+	} else if profile == nil {
+		// the profile is not synchronized yet, the property is checked by the edge platform
+		return allErrs
+	}
+	return append(allErrs, validatePropertyValue(specPath, op, profile)...)
+}
+
+// getDeviceProfile returns the profile of the device in the nodepool of the device, the profile may be in another
+// namespace when the devices are mapped into namespaces, nil is returned if the profile is not found.
+func (webhook *DeviceOperationHandler) getDeviceProfile(ctx context.Context, device *v1alpha1.Device) (*v1alpha1.DeviceProfile, error) {
+	var profile v1alpha1.DeviceProfile
+	err := webhook.Client.Get(ctx, client.ObjectKey{Namespace: device.Namespace, Name: device.Spec.Profile}, &profile)
+	if err == nil && profile.Spec.NodePool == device.Spec.NodePool {
+		return &profile, nil
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	var profiles v1alpha1.DeviceProfileList
+	if err := webhook.Client.List(ctx, &profiles); err != nil {
+		return nil, err
+	}
+	for i := range profiles.Items {
+		if profiles.Items[i].Name == device.Spec.Profile && profiles.Items[i].Spec.NodePool == device.Spec.NodePool {
+			return &profiles.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// validatePropertyValue checks the property is writable and the value matches the value type of the resource
+func validatePropertyValue(specPath *field.Path, op *v1alpha1.DeviceOperation, profile *v1alpha1.DeviceProfile) field.ErrorList {
+	for _, command := range profile.Spec.DeviceCommands {
+		if command.Name != op.Spec.PropertyName {
+			continue
+		}
+		if !strings.Contains(command.ReadWrite, "W") {
+			return field.ErrorList{field.Invalid(specPath.Child("propertyName"), op.Spec.PropertyName,
+				fmt.Sprintf("the command of profile %s is not writable", profile.Name))}
+		}
+		return nil
+	}
+
+	for _, resource := range profile.Spec.DeviceResources {
+		if resource.Name != op.Spec.PropertyName {
+			continue
+		}
+		if !strings.Contains(resource.Properties.ReadWrite, "W") {
+			return field.ErrorList{field.Invalid(specPath.Child("propertyName"), op.Spec.PropertyName,
+				fmt.Sprintf("the resource of profile %s is not writable", profile.Name))}
+		}
+		if err := validateValueType(op.Spec.Value, resource.Properties); err != nil {
+			return field.ErrorList{field.Invalid(specPath.Child("value"), op.Spec.Value, err.Error())}
+		}
+		return nil
+	}
+	return field.ErrorList{field.NotFound(specPath.Child("propertyName"), op.Spec.PropertyName)}
+}
+
+// validateValueType checks the value of the bool and numeric resources, including the minimum and maximum of the numbers
+func validateValueType(value string, properties v1alpha1.ResourceProperties) error {
+	valueType := strings.ToLower(properties.ValueType)
+	switch {
+	case valueType == "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("the value must be a bool")
+		}
+		return nil
+	case strings.HasPrefix(valueType, "int"), strings.HasPrefix(valueType, "uint"), strings.HasPrefix(valueType, "float"):
+		if strings.HasSuffix(valueType, "array") {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("the value must be a %s", properties.ValueType)
+	}
+	if minimum, err := strconv.ParseFloat(properties.Minimum, 64); err == nil && number < minimum {
+		return fmt.Errorf("the value must be greater than or equal to %s", properties.Minimum)
+	}
+	if maximum, err := strconv.ParseFloat(properties.Maximum, 64); err == nil && number > maximum {
+		return fmt.Errorf("the value must be less than or equal to %s", properties.Maximum)
+	}
+	return nil
+}
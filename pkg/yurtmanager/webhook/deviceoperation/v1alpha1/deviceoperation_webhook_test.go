@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+)
+
+func newDeviceOperation() *v1alpha1.DeviceOperation {
+	return &v1alpha1.DeviceOperation{
+		ObjectMeta: metav1.ObjectMeta{Name: "op", Namespace: metav1.NamespaceDefault},
+		Spec: v1alpha1.DeviceOperationSpec{
+			DeviceName:   "sensor",
+			PropertyName: "Int8",
+			Value:        "42",
+			Requester:    "mallory",
+		},
+	}
+}
+
+func TestDefault(t *testing.T) {
+	testcases := map[string]struct {
+		obj         runtime.Object
+		operation   admissionv1.Operation
+		errHappened bool
+		requester   string
+	}{
+		"it is not a deviceoperation": {
+			obj:         &corev1.Pod{},
+			operation:   admissionv1.Create,
+			errHappened: true,
+		},
+		"the requester is set on create": {
+			obj:       newDeviceOperation(),
+			operation: admissionv1.Create,
+			requester: "alice",
+		},
+		"the requester is kept on update": {
+			obj:       newDeviceOperation(),
+			operation: admissionv1.Update,
+			requester: "mallory",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			h := DeviceOperationHandler{}
+			ctx := admission.NewContextWithRequest(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: tc.operation,
+					UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+				},
+			})
+			err := h.Default(ctx, tc.obj)
+			if tc.errHappened {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			op := tc.obj.(*v1alpha1.DeviceOperation)
+			assert.Equal(t, tc.requester, op.Spec.Requester)
+			assert.Equal(t, ptr.To(v1alpha1.DefaultDeviceOperationMaxRetries), op.Spec.MaxRetries)
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	changedValue := newDeviceOperation()
+	changedValue.Spec.Value = "0"
+	changedRequester := newDeviceOperation()
+	changedRequester.Spec.Requester = "alice"
+	changedLabels := newDeviceOperation()
+	changedLabels.Labels = map[string]string{"team": "ops"}
+
+	testcases := map[string]struct {
+		newObj      runtime.Object
+		errHappened bool
+	}{
+		"it is not a deviceoperation": {
+			newObj:      &corev1.Pod{},
+			errHappened: true,
+		},
+		"the value is changed": {
+			newObj:      changedValue,
+			errHappened: true,
+		},
+		"the requester is changed": {
+			newObj:      changedRequester,
+			errHappened: true,
+		},
+		"the metadata is changed": {
+			newObj: changedLabels,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			h := DeviceOperationHandler{}
+			_, err := h.ValidateUpdate(context.TODO(), newDeviceOperation(), tc.newObj)
+			assert.Equal(t, tc.errHappened, err != nil)
+		})
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	device := &v1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: "sensor", Namespace: metav1.NamespaceDefault},
+		Spec:       v1alpha1.DeviceSpec{NodePool: "hangzhou", Profile: "thermometer"},
+	}
+	unknownProfileDevice := &v1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: "camera", Namespace: metav1.NamespaceDefault},
+		Spec:       v1alpha1.DeviceSpec{NodePool: "hangzhou", Profile: "camera"},
+	}
+	// the profile is in the namespace of yurt-iot-dock when the devices are mapped into other namespaces
+	profile := &v1alpha1.DeviceProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "thermometer", Namespace: "iot"},
+		Spec: v1alpha1.DeviceProfileSpec{
+			NodePool: "hangzhou",
+			DeviceResources: []v1alpha1.DeviceResource{
+				{Name: "Int8", Properties: v1alpha1.ResourceProperties{ReadWrite: "RW", ValueType: "Int8", Minimum: "-128", Maximum: "127"}},
+				{Name: "Temperature", Properties: v1alpha1.ResourceProperties{ReadWrite: "R", ValueType: "Float32"}},
+				{Name: "Label", Properties: v1alpha1.ResourceProperties{ReadWrite: "W", ValueType: "String"}},
+			},
+			DeviceCommands: []v1alpha1.DeviceCommand{{Name: "Reset", ReadWrite: "W"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(device, unknownProfileDevice, profile).Build()
+
+	newOp := func(mutate func(op *v1alpha1.DeviceOperation)) *v1alpha1.DeviceOperation {
+		op := newDeviceOperation()
+		mutate(op)
+		return op
+	}
+	testcases := map[string]struct {
+		obj         runtime.Object
+		errHappened bool
+	}{
+		"it is not a deviceoperation": {
+			obj:         &corev1.Pod{},
+			errHappened: true,
+		},
+		"a writable resource": {
+			obj: newDeviceOperation(),
+		},
+		"a writable command": {
+			obj: newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.PropertyName = "Reset" }),
+		},
+		"a string resource": {
+			obj: newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.PropertyName = "Label"; op.Spec.Value = "line 1" }),
+		},
+		"the profile of device is not synchronized": {
+			obj: newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.DeviceName = "camera"; op.Spec.PropertyName = "Zoom" }),
+		},
+		"the device is not found": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.DeviceName = "missing" }),
+			errHappened: true,
+		},
+		"the device name is invalid": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.DeviceName = "Sensor_1" }),
+			errHappened: true,
+		},
+		"the property is empty": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.PropertyName = "" }),
+			errHappened: true,
+		},
+		"the property is not in the profile": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.PropertyName = "Humidity" }),
+			errHappened: true,
+		},
+		"the property is read only": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.PropertyName = "Temperature"; op.Spec.Value = "1.5" }),
+			errHappened: true,
+		},
+		"the value is empty": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.Value = "" }),
+			errHappened: true,
+		},
+		"the value is not a number": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.Value = "hot" }),
+			errHappened: true,
+		},
+		"the value is out of range": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.Value = "300" }),
+			errHappened: true,
+		},
+		"the max retries is negative": {
+			obj:         newOp(func(op *v1alpha1.DeviceOperation) { op.Spec.MaxRetries = ptr.To[int32](-1) }),
+			errHappened: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			h := DeviceOperationHandler{Client: c}
+			_, err := h.ValidateCreate(context.TODO(), tc.obj)
+			assert.Equal(t, tc.errHappened, err != nil, "unexpected error: %v", err)
+		})
+	}
+}
@@ -18,14 +18,20 @@ package v1beta1
 
 import (
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	yurtClient "github.com/openyurtio/openyurt/cmd/yurt-manager/app/client"
+	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
 	"github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
 	"github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/util"
 )
 
 // SetupWebhookWithManager sets up Cluster webhooks. 	mutate path, validatepath, error
 func (webhook *GatewayHandler) SetupWebhookWithManager(mgr ctrl.Manager) (string, string, error) {
+	// init
+	webhook.Client = yurtClient.GetClientByControllerNameOrDie(mgr, names.GatewayPickupController)
+
 	return util.RegisterWebhook(mgr, &v1beta1.Gateway{}, webhook)
 }
 
@@ -34,6 +40,7 @@ func (webhook *GatewayHandler) SetupWebhookWithManager(mgr ctrl.Manager) (string
 
 // Cluster implements a validating and defaulting webhook for Cluster.
 type GatewayHandler struct {
+	Client client.Client
 }
 
 var _ webhook.CustomDefaulter = &GatewayHandler{}
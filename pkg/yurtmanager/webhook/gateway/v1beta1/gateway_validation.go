@@ -20,15 +20,23 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"reflect"
+	"sort"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/raven/util"
 )
 
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
@@ -38,7 +46,10 @@ func (webhook *GatewayHandler) ValidateCreate(ctx context.Context, obj runtime.O
 		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a Gateway but got a %T", obj))
 	}
 
-	return validate(gw)
+	if warnings, err := validate(gw); err != nil {
+		return warnings, err
+	}
+	return webhook.validateNodeSelector(ctx, gw)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
@@ -55,7 +66,14 @@ func (webhook *GatewayHandler) ValidateUpdate(ctx context.Context, oldObj, newOb
 	if newGw.GetName() != oldGw.GetName() {
 		return nil, apierrors.NewBadRequest(fmt.Sprintf("gateway name can not change"))
 	}
-	return validate(newGw)
+	if warnings, err := validate(newGw); err != nil {
+		return warnings, err
+	}
+	// the legacy conflicts are resolved by gateway pickup controller, only the change of node selector is rejected
+	if reflect.DeepEqual(oldGw.Spec.NodeSelector, newGw.Spec.NodeSelector) {
+		return nil, nil
+	}
+	return webhook.validateNodeSelector(ctx, newGw)
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
@@ -63,22 +81,91 @@ func (webhook *GatewayHandler) ValidateDelete(_ context.Context, obj runtime.Obj
 	return nil, nil
 }
 
+// validateNodeSelector rejects the gateway whose node selector selects the nodes already selected by other gateways,
+// the node would be labeled with either gateway nondeterministically.
+func (webhook *GatewayHandler) validateNodeSelector(ctx context.Context, g *v1beta1.Gateway) (admission.Warnings, error) {
+	if g.Spec.NodeSelector == nil {
+		return nil, nil
+	}
+	fldPath := field.NewPath("spec").Child("nodeSelector")
+	selector, err := metav1.LabelSelectorAsSelector(g.Spec.NodeSelector)
+	if err != nil {
+		return nil, apierrors.NewInvalid(
+			schema.GroupKind{Group: v1beta1.SchemeGroupVersion.Group, Kind: g.Kind},
+			g.Name, field.ErrorList{field.Invalid(fldPath, g.Spec.NodeSelector, err.Error())})
+	}
+
+	var nodeList corev1.NodeList
+	if err := webhook.Client.List(ctx, &nodeList, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("could not list nodes, %v", err))
+	}
+	if len(nodeList.Items) == 0 {
+		return nil, nil
+	}
+	var gwList v1beta1.GatewayList
+	if err := webhook.Client.List(ctx, &gwList); err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("could not list gateways, %v", err))
+	}
+
+	var errList field.ErrorList
+	for i := range gwList.Items {
+		other := &gwList.Items[i]
+		if other.Name == g.Name {
+			continue
+		}
+		var overlapped []string
+		for j := range nodeList.Items {
+			if util.GatewaySelectsNode(other, &nodeList.Items[j]) {
+				overlapped = append(overlapped, nodeList.Items[j].Name)
+			}
+		}
+		if len(overlapped) != 0 {
+			sort.Strings(overlapped)
+			errList = append(errList, field.Invalid(fldPath, g.Spec.NodeSelector,
+				fmt.Sprintf("the 'nodeSelector' field overlaps with gateway %s on nodes [%s]", other.Name, strings.Join(overlapped, ","))))
+		}
+	}
+	if errList != nil {
+		return nil, apierrors.NewInvalid(
+			schema.GroupKind{Group: v1beta1.SchemeGroupVersion.Group, Kind: g.Kind},
+			g.Name, errList)
+	}
+	return nil, nil
+}
+
 func validate(g *v1beta1.Gateway) (admission.Warnings, error) {
 	var errList field.ErrorList
 
 	if g.Spec.ExposeType != "" {
-		if g.Spec.ExposeType != v1beta1.ExposeTypeLoadBalancer && g.Spec.ExposeType != v1beta1.ExposeTypePublicIP {
-			fldPath := field.NewPath("spec").Child("exposeType")
-			errList = append(errList, field.Invalid(fldPath, g.Spec.ExposeType, "the 'exposeType' field is irregularity"))
-		}
-		if g.Spec.ExposeType == v1beta1.ExposeTypeLoadBalancer || g.Spec.ExposeType == v1beta1.ExposeTypePublicIP {
+		switch g.Spec.ExposeType {
+		case v1beta1.ExposeTypeLoadBalancer, v1beta1.ExposeTypePublicIP, v1beta1.ExposeTypeNodePort, v1beta1.ExposeTypeHostNetwork:
 			for i, ep := range g.Spec.Endpoints {
 				if ep.UnderNAT {
 					fldPath := field.NewPath("spec").Child(fmt.Sprintf("endpoints[%d]", i)).Child("underNAT")
 					errList = append(errList, field.Invalid(fldPath, ep.UnderNAT, fmt.Sprintf("the 'underNAT' field for exposed gateway %s/%s must be false", g.Namespace, g.Name)))
 				}
 			}
+		default:
+			fldPath := field.NewPath("spec").Child("exposeType")
+			errList = append(errList, field.Invalid(fldPath, g.Spec.ExposeType, "the 'exposeType' field is irregularity"))
+		}
+	}
+
+	if g.Spec.ProxyConfig.EdgeProxy && g.Spec.ExposeType != "" {
+		fldPath := field.NewPath("spec").Child("proxyConfig").Child("edgeProxy")
+		errList = append(errList, field.Invalid(fldPath, g.Spec.ProxyConfig.EdgeProxy, fmt.Sprintf("the 'edgeProxy' field for exposed gateway %s must be false", g.Name)))
+	}
+	seenSources := make(map[string]struct{}, len(g.Spec.ProxyConfig.AllowedSourceGateways))
+	for i, source := range g.Spec.ProxyConfig.AllowedSourceGateways {
+		fldPath := field.NewPath("spec").Child("proxyConfig").Child("allowedSourceGateways").Index(i)
+		if errs := validation.IsDNS1123Subdomain(source); len(errs) != 0 {
+			errList = append(errList, field.Invalid(fldPath, source, strings.Join(errs, ", ")))
+			continue
+		}
+		if _, ok := seenSources[source]; ok {
+			errList = append(errList, field.Duplicate(fldPath, source))
 		}
+		seenSources[source] = struct{}{}
 	}
 
 	if g.Spec.TunnelConfig.Replicas > 1 {
@@ -114,6 +201,10 @@ func validate(g *v1beta1.Gateway) (admission.Warnings, error) {
 					errList = append(errList, field.Invalid(fldPath, ep.PublicIP, "the 'publicIP' field must be a validate IP address"))
 				}
 			}
+			if len(ep.PublicIPs) != 0 {
+				fldPath := field.NewPath("spec").Child(fmt.Sprintf("endpoints[%d]", i)).Child("publicIPs")
+				errList = append(errList, validatePublicIPs(fldPath, ep.PublicIP, ep.PublicIPs)...)
+			}
 			if ep.Type != v1beta1.Tunnel && ep.Type != v1beta1.Proxy {
 				fldPath := field.NewPath("spec").Child(fmt.Sprintf("endpoints[%d]", i)).Child("type")
 				errList = append(errList, field.Invalid(fldPath, ep.Type, fmt.Sprintf("the 'type' field must be set %s or %s ", v1beta1.Tunnel, v1beta1.Proxy)))
@@ -122,6 +213,25 @@ func validate(g *v1beta1.Gateway) (admission.Warnings, error) {
 				fldPath := field.NewPath("spec").Child(fmt.Sprintf("endpoints[%d]", i)).Child("nodeName")
 				errList = append(errList, field.Invalid(fldPath, ep.NodeName, "the 'nodeName' field must not be empty"))
 			}
+			if ep.Bandwidth < 0 {
+				fldPath := field.NewPath("spec").Child(fmt.Sprintf("endpoints[%d]", i)).Child("bandwidth")
+				errList = append(errList, field.Invalid(fldPath, ep.Bandwidth, "the 'bandwidth' field must not be negative"))
+			}
+		}
+	}
+
+	if policy := g.Spec.ElectionPolicy; policy != nil {
+		fldPath := field.NewPath("spec").Child("electionPolicy")
+		if policy.MinDwellSeconds < 0 {
+			errList = append(errList, field.Invalid(fldPath.Child("minDwellSeconds"), policy.MinDwellSeconds, "the 'minDwellSeconds' field must not be negative"))
+		}
+		for i, natType := range policy.PreferredNATTypes {
+			switch natType {
+			case v1beta1.NATTypeFullCone, v1beta1.NATTypeRestrictedCone, v1beta1.NATTypePortRestrictedCone, v1beta1.NATTypeSymmetric:
+			default:
+				errList = append(errList, field.NotSupported(fldPath.Child("preferredNATTypes").Index(i), natType,
+					[]string{v1beta1.NATTypeFullCone, v1beta1.NATTypeRestrictedCone, v1beta1.NATTypePortRestrictedCone, v1beta1.NATTypeSymmetric}))
+			}
 		}
 	}
 
@@ -136,6 +246,40 @@ func validate(g *v1beta1.Gateway) (admission.Warnings, error) {
 	return nil, nil
 }
 
+// validatePublicIPs checks the public ips of dual-stack endpoint are valid, at most one for each IP family,
+// and include the primary public ip.
+func validatePublicIPs(fldPath *field.Path, publicIP string, publicIPs []string) field.ErrorList {
+	var errList field.ErrorList
+	var ipv4, ipv6 int
+	for j, ip := range publicIPs {
+		if err := validateIP(ip); err != nil {
+			errList = append(errList, field.Invalid(fldPath.Index(j), ip, "the 'publicIPs' field must be validate IP addresses"))
+			continue
+		}
+		if net.ParseIP(ip).To4() != nil {
+			ipv4++
+		} else {
+			ipv6++
+		}
+	}
+	if ipv4 > 1 || ipv6 > 1 {
+		errList = append(errList, field.Invalid(fldPath, publicIPs, "the 'publicIPs' field must have at most one IP address for each IP family"))
+	}
+	if publicIP != "" {
+		found := false
+		for _, ip := range publicIPs {
+			if ip == publicIP {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errList = append(errList, field.Invalid(fldPath, publicIPs, "the 'publicIPs' field must include the 'publicIP' field"))
+		}
+	}
+	return errList
+}
+
 func validateIP(ip string) error {
 	s := net.ParseIP(ip)
 	if s.To4() != nil || s.To16() != nil {
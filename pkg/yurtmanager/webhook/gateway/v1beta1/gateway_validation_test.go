@@ -21,7 +21,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
 )
@@ -47,6 +51,16 @@ func TestGatewayHandler_ValidateCreate(t *testing.T) {
 			obj:            mockGatewayWithExposeType(v1beta1.ExposeTypeLoadBalancer, true),
 			expectedErrMsg: "the 'underNAT' field for exposed gateway",
 		},
+		{
+			name:           "should return error when Gateway exposed by host network is under NAT",
+			obj:            mockGatewayWithExposeType(v1beta1.ExposeTypeHostNetwork, true),
+			expectedErrMsg: "the 'underNAT' field for exposed gateway",
+		},
+		{
+			name:           "should pass when Gateway is exposed by node port",
+			obj:            mockGatewayWithExposeType(v1beta1.ExposeTypeNodePort, false),
+			expectedErrMsg: "",
+		},
 		{
 			name:           "should return error when Gateway TunnelConfig.Replicas >1",
 			obj:            mockGatewayWithReplicas(2),
@@ -77,6 +91,69 @@ func TestGatewayHandler_ValidateCreate(t *testing.T) {
 			},
 			expectedErrMsg: "the 'underNAT' field in endpoints must be the same",
 		},
+		{
+			name:           "should return error when Gateway endpoint bandwidth is negative",
+			obj:            mockGatewayWithBandwidth(-1),
+			expectedErrMsg: "the 'bandwidth' field must not be negative",
+		},
+		{
+			name:           "should return error when Gateway public ips have invalid ip",
+			obj:            mockGatewayWithPublicIPs("192.168.1.1", "invalid-ip"),
+			expectedErrMsg: "the 'publicIPs' field must be validate IP addresses",
+		},
+		{
+			name:           "should return error when Gateway public ips have two ips of the same family",
+			obj:            mockGatewayWithPublicIPs("192.168.1.1", "192.168.1.2"),
+			expectedErrMsg: "the 'publicIPs' field must have at most one IP address for each IP family",
+		},
+		{
+			name:           "should return error when Gateway public ips do not include public ip",
+			obj:            mockGatewayWithPublicIPs("192.168.1.2", "fd00::1"),
+			expectedErrMsg: "the 'publicIPs' field must include the 'publicIP' field",
+		},
+		{
+			name:           "should pass when Gateway has dual-stack public ips",
+			obj:            mockGatewayWithPublicIPs("192.168.1.1", "fd00::1"),
+			expectedErrMsg: "",
+		},
+		{
+			name:           "should return error when Gateway election policy has negative dwell time",
+			obj:            mockGatewayWithElectionPolicy(&v1beta1.ElectionPolicy{MinDwellSeconds: -1}),
+			expectedErrMsg: "the 'minDwellSeconds' field must not be negative",
+		},
+		{
+			name:           "should return error when Gateway election policy has unknown NAT type",
+			obj:            mockGatewayWithElectionPolicy(&v1beta1.ElectionPolicy{PreferredNATTypes: []string{"Unknown"}}),
+			expectedErrMsg: "spec.electionPolicy.preferredNATTypes[0]: Unsupported value: \"Unknown\"",
+		},
+		{
+			name: "should pass when Gateway has valid election policy",
+			obj: mockGatewayWithElectionPolicy(&v1beta1.ElectionPolicy{
+				PreferredNATTypes: []string{v1beta1.NATTypeFullCone, v1beta1.NATTypeSymmetric},
+				MinDwellSeconds:   30,
+			}),
+			expectedErrMsg: "",
+		},
+		{
+			name:           "should return error when exposed Gateway enables edge proxy",
+			obj:            mockGatewayWithEdgeProxy(v1beta1.ExposeTypePublicIP),
+			expectedErrMsg: "the 'edgeProxy' field for exposed gateway",
+		},
+		{
+			name:           "should return error when Gateway has duplicated allowed source gateways",
+			obj:            mockGatewayWithEdgeProxy("", "gw-a", "gw-a"),
+			expectedErrMsg: "spec.proxyConfig.allowedSourceGateways[1]: Duplicate value",
+		},
+		{
+			name:           "should return error when Gateway has invalid allowed source gateway",
+			obj:            mockGatewayWithEdgeProxy("", "GW_A"),
+			expectedErrMsg: "spec.proxyConfig.allowedSourceGateways[0]: Invalid value",
+		},
+		{
+			name:           "should pass when edge Gateway enables edge proxy",
+			obj:            mockGatewayWithEdgeProxy("", "gw-a", "gw-b"),
+			expectedErrMsg: "",
+		},
 		{
 			name:           "should pass when object is a valid Gateway",
 			obj:            mockGateway(),
@@ -148,6 +225,70 @@ func TestGatewayHandler_ValidateUpdate(t *testing.T) {
 	}
 }
 
+func TestGatewayHandler_ValidateNodeSelector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+	nodes := []runtime.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"zone": "a", "pool": "p1"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2", Labels: map[string]string{"zone": "b", "pool": "p1"}}},
+	}
+	existing := mockGatewayWithNodeSelector("gw-a", map[string]string{"zone": "a"})
+	handler := &GatewayHandler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(nodes...).WithRuntimeObjects(existing).Build(),
+	}
+
+	tests := []struct {
+		name           string
+		oldObj         *v1beta1.Gateway
+		newObj         *v1beta1.Gateway
+		expectedErrMsg string
+	}{
+		{
+			name:           "should return error when created Gateway selects the node of another Gateway",
+			newObj:         mockGatewayWithNodeSelector("gw-b", map[string]string{"pool": "p1"}),
+			expectedErrMsg: "overlaps with gateway gw-a on nodes [node-1]",
+		},
+		{
+			name:   "should pass when created Gateway selects other nodes",
+			newObj: mockGatewayWithNodeSelector("gw-b", map[string]string{"zone": "b"}),
+		},
+		{
+			name:           "should return error when updated node selector overlaps",
+			oldObj:         mockGatewayWithNodeSelector("gw-b", map[string]string{"zone": "b"}),
+			newObj:         mockGatewayWithNodeSelector("gw-b", map[string]string{"pool": "p1"}),
+			expectedErrMsg: "overlaps with gateway gw-a on nodes [node-1]",
+		},
+		{
+			name:   "should pass when node selector of legacy conflicted Gateway is unchanged",
+			oldObj: mockGatewayWithNodeSelector("gw-b", map[string]string{"pool": "p1"}),
+			newObj: mockGatewayWithNodeSelector("gw-b", map[string]string{"pool": "p1"}),
+		},
+		{
+			name:   "should pass when Gateway updates its own node selector",
+			oldObj: mockGatewayWithNodeSelector("gw-a", map[string]string{"zone": "a"}),
+			newObj: mockGatewayWithNodeSelector("gw-a", map[string]string{"pool": "p1"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.oldObj == nil {
+				_, err = handler.ValidateCreate(context.Background(), tt.newObj)
+			} else {
+				_, err = handler.ValidateUpdate(context.Background(), tt.oldObj, tt.newObj)
+			}
+			if tt.expectedErrMsg != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErrMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestGatewayHandler_ValidateDelete(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -198,6 +339,13 @@ func mockGateway() *v1beta1.Gateway {
 	}
 }
 
+func mockGatewayWithNodeSelector(name string, matchLabels map[string]string) *v1beta1.Gateway {
+	g := mockGateway()
+	g.Name = name
+	g.Spec.NodeSelector = &metav1.LabelSelector{MatchLabels: matchLabels}
+	return g
+}
+
 func mockGatewayWithExposeType(ExposeType string, UnderNAT bool) *v1beta1.Gateway {
 	g := mockGateway()
 	if ExposeType != "" {
@@ -233,3 +381,29 @@ func mockGatewayWithReplicas(Replicas int) *v1beta1.Gateway {
 	g.Spec.TunnelConfig.Replicas = Replicas
 	return g
 }
+
+func mockGatewayWithBandwidth(bandwidth int) *v1beta1.Gateway {
+	g := mockGateway()
+	g.Spec.Endpoints[0].Bandwidth = bandwidth
+	return g
+}
+
+func mockGatewayWithElectionPolicy(policy *v1beta1.ElectionPolicy) *v1beta1.Gateway {
+	g := mockGateway()
+	g.Spec.ElectionPolicy = policy
+	return g
+}
+
+func mockGatewayWithPublicIPs(ips ...string) *v1beta1.Gateway {
+	g := mockGateway()
+	g.Spec.Endpoints[0].PublicIPs = ips
+	return g
+}
+
+func mockGatewayWithEdgeProxy(exposeType string, sources ...string) *v1beta1.Gateway {
+	g := mockGateway()
+	g.Spec.ExposeType = exposeType
+	g.Spec.ProxyConfig.EdgeProxy = true
+	g.Spec.ProxyConfig.AllowedSourceGateways = sources
+	return g
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/util"
+)
+
+// SetupWebhookWithManager sets up GatewayRoute webhooks. 	mutate path, validatepath, error
+func (webhook *GatewayRouteHandler) SetupWebhookWithManager(mgr ctrl.Manager) (string, string, error) {
+	return util.RegisterWebhook(mgr, &v1beta1.GatewayRoute{}, webhook)
+}
+
+// +kubebuilder:webhook:path=/validate-raven-openyurt-io-v1beta1-gatewayroute,mutating=false,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1;v1beta1,groups=raven.openyurt.io,resources=gatewayroutes,verbs=create;update,versions=v1beta1,name=validate.gatewayroute.v1beta1.raven.openyurt.io
+
+// GatewayRouteHandler implements a validating webhook for GatewayRoute.
+type GatewayRouteHandler struct {
+}
+
+var _ webhook.CustomValidator = &GatewayRouteHandler{}
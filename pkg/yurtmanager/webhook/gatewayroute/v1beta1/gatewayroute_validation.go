@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *GatewayRouteHandler) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	route, ok := obj.(*v1beta1.GatewayRoute)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a GatewayRoute but got a %T", obj))
+	}
+
+	return validate(route)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *GatewayRouteHandler) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	newRoute, ok := newObj.(*v1beta1.GatewayRoute)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a GatewayRoute but got a %T", newObj))
+	}
+	if _, ok := oldObj.(*v1beta1.GatewayRoute); !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a GatewayRoute but got a %T", oldObj))
+	}
+
+	return validate(newRoute)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *GatewayRouteHandler) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validate(r *v1beta1.GatewayRoute) (admission.Warnings, error) {
+	var errList field.ErrorList
+	specPath := field.NewPath("spec")
+
+	switch r.Spec.Mode {
+	case v1beta1.RouteModeFullMesh:
+		if len(r.Spec.Hubs) != 0 {
+			errList = append(errList, field.Forbidden(specPath.Child("hubs"), "the 'hubs' field is only allowed for HubAndSpoke route"))
+		}
+	case v1beta1.RouteModeHubAndSpoke:
+		if len(r.Spec.Hubs) == 0 {
+			errList = append(errList, field.Required(specPath.Child("hubs"), "the 'hubs' field must not be empty for HubAndSpoke route"))
+		}
+		for i, hub := range r.Spec.Hubs {
+			if hub == "" {
+				errList = append(errList, field.Invalid(specPath.Child("hubs").Index(i), hub, "the hub gateway name must not be empty"))
+			}
+		}
+	case v1beta1.RouteModePairs:
+		if len(r.Spec.Pairs) == 0 {
+			errList = append(errList, field.Required(specPath.Child("pairs"), "the 'pairs' field must not be empty for Pairs route"))
+		}
+		if r.Spec.GatewaySelector != nil {
+			errList = append(errList, field.Forbidden(specPath.Child("gatewaySelector"), "the 'gatewaySelector' field is not allowed for Pairs route"))
+		}
+		for i, pair := range r.Spec.Pairs {
+			fldPath := specPath.Child("pairs").Index(i)
+			if pair.Source == "" || pair.Destination == "" {
+				errList = append(errList, field.Invalid(fldPath, pair, "the 'source' and 'destination' fields must not be empty"))
+			} else if pair.Source == pair.Destination {
+				errList = append(errList, field.Invalid(fldPath, pair, "the 'source' and 'destination' fields must be different gateways"))
+			}
+		}
+	default:
+		errList = append(errList, field.NotSupported(specPath.Child("mode"), r.Spec.Mode,
+			[]string{v1beta1.RouteModeFullMesh, v1beta1.RouteModeHubAndSpoke, v1beta1.RouteModePairs}))
+	}
+
+	if r.Spec.Mode != v1beta1.RouteModePairs && len(r.Spec.Pairs) != 0 {
+		errList = append(errList, field.Forbidden(specPath.Child("pairs"), "the 'pairs' field is only allowed for Pairs route"))
+	}
+	if r.Spec.GatewaySelector != nil {
+		errList = append(errList, metav1validation.ValidateLabelSelector(r.Spec.GatewaySelector,
+			metav1validation.LabelSelectorValidationOptions{}, specPath.Child("gatewaySelector"))...)
+	}
+
+	if errList != nil {
+		return nil, apierrors.NewInvalid(
+			schema.GroupKind{Group: v1beta1.SchemeGroupVersion.Group, Kind: r.Kind},
+			r.Name, errList)
+	}
+
+	klog.Infof("Validate GatewayRoute %s successfully ...", klog.KObj(r))
+
+	return nil, nil
+}
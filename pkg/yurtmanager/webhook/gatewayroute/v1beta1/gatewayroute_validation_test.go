@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openyurtio/openyurt/pkg/apis/raven/v1beta1"
+)
+
+func mockGatewayRoute(spec v1beta1.GatewayRouteSpec) *v1beta1.GatewayRoute {
+	return &v1beta1.GatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route-1"},
+		Spec:       spec,
+	}
+}
+
+func TestGatewayRouteHandler_ValidateCreate(t *testing.T) {
+	tests := []struct {
+		name           string
+		obj            runtime.Object
+		expectedErrMsg string
+	}{
+		{
+			name:           "should return error when object is not a GatewayRoute",
+			obj:            &runtime.Unknown{},
+			expectedErrMsg: "expected a GatewayRoute but got a *runtime.Unknown",
+		},
+		{
+			name:           "should return error when mode is not supported",
+			obj:            mockGatewayRoute(v1beta1.GatewayRouteSpec{Mode: "Ring"}),
+			expectedErrMsg: "Unsupported value: \"Ring\"",
+		},
+		{
+			name: "should pass when full mesh route selects gateways",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode:            v1beta1.RouteModeFullMesh,
+				GatewaySelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "hangzhou"}},
+			}),
+		},
+		{
+			name: "should return error when full mesh route has hubs",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode: v1beta1.RouteModeFullMesh,
+				Hubs: []string{"gw-hub"},
+			}),
+			expectedErrMsg: "the 'hubs' field is only allowed for HubAndSpoke route",
+		},
+		{
+			name: "should return error when gateway selector is invalid",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode: v1beta1.RouteModeFullMesh,
+				GatewaySelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "region", Operator: metav1.LabelSelectorOpIn},
+				}},
+			}),
+			expectedErrMsg: "spec.gatewaySelector.matchExpressions[0].values",
+		},
+		{
+			name:           "should return error when hub and spoke route has no hub",
+			obj:            mockGatewayRoute(v1beta1.GatewayRouteSpec{Mode: v1beta1.RouteModeHubAndSpoke}),
+			expectedErrMsg: "the 'hubs' field must not be empty for HubAndSpoke route",
+		},
+		{
+			name: "should pass when hub and spoke route has hubs",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode: v1beta1.RouteModeHubAndSpoke,
+				Hubs: []string{"gw-hub"},
+			}),
+		},
+		{
+			name:           "should return error when pairs route has no pair",
+			obj:            mockGatewayRoute(v1beta1.GatewayRouteSpec{Mode: v1beta1.RouteModePairs}),
+			expectedErrMsg: "the 'pairs' field must not be empty for Pairs route",
+		},
+		{
+			name: "should return error when pair links the same gateway",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode:  v1beta1.RouteModePairs,
+				Pairs: []v1beta1.GatewayPair{{Source: "gw-1", Destination: "gw-1"}},
+			}),
+			expectedErrMsg: "the 'source' and 'destination' fields must be different gateways",
+		},
+		{
+			name: "should return error when pair has empty gateway",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode:  v1beta1.RouteModePairs,
+				Pairs: []v1beta1.GatewayPair{{Source: "gw-1"}},
+			}),
+			expectedErrMsg: "the 'source' and 'destination' fields must not be empty",
+		},
+		{
+			name: "should return error when pairs route has gateway selector",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode:            v1beta1.RouteModePairs,
+				GatewaySelector: &metav1.LabelSelector{},
+				Pairs:           []v1beta1.GatewayPair{{Source: "gw-1", Destination: "gw-2"}},
+			}),
+			expectedErrMsg: "the 'gatewaySelector' field is not allowed for Pairs route",
+		},
+		{
+			name: "should return error when hub and spoke route has pairs",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode:  v1beta1.RouteModeHubAndSpoke,
+				Hubs:  []string{"gw-hub"},
+				Pairs: []v1beta1.GatewayPair{{Source: "gw-1", Destination: "gw-2"}},
+			}),
+			expectedErrMsg: "the 'pairs' field is only allowed for Pairs route",
+		},
+		{
+			name: "should pass when pairs route has pairs",
+			obj: mockGatewayRoute(v1beta1.GatewayRouteSpec{
+				Mode:  v1beta1.RouteModePairs,
+				Pairs: []v1beta1.GatewayPair{{Source: "gw-1", Destination: "gw-2"}},
+			}),
+		},
+	}
+
+	handler := &GatewayRouteHandler{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.ValidateCreate(context.Background(), tt.obj)
+			if tt.expectedErrMsg != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErrMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGatewayRouteHandler_ValidateUpdate(t *testing.T) {
+	handler := &GatewayRouteHandler{}
+	valid := mockGatewayRoute(v1beta1.GatewayRouteSpec{Mode: v1beta1.RouteModeFullMesh})
+
+	_, err := handler.ValidateUpdate(context.Background(), &runtime.Unknown{}, valid)
+	assert.ErrorContains(t, err, "expected a GatewayRoute but got a *runtime.Unknown")
+
+	_, err = handler.ValidateUpdate(context.Background(), valid, mockGatewayRoute(v1beta1.GatewayRouteSpec{Mode: v1beta1.RouteModeHubAndSpoke}))
+	assert.ErrorContains(t, err, "the 'hubs' field must not be empty for HubAndSpoke route")
+
+	_, err = handler.ValidateUpdate(context.Background(), valid, valid)
+	assert.NoError(t, err)
+}
@@ -71,7 +71,8 @@ func (webhook *PlatformAdminHandler) ValidateUpdate(
 	// validate
 	newErrorList := webhook.validate(ctx, newPlatformAdmin)
 	oldErrorList := webhook.validate(ctx, oldPlatformAdmin)
-	if allErrs := append(newErrorList, oldErrorList...); len(allErrs) > 0 {
+	upgradeErrorList := webhook.validatePlatformAdminUpgrade(oldPlatformAdmin, newPlatformAdmin)
+	if allErrs := append(append(newErrorList, oldErrorList...), upgradeErrorList...); len(allErrs) > 0 {
 		return nil, apierrors.NewInvalid(
 			v1beta1.GroupVersion.WithKind("PlatformAdmin").GroupKind(),
 			newPlatformAdmin.Name,
@@ -103,15 +104,18 @@ func (webhook *PlatformAdminHandler) validate(
 }
 
 func (webhook *PlatformAdminHandler) validatePlatformAdminSpec(platformAdmin *v1beta1.PlatformAdmin) field.ErrorList {
-	// TODO: Need to divert traffic based on the type of platform
-
 	// Verify that the platform is supported
-	if platformAdmin.Spec.Platform != v1beta1.PlatformAdminPlatformEdgeX {
+	switch platformAdmin.Spec.Platform {
+	case v1beta1.PlatformAdminPlatformEdgeX:
+	case v1beta1.PlatformAdminPlatformMQTT:
+		// The mqtt platform works with an existing broker and has no versioned components
+		return nil
+	default:
 		return field.ErrorList{
 			field.Invalid(
 				field.NewPath("spec", "platform"),
 				platformAdmin.Spec.Platform,
-				"must be "+v1beta1.PlatformAdminPlatformEdgeX,
+				"must be one of "+strings.Join([]string{v1beta1.PlatformAdminPlatformEdgeX, v1beta1.PlatformAdminPlatformMQTT}, ","),
 			),
 		}
 	}
@@ -132,6 +136,35 @@ func (webhook *PlatformAdminHandler) validatePlatformAdminSpec(platformAdmin *v1
 	}
 }
 
+func (webhook *PlatformAdminHandler) validatePlatformAdminUpgrade(oldPlatformAdmin, newPlatformAdmin *v1beta1.PlatformAdmin) field.ErrorList {
+	if oldPlatformAdmin.Spec.Version == newPlatformAdmin.Spec.Version || newPlatformAdmin.Spec.Platform == v1beta1.PlatformAdminPlatformMQTT {
+		return nil
+	}
+
+	// Rolling back to the version which all the nodepools ran is always allowed, even while they are upgraded
+	if oldPlatformAdmin.Status.Version != "" && newPlatformAdmin.Spec.Version == oldPlatformAdmin.Status.Version {
+		return nil
+	}
+
+	// Verify that the nodepools are not being upgraded to another version
+	if oldPlatformAdmin.Status.Version != "" && oldPlatformAdmin.Status.Version != oldPlatformAdmin.Spec.Version {
+		return field.ErrorList{
+			field.Forbidden(
+				field.NewPath("spec", "version"),
+				fmt.Sprintf("can not change the version while the nodepools are upgraded from %s to %s", oldPlatformAdmin.Status.Version, oldPlatformAdmin.Spec.Version),
+			),
+		}
+	}
+
+	// Verify that the version can be upgraded in place
+	if err := config.CheckUpgradeCompatibility(oldPlatformAdmin.Spec.Version, newPlatformAdmin.Spec.Version); err != nil {
+		return field.ErrorList{
+			field.Invalid(field.NewPath("spec", "version"), newPlatformAdmin.Spec.Version, err.Error()),
+		}
+	}
+	return nil
+}
+
 func (webhook *PlatformAdminHandler) validatePlatformAdminWithNodePools(
 	ctx context.Context,
 	platformAdmin *v1beta1.PlatformAdmin,
@@ -77,6 +77,20 @@ func TestValidateCreate(t *testing.T) {
 			},
 			errCode: http.StatusUnprocessableEntity,
 		},
+		{
+			name:   "should get no err when platform is mqtt without manifest version",
+			client: NewFakeClient(buildClient(buildNodePool(), buildPlatformAdmin())).Build(),
+			obj: &v1beta1.PlatformAdmin{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "beijing-PlatformAdmin",
+				},
+				Spec: v1beta1.PlatformAdminSpec{
+					NodePools: []string{"beijing"},
+					Platform:  v1beta1.PlatformAdminPlatformMQTT,
+				},
+			},
+			errCode: 0,
+		},
 		{
 			name:   "should get StatusUnprocessableEntityError when list NodePoolList failed",
 			client: NewFakeClient(buildClient(nil, nil)).WithErr(&ut.NodePoolList{}, errors.New("list failed")).Build(),
@@ -269,6 +283,32 @@ func TestValidateUpdate(t *testing.T) {
 			},
 			errCode: 0,
 		},
+		{
+			name:   "should no err when version is upgraded to a compatible version",
+			client: NewFakeClient(buildClient(buildNodePool(), buildPlatformAdmin())).Build(),
+			oldObj: buildPlatformAdminWithVersion("levski", "levski"),
+			newObj: buildPlatformAdminWithVersion("minnesota", "levski"),
+		},
+		{
+			name:    "should get StatusUnprocessableEntityError when version is downgraded",
+			client:  NewFakeClient(buildClient(buildNodePool(), buildPlatformAdmin())).Build(),
+			oldObj:  buildPlatformAdminWithVersion("minnesota", "minnesota"),
+			newObj:  buildPlatformAdminWithVersion("levski", "minnesota"),
+			errCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "should get StatusUnprocessableEntityError when version is changed during upgrading",
+			client:  NewFakeClient(buildClient(buildNodePool(), buildPlatformAdmin())).Build(),
+			oldObj:  buildPlatformAdminWithVersion("minnesota", "levski"),
+			newObj:  buildPlatformAdminWithVersion("napa", "levski"),
+			errCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "should no err when version is rolled back to the version of nodepools during upgrading",
+			client: NewFakeClient(buildClient(buildNodePool(), buildPlatformAdmin())).Build(),
+			oldObj: buildPlatformAdminWithVersion("minnesota", "levski"),
+			newObj: buildPlatformAdminWithVersion("levski", "levski"),
+		},
 	}
 
 	manifest := &config.Manifest{
@@ -277,6 +317,15 @@ func TestValidateUpdate(t *testing.T) {
 				Name:               "v2",
 				RequiredComponents: []string{"edgex-core-data", "edgex-core-metadata"},
 			},
+			{
+				Name: "levski",
+			},
+			{
+				Name: "minnesota",
+			},
+			{
+				Name: "napa",
+			},
 		},
 	}
 
@@ -328,6 +377,22 @@ func buildPlatformAdmin() []client.Object {
 	return nodes
 }
 
+func buildPlatformAdminWithVersion(version, statusVersion string) *v1beta1.PlatformAdmin {
+	return &v1beta1.PlatformAdmin{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "beijing-PlatformAdmin",
+		},
+		Spec: v1beta1.PlatformAdminSpec{
+			NodePools: []string{"beijing"},
+			Platform:  v1beta1.PlatformAdminPlatformEdgeX,
+			Version:   version,
+		},
+		Status: v1beta1.PlatformAdminStatus{
+			Version: statusVersion,
+		},
+	}
+}
+
 func buildNodePool() []client.Object {
 	pools := []client.Object{
 		&ut.NodePool{
@@ -31,9 +31,12 @@ import (
 	"github.com/openyurtio/openyurt/cmd/yurt-manager/names"
 	controller "github.com/openyurtio/openyurt/pkg/yurtmanager/controller/base"
 	v1alpha1deploymentrender "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/deploymentrender/v1alpha1"
+	v1alpha1device "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/device/v1alpha1"
+	v1alpha1deviceoperation "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/deviceoperation/v1alpha1"
 	v1endpoints "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/endpoints/v1"
 	v1endpointslice "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/endpointslice/v1"
 	v1beta1gateway "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/gateway/v1beta1"
+	v1beta1gatewayroute "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/gatewayroute/v1beta1"
 	v1node "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/node/v1"
 	v1beta2nodepool "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/nodepool/v1beta2"
 	v1beta1platformadmin "github.com/openyurtio/openyurt/pkg/yurtmanager/webhook/platformadmin/v1beta1"
@@ -73,6 +76,7 @@ func addControllerWebhook(name string, handler SetupWebhookWithManager) {
 
 func init() {
 	addControllerWebhook(names.GatewayPickupController, &v1beta1gateway.GatewayHandler{})
+	addControllerWebhook(names.GatewayRouteController, &v1beta1gatewayroute.GatewayRouteHandler{})
 	addControllerWebhook(names.NodePoolController, &v1beta2nodepool.NodePoolHandler{})
 	addControllerWebhook(names.YurtStaticSetController, &v1alpha1yurtstaticset.YurtStaticSetHandler{})
 	addControllerWebhook(names.YurtAppSetController, &v1beta1yurtappset.YurtAppSetHandler{})
@@ -81,11 +85,14 @@ func init() {
 	addControllerWebhook(names.PlatformAdminController, &v1beta1platformadmin.PlatformAdminHandler{})
 	addControllerWebhook(names.YurtAppOverriderController, &v1alpha1yurtappoverrider.YurtAppOverriderHandler{})
 	addControllerWebhook(names.YurtAppOverriderController, &v1alpha1deploymentrender.DeploymentRenderHandler{})
+	addControllerWebhook(names.YurtAppOverriderController, &v1alpha1deploymentrender.StatefulSetRenderHandler{})
 
 	independentWebhooks[v1node.WebhookName] = &v1node.NodeHandler{}
 	independentWebhooks[v1alpha1pod.WebhookName] = &v1alpha1pod.PodHandler{}
 	independentWebhooks[v1endpoints.WebhookName] = &v1endpoints.EndpointsHandler{}
 	independentWebhooks[v1endpointslice.WebhookName] = &v1endpointslice.EndpointSliceHandler{}
+	independentWebhooks[v1alpha1device.WebhookName] = &v1alpha1device.DeviceHandler{}
+	independentWebhooks[v1alpha1deviceoperation.WebhookName] = &v1alpha1deviceoperation.DeviceOperationHandler{}
 }
 
 // Note !!! @kadisi
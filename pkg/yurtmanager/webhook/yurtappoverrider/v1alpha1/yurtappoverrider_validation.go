@@ -22,11 +22,13 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/yurtappoverrider/render"
 )
 
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
@@ -37,6 +39,9 @@ func (webhook *YurtAppOverriderHandler) ValidateCreate(ctx context.Context, obj
 	}
 
 	// validate
+	if err := validateSubject(overrider); err != nil {
+		return nil, err
+	}
 	if err := webhook.validateOneToOneBinding(ctx, overrider); err != nil {
 		return nil, err
 	}
@@ -101,7 +106,7 @@ func (webhook *YurtAppOverriderHandler) validateOneToOneBinding(ctx context.Cont
 		if overrider.Name == app.Name {
 			continue
 		}
-		if overrider.Subject.Kind == app.Subject.Kind && overrider.Subject.Name == app.Subject.Name {
+		if render.SubjectMatches(overrider.Subject, app.Subject.APIVersion, app.Subject.Kind, app.Subject.Name) {
 			duplicatedOverriders = append(duplicatedOverriders, overrider)
 		}
 	}
@@ -110,3 +115,11 @@ func (webhook *YurtAppOverriderHandler) validateOneToOneBinding(ctx context.Cont
 	}
 	return nil
 }
+
+// validateSubject checks the subject, which is YurtAppSet, YurtAppDaemon or any workload with pod template
+func validateSubject(overrider *v1alpha1.YurtAppOverrider) error {
+	if _, err := schema.ParseGroupVersion(overrider.Subject.APIVersion); err != nil {
+		return fmt.Errorf("invalid apiVersion %q of subject, %v", overrider.Subject.APIVersion, err)
+	}
+	return nil
+}
@@ -62,6 +62,18 @@ func TestYurtAppOverriderHandler_ValidateCreate(t *testing.T) {
 			client:      NewFakeClient(buildClient(buildYurtAppOverrider("test-not-exist"))),
 			expectedErr: "unable to bind multiple yurtappoverriders to one subject resource",
 		},
+		{
+			name: "should return error when apiVersion of subject is invalid",
+			obj: &v1alpha1.YurtAppOverrider{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Subject: v1alpha1.Subject{
+					TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1/foo", Kind: "StatefulSet"},
+					Name:     "demo",
+				},
+			},
+			client:      NewFakeClient(buildClient(buildYurtAppOverrider("test"))),
+			expectedErr: "invalid apiVersion",
+		},
 		{
 			name:        "should succeed when YurtAppOverrider is valid",
 			obj:         &v1alpha1.YurtAppOverrider{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
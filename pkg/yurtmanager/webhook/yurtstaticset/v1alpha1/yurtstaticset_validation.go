@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/yurtmanager/controller/util/maintenancewindow"
 )
 
 const (
@@ -122,6 +123,18 @@ func validateYurtStaticSetSpec(spec *v1alpha1.YurtStaticSetSpec) field.ErrorList
 			"max-unavailable is required in AdvancedRollingUpdate mode"))
 	}
 
+	if strategy.ReadyTimeout != nil && strategy.ReadyTimeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("upgradeStrategy").Child("readyTimeout"),
+			strategy.ReadyTimeout.Duration.String(), "readyTimeout should be positive"))
+	}
+
+	for i := range strategy.MaintenanceWindows {
+		if err := maintenancewindow.Validate(&strategy.MaintenanceWindows[i]); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("upgradeStrategy").Child("maintenanceWindows").Index(i),
+				strategy.MaintenanceWindows[i], err.Error()))
+		}
+	}
+
 	if allErrs != nil {
 		return allErrs
 	}
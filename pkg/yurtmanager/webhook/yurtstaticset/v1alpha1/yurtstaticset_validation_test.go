@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -77,6 +78,37 @@ func TestYurtStaticSetHandler_ValidateCreate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "max-unavailable is required in AdvancedRollingUpdate mode",
 		},
+		{
+			name: "should fail when maintenance window has invalid schedule",
+			obj: &v1alpha1.YurtStaticSet{
+				Spec: v1alpha1.YurtStaticSetSpec{
+					StaticPodManifest: "manifest",
+					UpgradeStrategy: v1alpha1.YurtStaticSetUpgradeStrategy{
+						Type: v1alpha1.OTAUpgradeStrategyType,
+						MaintenanceWindows: []v1alpha1.MaintenanceWindow{{
+							Schedule: "0 25 * * *",
+							Duration: metav1.Duration{Duration: time.Hour},
+						}},
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    "invalid hour field in schedule",
+		},
+		{
+			name: "should fail when ready timeout is not positive",
+			obj: &v1alpha1.YurtStaticSet{
+				Spec: v1alpha1.YurtStaticSetSpec{
+					StaticPodManifest: "manifest",
+					UpgradeStrategy: v1alpha1.YurtStaticSetUpgradeStrategy{
+						Type:         v1alpha1.OTAUpgradeStrategyType,
+						ReadyTimeout: &metav1.Duration{Duration: 0},
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    "readyTimeout should be positive",
+		},
 		{
 			name: "should pass when YurtStaticSet is valid",
 			obj: &v1alpha1.YurtStaticSet{